@@ -10,8 +10,11 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/backup"
 	"github.com/supergiant/control/pkg/controlplane"
+	"github.com/supergiant/control/pkg/events"
 	"github.com/supergiant/control/pkg/proxy"
+	"github.com/supergiant/control/pkg/workflows"
 )
 
 var (
@@ -29,6 +32,17 @@ var (
 	ProxiesPortRangeTo   = flag.Int("proxies-port-to", 60250, "last tcp port in a range of binding reverse proxies for service apps")
 	pprofListenStr       = flag.String("pprofListenStr", "",
 		"pprof listen str host:port")
+	backupDir      = flag.String("backup-dir", backup.DefaultDir, "directory to keep etcd snapshots of kubes in")
+	backupBucket   = flag.String("backup-s3-bucket", "", "S3 bucket to keep etcd snapshots of kubes in instead of backup directory")
+	backupS3Prefix = flag.String("backup-s3-prefix", "supergiant/backups", "prefix of etcd snapshot names in S3 bucket")
+	backupS3Region = flag.String("backup-s3-region", "us-east-1", "region of S3 bucket")
+
+	orphansGracePeriod = flag.Duration("orphans-grace-period", 0, "delete cloud resources of failed or deleted kubes that are orphaned longer than this, zero only reports them")
+
+	eventsMaxAge     = flag.Duration("events-max-age", events.DefaultMaxAge, "delete events of kubes that are older than this, zero keeps them")
+	eventsMaxPerKube = flag.Int("events-max-per-kube", events.DefaultMaxEvents, "keep at most this number of the latest events of a kube, zero keeps all")
+
+	stepParallelism = flag.Int("step-parallelism", workflows.DefaultParallelism, "number of steps of a task that may run at once")
 )
 
 func main() {
@@ -50,7 +64,19 @@ func main() {
 		PprofListenStr: *pprofListenStr,
 
 		ProxiesPortRange: proxy.PortRange{int32(*ProxiesPortRangeFrom), int32(*ProxiesPortRangeTo)},
-		Version:          version,
+		Backup: backup.Config{
+			Dir:    *backupDir,
+			Bucket: *backupBucket,
+			Prefix: *backupS3Prefix,
+			Region: *backupS3Region,
+		},
+		OrphansGracePeriod: *orphansGracePeriod,
+		Events: controlplane.EventsConfig{
+			MaxAge:     *eventsMaxAge,
+			MaxPerKube: *eventsMaxPerKube,
+		},
+		StepParallelism: *stepParallelism,
+		Version:         version,
 	}
 
 	server, err := controlplane.New(cfg)
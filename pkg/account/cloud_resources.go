@@ -0,0 +1,460 @@
+package account
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/digitalocean/godo"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// Types of cloud resources that are created for kubes.
+const (
+	ResourceInstance        = "instance"
+	ResourceDroplet         = "droplet"
+	ResourceLoadBalancer    = "load-balancer"
+	ResourceInternetGateway = "internet-gateway"
+	ResourceSubnet          = "subnet"
+	ResourceRouteTable      = "route-table"
+	ResourceSecurityGroup   = "security-group"
+	ResourceVPC             = "vpc"
+	ResourceKeyPair         = "key-pair"
+	ResourceSSHKey          = "ssh-key"
+	// ResourceAll stands for resources of any type.
+	ResourceAll = "all"
+)
+
+// resourceDeleteOrder lists resource types in the order they can be deleted,
+// resources keep dependent ones from being deleted until they are gone.
+var resourceDeleteOrder = []string{
+	ResourceInstance,
+	ResourceDroplet,
+	ResourceLoadBalancer,
+	ResourceInternetGateway,
+	ResourceSubnet,
+	ResourceRouteTable,
+	ResourceSecurityGroup,
+	ResourceVPC,
+}
+
+var (
+	// Kube IDs are the first 8 characters of uuid
+	clusterIDRe    = regexp.MustCompile(`^[0-9a-f]{8}$`)
+	loadBalancerRe = regexp.MustCompile(`^sg-([0-9a-f]{8})-api$`)
+)
+
+// CloudResource is a resource of a kube found in a cloud account.
+type CloudResource struct {
+	ClusterID string `json:"clusterId"`
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Region    string `json:"region,omitempty"`
+}
+
+// ResourcesCollector finds resources that have been created for kubes
+// in a cloud account, resources are either tagged with the kube ID or
+// named after it.
+type ResourcesCollector interface {
+	// ClusterResources returns resources of all kubes in the order
+	// they can be deleted.
+	ClusterResources(context.Context) ([]CloudResource, error)
+	DeleteResource(context.Context, CloudResource) error
+	// Skipped returns types of resources that are created for kubes
+	// but can't be attributed to them and are not collected.
+	Skipped() []string
+}
+
+// NewResourcesCollector returns collector of resources of the cloud account.
+func NewResourcesCollector(acc *model.CloudAccount) (ResourcesCollector, error) {
+	if acc == nil {
+		return nil, ErrNilAccount
+	}
+
+	switch acc.Provider {
+	case clouds.AWS:
+		return NewAWSCollector(acc)
+	case clouds.DigitalOcean:
+		return NewDOCollector(acc)
+	}
+	return nil, ErrUnsupportedProvider
+}
+
+func sortForDelete(resources []CloudResource) {
+	order := make(map[string]int, len(resourceDeleteOrder))
+	for i, t := range resourceDeleteOrder {
+		order[t] = i
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return order[resources[i].Type] < order[resources[j].Type]
+	})
+}
+
+// AWSCollector finds resources tagged with clouds.ClusterIDTag
+// in all regions. NOTE: key pairs can't be tagged and their names
+// don't contain the whole kube ID, they are skipped.
+type AWSCollector struct {
+	regions func(context.Context) ([]string, error)
+	ec2For  func(region string) ec2iface.EC2API
+	elbFor  func(region string) elbiface.ELBAPI
+}
+
+func NewAWSCollector(acc *model.CloudAccount) (*AWSCollector, error) {
+	if acc.Provider != clouds.AWS {
+		return nil, ErrUnsupportedProvider
+	}
+
+	keyID, secret := acc.Credentials[clouds.AWSAccessKeyID], acc.Credentials[clouds.AWSSecretKey]
+	if keyID == "" || secret == "" {
+		return nil, sgerrors.ErrInvalidCredentials
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Credentials: credentials.NewStaticCredentials(keyID, secret, ""),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "aws authentication")
+	}
+
+	c := &AWSCollector{
+		ec2For: func(region string) ec2iface.EC2API {
+			return ec2.New(sess, aws.NewConfig().WithRegion(region))
+		},
+		elbFor: func(region string) elbiface.ELBAPI {
+			return elb.New(sess, aws.NewConfig().WithRegion(region))
+		},
+	}
+	c.regions = func(ctx context.Context) ([]string, error) {
+		out, err := c.ec2For("us-east-1").DescribeRegionsWithContext(ctx, &ec2.DescribeRegionsInput{})
+		if err != nil {
+			return nil, errors.Wrap(err, "describe regions")
+		}
+
+		regions := make([]string, 0, len(out.Regions))
+		for _, r := range out.Regions {
+			regions = append(regions, aws.StringValue(r.RegionName))
+		}
+		return regions, nil
+	}
+
+	return c, nil
+}
+
+func (c *AWSCollector) ClusterResources(ctx context.Context) ([]CloudResource, error) {
+	regions, err := c.regions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]CloudResource, 0)
+	for _, region := range regions {
+		err := c.ec2For(region).DescribeTagsPagesWithContext(ctx, &ec2.DescribeTagsInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("key"),
+					Values: aws.StringSlice([]string{clouds.ClusterIDTag}),
+				},
+			},
+		}, func(out *ec2.DescribeTagsOutput, last bool) bool {
+			for _, tag := range out.Tags {
+				resources = append(resources, CloudResource{
+					ClusterID: aws.StringValue(tag.Value),
+					Type:      aws.StringValue(tag.ResourceType),
+					ID:        aws.StringValue(tag.ResourceId),
+					Region:    region,
+				})
+			}
+			return true
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "describe tags in %s", region)
+		}
+
+		err = c.elbFor(region).DescribeLoadBalancersPagesWithContext(ctx, &elb.DescribeLoadBalancersInput{},
+			func(out *elb.DescribeLoadBalancersOutput, last bool) bool {
+				for _, lb := range out.LoadBalancerDescriptions {
+					name := aws.StringValue(lb.LoadBalancerName)
+					if m := loadBalancerRe.FindStringSubmatch(name); m != nil {
+						resources = append(resources, CloudResource{
+							ClusterID: m[1],
+							Type:      ResourceLoadBalancer,
+							ID:        name,
+							Region:    region,
+						})
+					}
+				}
+				return true
+			})
+		if err != nil {
+			return nil, errors.Wrapf(err, "describe load balancers in %s", region)
+		}
+	}
+
+	sortForDelete(resources)
+	return resources, nil
+}
+
+func (c *AWSCollector) Skipped() []string {
+	return []string{ResourceKeyPair}
+}
+
+func (c *AWSCollector) DeleteResource(ctx context.Context, r CloudResource) error {
+	svc := c.ec2For(r.Region)
+
+	var err error
+	switch r.Type {
+	case ResourceInstance:
+		_, err = svc.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: aws.StringSlice([]string{r.ID}),
+		})
+	case ResourceLoadBalancer:
+		_, err = c.elbFor(r.Region).DeleteLoadBalancerWithContext(ctx, &elb.DeleteLoadBalancerInput{
+			LoadBalancerName: aws.String(r.ID),
+		})
+	case ResourceInternetGateway:
+		err = deleteInternetGateway(ctx, svc, r.ID)
+	case ResourceSubnet:
+		_, err = svc.DeleteSubnetWithContext(ctx, &ec2.DeleteSubnetInput{
+			SubnetId: aws.String(r.ID),
+		})
+	case ResourceRouteTable:
+		err = deleteRouteTable(ctx, svc, r.ID)
+	case ResourceSecurityGroup:
+		err = deleteSecurityGroup(ctx, svc, r.ID)
+	case ResourceVPC:
+		_, err = svc.DeleteVpcWithContext(ctx, &ec2.DeleteVpcInput{
+			VpcId: aws.String(r.ID),
+		})
+	default:
+		return errors.Errorf("unknown resource type %s of %s", r.Type, r.ID)
+	}
+
+	return errors.Wrapf(err, "delete %s %s", r.Type, r.ID)
+}
+
+func deleteInternetGateway(ctx context.Context, svc ec2iface.EC2API, id string) error {
+	out, err := svc.DescribeInternetGatewaysWithContext(ctx, &ec2.DescribeInternetGatewaysInput{
+		InternetGatewayIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, gw := range out.InternetGateways {
+		for _, a := range gw.Attachments {
+			_, err := svc.DetachInternetGatewayWithContext(ctx, &ec2.DetachInternetGatewayInput{
+				InternetGatewayId: aws.String(id),
+				VpcId:             a.VpcId,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "detach from vpc %s", aws.StringValue(a.VpcId))
+			}
+		}
+	}
+
+	_, err = svc.DeleteInternetGatewayWithContext(ctx, &ec2.DeleteInternetGatewayInput{
+		InternetGatewayId: aws.String(id),
+	})
+	return err
+}
+
+func deleteRouteTable(ctx context.Context, svc ec2iface.EC2API, id string) error {
+	out, err := svc.DescribeRouteTablesWithContext(ctx, &ec2.DescribeRouteTablesInput{
+		RouteTableIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, table := range out.RouteTables {
+		for _, a := range table.Associations {
+			// Main route table is deleted along with its vpc
+			if aws.BoolValue(a.Main) {
+				return nil
+			}
+
+			_, err := svc.DisassociateRouteTableWithContext(ctx, &ec2.DisassociateRouteTableInput{
+				AssociationId: a.RouteTableAssociationId,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "disassociate %s", aws.StringValue(a.RouteTableAssociationId))
+			}
+		}
+	}
+
+	_, err = svc.DeleteRouteTableWithContext(ctx, &ec2.DeleteRouteTableInput{
+		RouteTableId: aws.String(id),
+	})
+	return err
+}
+
+// deleteSecurityGroup revokes ingress rules first, security groups of
+// masters and nodes refer to each other and can't be deleted otherwise.
+func deleteSecurityGroup(ctx context.Context, svc ec2iface.EC2API, id string) error {
+	out, err := svc.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{
+		GroupIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, group := range out.SecurityGroups {
+		if len(group.IpPermissions) == 0 {
+			continue
+		}
+
+		_, err := svc.RevokeSecurityGroupIngressWithContext(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       aws.String(id),
+			IpPermissions: group.IpPermissions,
+		})
+		if err != nil {
+			return errors.Wrap(err, "revoke ingress")
+		}
+	}
+
+	_, err = svc.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{
+		GroupId: aws.String(id),
+	})
+	return err
+}
+
+type dropletService interface {
+	List(context.Context, *godo.ListOptions) ([]godo.Droplet, *godo.Response, error)
+	Delete(context.Context, int) (*godo.Response, error)
+}
+
+type loadBalancerService interface {
+	List(context.Context, *godo.ListOptions) ([]godo.LoadBalancer, *godo.Response, error)
+	Delete(context.Context, string) (*godo.Response, error)
+}
+
+// DOCollector finds droplets tagged with kube IDs and load balancers
+// named after them. NOTE: keys are named after nodes and shared by
+// droplets of a kube, they are skipped.
+type DOCollector struct {
+	getServices func() (dropletService, loadBalancerService)
+}
+
+func NewDOCollector(acc *model.CloudAccount) (*DOCollector, error) {
+	sdk, err := digitaloceansdk.NewFromAccount(acc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DOCollector{
+		getServices: func() (dropletService, loadBalancerService) {
+			client := sdk.GetClient()
+			return client.Droplets, client.LoadBalancers
+		},
+	}, nil
+}
+
+func (c *DOCollector) ClusterResources(ctx context.Context) ([]CloudResource, error) {
+	droplets, lbs := c.getServices()
+	resources := make([]CloudResource, 0)
+
+	opts := &godo.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+	for {
+		list, resp, err := droplets.List(ctx, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "list droplets")
+		}
+
+		for _, d := range list {
+			for _, tag := range d.Tags {
+				if !clusterIDRe.MatchString(tag) {
+					continue
+				}
+
+				r := CloudResource{
+					ClusterID: tag,
+					Type:      ResourceDroplet,
+					ID:        strconv.Itoa(d.ID),
+				}
+				if d.Region != nil {
+					r.Region = d.Region.Slug
+				}
+				resources = append(resources, r)
+				break
+			}
+		}
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		opts.Page++
+	}
+
+	opts.Page = 1
+	for {
+		list, resp, err := lbs.List(ctx, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "list load balancers")
+		}
+
+		for _, lb := range list {
+			if m := loadBalancerRe.FindStringSubmatch(lb.Name); m != nil {
+				r := CloudResource{
+					ClusterID: m[1],
+					Type:      ResourceLoadBalancer,
+					ID:        lb.ID,
+				}
+				if lb.Region != nil {
+					r.Region = lb.Region.Slug
+				}
+				resources = append(resources, r)
+			}
+		}
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		opts.Page++
+	}
+
+	sortForDelete(resources)
+	return resources, nil
+}
+
+func (c *DOCollector) Skipped() []string {
+	return []string{ResourceSSHKey}
+}
+
+func (c *DOCollector) DeleteResource(ctx context.Context, r CloudResource) error {
+	droplets, lbs := c.getServices()
+
+	var err error
+	switch r.Type {
+	case ResourceDroplet:
+		id, convErr := strconv.Atoi(r.ID)
+		if convErr != nil {
+			return errors.Wrapf(convErr, "droplet id %s", r.ID)
+		}
+		_, err = droplets.Delete(ctx, id)
+	case ResourceLoadBalancer:
+		_, err = lbs.Delete(ctx, r.ID)
+	default:
+		return errors.Errorf("unknown resource type %s of %s", r.Type, r.ID)
+	}
+
+	return errors.Wrapf(err, "delete %s %s", r.Type, r.ID)
+}
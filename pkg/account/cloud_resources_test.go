@@ -0,0 +1,215 @@
+package account
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/digitalocean/godo"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+)
+
+type fakeTagsEC2 struct {
+	ec2iface.EC2API
+	tags    []*ec2.TagDescription
+	deleted []string
+}
+
+func (f *fakeTagsEC2) DescribeTagsPagesWithContext(ctx aws.Context, input *ec2.DescribeTagsInput,
+	fn func(*ec2.DescribeTagsOutput, bool) bool, opts ...request.Option) error {
+	fn(&ec2.DescribeTagsOutput{Tags: f.tags}, true)
+	return nil
+}
+
+func (f *fakeTagsEC2) DeleteVpcWithContext(ctx aws.Context, input *ec2.DeleteVpcInput,
+	opts ...request.Option) (*ec2.DeleteVpcOutput, error) {
+	f.deleted = append(f.deleted, aws.StringValue(input.VpcId))
+	return &ec2.DeleteVpcOutput{}, nil
+}
+
+type fakeELB struct {
+	elbiface.ELBAPI
+	names []string
+}
+
+func (f *fakeELB) DescribeLoadBalancersPagesWithContext(ctx aws.Context, input *elb.DescribeLoadBalancersInput,
+	fn func(*elb.DescribeLoadBalancersOutput, bool) bool, opts ...request.Option) error {
+	out := &elb.DescribeLoadBalancersOutput{}
+	for _, name := range f.names {
+		out.LoadBalancerDescriptions = append(out.LoadBalancerDescriptions, &elb.LoadBalancerDescription{
+			LoadBalancerName: aws.String(name),
+		})
+	}
+	fn(out, true)
+	return nil
+}
+
+type fakeDroplets struct {
+	droplets []godo.Droplet
+	deleted  []int
+}
+
+func (f *fakeDroplets) List(context.Context, *godo.ListOptions) ([]godo.Droplet, *godo.Response, error) {
+	return f.droplets, nil, nil
+}
+
+func (f *fakeDroplets) Delete(ctx context.Context, id int) (*godo.Response, error) {
+	f.deleted = append(f.deleted, id)
+	return nil, nil
+}
+
+type fakeLoadBalancers struct {
+	lbs []godo.LoadBalancer
+	err error
+}
+
+func (f *fakeLoadBalancers) List(context.Context, *godo.ListOptions) ([]godo.LoadBalancer, *godo.Response, error) {
+	return f.lbs, nil, f.err
+}
+
+func (f *fakeLoadBalancers) Delete(context.Context, string) (*godo.Response, error) {
+	return nil, nil
+}
+
+func TestNewResourcesCollector(t *testing.T) {
+	if _, err := NewResourcesCollector(nil); err != ErrNilAccount {
+		t.Errorf("expected %v actual %v", ErrNilAccount, err)
+	}
+
+	_, err := NewResourcesCollector(&model.CloudAccount{Provider: clouds.GCE})
+	if err != ErrUnsupportedProvider {
+		t.Errorf("expected %v actual %v", ErrUnsupportedProvider, err)
+	}
+
+	_, err = NewResourcesCollector(&model.CloudAccount{
+		Provider:    clouds.AWS,
+		Credentials: map[string]string{},
+	})
+	if err == nil {
+		t.Errorf("aws account without keys: error expected")
+	}
+}
+
+func TestAWSCollector(t *testing.T) {
+	svc := &fakeTagsEC2{
+		tags: []*ec2.TagDescription{
+			{
+				ResourceType: aws.String(ResourceVPC),
+				ResourceId:   aws.String("vpc-1"),
+				Value:        aws.String("1234abcd"),
+			},
+			{
+				ResourceType: aws.String(ResourceInstance),
+				ResourceId:   aws.String("i-1"),
+				Value:        aws.String("1234abcd"),
+			},
+		},
+	}
+	c := &AWSCollector{
+		regions: func(context.Context) ([]string, error) {
+			return []string{"us-west-1"}, nil
+		},
+		ec2For: func(string) ec2iface.EC2API {
+			return svc
+		},
+		elbFor: func(string) elbiface.ELBAPI {
+			return &fakeELB{names: []string{"sg-1234abcd-api", "user-lb"}}
+		},
+	}
+
+	resources, err := c.ClusterResources(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []CloudResource{
+		{ClusterID: "1234abcd", Type: ResourceInstance, ID: "i-1", Region: "us-west-1"},
+		{ClusterID: "1234abcd", Type: ResourceLoadBalancer, ID: "sg-1234abcd-api", Region: "us-west-1"},
+		{ClusterID: "1234abcd", Type: ResourceVPC, ID: "vpc-1", Region: "us-west-1"},
+	}
+	if len(resources) != len(expected) {
+		t.Fatalf("expected %v actual %v", expected, resources)
+	}
+	for i := range expected {
+		if resources[i] != expected[i] {
+			t.Errorf("resource %d: expected %v actual %v", i, expected[i], resources[i])
+		}
+	}
+
+	if skipped := c.Skipped(); len(skipped) != 1 || skipped[0] != ResourceKeyPair {
+		t.Errorf("key pairs must be skipped %v", skipped)
+	}
+
+	if err := c.DeleteResource(context.Background(), expected[2]); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if len(svc.deleted) != 1 || svc.deleted[0] != "vpc-1" {
+		t.Errorf("vpc-1 has not been deleted %v", svc.deleted)
+	}
+
+	if err := c.DeleteResource(context.Background(), CloudResource{Type: "unknown"}); err == nil {
+		t.Errorf("unknown resource type: error expected")
+	}
+}
+
+func TestDOCollector(t *testing.T) {
+	droplets := &fakeDroplets{
+		droplets: []godo.Droplet{
+			{ID: 1, Tags: []string{"1234abcd", "test-master-abcd", "test"}},
+			{ID: 2, Tags: []string{"web"}},
+		},
+	}
+	lbs := &fakeLoadBalancers{
+		lbs: []godo.LoadBalancer{
+			{ID: "lb-1", Name: "sg-1234abcd-api"},
+			{ID: "lb-2", Name: "user-lb"},
+		},
+	}
+	c := &DOCollector{
+		getServices: func() (dropletService, loadBalancerService) {
+			return droplets, lbs
+		},
+	}
+
+	resources, err := c.ClusterResources(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []CloudResource{
+		{ClusterID: "1234abcd", Type: ResourceDroplet, ID: "1"},
+		{ClusterID: "1234abcd", Type: ResourceLoadBalancer, ID: "lb-1"},
+	}
+	if len(resources) != len(expected) {
+		t.Fatalf("expected %v actual %v", expected, resources)
+	}
+	for i := range expected {
+		if resources[i] != expected[i] {
+			t.Errorf("resource %d: expected %v actual %v", i, expected[i], resources[i])
+		}
+	}
+
+	if skipped := c.Skipped(); len(skipped) != 1 || skipped[0] != ResourceSSHKey {
+		t.Errorf("ssh keys must be skipped %v", skipped)
+	}
+
+	if err := c.DeleteResource(context.Background(), expected[0]); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if len(droplets.deleted) != 1 || droplets.deleted[0] != 1 {
+		t.Errorf("droplet 1 has not been deleted %v", droplets.deleted)
+	}
+
+	lbs.err = errors.New("list load balancers")
+	if _, err := c.ClusterResources(context.Background()); err == nil {
+		t.Errorf("list error expected")
+	}
+}
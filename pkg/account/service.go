@@ -106,6 +106,11 @@ func (s *Service) Create(ctx context.Context, account *model.CloudAccount) error
 			return errors.Wrapf(sgerrors.ErrInvalidCredentials,
 				"gce: not enough credentials %v", account.Credentials)
 		}
+	case clouds.BareMetal:
+		if account.Credentials[clouds.BareMetalUser] == "" ||
+			account.Credentials[clouds.BareMetalPrivateKey] == "" {
+			return errors.Wrap(sgerrors.ErrInvalidCredentials, "both ssh user and private key should be provided")
+		}
 	default:
 		return sgerrors.ErrUnsupportedProvider
 	}
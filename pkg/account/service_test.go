@@ -77,6 +77,18 @@ func TestServiceCreate(t *testing.T) {
 			},
 			expectedErr: sgerrors.ErrInvalidCredentials,
 		},
+		{
+			getResponse: nil,
+			getError:    sgerrors.ErrNotFound,
+			account: &model.CloudAccount{
+				Name:     "test",
+				Provider: clouds.BareMetal,
+				Credentials: map[string]string{
+					clouds.BareMetalUser: "root",
+				},
+			},
+			expectedErr: sgerrors.ErrInvalidCredentials,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// LocalStore keeps snapshots in a directory of the controlplane host.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore doesn't touch the directory, it is created
+// by the first Put if it doesn't exist.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, errors.New("backup directory is not set")
+	}
+
+	return &LocalStore{
+		dir: dir,
+	}, nil
+}
+
+// Put writes the snapshot to a temporary file first, so that an
+// interrupted transfer never leaves a truncated snapshot under the key.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if err := validateKey(key); err != nil {
+		return 0, err
+	}
+
+	fileName := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(fileName), 0700); err != nil {
+		return 0, errors.Wrapf(err, "create directory for %s", key)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(fileName), ".snapshot")
+	if err != nil {
+		return 0, errors.Wrap(err, "create temporary file")
+	}
+	defer os.Remove(f.Name())
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return 0, errors.Wrapf(err, "write %s", key)
+	}
+
+	if err := f.Close(); err != nil {
+		return 0, errors.Wrapf(err, "write %s", key)
+	}
+
+	if err := os.Rename(f.Name(), fileName); err != nil {
+		return 0, errors.Wrapf(err, "save %s", key)
+	}
+
+	return n, nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrapf(sgerrors.ErrNotFound, "snapshot %s", key)
+		}
+		return nil, errors.Wrapf(err, "open %s", key)
+	}
+
+	return f, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "delete %s", key)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package backup
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewLocalStore(filepath.Join(dir, "snapshots"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "snapshots")); !os.IsNotExist(err) {
+		t.Errorf("directory must be created by the first put, stat caused %v", err)
+	}
+
+	n, err := s.Put(context.Background(), "kube/snapshot.db", strings.NewReader("data"))
+	if err != nil {
+		t.Fatalf("put: unexpected error %v", err)
+	}
+
+	if n != 4 {
+		t.Errorf("wrong size expected 4 actual %d", n)
+	}
+
+	r, err := s.Get(context.Background(), "kube/snapshot.db")
+	if err != nil {
+		t.Fatalf("get: unexpected error %v", err)
+	}
+
+	data, _ := ioutil.ReadAll(r)
+	r.Close()
+	if string(data) != "data" {
+		t.Errorf("wrong content expected data actual %s", string(data))
+	}
+
+	if err := s.Delete(context.Background(), "kube/snapshot.db"); err != nil {
+		t.Fatalf("delete: unexpected error %v", err)
+	}
+
+	if _, err := s.Get(context.Background(), "kube/snapshot.db"); !sgerrors.IsNotFound(err) {
+		t.Errorf("expected not found actual %v", err)
+	}
+
+	if err := s.Delete(context.Background(), "kube/snapshot.db"); err != nil {
+		t.Errorf("delete of missing snapshot: unexpected error %v", err)
+	}
+}
+
+func TestLocalStoreInvalidKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../snapshot.db", "kube//snapshot.db"} {
+		if _, err := s.Put(context.Background(), key, strings.NewReader("data")); err == nil {
+			t.Errorf("key %q: error expected", key)
+		}
+	}
+}
+
+func TestNewLocalStoreNoDir(t *testing.T) {
+	if _, err := NewLocalStore(""); err == nil {
+		t.Errorf("error expected")
+	}
+}
@@ -0,0 +1,125 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+type s3Service interface {
+	PutObjectWithContext(aws.Context, *s3.PutObjectInput, ...request.Option) (*s3.PutObjectOutput, error)
+	GetObjectWithContext(aws.Context, *s3.GetObjectInput, ...request.Option) (*s3.GetObjectOutput, error)
+	DeleteObjectWithContext(aws.Context, *s3.DeleteObjectInput, ...request.Option) (*s3.DeleteObjectOutput, error)
+}
+
+// S3Store keeps snapshots in a S3 bucket, objects are named prefix/key.
+type S3Store struct {
+	bucket string
+	prefix string
+	svc    s3Service
+}
+
+// NewS3Store creates a store for the bucket, credentials are taken from
+// the default chain: environment, shared credentials file or instance role.
+func NewS3Store(bucket, prefix, region string) (*S3Store, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Region: aws.String(region),
+		},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create aws session")
+	}
+
+	return &S3Store{
+		bucket: bucket,
+		prefix: prefix,
+		svc:    s3.New(sess),
+	}, nil
+}
+
+// Put spools the snapshot to a temporary file, the object size
+// must be known before it is uploaded.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if err := validateKey(key); err != nil {
+		return 0, err
+	}
+
+	f, err := ioutil.TempFile("", "snapshot")
+	if err != nil {
+		return 0, errors.Wrap(err, "create temporary file")
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return 0, errors.Wrapf(err, "read %s", key)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, errors.Wrapf(err, "read %s", key)
+	}
+
+	_, err = s.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s.objectKey(key)),
+		Body:          f,
+		ContentLength: aws.Int64(n),
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "upload %s to bucket %s", key, s.bucket)
+	}
+
+	return n, nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	out, err := s.svc.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, errors.Wrapf(sgerrors.ErrNotFound, "snapshot %s", key)
+		}
+		return nil, errors.Wrapf(err, "download %s from bucket %s", key, s.bucket)
+	}
+
+	return out.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	_, err := s.svc.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+
+	return errors.Wrapf(err, "delete %s from bucket %s", key, s.bucket)
+}
+
+func (s *S3Store) objectKey(key string) string {
+	return path.Join(s.prefix, key)
+}
@@ -0,0 +1,109 @@
+package backup
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+type fakeS3 struct {
+	objects map[string]string
+	err     error
+}
+
+func (f *fakeS3) PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput,
+	opts ...request.Option) (*s3.PutObjectOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	data, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[*input.Bucket+"/"+*input.Key] = string(data)
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput,
+	opts ...request.Option) (*s3.GetObjectOutput, error) {
+	data, ok := f.objects[*input.Bucket+"/"+*input.Key]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "not found", nil)
+	}
+
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(data)),
+	}, nil
+}
+
+func (f *fakeS3) DeleteObjectWithContext(ctx aws.Context, input *s3.DeleteObjectInput,
+	opts ...request.Option) (*s3.DeleteObjectOutput, error) {
+	delete(f.objects, *input.Bucket+"/"+*input.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestS3Store(t *testing.T) {
+	svc := &fakeS3{
+		objects: map[string]string{},
+	}
+	s := &S3Store{
+		bucket: "bucket",
+		prefix: "etcd",
+		svc:    svc,
+	}
+
+	n, err := s.Put(context.Background(), "kube/snapshot.db", strings.NewReader("data"))
+	if err != nil {
+		t.Fatalf("put: unexpected error %v", err)
+	}
+
+	if n != 4 {
+		t.Errorf("wrong size expected 4 actual %d", n)
+	}
+
+	if svc.objects["bucket/etcd/kube/snapshot.db"] != "data" {
+		t.Errorf("object has not been uploaded %v", svc.objects)
+	}
+
+	r, err := s.Get(context.Background(), "kube/snapshot.db")
+	if err != nil {
+		t.Fatalf("get: unexpected error %v", err)
+	}
+
+	data, _ := ioutil.ReadAll(r)
+	if string(data) != "data" {
+		t.Errorf("wrong content expected data actual %s", string(data))
+	}
+
+	if err := s.Delete(context.Background(), "kube/snapshot.db"); err != nil {
+		t.Fatalf("delete: unexpected error %v", err)
+	}
+
+	if _, err := s.Get(context.Background(), "kube/snapshot.db"); !sgerrors.IsNotFound(err) {
+		t.Errorf("expected not found actual %v", err)
+	}
+}
+
+func TestS3StorePutError(t *testing.T) {
+	s := &S3Store{
+		bucket: "bucket",
+		svc: &fakeS3{
+			err: errors.New("access denied"),
+		},
+	}
+
+	if _, err := s.Put(context.Background(), "kube/snapshot.db", strings.NewReader("data")); err == nil {
+		t.Errorf("error expected")
+	}
+}
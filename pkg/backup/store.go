@@ -0,0 +1,60 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Store keeps etcd snapshots of kubes, snapshots are addressed by keys
+// that look like relative file paths e.g. kubeID/snapshotID.db.
+type Store interface {
+	// Put saves content of the reader under the key and returns its size.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Get returns content of the key, sgerrors.ErrNotFound is returned
+	// when there is no such key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DefaultDir is the local directory of snapshots when none is configured.
+const DefaultDir = "/var/lib/supergiant/backups"
+
+// Config chooses where snapshots are kept, the S3 bucket is used
+// when it is set, otherwise snapshots are kept in the local directory.
+type Config struct {
+	Dir string
+
+	Bucket string
+	Prefix string
+	Region string
+}
+
+// NewStore creates a store configured by cfg.
+func NewStore(cfg Config) (Store, error) {
+	if cfg.Bucket != "" {
+		return NewS3Store(cfg.Bucket, cfg.Prefix, cfg.Region)
+	}
+
+	if cfg.Dir == "" {
+		return NewLocalStore(DefaultDir)
+	}
+
+	return NewLocalStore(cfg.Dir)
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return errors.Errorf("invalid key %q", key)
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return errors.Errorf("invalid key %q", key)
+		}
+	}
+
+	return nil
+}
@@ -10,6 +10,9 @@ const (
 	Packet       Name = "packet"
 	GCE          Name = "gce"
 	OpenStack    Name = "openstack"
+	// BareMetal is a provider of machines that already exist
+	// and are reachable over SSH.
+	BareMetal Name = "baremetal"
 
 	Unknown Name = "unknown"
 )
@@ -26,6 +29,8 @@ func ToProvider(name string) (Name, error) {
 		return GCE, nil
 	case string(OpenStack):
 		return OpenStack, nil
+	case string(BareMetal):
+		return BareMetal, nil
 	}
 	return Unknown, errors.New("invalid provider")
 }
@@ -40,6 +45,10 @@ const (
 	GCEClientEmail = "client_email"
 	GCETokenURI    = "token_uri"
 
+	BareMetalUser       = "user"
+	BareMetalPrivateKey = "privateKey"
+	BareMetalPort       = "port"
+
 	ClusterIDTag                = "supergiant.io/cluster-id"
 
 	AWSAccessKeyID             = "access_key"
@@ -58,4 +67,6 @@ const (
 	AwsMasterInstanceProfile    = "aws_master_instance_profile"
 	AwsNodeInstanceProfile      = "aws_node_instance_profile"
 	AwsImageID = "aws_image_id"
+	// AwsResourcesTagged is set for kubes that tag cloud resources they create
+	AwsResourcesTagged = "aws_resources_tagged"
 )
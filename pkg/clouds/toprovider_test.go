@@ -31,6 +31,10 @@ func TestToProvider(t *testing.T) {
 			str:     "gce",
 			isValid: true,
 		},
+		{
+			str:     "baremetal",
+			isValid: true,
+		},
 		{
 			str:     "foobar",
 			isValid: false,
@@ -17,6 +17,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/supergiant/control/pkg/account"
 	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/backup"
+	"github.com/supergiant/control/pkg/events"
 	"github.com/supergiant/control/pkg/jwt"
 	"github.com/supergiant/control/pkg/kube"
 	"github.com/supergiant/control/pkg/profile"
@@ -33,6 +35,7 @@ import (
 	"github.com/supergiant/control/pkg/workflows"
 	"github.com/supergiant/control/pkg/workflows/steps/amazon"
 	"github.com/supergiant/control/pkg/workflows/steps/authorizedKeys"
+	"github.com/supergiant/control/pkg/workflows/steps/baremetal"
 	"github.com/supergiant/control/pkg/workflows/steps/certificates"
 	"github.com/supergiant/control/pkg/workflows/steps/clustercheck"
 	"github.com/supergiant/control/pkg/workflows/steps/cni"
@@ -47,9 +50,11 @@ import (
 	"github.com/supergiant/control/pkg/workflows/steps/network"
 	"github.com/supergiant/control/pkg/workflows/steps/poststart"
 	"github.com/supergiant/control/pkg/workflows/steps/prometheus"
+	"github.com/supergiant/control/pkg/workflows/steps/restart"
 	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 	"github.com/supergiant/control/pkg/workflows/steps/storageclass"
 	"github.com/supergiant/control/pkg/workflows/steps/tiller"
+	"github.com/supergiant/control/pkg/workflows/steps/upgradecheck"
 	"k8s.io/helm/pkg/repo"
 )
 
@@ -92,9 +97,29 @@ type Config struct {
 
 	ProxiesPortRange proxy.PortRange
 
+	// Backup is where etcd snapshots of kubes are kept
+	Backup backup.Config
+
+	// OrphansGracePeriod is how long resources of failed or deleted
+	// kubes are kept in clouds, zero disables their deletion
+	OrphansGracePeriod time.Duration
+
+	// Events is retention of events of kubes
+	Events EventsConfig
+
+	// StepParallelism is how many steps of a task may run at once,
+	// zero keeps the default
+	StepParallelism int
+
 	Version string
 }
 
+// EventsConfig tells how long events of kubes are kept.
+type EventsConfig struct {
+	MaxAge     time.Duration
+	MaxPerKube int
+}
+
 func New(cfg *Config) (*Server, error) {
 	if err := validate(cfg); err != nil {
 		return nil, err
@@ -205,6 +230,11 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 	protectedAPI := router.PathPrefix("/v1/api").Subrouter()
 	repository := storage.NewETCDRepository(etcdCfg)
 
+	// Events are recorded from the start so that reconcile of tasks is recorded too
+	eventStore := events.NewStore(repository, cfg.Events.MaxAge, cfg.Events.MaxPerKube)
+	events.SetRecorder(eventStore)
+	go eventStore.Run(context.Background(), events.DefaultPruneInterval)
+
 	accountService := account.NewService(account.DefaultStoragePrefix, repository)
 	accountHandler := account.NewHandler(accountService)
 	accountHandler.Register(protectedAPI)
@@ -246,6 +276,15 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 	prometheus.Init()
 	gce.Init()
 	storageclass.Init()
+	upgradecheck.Init()
+	restart.Init()
+	baremetal.Init()
+
+	backupStore, err := backup.NewStore(cfg.Backup)
+	if err != nil {
+		return nil, errors.Wrap(err, "new backup store")
+	}
+	etcd.InitRestore(backupStore)
 
 	amazon.InitFindAMI(amazon.GetEC2)
 	amazon.InitImportKeyPair(amazon.GetEC2)
@@ -267,7 +306,15 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 	amazon.InitDeleteRouteTable(amazon.GetEC2)
 	amazon.InitDeleteInternetGateWay(amazon.GetEC2)
 	amazon.InitDeleteKeyPair(amazon.GetEC2)
-	workflows.Init()
+	amazon.InitCreateLoadBalancer(amazon.GetELB, amazon.GetEC2)
+	amazon.InitRegisterLoadBalancer(amazon.GetELB)
+	amazon.InitDeleteLoadBalancer(amazon.GetELB)
+	if err := workflows.Init(); err != nil {
+		return nil, errors.Wrap(err, "init workflows")
+	}
+	if cfg.StepParallelism > 0 {
+		workflows.SetParallelism(cfg.StepParallelism)
+	}
 
 	taskHandler := workflows.NewTaskHandler(repository, sshRunner.NewRunner, accountService)
 	taskHandler.Register(protectedAPI)
@@ -287,8 +334,14 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 	taskProvisioner := provisioner.NewProvisioner(repository,
 		kubeService,
 		cfg.SpawnInterval)
+	// Resume provisioning that has been interrupted by previous shutdown
+	go func() {
+		if err := taskProvisioner.Reconcile(context.Background()); err != nil {
+			logrus.Errorf("reconcile interrupted tasks: %v", err)
+		}
+	}()
 	provisionHandler := provisioner.NewHandler(kubeService, accountService,
-		taskProvisioner)
+		profileService, taskProvisioner)
 	provisionHandler.Register(protectedAPI)
 	apiProxy := proxy.NewReverseProxyContainer(cfg.ProxiesPortRange, logrus.New().WithField("component", "proxy"))
 
@@ -296,6 +349,36 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 		taskProvisioner, repository, apiProxy)
 	kubeHandler.Register(protectedAPI)
 
+	repairer := kube.NewRepairer(kubeHandler, repository, kube.DefaultRepairInterval)
+	go repairer.Run(context.Background())
+
+	autoscaler := kube.NewAutoscaler(kubeHandler, repository, kube.DefaultAutoscalerInterval)
+	go autoscaler.Run(context.Background())
+
+	backuper := kube.NewBackuper(kubeHandler, repository, backupStore, kube.DefaultBackupInterval)
+	backuper.Register(protectedAPI)
+	go backuper.Run(context.Background())
+
+	certChecker := kube.NewCertChecker(kubeHandler, repository, kube.DefaultCertCheckInterval)
+	certChecker.Register(protectedAPI)
+	go certChecker.Run(context.Background())
+
+	healthChecker := kube.NewHealthChecker(kubeHandler, repository, certChecker, kube.DefaultHealthCheckInterval)
+	healthChecker.Register(protectedAPI)
+	go healthChecker.Run(context.Background())
+
+	scheduler := kube.NewScheduler(kubeHandler, repository, kube.DefaultSchedulerInterval)
+	scheduler.Register(protectedAPI)
+	go scheduler.Run(context.Background())
+
+	orphanCollector := kube.NewOrphanCollector(kubeHandler, accountService, repository,
+		kube.DefaultOrphanCheckInterval, cfg.OrphansGracePeriod)
+	orphanCollector.Register(protectedAPI)
+	go orphanCollector.Run(context.Background())
+
+	eventsHandler := kube.NewEventsHandler(eventStore)
+	eventsHandler.Register(protectedAPI)
+
 	authMiddleware := api.Middleware{
 		TokenService: jwtService,
 	}
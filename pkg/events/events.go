@@ -0,0 +1,135 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Type is a kind of the event that has happened to a kube.
+type Type string
+
+const (
+	KubeCreated      Type = "KubeCreated"
+	KubeDeleted      Type = "KubeDeleted"
+	KubeStateChanged Type = "KubeStateChanged"
+	NodeAdded        Type = "NodeAdded"
+	NodeFailed       Type = "NodeFailed"
+	NodeRemoved      Type = "NodeRemoved"
+	TaskStarted      Type = "TaskStarted"
+	TaskSucceeded    Type = "TaskSucceeded"
+	TaskFailed       Type = "TaskFailed"
+	TaskCancelled    Type = "TaskCancelled"
+	StepFailed       Type = "StepFailed"
+	ReleaseInstalled Type = "ReleaseInstalled"
+	ReleaseDeleted   Type = "ReleaseDeleted"
+	HealthDegraded   Type = "HealthDegraded"
+	HealthRecovered  Type = "HealthRecovered"
+)
+
+// Severity tells whether the event needs attention.
+type Severity string
+
+const (
+	Normal  Severity = "normal"
+	Warning Severity = "warning"
+)
+
+// warnings are types of events that are emitted with warning severity
+// unless the severity is set explicitly.
+var warnings = map[Type]bool{
+	NodeFailed:     true,
+	TaskFailed:     true,
+	StepFailed:     true,
+	HealthDegraded: true,
+}
+
+// Event is a record of something that has happened to a kube,
+// node and task references are set when the event concerns them.
+type Event struct {
+	ID       string            `json:"id"`
+	Type     Type              `json:"type"`
+	Severity Severity          `json:"severity"`
+	KubeID   string            `json:"kubeId"`
+	NodeName string            `json:"nodeName,omitempty"`
+	TaskID   string            `json:"taskId,omitempty"`
+	Message  string            `json:"message"`
+	Time     time.Time         `json:"time"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// Recorder persists events.
+type Recorder interface {
+	Record(ctx context.Context, e *Event) error
+}
+
+const recordTimeout = 10 * time.Second
+
+var (
+	m        sync.RWMutex
+	recorder Recorder
+)
+
+// SetRecorder sets the recorder that all emitted events go to,
+// events are dropped until the recorder is set.
+func SetRecorder(r Recorder) {
+	m.Lock()
+	defer m.Unlock()
+	recorder = r
+}
+
+// Emit records the event, failures are logged since an event must
+// never break the operation that emits it.
+func Emit(e Event) {
+	m.RLock()
+	r := recorder
+	m.RUnlock()
+
+	if r == nil || e.KubeID == "" {
+		return
+	}
+
+	if e.Severity == "" {
+		e.Severity = Normal
+		if warnings[e.Type] {
+			e.Severity = Warning
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+
+	if err := r.Record(ctx, &e); err != nil {
+		logrus.Errorf("events: record %s of kube %s: %v", e.Type, e.KubeID, err)
+	}
+}
+
+// Emitf is a shortcut for emitting an event of the kube with a formatted message.
+func Emitf(kubeID string, t Type, format string, args ...interface{}) {
+	Emit(Event{
+		Type:    t,
+		KubeID:  kubeID,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// StateChanged emits a change of the kube state, reason may be empty.
+func StateChanged(kubeID string, from, to interface{}, reason string) {
+	msg := fmt.Sprintf("kube state has changed from %s to %s", from, to)
+	if reason != "" {
+		msg += ": " + reason
+	}
+
+	Emit(Event{
+		Type:    KubeStateChanged,
+		KubeID:  kubeID,
+		Message: msg,
+		Details: map[string]string{
+			"from": fmt.Sprint(from),
+			"to":   fmt.Sprint(to),
+		},
+	})
+}
@@ -0,0 +1,208 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	// Events are stored under the prefix of their kube ordered by time
+	DefaultStoragePrefix = "/events/"
+
+	DefaultMaxAge        = 7 * 24 * time.Hour
+	DefaultMaxEvents     = 1000
+	DefaultPruneInterval = time.Hour
+)
+
+// Filter selects events, empty fields match any event.
+type Filter struct {
+	Types    []Type
+	Severity Severity
+	NodeName string
+	TaskID   string
+	Since    time.Time
+	Until    time.Time
+	// After selects events that have been recorded after the event with this ID
+	After string
+	// Limit selects the latest events
+	Limit int
+}
+
+// Match returns true if the event is selected by the filter.
+func (f Filter) Match(e *Event) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if e.Type == t {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	switch {
+	case f.Severity != "" && e.Severity != f.Severity:
+		return false
+	case f.NodeName != "" && e.NodeName != f.NodeName:
+		return false
+	case f.TaskID != "" && e.TaskID != f.TaskID:
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && e.Time.After(f.Until):
+		return false
+	case f.After != "" && e.ID <= f.After:
+		return false
+	}
+
+	return true
+}
+
+// Store keeps events of kubes, events older than max age and the oldest
+// events of kubes that have more than max events are pruned, zero values
+// disable the limits.
+type Store struct {
+	repo      storage.Interface
+	prefix    string
+	maxAge    time.Duration
+	maxEvents int
+
+	now func() time.Time
+}
+
+func NewStore(repo storage.Interface, maxAge time.Duration, maxEvents int) *Store {
+	return &Store{
+		repo:      repo,
+		prefix:    DefaultStoragePrefix,
+		maxAge:    maxAge,
+		maxEvents: maxEvents,
+		now:       time.Now,
+	}
+}
+
+// Record saves the event, ID is made of the event time so that events
+// of the kube are kept in the order they have happened.
+func (s *Store) Record(ctx context.Context, e *Event) error {
+	if e.KubeID == "" {
+		return errors.New("kube id is empty")
+	}
+
+	if e.Time.IsZero() {
+		e.Time = s.now()
+	}
+	e.ID = fmt.Sprintf("%019d-%s", e.Time.UnixNano(), uuid.New()[:8])
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+
+	return errors.Wrap(s.repo.Put(ctx, s.prefix, e.KubeID+"/"+e.ID, data), "storage: put")
+}
+
+// List returns events of the kube that match the filter, the oldest first.
+func (s *Store) List(ctx context.Context, kubeID string, f Filter) ([]Event, error) {
+	all, err := s.list(ctx, kubeID+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Event, 0)
+	for i := range all {
+		if f.Match(&all[i]) {
+			result = append(result, all[i])
+		}
+	}
+
+	if f.Limit > 0 && len(result) > f.Limit {
+		result = result[len(result)-f.Limit:]
+	}
+
+	return result, nil
+}
+
+// Run prunes events periodically until the context is done.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Prune(ctx); err != nil {
+				logrus.Errorf("events: prune %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Prune deletes events that have exceeded retention.
+func (s *Store) Prune(ctx context.Context) error {
+	all, err := s.list(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	count := make(map[string]int)
+	for _, e := range all {
+		count[e.KubeID]++
+	}
+
+	oldest := s.now().Add(-s.maxAge)
+	// Events are sorted by time, so the oldest events of the kube come first
+	for _, e := range all {
+		expired := s.maxAge > 0 && !e.Time.After(oldest)
+		if !expired && (s.maxEvents <= 0 || count[e.KubeID] <= s.maxEvents) {
+			continue
+		}
+
+		if err := s.repo.Delete(ctx, s.prefix, e.KubeID+"/"+e.ID); err != nil {
+			return errors.Wrapf(err, "delete event %s of kube %s", e.ID, e.KubeID)
+		}
+		count[e.KubeID]--
+	}
+
+	return nil
+}
+
+func (s *Store) list(ctx context.Context, prefix string) ([]Event, error) {
+	rawEvents, err := s.repo.GetAll(ctx, s.prefix+prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "storage: get all")
+	}
+
+	result := make([]Event, 0, len(rawEvents))
+	for _, raw := range rawEvents {
+		e := Event{}
+		if err := json.Unmarshal(raw, &e); err != nil {
+			logrus.Warnf("events: unmarshal %v", err)
+			continue
+		}
+
+		result = append(result, e)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].KubeID != result[j].KubeID {
+			return result[i].KubeID < result[j].KubeID
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
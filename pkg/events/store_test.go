@@ -0,0 +1,191 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memRepository struct {
+	m    sync.Mutex
+	data map[string][]byte
+}
+
+func (r *memRepository) Put(ctx context.Context, prefix string, key string, value []byte) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.data[prefix+key] = value
+	return nil
+}
+
+func (r *memRepository) Get(ctx context.Context, prefix string, key string) ([]byte, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.data[prefix+key], nil
+}
+
+func (r *memRepository) GetAll(ctx context.Context, prefix string) ([][]byte, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	result := make([][]byte, 0)
+	for key, value := range r.data {
+		if strings.HasPrefix(key, prefix) {
+			result = append(result, value)
+		}
+	}
+	return result, nil
+}
+
+func (r *memRepository) Delete(ctx context.Context, prefix string, key string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.data, prefix+key)
+	return nil
+}
+
+func newTestStore(maxAge time.Duration, maxEvents int) (*Store, *time.Time) {
+	now := time.Date(2019, 1, 1, 10, 0, 0, 0, time.UTC)
+	s := NewStore(&memRepository{data: map[string][]byte{}}, maxAge, maxEvents)
+	s.now = func() time.Time {
+		return now
+	}
+
+	return s, &now
+}
+
+func TestStoreList(t *testing.T) {
+	s, now := newTestStore(DefaultMaxAge, DefaultMaxEvents)
+	ctx := context.Background()
+
+	for _, e := range []Event{
+		{Type: KubeCreated, KubeID: "abcd", Severity: Normal},
+		{Type: NodeAdded, KubeID: "abcd", NodeName: "node-1", Severity: Normal},
+		{Type: StepFailed, KubeID: "abcd", NodeName: "node-2", TaskID: "task-2", Severity: Warning},
+		{Type: NodeAdded, KubeID: "abcde", NodeName: "node-1", Severity: Normal},
+		{Type: KubeStateChanged, KubeID: "abcd", Severity: Normal},
+	} {
+		e := e
+		require.Nil(t, s.Record(ctx, &e))
+		*now = now.Add(time.Minute)
+	}
+
+	require.NotNil(t, s.Record(ctx, &Event{Type: NodeAdded}), "kube id is required")
+
+	testCases := []struct {
+		description string
+		filter      Filter
+		expected    []Type
+	}{
+		{
+			description: "all events of kube",
+			expected:    []Type{KubeCreated, NodeAdded, StepFailed, KubeStateChanged},
+		},
+		{
+			description: "types",
+			filter:      Filter{Types: []Type{NodeAdded, KubeStateChanged}},
+			expected:    []Type{NodeAdded, KubeStateChanged},
+		},
+		{
+			description: "warnings",
+			filter:      Filter{Severity: Warning},
+			expected:    []Type{StepFailed},
+		},
+		{
+			description: "node",
+			filter:      Filter{NodeName: "node-2"},
+			expected:    []Type{StepFailed},
+		},
+		{
+			description: "task",
+			filter:      Filter{TaskID: "task-1"},
+			expected:    []Type{},
+		},
+		{
+			description: "time range",
+			filter: Filter{
+				Since: time.Date(2019, 1, 1, 10, 1, 0, 0, time.UTC),
+				Until: time.Date(2019, 1, 1, 10, 2, 0, 0, time.UTC),
+			},
+			expected: []Type{NodeAdded, StepFailed},
+		},
+		{
+			description: "latest",
+			filter:      Filter{Limit: 2},
+			expected:    []Type{StepFailed, KubeStateChanged},
+		},
+	}
+
+	for _, testCase := range testCases {
+		list, err := s.List(ctx, "abcd", testCase.filter)
+		require.Nil(t, err, testCase.description)
+
+		types := make([]Type, 0)
+		for _, e := range list {
+			types = append(types, e.Type)
+		}
+		require.Equal(t, testCase.expected, types, testCase.description)
+	}
+
+	list, err := s.List(ctx, "abcd", Filter{})
+	require.Nil(t, err)
+	after, err := s.List(ctx, "abcd", Filter{After: list[1].ID})
+	require.Nil(t, err)
+	require.Equal(t, list[2:], after)
+}
+
+func TestStorePrune(t *testing.T) {
+	s, now := newTestStore(2*time.Hour, 3)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		require.Nil(t, s.Record(ctx, &Event{Type: NodeAdded, KubeID: "abcd"}))
+		require.Nil(t, s.Record(ctx, &Event{Type: NodeAdded, KubeID: "efgh"}))
+		*now = now.Add(20 * time.Minute)
+	}
+
+	// Events of efgh are recorded at 10:00, 10:20, 10:40, 11:00 and 11:20
+	require.Nil(t, s.Prune(ctx))
+
+	list, err := s.List(ctx, "efgh", Filter{})
+	require.Nil(t, err)
+	require.Len(t, list, 3)
+	require.Equal(t, time.Date(2019, 1, 1, 10, 40, 0, 0, time.UTC), list[0].Time)
+
+	*now = now.Add(2 * time.Hour)
+	require.Nil(t, s.Prune(ctx))
+
+	list, err = s.List(ctx, "abcd", Filter{})
+	require.Nil(t, err)
+	require.Empty(t, list)
+}
+
+type recorderFunc func(ctx context.Context, e *Event) error
+
+func (f recorderFunc) Record(ctx context.Context, e *Event) error {
+	return f(ctx, e)
+}
+
+func TestEmit(t *testing.T) {
+	var recorded []Event
+	SetRecorder(recorderFunc(func(ctx context.Context, e *Event) error {
+		recorded = append(recorded, *e)
+		return nil
+	}))
+	defer SetRecorder(nil)
+
+	Emitf("abcd", TaskFailed, "task %s has failed", "master")
+	Emitf("abcd", TaskSucceeded, "task %s has finished", "master")
+	Emitf("", TaskSucceeded, "events without kube are dropped")
+	StateChanged("abcd", "provisioning", "operational", "")
+
+	require.Len(t, recorded, 3)
+	require.Equal(t, Warning, recorded[0].Severity)
+	require.Equal(t, "task master has failed", recorded[0].Message)
+	require.Equal(t, Normal, recorded[1].Severity)
+	require.Equal(t, "operational", recorded[2].Details["to"])
+}
@@ -0,0 +1,559 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	// DefaultAutoscalerPrefix is a storage prefix of scaling decisions of kubes
+	DefaultAutoscalerPrefix   = "/autoscaler/"
+	DefaultAutoscalerInterval = time.Minute
+
+	defaultScaleUpCooldown      = "3m"
+	defaultScaleDownCooldown    = "10m"
+	defaultScaleDownUtilization = 50
+
+	// maxScalingDecisions is a number of latest decisions kept per kube
+	maxScalingDecisions = 100
+)
+
+type ScalingAction string
+
+const (
+	ScaleUp   ScalingAction = "scaleUp"
+	ScaleDown ScalingAction = "scaleDown"
+)
+
+// ScalingDecision is a change of worker count made by the autoscaler,
+// decisions made in dry run mode are recorded but not applied.
+type ScalingDecision struct {
+	Time   time.Time     `json:"time"`
+	Action ScalingAction `json:"action"`
+	Count  int           `json:"count"`
+	Nodes  []string      `json:"nodes,omitempty"`
+	Reason string        `json:"reason"`
+	DryRun bool          `json:"dryRun"`
+	Tasks  []string      `json:"tasks,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// Autoscaler periodically adds workers to kubes that have unschedulable
+// pods and removes underutilized ones within autoscaling limits of the kube.
+type Autoscaler struct {
+	h           *Handler
+	repo        storage.Interface
+	interval    time.Duration
+	pendingPods func(*model.Kube) ([]corev1.Pod, error)
+	now         func() time.Time
+}
+
+func NewAutoscaler(h *Handler, repo storage.Interface, interval time.Duration) *Autoscaler {
+	return &Autoscaler{
+		h:        h,
+		repo:     repo,
+		interval: interval,
+		pendingPods: func(k *model.Kube) ([]corev1.Pod, error) {
+			client, err := coreV1ClientFor(k)
+			if err != nil {
+				return nil, errors.Wrap(err, "build kubernetes client")
+			}
+
+			podList, err := client.Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+				FieldSelector: fields.OneTermEqualSelector("status.phase", string(corev1.PodPending)).String(),
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "list pending pods")
+			}
+
+			return unschedulablePods(podList.Items), nil
+		},
+		now: time.Now,
+	}
+}
+
+// Run checks kubes until context is done.
+func (a *Autoscaler) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.scaleAll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *Autoscaler) scaleAll(ctx context.Context) {
+	kubes, err := a.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("autoscaler: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+		if !k.Autoscaling.Enabled || !operational(k) {
+			continue
+		}
+
+		if err := a.scaleKube(ctx, k); err != nil {
+			logrus.Errorf("autoscaler: kube %s: %v", k.ID, err)
+		}
+	}
+}
+
+// scaleKube makes at most one scaling decision for the kube per check.
+func (a *Autoscaler) scaleKube(ctx context.Context, k *model.Kube) error {
+	settings := autoscalingSettings(k.Autoscaling)
+	workers := poolNodes(k, settings.Pool)
+
+	// Wait until nodes that are being added or deleted settle down
+	for _, n := range workers {
+		if n.State != node.StateActive {
+			return nil
+		}
+	}
+
+	decisions, err := loadScalingDecisions(ctx, a.repo, k.ID)
+	if err != nil {
+		return err
+	}
+
+	decision, err := a.decide(k, settings, workers, decisions)
+	if err != nil || decision == nil {
+		return err
+	}
+
+	logrus.Infof("autoscaler: %s kube %s by %d, dry run %v: %s",
+		decision.Action, k.ID, decision.Count, decision.DryRun, decision.Reason)
+
+	if !decision.DryRun {
+		decision.Tasks, err = a.apply(ctx, k, settings, workers, decision)
+		if err != nil {
+			decision.Error = err.Error()
+		}
+	}
+
+	return a.record(ctx, k.ID, decisions, *decision)
+}
+
+// decide returns nil if count of workers should stay the same. Cooldowns
+// take dry run and failed decisions into account as well, so dry run
+// records decisions at the same pace the real autoscaler would make them.
+func (a *Autoscaler) decide(k *model.Kube, settings model.Autoscaling,
+	workers []*node.Node, decisions []ScalingDecision) (*ScalingDecision, error) {
+	now := a.now()
+	count := len(workers)
+
+	// NOTE: settings are validated when they are updated.
+	scaleUpCooldown, err := time.ParseDuration(settings.ScaleUpCooldown)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse scale up cooldown")
+	}
+	scaleDownCooldown, err := time.ParseDuration(settings.ScaleDownCooldown)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse scale down cooldown")
+	}
+
+	scaleUpAllowed := now.Sub(lastDecision(decisions, ScaleUp)) > scaleUpCooldown
+	scaleDownAllowed := now.Sub(lastDecision(decisions, "")) > scaleDownCooldown
+
+	newDecision := func(action ScalingAction, count int, reason string) *ScalingDecision {
+		return &ScalingDecision{
+			Time:   now,
+			Action: action,
+			Count:  count,
+			Reason: reason,
+			DryRun: settings.DryRun,
+		}
+	}
+
+	switch {
+	case count < settings.MinNodes:
+		if !scaleUpAllowed {
+			return nil, nil
+		}
+		return newDecision(ScaleUp, settings.MinNodes-count,
+			fmt.Sprintf("%d workers are less than minimum %d", count, settings.MinNodes)), nil
+	case count > settings.MaxNodes:
+		if !scaleDownAllowed {
+			return nil, nil
+		}
+		decision := newDecision(ScaleDown, count-settings.MaxNodes,
+			fmt.Sprintf("%d workers are more than maximum %d", count, settings.MaxNodes))
+		for _, n := range workers[settings.MaxNodes:] {
+			decision.Nodes = append(decision.Nodes, n.Name)
+		}
+		return decision, nil
+	}
+
+	pending, err := a.pendingPods(k)
+	if err != nil {
+		return nil, errors.Wrap(err, "get unschedulable pods")
+	}
+
+	// NOTE: pods are not matched against selectors and taints of the pool,
+	// a worker is added whenever any pod can't be scheduled.
+	if len(pending) > 0 {
+		if count >= settings.MaxNodes || !scaleUpAllowed {
+			return nil, nil
+		}
+		return newDecision(ScaleUp, 1,
+			fmt.Sprintf("%d pods are unschedulable", len(pending))), nil
+	}
+
+	if count <= settings.MinNodes || !scaleDownAllowed {
+		return nil, nil
+	}
+
+	metrics, err := a.h.nodesMetrics(k)
+	if err != nil {
+		return nil, errors.Wrap(err, "get nodes metrics")
+	}
+
+	n, usage := underutilizedNode(workers, metrics, settings.ScaleDownUtilization)
+	if n == nil {
+		return nil, nil
+	}
+
+	decision := newDecision(ScaleDown, 1,
+		fmt.Sprintf("node %s utilization %.0f%% is below %d%%", n.Name, usage, settings.ScaleDownUtilization))
+	decision.Nodes = []string{n.Name}
+
+	return decision, nil
+}
+
+// apply provisions nodes with the profile of existing workers or
+// drains and deletes nodes of the decision one at a time.
+func (a *Autoscaler) apply(ctx context.Context, k *model.Kube, settings model.Autoscaling,
+	workers []*node.Node, decision *ScalingDecision) ([]string, error) {
+	acc, err := a.h.accountService.Get(ctx, k.AccountName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get account %s", k.AccountName)
+	}
+
+	pool := k.NodePools[settings.Pool]
+	tasks := make([]string, 0, decision.Count)
+
+	var plan []deleteNodeStep
+
+	switch decision.Action {
+	case ScaleUp:
+		template := &node.Node{Pool: settings.Pool}
+		if len(workers) > 0 {
+			template = workers[len(workers)-1]
+		}
+
+		nodeProfile, _, err := replacementProfile(k, template)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeProfiles := make([]profile.NodeProfile, 0, decision.Count)
+		for i := 0; i < decision.Count; i++ {
+			nodeProfiles = append(nodeProfiles, nodeProfile)
+		}
+
+		tasks, err = a.h.provisionNodes(ctx, k, acc, nodeProfiles, pool)
+		if err != nil {
+			return nil, errors.Wrap(err, "provision nodes")
+		}
+	case ScaleDown:
+		for _, name := range decision.Nodes {
+			n := k.Nodes[name]
+			if n == nil {
+				return nil, errors.Wrapf(sgerrors.ErrNotFound, "node %s", name)
+			}
+
+			t, config, err := a.h.deleteNodeTask(ctx, k, acc, n)
+			if err != nil {
+				return nil, errors.Wrapf(err, "delete node %s", name)
+			}
+
+			plan = append(plan, deleteNodeStep{
+				task:   t,
+				config: config,
+				kube:   k,
+				node:   n,
+			})
+			tasks = append(tasks, t.ID)
+		}
+	}
+
+	if pool != nil {
+		if decision.Action == ScaleUp {
+			pool.Count = len(workers) + decision.Count
+		} else {
+			pool.Count = len(workers) - decision.Count
+		}
+	}
+
+	// Kube is saved before nodes are deleted, removal updates the kube on its own
+	k.Tasks = append(k.Tasks, tasks...)
+	if err := a.h.svc.Create(ctx, k); err != nil {
+		return tasks, errors.Wrap(err, "save kube")
+	}
+
+	if len(plan) > 0 {
+		go a.h.runPoolScaleDown(k.ID, settings.Pool, plan)
+	}
+
+	return tasks, nil
+}
+
+func (a *Autoscaler) record(ctx context.Context, kubeID string, decisions []ScalingDecision, decision ScalingDecision) error {
+	decisions = append(decisions, decision)
+	if len(decisions) > maxScalingDecisions {
+		decisions = decisions[len(decisions)-maxScalingDecisions:]
+	}
+
+	data, err := json.Marshal(decisions)
+	if err != nil {
+		return errors.Wrap(err, "marshal scaling decisions")
+	}
+
+	return errors.Wrap(a.repo.Put(ctx, DefaultAutoscalerPrefix, kubeID, data), "save scaling decisions")
+}
+
+func loadScalingDecisions(ctx context.Context, repo storage.Interface, kubeID string) ([]ScalingDecision, error) {
+	decisions := make([]ScalingDecision, 0)
+
+	data, err := repo.Get(ctx, DefaultAutoscalerPrefix, kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return decisions, nil
+		}
+		return nil, errors.Wrap(err, "get scaling decisions")
+	}
+
+	if err := json.Unmarshal(data, &decisions); err != nil {
+		return nil, errors.Wrap(err, "unmarshal scaling decisions")
+	}
+
+	return decisions, nil
+}
+
+// lastDecision returns time of the latest decision with the action,
+// empty action matches any decision.
+func lastDecision(decisions []ScalingDecision, action ScalingAction) time.Time {
+	for i := len(decisions) - 1; i >= 0; i-- {
+		if action == "" || decisions[i].Action == action {
+			return decisions[i].Time
+		}
+	}
+
+	return time.Time{}
+}
+
+// unschedulablePods returns pods the scheduler has failed to find a node for.
+func unschedulablePods(pods []corev1.Pod) []corev1.Pod {
+	unschedulable := make([]corev1.Pod, 0)
+
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse &&
+				cond.Reason == corev1.PodReasonUnschedulable {
+				unschedulable = append(unschedulable, pod)
+				break
+			}
+		}
+	}
+
+	return unschedulable
+}
+
+// underutilizedNode returns the least utilized worker whose cpu and memory
+// utilization in percents are both below threshold. Workers without
+// metrics are never removed.
+func underutilizedNode(workers []*node.Node, metrics map[string]map[string]interface{},
+	threshold int) (*node.Node, float64) {
+	var (
+		candidate *node.Node
+		minUsage  float64
+	)
+
+	for _, n := range workers {
+		nodeMetrics := metrics[strings.ToLower(n.Name)]
+
+		cpu, cpuOk := utilization(nodeMetrics["cpu"])
+		memory, memoryOk := utilization(nodeMetrics["memory"])
+		if !cpuOk || !memoryOk {
+			continue
+		}
+
+		usage := math.Max(cpu, memory) * 100
+		if usage < float64(threshold) && (candidate == nil || usage < minUsage) {
+			candidate, minUsage = n, usage
+		}
+	}
+
+	return candidate, minUsage
+}
+
+// utilization parses a value of prometheus query result, prometheus
+// returns sample values as strings.
+func utilization(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// autoscalingSettings fills settings that are not set with defaults.
+func autoscalingSettings(settings model.Autoscaling) model.Autoscaling {
+	if settings.ScaleUpCooldown == "" {
+		settings.ScaleUpCooldown = defaultScaleUpCooldown
+	}
+	if settings.ScaleDownCooldown == "" {
+		settings.ScaleDownCooldown = defaultScaleDownCooldown
+	}
+	if settings.ScaleDownUtilization == 0 {
+		settings.ScaleDownUtilization = defaultScaleDownUtilization
+	}
+
+	return settings
+}
+
+func validateAutoscaling(k *model.Kube, settings model.Autoscaling) error {
+	if settings.MinNodes < 0 || settings.MaxNodes < settings.MinNodes {
+		return errors.Errorf("node counts must satisfy 0 <= minNodes <= maxNodes, got %d and %d",
+			settings.MinNodes, settings.MaxNodes)
+	}
+
+	if settings.Enabled && settings.MaxNodes == 0 {
+		return errors.New("maxNodes must be positive")
+	}
+
+	if settings.ScaleDownUtilization < 0 || settings.ScaleDownUtilization > 100 {
+		return errors.Errorf("scale down utilization must be between 0 and 100, got %d",
+			settings.ScaleDownUtilization)
+	}
+
+	for _, cooldown := range []string{settings.ScaleUpCooldown, settings.ScaleDownCooldown} {
+		if cooldown == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(cooldown); err != nil || d < 0 {
+			return errors.Errorf("%q is not a valid cooldown", cooldown)
+		}
+	}
+
+	if settings.Pool != "" && k.NodePools[settings.Pool] == nil {
+		return errors.Errorf("node pool %s not found", settings.Pool)
+	}
+
+	return nil
+}
+
+func (h *Handler) getAutoscaling(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(autoscalingSettings(k.Autoscaling)); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) updateAutoscaling(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	settings := model.Autoscaling{}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := validateAutoscaling(k, settings); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if settings.Enabled && provisioningDisabled(w, k) {
+		return
+	}
+
+	k.Autoscaling = autoscalingSettings(settings)
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(k.Autoscaling); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) getScalingDecisions(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	if _, err := h.svc.Get(r.Context(), kubeID); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	decisions, err := loadScalingDecisions(r.Context(), h.repo, kubeID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(decisions); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
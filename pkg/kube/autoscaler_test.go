@@ -0,0 +1,477 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func unschedulablePod(name string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{
+				{
+					Type:   corev1.PodScheduled,
+					Status: corev1.ConditionFalse,
+					Reason: corev1.PodReasonUnschedulable,
+				},
+			},
+		},
+	}
+}
+
+// metricsFunc returns the same utilization for cpu and memory of nodes.
+func metricsFunc(usage map[string]string) func(string, *model.Kube) (*MetricResponse, error) {
+	return func(string, *model.Kube) (*MetricResponse, error) {
+		results := make([]string, 0, len(usage))
+		for name, value := range usage {
+			results = append(results, fmt.Sprintf(`{"metric":{"node":%q},"value":[0,%q]}`, name, value))
+		}
+
+		resp := &MetricResponse{}
+		err := json.Unmarshal([]byte(`{"data":{"result":[`+strings.Join(results, ",")+`]}}`), resp)
+		return resp, err
+	}
+}
+
+func TestUnschedulablePods(t *testing.T) {
+	pending := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pulling",
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+		},
+	}
+
+	pods := unschedulablePods([]corev1.Pod{pending, unschedulablePod("web")})
+
+	if len(pods) != 1 || pods[0].Name != "web" {
+		t.Errorf("wrong unschedulable pods %v", pods)
+	}
+}
+
+func TestUnderutilizedNode(t *testing.T) {
+	workers := []*node.Node{
+		{Name: "Node-1"},
+		{Name: "node-2"},
+		{Name: "node-3"},
+		{Name: "node-4"},
+	}
+	metrics := map[string]map[string]interface{}{
+		"node-1": {"cpu": "0.3", "memory": "0.2"},
+		"node-2": {"cpu": 0.1, "memory": 0.1},
+		"node-3": {"cpu": "0.9", "memory": "0.1"},
+	}
+
+	n, usage := underutilizedNode(workers, metrics, 50)
+	if n == nil || n.Name != "node-2" || usage != 10 {
+		t.Errorf("wrong underutilized node %v %v", n, usage)
+	}
+
+	if n, _ := underutilizedNode(workers, metrics, 5); n != nil {
+		t.Errorf("unexpected underutilized node %v", n)
+	}
+}
+
+func TestAutoscalerDecide(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		description string
+		minNodes    int
+		maxNodes    int
+		pending     []corev1.Pod
+		usage       map[string]string
+		decisions   []ScalingDecision
+
+		expectedAction ScalingAction
+		expectedCount  int
+		expectedNodes  []string
+	}{
+		{
+			description:    "below minimum",
+			minNodes:       5,
+			maxNodes:       10,
+			expectedAction: ScaleUp,
+			expectedCount:  2,
+		},
+		{
+			description:    "above maximum",
+			maxNodes:       1,
+			expectedAction: ScaleDown,
+			expectedCount:  2,
+			expectedNodes:  []string{"node-2", "node-3"},
+		},
+		{
+			description:    "unschedulable pods",
+			maxNodes:       10,
+			pending:        []corev1.Pod{unschedulablePod("web")},
+			expectedAction: ScaleUp,
+			expectedCount:  1,
+		},
+		{
+			description: "unschedulable pods at maximum",
+			maxNodes:    3,
+			pending:     []corev1.Pod{unschedulablePod("web")},
+		},
+		{
+			description: "scale up cooldown",
+			maxNodes:    10,
+			pending:     []corev1.Pod{unschedulablePod("web")},
+			decisions: []ScalingDecision{
+				{Time: now.Add(-time.Minute), Action: ScaleUp, DryRun: true},
+			},
+		},
+		{
+			description:    "underutilized",
+			maxNodes:       10,
+			usage:          map[string]string{"node-1": "0.7", "node-2": "0.2", "node-3": "0.3"},
+			expectedAction: ScaleDown,
+			expectedCount:  1,
+			expectedNodes:  []string{"node-2"},
+		},
+		{
+			description: "underutilized at minimum",
+			minNodes:    3,
+			maxNodes:    10,
+			usage:       map[string]string{"node-2": "0.2"},
+		},
+		{
+			description: "scale down cooldown",
+			maxNodes:    10,
+			usage:       map[string]string{"node-2": "0.2"},
+			decisions: []ScalingDecision{
+				{Time: now.Add(-5 * time.Minute), Action: ScaleUp},
+			},
+		},
+		{
+			description: "utilized",
+			maxNodes:    10,
+			usage:       map[string]string{"node-1": "0.7", "node-2": "0.8", "node-3": "0.6"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		k := &model.Kube{
+			ID: "test",
+			Masters: map[string]*node.Node{
+				"master-1": {Name: "master-1"},
+			},
+			Nodes: map[string]*node.Node{
+				"node-1": {Name: "node-1", State: node.StateActive},
+				"node-2": {Name: "node-2", State: node.StateActive},
+				"node-3": {Name: "node-3", State: node.StateActive},
+			},
+		}
+		settings := autoscalingSettings(model.Autoscaling{
+			MinNodes: testCase.minNodes,
+			MaxNodes: testCase.maxNodes,
+		})
+
+		a := &Autoscaler{
+			h: &Handler{
+				getMetrics: metricsFunc(testCase.usage),
+			},
+			pendingPods: func(*model.Kube) ([]corev1.Pod, error) {
+				return testCase.pending, nil
+			},
+			now: func() time.Time {
+				return now
+			},
+		}
+
+		decision, err := a.decide(k, settings, poolNodes(k, ""), testCase.decisions)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		if testCase.expectedAction == "" {
+			if decision != nil {
+				t.Errorf("%s: unexpected decision %v", testCase.description, decision)
+			}
+			continue
+		}
+
+		if decision == nil {
+			t.Errorf("%s: decision expected", testCase.description)
+			continue
+		}
+
+		if decision.Action != testCase.expectedAction || decision.Count != testCase.expectedCount {
+			t.Errorf("%s: wrong decision expected %s %d actual %s %d", testCase.description,
+				testCase.expectedAction, testCase.expectedCount, decision.Action, decision.Count)
+		}
+
+		if fmt.Sprint(decision.Nodes) != fmt.Sprint(testCase.expectedNodes) {
+			t.Errorf("%s: wrong nodes expected %v actual %v",
+				testCase.description, testCase.expectedNodes, decision.Nodes)
+		}
+	}
+}
+
+func TestAutoscalerScaleKube(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		k := pooledKube()
+		k.Autoscaling = model.Autoscaling{
+			Enabled:  true,
+			DryRun:   dryRun,
+			Pool:     "gpu",
+			MinNodes: 1,
+			MaxNodes: 5,
+		}
+		for _, n := range k.Nodes {
+			n.State = node.StateActive
+		}
+
+		svc := new(kubeServiceMock)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		accService := new(accServiceMock)
+		accService.On("Get", mock.Anything, mock.Anything).
+			Return(&model.CloudAccount{Provider: clouds.DigitalOcean}, nil)
+
+		provisioner := new(mockNodeProvisioner)
+		provisioner.On("ProvisionNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]string{"task-1"}, nil)
+
+		var saved []ScalingDecision
+		mockRepo := new(testutils.MockStorage)
+		mockRepo.On("Get", mock.Anything, DefaultAutoscalerPrefix, k.ID).
+			Return(nil, sgerrors.ErrNotFound)
+		mockRepo.On("Put", mock.Anything, DefaultAutoscalerPrefix, k.ID, mock.Anything).
+			Run(func(args mock.Arguments) {
+				json.Unmarshal(args.Get(3).([]byte), &saved)
+			}).
+			Return(nil)
+
+		h := &Handler{
+			svc:             svc,
+			accountService:  accService,
+			nodeProvisioner: provisioner,
+			repo:            mockRepo,
+		}
+
+		a := NewAutoscaler(h, mockRepo, time.Minute)
+		a.pendingPods = func(*model.Kube) ([]corev1.Pod, error) {
+			return []corev1.Pod{unschedulablePod("cuda")}, nil
+		}
+
+		if err := a.scaleKube(context.Background(), k); err != nil {
+			t.Errorf("dry run %v: unexpected error %v", dryRun, err)
+			continue
+		}
+
+		if len(saved) != 1 || saved[0].Action != ScaleUp || saved[0].DryRun != dryRun {
+			t.Errorf("dry run %v: wrong decisions %v", dryRun, saved)
+			continue
+		}
+
+		if dryRun {
+			provisioner.AssertNotCalled(t, "ProvisionNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			if k.NodePools["gpu"].Count != 2 {
+				t.Errorf("dry run must not change pool count, actual %d", k.NodePools["gpu"].Count)
+			}
+			continue
+		}
+
+		provisioner.AssertCalled(t, "ProvisionNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		if len(saved[0].Tasks) != 1 || saved[0].Error != "" {
+			t.Errorf("wrong decision %v", saved[0])
+		}
+		if k.NodePools["gpu"].Count != 3 {
+			t.Errorf("wrong pool count expected 3 actual %d", k.NodePools["gpu"].Count)
+		}
+	}
+}
+
+func TestAutoscalerScaleDown(t *testing.T) {
+	k := repairKubeFixture()
+	k.Autoscaling = model.Autoscaling{
+		Enabled:  true,
+		MaxNodes: 4,
+	}
+
+	// NOTE: kube is shared with the goroutine that deletes the node,
+	// test waits until the node is removed from the saved kube.
+	deleted := make(chan struct{})
+	once := sync.Once{}
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).
+		Return(k, nil)
+	svc.On(serviceCreate, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			if _, ok := args.Get(1).(*model.Kube).Nodes["node-5"]; !ok {
+				once.Do(func() { close(deleted) })
+			}
+		}).
+		Return(nil)
+
+	accService := new(accServiceMock)
+	accService.On("Get", mock.Anything, mock.Anything).
+		Return(&model.CloudAccount{Provider: clouds.DigitalOcean}, nil)
+
+	mockRepo := new(testutils.MockStorage)
+	mockRepo.On("Get", mock.Anything, DefaultAutoscalerPrefix, k.ID).
+		Return(nil, sgerrors.ErrNotFound)
+	mockRepo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	drainer := new(mockDrainer)
+	drainer.On("Drain", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	drainer.On("DeleteNode", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.DigitalOceanDeleteNode, []steps.Step{})
+
+	h := &Handler{
+		svc:            svc,
+		accountService: accService,
+		workflowMap: map[clouds.Name]workflows.WorkflowSet{
+			clouds.DigitalOcean: {
+				DeleteNode: workflows.DigitalOceanDeleteNode,
+			},
+		},
+		repo:    mockRepo,
+		drainer: drainer,
+		getWriter: func(string) (io.WriteCloser, error) {
+			return &bufferCloser{}, nil
+		},
+	}
+
+	n := k.Nodes["node-5"]
+	a := NewAutoscaler(h, mockRepo, time.Minute)
+	if err := a.scaleKube(context.Background(), k); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	select {
+	case <-deleted:
+	case <-time.After(time.Second * 5):
+		t.Fatal("node has not been deleted")
+	}
+
+	drainer.AssertCalled(t, "Drain", mock.Anything, mock.Anything, n, DefaultDrainTimeout)
+}
+
+func TestUpdateAutoscaling(t *testing.T) {
+	testCases := []struct {
+		description string
+		body        string
+		kubeErr     error
+
+		expectedCode int
+	}{
+		{
+			description:  "malformed body",
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "kube not found",
+			body:         `{"enabled": true, "maxNodes": 3}`,
+			kubeErr:      sgerrors.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description:  "min is greater than max",
+			body:         `{"enabled": true, "minNodes": 5, "maxNodes": 3}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "zero max",
+			body:         `{"enabled": true}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "wrong utilization",
+			body:         `{"enabled": true, "maxNodes": 3, "scaleDownUtilization": 101}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "invalid cooldown",
+			body:         `{"enabled": true, "maxNodes": 3, "scaleDownCooldown": "ten minutes"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "negative cooldown",
+			body:         `{"enabled": true, "maxNodes": 3, "scaleUpCooldown": "-1m"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "unknown pool",
+			body:         `{"enabled": true, "maxNodes": 3, "pool": "cpu"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "success",
+			body:         `{"enabled": true, "dryRun": true, "maxNodes": 3, "pool": "gpu"}`,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, testCase := range testCases {
+		k := pooledKube()
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(k, testCase.kubeErr)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := Handler{
+			svc: svc,
+		}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/kubes/{kubeID}/autoscaling", h.updateAutoscaling)
+
+		req, _ := http.NewRequest(http.MethodPut, "/kubes/test/autoscaling",
+			bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		if !k.Autoscaling.DryRun || k.Autoscaling.ScaleUpCooldown != defaultScaleUpCooldown {
+			t.Errorf("%s: wrong settings %v", testCase.description, k.Autoscaling)
+		}
+	}
+}
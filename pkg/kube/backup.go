@@ -0,0 +1,623 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/backup"
+	"github.com/supergiant/control/pkg/events"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	// DefaultBackupPrefix is a storage prefix of etcd snapshots of kubes
+	DefaultBackupPrefix   = "/backups/"
+	DefaultBackupInterval = time.Minute
+
+	defaultSnapshotInterval  = "6h"
+	defaultSnapshotRetention = 7
+	minSnapshotInterval      = 10 * time.Minute
+
+	snapshotTimeout = 10 * time.Minute
+)
+
+// snapshotScript saves a snapshot of the local etcd member and writes it
+// to stdout, etcdctl output goes to stderr to keep the snapshot intact.
+const snapshotScript = `set -e
+SNAPSHOT=$(mktemp /tmp/etcd-snapshot.XXXXXX)
+trap "sudo rm -f ${SNAPSHOT}" EXIT
+sudo ETCDCTL_API=3 etcdctl --endpoints=http://127.0.0.1:2379 snapshot save ${SNAPSHOT} 1>&2
+sudo cat ${SNAPSHOT}`
+
+// Snapshot is an etcd snapshot of a kube kept in the backup store.
+type Snapshot struct {
+	ID     string `json:"id"`
+	KubeID string `json:"kubeId"`
+	// Key is a name of the snapshot in the backup store.
+	Key       string    `json:"key"`
+	Master    string    `json:"master"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RestoreResponse struct {
+	Tasks []string `json:"tasks"`
+}
+
+// Backuper periodically takes etcd snapshots of kubes that have backups
+// enabled, keeps them in the backup store and restores kubes from them.
+type Backuper struct {
+	h        *Handler
+	repo     storage.Interface
+	store    backup.Store
+	interval time.Duration
+	snapshot func(context.Context, *model.Kube, *node.Node, io.Writer) error
+	now      func() time.Time
+
+	// m guards read-modify-write of snapshot lists
+	m sync.Mutex
+}
+
+func NewBackuper(h *Handler, repo storage.Interface, store backup.Store, interval time.Duration) *Backuper {
+	return &Backuper{
+		h:        h,
+		repo:     repo,
+		store:    store,
+		interval: interval,
+		snapshot: sshSnapshot,
+		now:      time.Now,
+	}
+}
+
+func (b *Backuper) Register(r *mux.Router) {
+	r.HandleFunc("/kubes/{kubeID}/backup", b.getBackup).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/backup", b.updateBackup).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/snapshots", b.listSnapshots).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/snapshots", b.createSnapshot).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/snapshots/{snapshotID}", b.deleteSnapshot).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/snapshots/{snapshotID}/restore", b.restoreSnapshot).Methods(http.MethodPost)
+}
+
+// Run takes snapshots until context is done.
+func (b *Backuper) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.backupAll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *Backuper) backupAll(ctx context.Context) {
+	kubes, err := b.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("backup: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+		if !k.Backup.Enabled || !operational(k) {
+			continue
+		}
+
+		if err := b.backupKube(ctx, k); err != nil {
+			logrus.Errorf("backup: kube %s: %v", k.ID, err)
+		}
+	}
+}
+
+// backupKube takes a snapshot when the latest one is older than
+// the interval and deletes snapshots beyond retention.
+func (b *Backuper) backupKube(ctx context.Context, k *model.Kube) error {
+	settings := backupSettings(k.Backup)
+
+	snapshots, err := loadSnapshots(ctx, b.repo, k.ID)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: settings are validated when they are updated.
+	interval, err := time.ParseDuration(settings.Interval)
+	if err != nil {
+		return errors.Wrap(err, "parse snapshot interval")
+	}
+
+	if n := len(snapshots); n > 0 && b.now().Sub(snapshots[n-1].CreatedAt) < interval {
+		return nil
+	}
+
+	s, err := b.takeSnapshot(ctx, k)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("backup: snapshot %s of kube %s has been taken from %s", s.ID, k.ID, s.Master)
+
+	return b.prune(ctx, k.ID, settings.Retention)
+}
+
+// takeSnapshot streams snapshot from a master to the store.
+func (b *Backuper) takeSnapshot(ctx context.Context, k *model.Kube) (*Snapshot, error) {
+	master := snapshotMaster(k)
+	if master == nil {
+		return nil, errors.Wrap(sgerrors.ErrNotFound, "active master")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	id := uuid.New()[:8]
+	s := Snapshot{
+		ID:        id,
+		KubeID:    k.ID,
+		Key:       k.ID + "/" + id + ".db",
+		Master:    master.Name,
+		CreatedAt: b.now(),
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(b.snapshot(ctx, k, master, pw))
+	}()
+
+	size, err := b.store.Put(ctx, s.Key, pr)
+	// Unblock the runner if store has stopped reading
+	pr.Close()
+	if err == nil && size == 0 {
+		err = errors.New("snapshot is empty")
+	}
+	if err != nil {
+		if deleteErr := b.store.Delete(ctx, s.Key); deleteErr != nil {
+			logrus.Errorf("backup: delete snapshot %s: %v", s.Key, deleteErr)
+		}
+		return nil, errors.Wrapf(err, "take snapshot from %s", master.Name)
+	}
+	s.Size = size
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	snapshots, err := loadSnapshots(ctx, b.repo, k.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := saveSnapshots(ctx, b.repo, k.ID, append(snapshots, s)); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// prune deletes the oldest snapshots so that at most retention are kept.
+func (b *Backuper) prune(ctx context.Context, kubeID string, retention int) error {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	snapshots, err := loadSnapshots(ctx, b.repo, kubeID)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) <= retention {
+		return nil
+	}
+
+	kept := make([]Snapshot, 0, len(snapshots))
+	for i, s := range snapshots {
+		if i >= len(snapshots)-retention {
+			kept = append(kept, s)
+			continue
+		}
+
+		// Snapshot that can't be deleted is kept to retry next time
+		if err := b.store.Delete(ctx, s.Key); err != nil {
+			logrus.Errorf("backup: delete snapshot %s: %v", s.Key, err)
+			kept = append(kept, s)
+		}
+	}
+
+	return saveSnapshots(ctx, b.repo, kubeID, kept)
+}
+
+// sshSnapshot runs etcdctl on the master with bootstrap key of the kube.
+func sshSnapshot(ctx context.Context, k *model.Kube, n *node.Node, w io.Writer) error {
+	r, err := sshRunner(k, n)
+	if err != nil {
+		return errors.Wrap(err, "setup runner")
+	}
+
+	stderr := &bytes.Buffer{}
+	err = r.Run(&runner.Command{
+		Ctx:    ctx,
+		Script: snapshotScript,
+		Out:    w,
+		Err:    stderr,
+	})
+
+	return errors.Wrapf(err, "etcdctl snapshot save: %s", stderr.String())
+}
+
+// snapshotMaster returns the first active master, all members
+// of etcd cluster have the same data.
+func snapshotMaster(k *model.Kube) *node.Node {
+	for _, n := range sortedNodes(k.Masters) {
+		if n.State == node.StateActive && n.PublicIp != "" {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// loadSnapshots returns snapshots of the kube sorted from oldest to newest.
+func loadSnapshots(ctx context.Context, repo storage.Interface, kubeID string) ([]Snapshot, error) {
+	snapshots := make([]Snapshot, 0)
+
+	data, err := repo.Get(ctx, DefaultBackupPrefix, kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return snapshots, nil
+		}
+		return nil, errors.Wrap(err, "get snapshots")
+	}
+
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, errors.Wrap(err, "unmarshal snapshots")
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+func saveSnapshots(ctx context.Context, repo storage.Interface, kubeID string, snapshots []Snapshot) error {
+	data, err := json.Marshal(snapshots)
+	if err != nil {
+		return errors.Wrap(err, "marshal snapshots")
+	}
+
+	return errors.Wrap(repo.Put(ctx, DefaultBackupPrefix, kubeID, data), "save snapshots")
+}
+
+func findSnapshot(snapshots []Snapshot, id string) (Snapshot, bool) {
+	for _, s := range snapshots {
+		if s.ID == id {
+			return s, true
+		}
+	}
+
+	return Snapshot{}, false
+}
+
+// backupSettings fills settings that are not set with defaults.
+func backupSettings(settings model.Backup) model.Backup {
+	if settings.Interval == "" {
+		settings.Interval = defaultSnapshotInterval
+	}
+	if settings.Retention == 0 {
+		settings.Retention = defaultSnapshotRetention
+	}
+
+	return settings
+}
+
+func (b *Backuper) getBackup(w http.ResponseWriter, r *http.Request) {
+	k, ok := b.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(backupSettings(k.Backup)); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (b *Backuper) updateBackup(w http.ResponseWriter, r *http.Request) {
+	settings := model.Backup{}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if settings.Retention < 0 {
+		message.SendValidationFailed(w, errors.New("retention must not be negative"))
+		return
+	}
+
+	if settings.Interval != "" {
+		if d, err := time.ParseDuration(settings.Interval); err != nil || d < minSnapshotInterval {
+			message.SendValidationFailed(w, errors.Errorf("interval %q must be a duration "+
+				"not less than %s", settings.Interval, minSnapshotInterval))
+			return
+		}
+	}
+
+	k, ok := b.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if settings.Enabled && provisioningDisabled(w, k) {
+		return
+	}
+
+	k.Backup = backupSettings(settings)
+	if err := b.h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(k.Backup); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (b *Backuper) listSnapshots(w http.ResponseWriter, r *http.Request) {
+	k, ok := b.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	snapshots, err := loadSnapshots(r.Context(), b.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(snapshots); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// createSnapshot takes a snapshot in background, it may take longer than
+// request timeout. Snapshot is subject to retention as scheduled ones are.
+func (b *Backuper) createSnapshot(w http.ResponseWriter, r *http.Request) {
+	k, ok := b.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if provisioningDisabled(w, k) {
+		return
+	}
+
+	if !operational(k) {
+		http.Error(w, "kube in state "+string(k.State)+" can't be backed up", http.StatusConflict)
+		return
+	}
+
+	if snapshotMaster(k) == nil {
+		http.Error(w, "kube "+k.ID+" has no active master", http.StatusConflict)
+		return
+	}
+
+	go func() {
+		s, err := b.takeSnapshot(context.Background(), k)
+		if err != nil {
+			logrus.Errorf("backup: kube %s: %v", k.ID, err)
+			return
+		}
+		logrus.Infof("backup: snapshot %s of kube %s has been taken from %s", s.ID, k.ID, s.Master)
+
+		if err := b.prune(context.Background(), k.ID, backupSettings(k.Backup).Retention); err != nil {
+			logrus.Errorf("backup: kube %s: %v", k.ID, err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (b *Backuper) deleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshotID := mux.Vars(r)["snapshotID"]
+
+	k, ok := b.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	snapshots, err := loadSnapshots(r.Context(), b.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	s, found := findSnapshot(snapshots, snapshotID)
+	if !found {
+		message.SendNotFound(w, snapshotID, sgerrors.ErrNotFound)
+		return
+	}
+
+	if err := b.store.Delete(r.Context(), s.Key); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	kept := make([]Snapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if snapshot.ID != snapshotID {
+			kept = append(kept, snapshot)
+		}
+	}
+
+	if err := saveSnapshots(r.Context(), b.repo, k.ID, kept); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// restoreSnapshot rebuilds etcd cluster of the kube from the snapshot,
+// all masters are restored at the same time since etcd members can't
+// become healthy until quorum of the restored cluster is up.
+func (b *Backuper) restoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshotID := mux.Vars(r)["snapshotID"]
+
+	k, ok := b.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if provisioningDisabled(w, k) {
+		return
+	}
+
+	if !operational(k) && k.State != model.StateFailed {
+		http.Error(w, "kube in state "+string(k.State)+" can't be restored", http.StatusConflict)
+		return
+	}
+
+	snapshots, err := loadSnapshots(r.Context(), b.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	s, found := findSnapshot(snapshots, snapshotID)
+	if !found {
+		message.SendNotFound(w, snapshotID, sgerrors.ErrNotFound)
+		return
+	}
+
+	masters := sortedNodes(k.Masters)
+	plan := make([]nodeTask, 0, len(masters))
+	resp := RestoreResponse{
+		Tasks: make([]string, 0, len(masters)),
+	}
+	// Members of the restored cluster must agree on the token
+	clusterToken := uuid.New()
+
+	for _, n := range masters {
+		t, err := workflows.NewTask(workflows.RestoreEtcd, b.repo)
+		if err != nil {
+			if sgerrors.IsNotFound(err) {
+				message.SendNotFound(w, workflows.RestoreEtcd, err)
+				return
+			}
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		config := restoreConfig(k, n, s.Key, clusterToken)
+		config.TaskID = t.ID
+
+		plan = append(plan, nodeTask{
+			task:   t,
+			config: config,
+		})
+		resp.Tasks = append(resp.Tasks, t.ID)
+		k.Tasks = append(k.Tasks, t.ID)
+	}
+
+	state := k.State
+	k.State = model.StateRestoring
+	if err := b.h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+	events.StateChanged(k.ID, state, k.State, "etcd is being restored from snapshot "+s.ID)
+
+	go b.runRestore(k.ID, s.ID, plan)
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Error(errors.Wrap(err, "marshal json"))
+	}
+}
+
+// runRestore restores masters concurrently, kube is failed
+// if any of etcd members has not been restored.
+func (b *Backuper) runRestore(kubeID, snapshotID string, plan []nodeTask) {
+	errs := make(chan error, len(plan))
+
+	for _, s := range plan {
+		go func(s nodeTask) {
+			writer, err := b.h.getWriter(util.MakeFileName(s.task.ID))
+			if err != nil {
+				errs <- errors.Wrapf(err, "get writer for task %s", s.task.ID)
+				return
+			}
+
+			if err := <-s.task.RunConfig(context.Background(), s.config, writer); err != nil {
+				errs <- errors.Wrapf(err, "restore master %s", s.config.Node.Name)
+				return
+			}
+			errs <- nil
+		}(s)
+	}
+
+	restored := true
+	for range plan {
+		if err := <-errs; err != nil {
+			logrus.Errorf("restore kube %s from snapshot %s: %v", kubeID, snapshotID, err)
+			restored = false
+		}
+	}
+
+	// Reload kube, it could have been changed while restore is running
+	k, err := b.h.svc.Get(context.Background(), kubeID)
+	if err != nil {
+		logrus.Errorf("restore kube %s: %v", kubeID, err)
+		return
+	}
+
+	k.State = model.StateOperational
+	if !restored {
+		k.State = model.StateFailed
+	}
+
+	if err := b.h.svc.Create(context.Background(), k); err != nil {
+		logrus.Errorf("update kube %s caused %v", kubeID, err)
+		return
+	}
+	events.StateChanged(k.ID, model.StateRestoring, k.State, "etcd restore from snapshot "+snapshotID+" has finished")
+
+	logrus.Infof("kube %s restore from snapshot %s is finished with state %s",
+		kubeID, snapshotID, k.State)
+}
+
+func restoreConfig(k *model.Kube, n *node.Node, snapshot, clusterToken string) *steps.Config {
+	config := steps.NewConfig(k.Name, "", k.AccountName, kubeProfileFrom(k, k.Provider))
+	config.ClusterID = k.ID
+	config.IsMaster = true
+	config.Node = *n
+	useBootstrapSSH(config, k)
+	config.EtcdConfig.Snapshot = snapshot
+	config.EtcdConfig.ClusterToken = clusterToken
+
+	for _, master := range k.Masters {
+		config.AddMaster(master)
+	}
+
+	return config
+}
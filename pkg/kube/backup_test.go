@@ -0,0 +1,511 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type memRepository struct {
+	m    sync.Mutex
+	data map[string][]byte
+}
+
+func (r *memRepository) Put(ctx context.Context, prefix string, key string, value []byte) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.data[prefix+key] = value
+	return nil
+}
+
+func (r *memRepository) Get(ctx context.Context, prefix string, key string) ([]byte, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	value, ok := r.data[prefix+key]
+	if !ok {
+		return nil, sgerrors.ErrNotFound
+	}
+	return value, nil
+}
+
+func (r *memRepository) GetAll(ctx context.Context, prefix string) ([][]byte, error) {
+	return nil, nil
+}
+
+func (r *memRepository) Delete(ctx context.Context, prefix string, key string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.data, prefix+key)
+	return nil
+}
+
+type memStore struct {
+	m         sync.Mutex
+	snapshots map[string]string
+}
+
+func (s *memStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.snapshots[key] = string(data)
+	return int64(len(data)), nil
+}
+
+func (s *memStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	data, ok := s.snapshots[key]
+	if !ok {
+		return nil, sgerrors.ErrNotFound
+	}
+	return ioutil.NopCloser(strings.NewReader(data)), nil
+}
+
+func (s *memStore) Delete(ctx context.Context, key string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.snapshots, key)
+	return nil
+}
+
+func backupKubeFixture() *model.Kube {
+	return &model.Kube{
+		ID:    "test",
+		State: model.StateOperational,
+		Masters: map[string]*node.Node{
+			"master-1": {ID: "1", Name: "master-1", PublicIp: "10.0.0.1", State: node.StateActive},
+			"master-2": {ID: "2", Name: "master-2", PublicIp: "10.0.0.2", State: node.StateActive},
+		},
+		Backup: model.Backup{
+			Enabled:   true,
+			Interval:  "1h",
+			Retention: 2,
+		},
+	}
+}
+
+func TestBackupKube(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		description string
+		snapshots   []Snapshot
+		snapshotErr error
+
+		expectErr     bool
+		expectedCount int
+		expectTaken   bool
+	}{
+		{
+			description:   "first snapshot",
+			expectedCount: 1,
+			expectTaken:   true,
+		},
+		{
+			description: "latest is recent",
+			snapshots: []Snapshot{
+				{ID: "1", Key: "test/1.db", CreatedAt: now.Add(-time.Minute)},
+			},
+			expectedCount: 1,
+		},
+		{
+			description: "retention",
+			snapshots: []Snapshot{
+				{ID: "2", Key: "test/2.db", CreatedAt: now.Add(-2 * time.Hour)},
+				{ID: "1", Key: "test/1.db", CreatedAt: now.Add(-3 * time.Hour)},
+			},
+			expectedCount: 2,
+			expectTaken:   true,
+		},
+		{
+			description:   "etcdctl error",
+			snapshotErr:   errors.New("etcdctl not found"),
+			expectErr:     true,
+			expectedCount: 0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		k := backupKubeFixture()
+		repo := &memRepository{
+			data: map[string][]byte{},
+		}
+		store := &memStore{
+			snapshots: map[string]string{},
+		}
+		for _, s := range testCase.snapshots {
+			store.snapshots[s.Key] = "old"
+		}
+		if testCase.snapshots != nil {
+			saveSnapshots(context.Background(), repo, k.ID, testCase.snapshots)
+		}
+
+		var master string
+		b := NewBackuper(&Handler{}, repo, store, time.Minute)
+		b.now = func() time.Time {
+			return now
+		}
+		b.snapshot = func(ctx context.Context, k *model.Kube, n *node.Node, w io.Writer) error {
+			master = n.Name
+			if testCase.snapshotErr != nil {
+				return testCase.snapshotErr
+			}
+			_, err := w.Write([]byte("snapshot"))
+			return err
+		}
+
+		err := b.backupKube(context.Background(), k)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		snapshots, _ := loadSnapshots(context.Background(), repo, k.ID)
+		if len(snapshots) != testCase.expectedCount {
+			t.Errorf("%s: wrong count of snapshots expected %d actual %d",
+				testCase.description, testCase.expectedCount, len(snapshots))
+			continue
+		}
+
+		if len(store.snapshots) != testCase.expectedCount {
+			t.Errorf("%s: wrong count of stored snapshots expected %d actual %v",
+				testCase.description, testCase.expectedCount, store.snapshots)
+		}
+
+		if !testCase.expectTaken {
+			continue
+		}
+
+		latest := snapshots[len(snapshots)-1]
+		if master != "master-1" || latest.Master != master {
+			t.Errorf("%s: snapshot must be taken from master-1 actual %s",
+				testCase.description, latest.Master)
+		}
+
+		if store.snapshots[latest.Key] != "snapshot" || latest.Size != int64(len("snapshot")) {
+			t.Errorf("%s: wrong snapshot %v", testCase.description, latest)
+		}
+
+		if _, ok := store.snapshots["test/1.db"]; ok {
+			t.Errorf("%s: the oldest snapshot must be deleted", testCase.description)
+		}
+	}
+}
+
+func TestUpdateBackup(t *testing.T) {
+	testCases := []struct {
+		description string
+		body        string
+		kubeState   model.KubeState
+		kubeErr     error
+
+		expectedCode     int
+		expectedSettings model.Backup
+	}{
+		{
+			description:  "malformed body",
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "negative retention",
+			body:         `{"enabled": true, "retention": -1}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "short interval",
+			body:         `{"enabled": true, "interval": "1s"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "invalid interval",
+			body:         `{"enabled": true, "interval": "hourly"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "kube not found",
+			body:         `{"enabled": true}`,
+			kubeErr:      sgerrors.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description:  "imported",
+			body:         `{"enabled": true}`,
+			kubeState:    model.StateImported,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description:  "defaults",
+			body:         `{"enabled": true, "retention": 3}`,
+			expectedCode: http.StatusOK,
+			expectedSettings: model.Backup{
+				Enabled:   true,
+				Interval:  defaultSnapshotInterval,
+				Retention: 3,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		k := &model.Kube{ID: "test", State: model.StateOperational}
+		if testCase.kubeState != "" {
+			k.State = testCase.kubeState
+		}
+
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(k, testCase.kubeErr)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		b := NewBackuper(&Handler{svc: svc}, nil, nil, time.Minute)
+
+		router := mux.NewRouter()
+		b.Register(router)
+
+		req, _ := http.NewRequest(http.MethodPut, "/kubes/test/backup",
+			bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		if k.Backup != testCase.expectedSettings {
+			t.Errorf("%s: wrong settings expected %v actual %v",
+				testCase.description, testCase.expectedSettings, k.Backup)
+		}
+	}
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	k := backupKubeFixture()
+	repo := &memRepository{
+		data: map[string][]byte{},
+	}
+	store := &memStore{
+		snapshots: map[string]string{
+			"test/1.db": "1",
+			"test/2.db": "2",
+		},
+	}
+	saveSnapshots(context.Background(), repo, k.ID, []Snapshot{
+		{ID: "1", Key: "test/1.db"},
+		{ID: "2", Key: "test/2.db"},
+	})
+
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).
+		Return(k, nil)
+
+	b := NewBackuper(&Handler{svc: svc}, repo, store, time.Minute)
+	router := mux.NewRouter()
+	b.Register(router)
+
+	for _, testCase := range []struct {
+		snapshotID   string
+		expectedCode int
+	}{
+		{"3", http.StatusNotFound},
+		{"1", http.StatusAccepted},
+	} {
+		req, _ := http.NewRequest(http.MethodDelete, "/kubes/test/snapshots/"+testCase.snapshotID, nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("snapshot %s: wrong response code expected %d actual %d",
+				testCase.snapshotID, testCase.expectedCode, rec.Code)
+		}
+	}
+
+	snapshots, _ := loadSnapshots(context.Background(), repo, k.ID)
+	if len(snapshots) != 1 || snapshots[0].ID != "2" {
+		t.Errorf("wrong snapshots %v", snapshots)
+	}
+
+	if _, ok := store.snapshots["test/1.db"]; ok || len(store.snapshots) != 1 {
+		t.Errorf("snapshot must be deleted from store %v", store.snapshots)
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.RestoreEtcd, []steps.Step{})
+
+	testCases := []struct {
+		description string
+		kubeState   model.KubeState
+		snapshotID  string
+
+		expectedCode   int
+		expectedStates []model.KubeState
+	}{
+		{
+			description:  "provisioning",
+			kubeState:    model.StateProvisioning,
+			snapshotID:   "1",
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description:  "snapshot not found",
+			kubeState:    model.StateOperational,
+			snapshotID:   "2",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description:    "restore",
+			kubeState:      model.StateFailed,
+			snapshotID:     "1",
+			expectedCode:   http.StatusAccepted,
+			expectedStates: []model.KubeState{model.StateRestoring, model.StateOperational},
+		},
+	}
+
+	for _, testCase := range testCases {
+		k := backupKubeFixture()
+		k.State = testCase.kubeState
+
+		repo := &memRepository{
+			data: map[string][]byte{},
+		}
+		saveSnapshots(context.Background(), repo, k.ID, []Snapshot{
+			{ID: "1", Key: "test/1.db"},
+		})
+
+		var (
+			m      sync.Mutex
+			states []model.KubeState
+		)
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(k, nil)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				m.Lock()
+				defer m.Unlock()
+				states = append(states, args.Get(1).(*model.Kube).State)
+			}).
+			Return(nil)
+
+		h := &Handler{
+			svc:  svc,
+			repo: repo,
+			getWriter: func(string) (io.WriteCloser, error) {
+				return &bufferCloser{}, nil
+			},
+		}
+		b := NewBackuper(h, repo, nil, time.Minute)
+
+		router := mux.NewRouter()
+		b.Register(router)
+
+		req, _ := http.NewRequest(http.MethodPost,
+			"/kubes/test/snapshots/"+testCase.snapshotID+"/restore", nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusAccepted {
+			continue
+		}
+
+		resp := RestoreResponse{}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		if len(resp.Tasks) != len(k.Masters) {
+			t.Errorf("%s: wrong count of tasks expected %d actual %d",
+				testCase.description, len(k.Masters), len(resp.Tasks))
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			m.Lock()
+			count := len(states)
+			m.Unlock()
+
+			if count >= len(testCase.expectedStates) || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		m.Lock()
+		if len(states) != len(testCase.expectedStates) {
+			t.Errorf("%s: wrong states expected %v actual %v",
+				testCase.description, testCase.expectedStates, states)
+		} else {
+			for i := range states {
+				if states[i] != testCase.expectedStates[i] {
+					t.Errorf("%s: wrong states expected %v actual %v",
+						testCase.description, testCase.expectedStates, states)
+					break
+				}
+			}
+		}
+		m.Unlock()
+	}
+}
+
+func TestRestoreConfig(t *testing.T) {
+	k := backupKubeFixture()
+	k.SshUser = "ubuntu"
+	k.SshPort = "2222"
+
+	config := restoreConfig(k, k.Masters["master-2"], "test/1.db", "token")
+
+	if !config.IsMaster || config.Node.Name != "master-2" || config.SshConfig.User != "ubuntu" ||
+		config.SshConfig.Port != "2222" {
+		t.Errorf("wrong config of master %v", config)
+	}
+
+	if config.EtcdConfig.Snapshot != "test/1.db" || config.EtcdConfig.ClusterToken != "token" {
+		t.Errorf("wrong etcd config %v", config.EtcdConfig)
+	}
+
+	if len(config.GetMasters()) != len(k.Masters) {
+		t.Errorf("wrong count of masters expected %d actual %d",
+			len(k.Masters), len(config.GetMasters()))
+	}
+}
@@ -21,7 +21,7 @@ var (
 
 // Default variables for certs.
 const (
-	DefaultCertsPath = "/etc/kubernets/ssl"
+	DefaultCertsPath = "/etc/kubernetes/ssl"
 )
 
 // Bundler represents a key/certificate pair.
@@ -86,20 +86,21 @@ func (c *Certs) getFile(ctx context.Context, path string) ([]byte, error) {
 	return stdout.Bytes(), nil
 }
 
+// catCmd reads files with sudo, keys are readable by root only.
 func catCmd(path string) string {
-	return "/usr/bin/cat " + path
+	return "sudo cat " + path
 }
 
 func keyName(name string) string {
 	if strings.TrimSpace(name) == "" {
 		return name
 	}
-	return name + ".key"
+	return name + "-key.pem"
 }
 
 func certName(name string) string {
 	if strings.TrimSpace(name) == "" {
 		return name
 	}
-	return name + ".crt"
+	return name + ".pem"
 }
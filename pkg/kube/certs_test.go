@@ -174,7 +174,7 @@ func TestKeyName(t *testing.T) {
 		// TC#1
 		{"", ""},
 		// TC#1
-		{"k", "k.key"},
+		{"k", "k-key.pem"},
 	}
 
 	for i, tc := range tcs {
@@ -190,7 +190,7 @@ func TestCertName(t *testing.T) {
 		// TC#1
 		{"", ""},
 		// TC#1
-		{"k", "k.crt"},
+		{"k", "k.pem"},
 	}
 
 	for i, tc := range tcs {
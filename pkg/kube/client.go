@@ -2,6 +2,7 @@ package kube
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -13,6 +14,7 @@ import (
 	clientcmddapi "k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/proxy"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/util"
 )
@@ -29,13 +31,16 @@ func NewConfigFor(k *model.Kube) (*rest.Config, error) {
 		&clientcmd.ConfigOverrides{},
 		nil,
 	).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "build rest config")
+	}
 
 	restConf.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}
 	if len(restConf.UserAgent) == 0 {
 		restConf.UserAgent = rest.DefaultKubernetesUserAgent()
 	}
 
-	return restConf, errors.Wrap(err, "build rest config")
+	return restConf, nil
 }
 
 func restClientForGroupVersion(k *model.Kube, gv schema.GroupVersion) (rest.Interface, error) {
@@ -58,28 +63,30 @@ func discoveryClient(k *model.Kube) (*discovery.DiscoveryClient, error) {
 
 // adminKubeConfig returns a cluster-admin kubeconfig for provided cluster.
 func adminKubeConfig(k *model.Kube) (clientcmddapi.Config, error) {
-	// TODO: this should be an address of the master load balancer
-	if k == nil || len(k.Masters) == 0 {
-		// TODO: use another base error, not ErrNotFound
-		return clientcmddapi.Config{}, errors.Wrap(sgerrors.ErrNotFound, "master nodes")
+	if k == nil {
+		return clientcmddapi.Config{}, errors.Wrap(sgerrors.ErrNotFound, "kube")
 	}
-	m := util.GetRandomNode(k.Masters)
 
-	var apiAddr string
-	if k.APIPort != "" {
-		apiAddr = fmt.Sprintf("https://%s:%s", m.PublicIp, k.APIPort)
-	} else {
-		// TODO: apiPort has been hardcoded in provisioner, use 443 by default
-		apiAddr = fmt.Sprintf("https://%s", m.PublicIp)
+	apiAddr, err := apiAddress(k)
+	if err != nil {
+		return clientcmddapi.Config{}, err
+	}
+
+	authInfo := &clientcmddapi.AuthInfo{
+		ClientCertificateData: []byte(k.Auth.AdminCert),
+		ClientKeyData:         []byte(k.Auth.AdminKey),
+		Token:                 k.Auth.BearerToken,
+	}
+	// Imported kubes may have basic auth credentials only
+	if k.Auth.AdminCert == "" && k.Auth.BearerToken == "" {
+		authInfo.Username = k.User
+		authInfo.Password = k.Password
 	}
 
 	// TODO: add validation
 	return clientcmddapi.Config{
 		AuthInfos: map[string]*clientcmddapi.AuthInfo{
-			adminContext(k.Name): {
-				ClientCertificateData: []byte(k.Auth.AdminCert),
-				ClientKeyData:         []byte(k.Auth.AdminKey),
-			},
+			adminContext(k.Name): authInfo,
 		},
 		Clusters: map[string]*clientcmddapi.Cluster{
 			k.Name: {
@@ -97,6 +104,64 @@ func adminKubeConfig(k *model.Kube) (clientcmddapi.Config, error) {
 	}, nil
 }
 
+// apiAddress returns an url of kubernetes API, imported kubes are
+// reachable at the endpoint they have been imported with.
+func apiAddress(k *model.Kube) (string, error) {
+	if k.APIEndpoint != "" {
+		return k.APIEndpoint, nil
+	}
+
+	if len(k.Masters) == 0 {
+		// TODO: use another base error, not ErrNotFound
+		return "", errors.Wrap(sgerrors.ErrNotFound, "master nodes")
+	}
+	m := util.GetRandomNode(k.Masters)
+
+	if k.APIPort != "" {
+		return fmt.Sprintf("https://%s:%s", m.PublicIp, k.APIPort), nil
+	}
+	// TODO: apiPort has been hardcoded in provisioner, use 443 by default
+	return fmt.Sprintf("https://%s", m.PublicIp), nil
+}
+
+// apiHost returns a host that services and metrics are proxied through.
+func apiHost(k *model.Kube) (string, error) {
+	if k.APIEndpoint != "" {
+		u, err := url.Parse(k.APIEndpoint)
+		if err != nil {
+			return "", errors.Wrap(err, "parse api endpoint")
+		}
+		return u.Host, nil
+	}
+
+	for _, m := range k.Masters {
+		if m != nil {
+			return m.PublicIp, nil
+		}
+	}
+
+	return "", errors.Wrap(sgerrors.ErrNotFound, "master nodes")
+}
+
+// proxyTarget returns a target of the services proxy that authenticates
+// at kubernetes API the same way admin kubeconfig of the kube does.
+func proxyTarget(k *model.Kube, proxyID, targetURL, selfLink string) *proxy.Target {
+	target := &proxy.Target{
+		ProxyID:     proxyID,
+		TargetURL:   targetURL,
+		SelfLink:    selfLink,
+		BearerToken: k.Auth.BearerToken,
+		ClientCert:  k.Auth.AdminCert,
+		ClientKey:   k.Auth.AdminKey,
+	}
+	if k.Auth.AdminCert == "" && k.Auth.BearerToken == "" {
+		target.User = k.User
+		target.Password = k.Password
+	}
+
+	return target
+}
+
 func setGroupDefaults(config *rest.Config, gv schema.GroupVersion) {
 	config.GroupVersion = &gv
 	if len(gv.Group) == 0 {
@@ -84,3 +84,56 @@ func TestDiscoveryClient(t *testing.T) {
 		}
 	}
 }
+
+func TestAdminKubeConfigImported(t *testing.T) {
+	k := &model.Kube{
+		Name:        "prod",
+		APIEndpoint: "https://10.20.30.40:6443",
+		Auth: model.Auth{
+			BearerToken: "secret",
+		},
+	}
+
+	conf, err := adminKubeConfig(k)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if server := conf.Clusters[k.Name].Server; server != k.APIEndpoint {
+		t.Errorf("wrong server expected %s actual %s", k.APIEndpoint, server)
+	}
+
+	if token := conf.AuthInfos[adminContext(k.Name)].Token; token != "secret" {
+		t.Errorf("wrong token expected secret actual %s", token)
+	}
+
+	host, err := apiHost(k)
+	if err != nil || host != "10.20.30.40:6443" {
+		t.Errorf("wrong api host %s %v", host, err)
+	}
+}
+
+func TestProxyTarget(t *testing.T) {
+	imported := &model.Kube{
+		User:     "user",
+		Password: "password",
+		Auth: model.Auth{
+			BearerToken: "secret",
+		},
+	}
+
+	target := proxyTarget(imported, "id", "https://10.20.30.40/proxy", "/api/v1/services/web")
+	if target.BearerToken != "secret" || target.User != "" || target.Password != "" {
+		t.Errorf("imported kube must be proxied with token only %+v", target)
+	}
+
+	basic := &model.Kube{
+		User:     "user",
+		Password: "password",
+	}
+
+	target = proxyTarget(basic, "id", "https://10.20.30.40/proxy", "/api/v1/services/web")
+	if target.User != "user" || target.Password != "password" {
+		t.Errorf("kube without token and certificate must be proxied with basic auth %+v", target)
+	}
+}
@@ -0,0 +1,187 @@
+package kube
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// cronDescriptors are shortcuts for common schedules.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSchedule is a parsed cron expression of five fields: minute, hour,
+// day of month, month and day of week. Fields support *, lists, ranges
+// and steps, sunday is both 0 and 7.
+type cronSchedule struct {
+	minutes [60]bool
+	hours   [24]bool
+	days    [32]bool
+	months  [13]bool
+	weekday [7]bool
+
+	// Day is matched by either of day fields if both are restricted
+	anyDay     bool
+	anyWeekday bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteField  = cronField{"minute", 0, 59}
+	hourField    = cronField{"hour", 0, 23}
+	dayField     = cronField{"day of month", 1, 31}
+	monthField   = cronField{"month", 1, 12}
+	weekdayField = cronField{"day of week", 0, 7}
+)
+
+func parseCron(expr string) (*cronSchedule, error) {
+	if descriptor, ok := cronDescriptors[strings.TrimSpace(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+
+	s := &cronSchedule{
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+
+	if err := parseCronField(fields[0], minuteField, s.minutes[:]); err != nil {
+		return nil, err
+	}
+	if err := parseCronField(fields[1], hourField, s.hours[:]); err != nil {
+		return nil, err
+	}
+	if err := parseCronField(fields[2], dayField, s.days[:]); err != nil {
+		return nil, err
+	}
+	if err := parseCronField(fields[3], monthField, s.months[:]); err != nil {
+		return nil, err
+	}
+
+	weekday := make([]bool, weekdayField.max+1)
+	if err := parseCronField(fields[4], weekdayField, weekday); err != nil {
+		return nil, err
+	}
+	copy(s.weekday[:], weekday)
+	s.weekday[0] = s.weekday[0] || weekday[7]
+
+	return s, nil
+}
+
+// parseCronField sets values matched by a comma separated list
+// of values, ranges and steps.
+func parseCronField(value string, field cronField, set []bool) error {
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return errors.Errorf("invalid step %q of %s", part, field.name)
+			}
+		}
+
+		var from, to int
+		switch {
+		case rangePart == "*":
+			from, to = field.min, field.max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return errors.Errorf("invalid range %q of %s", part, field.name)
+			}
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return errors.Errorf("invalid range %q of %s", part, field.name)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return errors.Errorf("invalid value %q of %s", part, field.name)
+			}
+			from, to = n, n
+			// Single value with a step runs until the end of the range
+			if step > 1 {
+				to = field.max
+			}
+		}
+
+		if from < field.min || to > field.max || from > to {
+			return errors.Errorf("%q of %s is out of range %d-%d", part, field.name, field.min, field.max)
+		}
+
+		for i := from; i <= to; i += step {
+			set[i] = true
+		}
+	}
+
+	return nil
+}
+
+// Next returns the first time after t the schedule matches in location
+// of t, zero time is returned if the schedule never matches.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Some dates like 30th of February never come
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		year, month, day := t.Date()
+
+		switch {
+		case !s.months[month]:
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		case !s.hours[t.Hour()]:
+			next := time.Date(year, month, day, t.Hour()+1, 0, 0, 0, loc)
+			// Wall clock may go back when daylight saving time ends
+			if !next.After(t) {
+				next = t.Add(time.Minute)
+			}
+			t = next
+		case !s.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	day, weekday := s.days[t.Day()], s.weekday[t.Weekday()]
+
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
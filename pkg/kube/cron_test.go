@@ -0,0 +1,109 @@
+package kube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("expression %q: error expected", expr)
+		}
+	}
+
+	s, err := parseCron("0,30 9-17/4 * * 7")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !s.minutes[30] || s.minutes[15] {
+		t.Errorf("wrong minutes %v", s.minutes)
+	}
+	if !s.hours[9] || !s.hours[13] || !s.hours[17] || s.hours[10] {
+		t.Errorf("wrong hours %v", s.hours)
+	}
+	if !s.weekday[0] {
+		t.Errorf("7 must be sunday %v", s.weekday)
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("load location: %v", err)
+	}
+
+	testCases := []struct {
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			expr:     "*/15 * * * *",
+			from:     time.Date(2019, 1, 1, 10, 7, 30, 0, time.UTC),
+			expected: time.Date(2019, 1, 1, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			expr:     "0 8 * * 1-5",
+			from:     time.Date(2019, 1, 4, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2019, 1, 7, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			// Either day of month or day of week matches
+			expr:     "0 0 15 * 1",
+			from:     time.Date(2019, 1, 8, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2019, 1, 14, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:     "@monthly",
+			from:     time.Date(2019, 12, 31, 23, 59, 0, 0, time.UTC),
+			expected: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:     "0 0 29 2 *",
+			from:     time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:     "0 9 * * *",
+			from:     time.Date(2019, 6, 1, 12, 0, 0, 0, newYork),
+			expected: time.Date(2019, 6, 2, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			// 2:30 doesn't exist when daylight saving time starts
+			expr:     "30 2 * * *",
+			from:     time.Date(2019, 3, 10, 0, 0, 0, 0, newYork),
+			expected: time.Date(2019, 3, 11, 6, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, testCase := range testCases {
+		s, err := parseCron(testCase.expr)
+		if err != nil {
+			t.Errorf("expression %q: unexpected error %v", testCase.expr, err)
+			continue
+		}
+
+		if next := s.Next(testCase.from); !next.Equal(testCase.expected) {
+			t.Errorf("expression %q from %s: expected %s actual %s",
+				testCase.expr, testCase.from, testCase.expected, next.UTC())
+		}
+	}
+
+	s, _ := parseCron("0 0 30 2 *")
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Errorf("30th of February must never come, got %s", next)
+	}
+}
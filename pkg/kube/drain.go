@@ -0,0 +1,270 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const (
+	// DefaultDrainTimeout is how long pods are evicted from a node
+	// before the drain is considered failed.
+	DefaultDrainTimeout = 5 * time.Minute
+
+	// mirrorPodAnnotation marks static pods that can't be evicted
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+)
+
+// nodeDrainer prepares nodes for deletion through kubernetes API.
+type nodeDrainer interface {
+	// Drain cordons the node and evicts its pods respecting pod disruption budgets.
+	Drain(ctx context.Context, k *model.Kube, n *node.Node, timeout time.Duration) error
+	// Uncordon makes the node schedulable again.
+	Uncordon(ctx context.Context, k *model.Kube, n *node.Node) error
+	// DeleteNode removes the node object from kubernetes API.
+	DeleteNode(ctx context.Context, k *model.Kube, n *node.Node) error
+}
+
+// apiDrainer does the same as kubectl drain using kubernetes API of the kube.
+type apiDrainer struct {
+	clientFor    func(*model.Kube) (corev1client.CoreV1Interface, error)
+	pollInterval time.Duration
+}
+
+func newAPIDrainer() *apiDrainer {
+	return &apiDrainer{
+		clientFor:    coreV1ClientFor,
+		pollInterval: time.Second * 5,
+	}
+}
+
+func coreV1ClientFor(k *model.Kube) (corev1client.CoreV1Interface, error) {
+	restConf, err := NewConfigFor(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return corev1client.NewForConfig(restConf)
+}
+
+func listK8sNodes(k *model.Kube) ([]corev1.Node, error) {
+	client, err := coreV1ClientFor(k)
+	if err != nil {
+		return nil, errors.Wrap(err, "build kubernetes client")
+	}
+
+	nodeList, err := client.Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list nodes")
+	}
+
+	return nodeList.Items, nil
+}
+
+func (d *apiDrainer) Drain(ctx context.Context, k *model.Kube, n *node.Node, timeout time.Duration) error {
+	client, err := d.clientFor(k)
+	if err != nil {
+		return errors.Wrap(err, "build kubernetes client")
+	}
+
+	k8sNode, err := findK8sNode(client, n)
+	if err != nil {
+		// Machine has not joined the cluster, there is nothing to drain
+		if sgerrors.IsNotFound(err) {
+			logrus.Infof("node %s not found in kube %s, skip drain", n.Name, k.ID)
+			return nil
+		}
+		return err
+	}
+
+	if err := setUnschedulable(client, k8sNode.Name, true); err != nil {
+		return errors.Wrapf(err, "cordon node %s", k8sNode.Name)
+	}
+
+	podList, err := client.Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", k8sNode.Name).String(),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "list pods of node %s", k8sNode.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	pods := podsToEvict(podList.Items)
+	for _, pod := range pods {
+		if err := d.evict(ctx, client, pod); err != nil {
+			return err
+		}
+	}
+
+	return d.waitPodsDeleted(ctx, client, pods)
+}
+
+func (d *apiDrainer) Uncordon(ctx context.Context, k *model.Kube, n *node.Node) error {
+	client, err := d.clientFor(k)
+	if err != nil {
+		return errors.Wrap(err, "build kubernetes client")
+	}
+
+	k8sNode, err := findK8sNode(client, n)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrapf(setUnschedulable(client, k8sNode.Name, false),
+		"uncordon node %s", k8sNode.Name)
+}
+
+func (d *apiDrainer) DeleteNode(ctx context.Context, k *model.Kube, n *node.Node) error {
+	client, err := d.clientFor(k)
+	if err != nil {
+		return errors.Wrap(err, "build kubernetes client")
+	}
+
+	k8sNode, err := findK8sNode(client, n)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	err = client.Nodes().Delete(k8sNode.Name, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "delete node %s", k8sNode.Name)
+	}
+
+	return nil
+}
+
+// evict asks API server to evict the pod, eviction that violates
+// pod disruption budget is retried until context is done.
+func (d *apiDrainer) evict(ctx context.Context, client corev1client.CoreV1Interface, pod corev1.Pod) error {
+	eviction := &policy.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+
+	for {
+		err := client.Pods(pod.Namespace).Evict(eviction)
+		switch {
+		case err == nil, apierrors.IsNotFound(err):
+			return nil
+		case apierrors.IsTooManyRequests(err):
+			logrus.Debugf("eviction of pod %s/%s is blocked by disruption budget, retry",
+				pod.Namespace, pod.Name)
+		default:
+			return errors.Wrapf(err, "evict pod %s/%s", pod.Namespace, pod.Name)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "evict pod %s/%s", pod.Namespace, pod.Name)
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
+
+func (d *apiDrainer) waitPodsDeleted(ctx context.Context, client corev1client.CoreV1Interface, pods []corev1.Pod) error {
+	for _, pod := range pods {
+		for {
+			p, err := client.Pods(pod.Namespace).Get(pod.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) || (err == nil && p.UID != pod.UID) {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return errors.Wrapf(ctx.Err(), "wait pod %s/%s deleted", pod.Namespace, pod.Name)
+			case <-time.After(d.pollInterval):
+			}
+		}
+	}
+
+	return nil
+}
+
+// findK8sNode finds kubernetes node of the machine, kubelet may register
+// node with a hostname that differs from machine name, e.g. on AWS.
+func findK8sNode(client corev1client.CoreV1Interface, n *node.Node) (*corev1.Node, error) {
+	nodeList, err := client.Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list nodes")
+	}
+
+	if k8sNode := matchK8sNode(nodeList.Items, n); k8sNode != nil {
+		return k8sNode, nil
+	}
+
+	return nil, errors.Wrapf(sgerrors.ErrNotFound, "kubernetes node %s", n.Name)
+}
+
+// matchK8sNode looks for the machine by name first and then by private ip.
+func matchK8sNode(k8sNodes []corev1.Node, n *node.Node) *corev1.Node {
+	for i, k8sNode := range k8sNodes {
+		if strings.EqualFold(k8sNode.Name, n.Name) {
+			return &k8sNodes[i]
+		}
+	}
+
+	for i, k8sNode := range k8sNodes {
+		for _, addr := range k8sNode.Status.Addresses {
+			if addr.Type == corev1.NodeInternalIP && n.PrivateIp != "" && addr.Address == n.PrivateIp {
+				return &k8sNodes[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+func setUnschedulable(client corev1client.CoreV1Interface, nodeName string, unschedulable bool) error {
+	patch := `{"spec":{"unschedulable":false}}`
+	if unschedulable {
+		patch = `{"spec":{"unschedulable":true}}`
+	}
+
+	_, err := client.Nodes().Patch(nodeName, types.StrategicMergePatchType, []byte(patch))
+	return err
+}
+
+// podsToEvict skips pods that are not rescheduled on eviction: mirror pods,
+// daemon set pods and pods that have already finished.
+func podsToEvict(pods []corev1.Pod) []corev1.Pod {
+	evict := make([]corev1.Pod, 0, len(pods))
+
+	for _, pod := range pods {
+		if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+			continue
+		}
+
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		if ref := metav1.GetControllerOf(&pod); ref != nil && ref.Kind == "DaemonSet" {
+			continue
+		}
+
+		evict = append(evict, pod)
+	}
+
+	return evict
+}
@@ -0,0 +1,223 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type mockDrainer struct {
+	mock.Mock
+}
+
+func (m *mockDrainer) Drain(ctx context.Context, k *model.Kube, n *node.Node, timeout time.Duration) error {
+	args := m.Called(ctx, k, n, timeout)
+	return args.Error(0)
+}
+
+func (m *mockDrainer) Uncordon(ctx context.Context, k *model.Kube, n *node.Node) error {
+	args := m.Called(ctx, k, n)
+	return args.Error(0)
+}
+
+func (m *mockDrainer) DeleteNode(ctx context.Context, k *model.Kube, n *node.Node) error {
+	args := m.Called(ctx, k, n)
+	return args.Error(0)
+}
+
+func TestPodsToEvict(t *testing.T) {
+	isController := true
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "app",
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "kube-proxy",
+				Annotations: map[string]string{
+					mirrorPodAnnotation: "hash",
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "fluentd",
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind:       "DaemonSet",
+						Name:       "fluentd",
+						Controller: &isController,
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "job",
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodSucceeded,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web",
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind:       "ReplicaSet",
+						Name:       "web",
+						Controller: &isController,
+					},
+				},
+			},
+		},
+	}
+
+	evict := podsToEvict(pods)
+
+	if len(evict) != 2 || evict[0].Name != "app" || evict[1].Name != "web" {
+		t.Errorf("wrong pods to evict %v", evict)
+	}
+}
+
+func TestRemoveNode(t *testing.T) {
+	testCases := []struct {
+		description string
+		force       bool
+		drainErr    error
+
+		expectDrain   bool
+		expectDeleted bool
+	}{
+		{
+			description:   "success",
+			expectDrain:   true,
+			expectDeleted: true,
+		},
+		{
+			description:   "force",
+			force:         true,
+			expectDeleted: true,
+		},
+		{
+			description: "drain timeout",
+			drainErr:    errors.New("timeout"),
+			expectDrain: true,
+		},
+	}
+
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.DigitalOceanDeleteNode, []steps.Step{})
+
+	for _, testCase := range testCases {
+		n := &node.Node{
+			Name:  "node-1",
+			State: node.StateActive,
+		}
+		k := &model.Kube{
+			ID: "test",
+			Nodes: map[string]*node.Node{
+				n.Name: n,
+			},
+		}
+
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(k, nil)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		drainer := new(mockDrainer)
+		drainer.On("Drain", mock.Anything, k, n, time.Minute).
+			Return(testCase.drainErr)
+		drainer.On("Uncordon", mock.Anything, k, n).
+			Return(nil)
+		drainer.On("DeleteNode", mock.Anything, k, n).
+			Return(nil)
+
+		mockRepo := new(testutils.MockStorage)
+		mockRepo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := Handler{
+			svc:     svc,
+			drainer: drainer,
+		}
+
+		task, err := workflows.NewTask(workflows.DigitalOceanDeleteNode, mockRepo)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", testCase.description, err)
+		}
+
+		out := &bufferCloser{}
+		err = h.removeNode(k, n, task, &steps.Config{}, out, testCase.force, time.Minute)
+
+		if (err != nil) != (testCase.drainErr != nil) {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+		}
+
+		if testCase.expectDrain {
+			drainer.AssertCalled(t, "Drain", mock.Anything, k, n, time.Minute)
+		} else {
+			drainer.AssertNotCalled(t, "Drain", mock.Anything, k, n, time.Minute)
+		}
+
+		_, exists := k.Nodes[n.Name]
+		if exists == testCase.expectDeleted {
+			t.Errorf("%s: node deleted expected %v actual %v",
+				testCase.description, testCase.expectDeleted, !exists)
+		}
+
+		if testCase.expectDeleted {
+			drainer.AssertCalled(t, "DeleteNode", mock.Anything, k, n)
+			continue
+		}
+
+		drainer.AssertCalled(t, "Uncordon", mock.Anything, k, n)
+		drainer.AssertNotCalled(t, "DeleteNode", mock.Anything, k, n)
+		if task.Status != statuses.Error || !out.closed {
+			t.Errorf("%s: task that has not been run must fail, status %s closed %v",
+				testCase.description, task.Status, out.closed)
+		}
+		if n.State != node.StateActive {
+			t.Errorf("%s: wrong node state expected %s actual %s",
+				testCase.description, node.StateActive, n.State)
+		}
+	}
+}
+
+func TestDeleteNodeQueryParams(t *testing.T) {
+	for _, query := range []string{"force=maybe", "timeout=soon", "timeout=-1m"} {
+		h := Handler{}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/{kubeID}/nodes/{nodename}", h.deleteNode).Methods(http.MethodDelete)
+
+		req, _ := http.NewRequest(http.MethodDelete, "/test/nodes/test?"+query, nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				query, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
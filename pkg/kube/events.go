@@ -0,0 +1,175 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/events"
+	"github.com/supergiant/control/pkg/message"
+)
+
+const eventsPollInterval = 2 * time.Second
+
+// EventsHandler serves the timeline of events of kubes, events of deleted
+// kubes are served until they are pruned.
+type EventsHandler struct {
+	store        *events.Store
+	pollInterval time.Duration
+}
+
+func NewEventsHandler(store *events.Store) *EventsHandler {
+	return &EventsHandler{
+		store:        store,
+		pollInterval: eventsPollInterval,
+	}
+}
+
+func (h *EventsHandler) Register(r *mux.Router) {
+	r.HandleFunc("/kubes/{kubeID}/events", h.listEvents).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/events/stream", h.streamEvents).Methods(http.MethodGet)
+}
+
+func (h *EventsHandler) listEvents(w http.ResponseWriter, r *http.Request) {
+	f, err := eventsFilter(r)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	list, err := h.store.List(r.Context(), mux.Vars(r)["kubeID"], f)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// streamEvents sends events that match the filter over websocket, events
+// are polled from storage so that events recorded by other controlplanes
+// are streamed too.
+func (h *EventsHandler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+	f, err := eventsFilter(r)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	var upgrader = websocket.Upgrader{
+		HandshakeTimeout: time.Second * 10,
+		WriteBufferSize:  1024,
+		ReadBufferSize:   0,
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+
+	c, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logrus.Errorf("events: upgrade connection %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// Client messages are discarded, read fails when client disconnects
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := c.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer c.Close()
+		defer cancel()
+
+		pollTicker := time.NewTicker(h.pollInterval)
+		defer pollTicker.Stop()
+		pingTicker := time.NewTicker(time.Second * 60)
+		defer pingTicker.Stop()
+
+		for {
+			list, err := h.store.List(ctx, kubeID, f)
+			if err != nil {
+				logrus.Errorf("events: kube %s: %v", kubeID, err)
+			}
+
+			for i := range list {
+				c.SetWriteDeadline(time.Now().Add(time.Second * 10))
+				// Do not log this error, since client can simply disconnect
+				if err := c.WriteJSON(list[i]); err != nil {
+					return
+				}
+				f.After = list[i].ID
+			}
+			// Limit applies to the events that have been recorded before streaming
+			f.Limit = 0
+
+			select {
+			case <-pollTicker.C:
+			case <-pingTicker.C:
+				c.SetWriteDeadline(time.Now().Add(time.Second * 10))
+				if err := c.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// eventsFilter parses query of the request, type may be a comma separated
+// list, since and until are RFC3339 times.
+func eventsFilter(r *http.Request) (events.Filter, error) {
+	q := r.URL.Query()
+	f := events.Filter{
+		Severity: events.Severity(q.Get("severity")),
+		NodeName: q.Get("node"),
+		TaskID:   q.Get("task"),
+		After:    q.Get("after"),
+	}
+
+	for _, value := range q["type"] {
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				f.Types = append(f.Types, events.Type(t))
+			}
+		}
+	}
+
+	var err error
+	if since := q.Get("since"); since != "" {
+		if f.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return f, errors.Wrap(err, "since")
+		}
+	}
+
+	if until := q.Get("until"); until != "" {
+		if f.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return f, errors.Wrap(err, "until")
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		if f.Limit, err = strconv.Atoi(limit); err != nil || f.Limit < 0 {
+			return f, errors.Errorf("limit %q must be a non negative number", limit)
+		}
+	}
+
+	return f, nil
+}
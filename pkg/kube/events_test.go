@@ -0,0 +1,159 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/events"
+	"github.com/supergiant/control/pkg/model"
+)
+
+// eventsRepository is a memRepository that lists values by prefix.
+type eventsRepository struct {
+	*memRepository
+}
+
+func (r *eventsRepository) GetAll(ctx context.Context, prefix string) ([][]byte, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	result := make([][]byte, 0)
+	for key, value := range r.data {
+		if strings.HasPrefix(key, prefix) {
+			result = append(result, value)
+		}
+	}
+	return result, nil
+}
+
+func newTestEventStore() *events.Store {
+	return events.NewStore(&eventsRepository{&memRepository{data: map[string][]byte{}}},
+		events.DefaultMaxAge, events.DefaultMaxEvents)
+}
+
+func TestListEvents(t *testing.T) {
+	store := newTestEventStore()
+	for _, e := range []events.Event{
+		{Type: events.NodeAdded, KubeID: "test", NodeName: "node-1", Severity: events.Normal},
+		{Type: events.StepFailed, KubeID: "test", NodeName: "node-2", Severity: events.Warning},
+		{Type: events.ReleaseInstalled, KubeID: "test", Severity: events.Normal},
+	} {
+		e := e
+		require.Nil(t, store.Record(context.Background(), &e))
+	}
+
+	router := mux.NewRouter()
+	NewEventsHandler(store).Register(router)
+
+	testCases := []struct {
+		query        string
+		expectedCode int
+		expected     int
+	}{
+		{
+			expectedCode: http.StatusOK,
+			expected:     3,
+		},
+		{
+			query:        "type=NodeAdded,ReleaseInstalled",
+			expectedCode: http.StatusOK,
+			expected:     2,
+		},
+		{
+			query:        "severity=warning&node=node-2",
+			expectedCode: http.StatusOK,
+			expected:     1,
+		},
+		{
+			query:        "limit=1",
+			expectedCode: http.StatusOK,
+			expected:     1,
+		},
+		{
+			query:        "since=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			query:        "limit=-1",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, "/kubes/test/events?"+testCase.query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, testCase.expectedCode, rec.Code, testCase.query)
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		list := make([]events.Event, 0)
+		require.Nil(t, json.NewDecoder(rec.Body).Decode(&list))
+		require.Len(t, list, testCase.expected, testCase.query)
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	store := newTestEventStore()
+	require.Nil(t, store.Record(context.Background(), &events.Event{Type: events.KubeCreated, KubeID: "test"}))
+
+	h := NewEventsHandler(store)
+	h.pollInterval = time.Millisecond * 10
+
+	router := mux.NewRouter()
+	h.Register(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/kubes/test/events/stream?type=KubeCreated,NodeAdded"
+	c, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Nil(t, err)
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(time.Second * 5))
+
+	e := events.Event{}
+	require.Nil(t, c.ReadJSON(&e))
+	require.Equal(t, events.KubeCreated, e.Type)
+
+	require.Nil(t, store.Record(context.Background(), &events.Event{Type: events.StepFailed, KubeID: "test"}))
+	require.Nil(t, store.Record(context.Background(), &events.Event{Type: events.NodeAdded, KubeID: "test", NodeName: "node-1"}))
+
+	require.Nil(t, c.ReadJSON(&e))
+	require.Equal(t, events.NodeAdded, e.Type)
+	require.Equal(t, "node-1", e.NodeName)
+}
+
+func TestHealthCheckerEvents(t *testing.T) {
+	store := newTestEventStore()
+	events.SetRecorder(store)
+	defer events.SetRecorder(nil)
+
+	k := healthKube()
+	probe := healthyProbe()
+	probe.etcdErr = map[string]error{"master-1": errors.New("unhealthy cluster")}
+	c, _, _ := newTestHealthChecker(k, probe)
+
+	require.Nil(t, c.updateState(context.Background(), c.check(context.Background(), k)))
+	require.Equal(t, model.StateDegraded, k.State)
+
+	list, err := store.List(context.Background(), k.ID, events.Filter{})
+	require.Nil(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, events.HealthDegraded, list[0].Type)
+	require.Equal(t, events.Warning, list[0].Severity)
+	require.Contains(t, list[0].Details, HealthCheckEtcd)
+	require.Equal(t, events.KubeStateChanged, list[1].Type)
+	require.Equal(t, string(model.StateDegraded), list[1].Details["to"])
+}
@@ -0,0 +1,315 @@
+package kube
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	// DefaultCertsPrefix is a storage prefix of the latest
+	// certificate expiry reports of kubes
+	DefaultCertsPrefix       = "/certs/"
+	DefaultCertCheckInterval = 12 * time.Hour
+
+	defaultCertWarnBefore = 30 * 24 * time.Hour
+)
+
+var (
+	// masterCerts and workerCerts are names of certificates that
+	// the certificates step writes to DefaultCertsPath of nodes.
+	masterCerts = []string{"ca", "apiserver", "worker", "admin"}
+	workerCerts = []string{"ca", "worker", "admin"}
+)
+
+// CertExpiry is a validity period of a certificate, node is empty
+// for certificates that are kept by supergiant.
+type CertExpiry struct {
+	Node      string    `json:"node,omitempty"`
+	Name      string    `json:"name"`
+	Subject   string    `json:"subject"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	// Expiring is set when certificate expires within warning period.
+	Expiring bool `json:"expiring"`
+}
+
+// CertsReport holds expiry of certificates of a kube, errors
+// map nodes to reasons why their certificates can't be read.
+type CertsReport struct {
+	KubeID       string            `json:"kubeId"`
+	CheckedAt    time.Time         `json:"checkedAt"`
+	Certificates []CertExpiry      `json:"certificates"`
+	Errors       map[string]string `json:"errors,omitempty"`
+}
+
+// Expiring returns certificates that expire within warning period.
+func (r *CertsReport) Expiring() []CertExpiry {
+	expiring := make([]CertExpiry, 0)
+	for _, c := range r.Certificates {
+		if c.Expiring {
+			expiring = append(expiring, c)
+		}
+	}
+
+	return expiring
+}
+
+// CertChecker reads certificates from nodes of kubes and
+// periodically warns about certificates that expire soon.
+type CertChecker struct {
+	h          *Handler
+	repo       storage.Interface
+	interval   time.Duration
+	warnBefore time.Duration
+	bundleFor  func(context.Context, *model.Kube, *node.Node, string) (*Bundle, error)
+	now        func() time.Time
+}
+
+func NewCertChecker(h *Handler, repo storage.Interface, interval time.Duration) *CertChecker {
+	return &CertChecker{
+		h:          h,
+		repo:       repo,
+		interval:   interval,
+		warnBefore: defaultCertWarnBefore,
+		bundleFor:  sshBundleFor,
+		now:        time.Now,
+	}
+}
+
+func (c *CertChecker) Register(r *mux.Router) {
+	r.HandleFunc("/kubes/{kubeID}/certificates", c.getCertificates).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/certificates/warnings", c.getWarnings).Methods(http.MethodGet)
+}
+
+// Run checks kubes until context is done.
+func (c *CertChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.checkAll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *CertChecker) checkAll(ctx context.Context) {
+	kubes, err := c.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("certificates: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+		if !operational(k) {
+			continue
+		}
+
+		report := c.report(ctx, k)
+		for _, cert := range report.Expiring() {
+			logrus.Warnf("certificates: %s of node %q of kube %s expires at %s",
+				cert.Name, cert.Node, k.ID, cert.NotAfter.Format(time.RFC3339))
+		}
+		for nodeName, reason := range report.Errors {
+			logrus.Errorf("certificates: node %q of kube %s: %s", nodeName, k.ID, reason)
+		}
+
+		if err := saveCertsReport(ctx, c.repo, report); err != nil {
+			logrus.Errorf("certificates: kube %s: %v", k.ID, err)
+		}
+	}
+}
+
+// report reads certificates of all active nodes at the same time,
+// certificates kept in the kube are added to the report as well.
+func (c *CertChecker) report(ctx context.Context, k *model.Kube) *CertsReport {
+	report := &CertsReport{
+		KubeID:       k.ID,
+		CheckedAt:    c.now(),
+		Certificates: make([]CertExpiry, 0),
+		Errors:       make(map[string]string),
+	}
+
+	for name, data := range map[string]string{"ca": k.Auth.CACert, "admin": k.Auth.AdminCert} {
+		if data == "" {
+			continue
+		}
+
+		expiry, err := c.certExpiry([]byte(data))
+		if err != nil {
+			report.Errors[""] = errors.Wrapf(err, "certificate %s", name).Error()
+			continue
+		}
+		expiry.Name = name
+		report.Certificates = append(report.Certificates, *expiry)
+	}
+
+	var (
+		m  sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, role := range []struct {
+		nodes map[string]*node.Node
+		names []string
+	}{
+		{k.Masters, masterCerts},
+		{k.Nodes, workerCerts},
+	} {
+		for _, n := range sortedNodes(role.nodes) {
+			if n.State != node.StateActive {
+				continue
+			}
+
+			wg.Add(1)
+			go func(n *node.Node, names []string) {
+				defer wg.Done()
+
+				certs, err := c.nodeCerts(ctx, k, n, names)
+
+				m.Lock()
+				defer m.Unlock()
+				report.Certificates = append(report.Certificates, certs...)
+				if err != nil {
+					report.Errors[n.Name] = err.Error()
+				}
+			}(n, role.names)
+		}
+	}
+	wg.Wait()
+
+	sort.SliceStable(report.Certificates, func(i, j int) bool {
+		return report.Certificates[i].NotAfter.Before(report.Certificates[j].NotAfter)
+	})
+
+	return report
+}
+
+func (c *CertChecker) nodeCerts(ctx context.Context, k *model.Kube, n *node.Node, names []string) ([]CertExpiry, error) {
+	certs := make([]CertExpiry, 0, len(names))
+
+	for _, name := range names {
+		b, err := c.bundleFor(ctx, k, n, name)
+		if err != nil {
+			return certs, errors.Wrapf(err, "read certificate %s", name)
+		}
+
+		expiry, err := c.certExpiry(b.Cert)
+		if err != nil {
+			return certs, errors.Wrapf(err, "certificate %s", name)
+		}
+		expiry.Node = n.Name
+		expiry.Name = name
+
+		certs = append(certs, *expiry)
+	}
+
+	return certs, nil
+}
+
+func (c *CertChecker) certExpiry(data []byte) (*CertExpiry, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("decode pem")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse certificate")
+	}
+
+	return &CertExpiry{
+		Subject:   cert.Subject.CommonName,
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+		Expiring:  cert.NotAfter.Before(c.now().Add(c.warnBefore)),
+	}, nil
+}
+
+// sshBundleFor reads certificate of the node with bootstrap key of the kube.
+func sshBundleFor(ctx context.Context, k *model.Kube, n *node.Node, name string) (*Bundle, error) {
+	r, err := sshRunner(k, n)
+	if err != nil {
+		return nil, errors.Wrap(err, "setup runner")
+	}
+
+	certs, err := NewCerts(DefaultCertsPath, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "setup certs getter")
+	}
+
+	return certs.BundleFor(ctx, name)
+}
+
+func saveCertsReport(ctx context.Context, repo storage.Interface, report *CertsReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "marshal certificates report")
+	}
+
+	return errors.Wrap(repo.Put(ctx, DefaultCertsPrefix, report.KubeID, data), "save certificates report")
+}
+
+// getCertificates reads certificates from nodes of the kube.
+func (c *CertChecker) getCertificates(w http.ResponseWriter, r *http.Request) {
+	k, ok := c.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if provisioningDisabled(w, k) {
+		return
+	}
+
+	report := c.report(r.Context(), k)
+	if err := saveCertsReport(r.Context(), c.repo, report); err != nil {
+		logrus.Errorf("certificates: kube %s: %v", k.ID, err)
+	}
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// getWarnings returns certificates that expire soon found by the latest check.
+func (c *CertChecker) getWarnings(w http.ResponseWriter, r *http.Request) {
+	k, ok := c.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := loadCertsReport(r.Context(), c.repo, k.ID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, "certificates report of "+k.ID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+	report.Certificates = report.Expiring()
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
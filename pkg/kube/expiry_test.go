@@ -0,0 +1,151 @@
+package kube
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+)
+
+func testCertPEM(t *testing.T, cn string, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCertsReport(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	soon := now.Add(10 * 24 * time.Hour)
+	later := now.Add(300 * 24 * time.Hour)
+
+	k := &model.Kube{
+		ID: "test",
+		Auth: model.Auth{
+			CACert:    string(testCertPEM(t, "kubernetes", now.Add(10*365*24*time.Hour))),
+			AdminCert: string(testCertPEM(t, "kubernetes-admin", later)),
+		},
+		Masters: map[string]*node.Node{
+			"master-1": {Name: "master-1", State: node.StateActive},
+		},
+		Nodes: map[string]*node.Node{
+			"node-1": {Name: "node-1", State: node.StateActive},
+			"node-2": {Name: "node-2", State: node.StateActive},
+			"node-3": {Name: "node-3", State: node.StateProvisioning},
+		},
+	}
+
+	checker := NewCertChecker(nil, nil, time.Hour)
+	checker.now = func() time.Time { return now }
+	checker.bundleFor = func(ctx context.Context, k *model.Kube, n *node.Node, name string) (*Bundle, error) {
+		switch {
+		case n.Name == "node-2":
+			return nil, errors.New("connection refused")
+		case n.Name == "node-3":
+			t.Errorf("certificates of inactive node have been read")
+		case n.Name == "master-1" && name == "apiserver":
+			return &Bundle{Cert: testCertPEM(t, "kube-apiserver", soon)}, nil
+		}
+		return &Bundle{Cert: testCertPEM(t, name, later)}, nil
+	}
+
+	report := checker.report(context.Background(), k)
+
+	// ca and admin of the kube, 4 of the master and 3 of the worker
+	if len(report.Certificates) != 9 {
+		t.Fatalf("wrong certificate count expected 9 actual %d %+v",
+			len(report.Certificates), report.Certificates)
+	}
+
+	first := report.Certificates[0]
+	if first.Node != "master-1" || first.Name != "apiserver" || !first.Expiring {
+		t.Errorf("certificate that expires first is wrong %+v", first)
+	}
+
+	if expiring := report.Expiring(); len(expiring) != 1 {
+		t.Errorf("wrong expiring certificates %+v", expiring)
+	}
+
+	if _, ok := report.Errors["node-2"]; !ok || len(report.Errors) != 1 {
+		t.Errorf("wrong errors %v", report.Errors)
+	}
+}
+
+func TestGetCertWarnings(t *testing.T) {
+	repo := &memRepository{
+		data: map[string][]byte{},
+	}
+
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).
+		Return(&model.Kube{ID: "test"}, nil)
+
+	checker := NewCertChecker(&Handler{svc: svc}, repo, time.Hour)
+	router := mux.NewRouter()
+	checker.Register(router)
+
+	req, _ := http.NewRequest(http.MethodGet, "/kubes/test/certificates/warnings", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("no report: expected code %d actual %d", http.StatusNotFound, rec.Code)
+	}
+
+	err := saveCertsReport(context.Background(), repo, &CertsReport{
+		KubeID: "test",
+		Certificates: []CertExpiry{
+			{Node: "master-1", Name: "apiserver", Expiring: true},
+			{Node: "master-1", Name: "worker"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("wrong response code expected %d actual %d", http.StatusOK, rec.Code)
+	}
+
+	report := &CertsReport{}
+	if err := json.NewDecoder(rec.Body).Decode(report); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Certificates) != 1 || report.Certificates[0].Name != "apiserver" {
+		t.Errorf("wrong warnings %+v", report.Certificates)
+	}
+}
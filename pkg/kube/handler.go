@@ -18,6 +18,7 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/events"
 	"github.com/supergiant/control/pkg/message"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/node"
@@ -77,9 +78,11 @@ type Handler struct {
 	nodeProvisioner nodeProvisioner
 	workflowMap     map[clouds.Name]workflows.WorkflowSet
 	repo            storage.Interface
+	drainer         nodeDrainer
 	getWriter       func(string) (io.WriteCloser, error)
 	getMetrics      func(string, *model.Kube) (*MetricResponse, error)
 	getK8sServices  func(*model.Kube, string, string) (*corev1.ServiceList, error)
+	getK8sNodes     func(*model.Kube) ([]corev1.Node, error)
 	proxies         proxy.Container
 }
 
@@ -99,18 +102,32 @@ func NewHandler(
 			clouds.DigitalOcean: {
 				DeleteCluster: workflows.DigitalOceanDeleteCluster,
 				DeleteNode:    workflows.DigitalOceanDeleteNode,
+				UpgradeMaster: workflows.UpgradeMaster,
+				UpgradeNode:   workflows.UpgradeNode,
 			},
 			clouds.AWS: {
 				DeleteCluster: workflows.AWSDeleteCluster,
 				DeleteNode:    workflows.AWSDeleteNode,
+				UpgradeMaster: workflows.UpgradeMaster,
+				UpgradeNode:   workflows.UpgradeNode,
 			},
 			clouds.GCE: {
 				DeleteCluster: workflows.GCEDeleteCluster,
 				DeleteNode:    workflows.GCEDeleteNode,
+				UpgradeMaster: workflows.UpgradeMaster,
+				UpgradeNode:   workflows.UpgradeNode,
+			},
+			clouds.BareMetal: {
+				DeleteCluster: workflows.BareMetalDeleteCluster,
+				DeleteNode:    workflows.BareMetalDeleteNode,
+				UpgradeMaster: workflows.UpgradeMaster,
+				UpgradeNode:   workflows.UpgradeNode,
 			},
 		},
-		repo:      repo,
-		getWriter: util.GetWriter,
+		repo:        repo,
+		drainer:     newAPIDrainer(),
+		getWriter:   util.GetWriter,
+		getK8sNodes: listK8sNodes,
 		getMetrics: func(metricURI string, k *model.Kube) (*MetricResponse, error) {
 			cfg, err := NewConfigFor(k)
 			if err != nil {
@@ -167,9 +184,13 @@ func NewHandler(
 // Register adds kube handlers to a router.
 func (h *Handler) Register(r *mux.Router) {
 	r.HandleFunc("/kubes", h.createKube).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/import", h.importKube).Methods(http.MethodPost)
 	r.HandleFunc("/kubes", h.listKubes).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}", h.getKube).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}", h.deleteKube).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/delete/plan", h.getDeletePlan).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/upgrade", h.upgradeKube).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/certificates/rotate", h.rotateCertificates).Methods(http.MethodPost)
 
 	r.HandleFunc("/kubes/{kubeID}/users/{uname}/kubeconfig", h.getKubeconfig).Methods(http.MethodGet)
 
@@ -183,9 +204,20 @@ func (h *Handler) Register(r *mux.Router) {
 
 	r.HandleFunc("/kubes/{kubeID}/certs/{cname}", h.getCerts).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/tasks", h.getTasks).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/tasks", h.runTasks).Methods(http.MethodPost)
 
 	r.HandleFunc("/kubes/{kubeID}/nodes", h.addNode).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/discover", h.rediscoverNodes).Methods(http.MethodPost)
 	r.HandleFunc("/kubes/{kubeID}/nodes/{nodename}", h.deleteNode).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/pools", h.listPools).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/pools/{pool}", h.getPool).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/pools/{pool}", h.scalePool).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/autorepair", h.getAutoRepair).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/autorepair", h.updateAutoRepair).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/autorepair/events", h.getRepairEvents).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/autoscaling", h.getAutoscaling).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/autoscaling", h.updateAutoscaling).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/autoscaling/decisions", h.getScalingDecisions).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/metrics", h.getClusterMetrics).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/nodes/metrics", h.getNodesMetrics).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/services", h.getServices).Methods(http.MethodGet)
@@ -200,8 +232,7 @@ func (h *Handler) getTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tasks, err := h.getKubeTasks(r.Context(), id)
-
+	k, err := h.svc.Get(r.Context(), id)
 	if err != nil {
 		if sgerrors.IsNotFound(err) {
 			message.SendNotFound(w, id, err)
@@ -212,7 +243,21 @@ func (h *Handler) getTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(tasks) == 0 {
+	// NOTE: kube keeps ids of its tasks, only they are read from storage.
+	if len(k.Tasks) == 0 {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	tasks, err := workflows.ListTasks(r.Context(), h.repo, workflows.TaskFilter{
+		IDs: k.Tasks,
+	}, 0, len(k.Tasks))
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if len(tasks.Items) == 0 {
 		http.Error(w, "", http.StatusNotFound)
 		return
 	}
@@ -224,9 +269,9 @@ func (h *Handler) getTasks(w http.ResponseWriter, r *http.Request) {
 		StepStatuses []workflows.StepStatus `json:"stepsStatuses"`
 	}
 
-	resp := make([]taskDTO, 0, len(tasks))
+	resp := make([]taskDTO, 0, len(tasks.Items))
 
-	for _, task := range tasks {
+	for _, task := range tasks.Items {
 		resp = append(resp, taskDTO{
 			ID:           task.ID,
 			Type:         task.Type,
@@ -293,6 +338,24 @@ func (h *Handler) getKube(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// kubeFromRequest gets kube by id from the request path, the error
+// response is sent when kube can't be found.
+func (h *Handler) kubeFromRequest(w http.ResponseWriter, r *http.Request) (*model.Kube, bool) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return nil, false
+		}
+		message.SendUnknownError(w, err)
+		return nil, false
+	}
+
+	return k, true
+}
+
 func (h *Handler) listKubes(w http.ResponseWriter, r *http.Request) {
 	kubes, err := h.svc.ListAll(r.Context())
 	if err != nil {
@@ -310,6 +373,12 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 	kubeID := vars["kubeID"]
 	logrus.Debugf("Delete kube %s", kubeID)
 
+	untagged, err := deleteUntagged(r)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
 	if err := h.nodeProvisioner.Cancel(kubeID); err != nil {
 		logrus.Debugf("cancel kube tasks error %v", err)
 	}
@@ -324,6 +393,17 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Machines of imported kube are left intact, kube is just forgotten
+	if k.State == model.StateImported {
+		if err := h.svc.Delete(r.Context(), kubeID); err != nil {
+			message.SendUnknownError(w, err)
+			return
+		}
+		events.Emitf(kubeID, events.KubeDeleted, "imported kube %s has been forgotten", k.Name)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
 	acc, err := h.accountService.Get(r.Context(), k.AccountName)
 
 	if err != nil {
@@ -348,24 +428,7 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	config := &steps.Config{
-		Provider:         k.Provider,
-		ClusterID:        k.ID,
-		ClusterName:      k.Name,
-		CloudAccountName: k.AccountName,
-		Masters:          steps.NewMap(k.Masters),
-		Nodes:            steps.NewMap(k.Nodes),
-	}
-
-	// Load things specific to cloud provider
-	err = util.LoadCloudSpecificDataFromKube(k, config)
-
-	if err != nil {
-		message.SendUnknownError(w, err)
-		return
-	}
-
-	err = util.FillCloudAccountCredentials(r.Context(), acc, config)
+	config, err := deleteClusterConfig(r.Context(), k, acc)
 
 	if err != nil {
 		if sgerrors.IsNotFound(err) {
@@ -375,6 +438,7 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 		message.SendUnknownError(w, err)
 		return
 	}
+	config.AWSConfig.DeleteUntagged = untagged
 
 	fileName := util.MakeFileName(t.ID)
 	writer, err := h.getWriter(fileName)
@@ -384,16 +448,18 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errChan := t.Run(context.Background(), *config, writer)
+	errChan := t.RunConfig(context.Background(), config, writer)
 
 	go func(t *workflows.Task) {
 		// Update kube with deleting state
+		state := k.State
 		k.State = model.StateDeleting
 		err = h.svc.Create(context.Background(), k)
 
 		if err != nil {
 			logrus.Errorf("update cluster %s caused %v", kubeID, err)
 		}
+		events.StateChanged(kubeID, state, k.State, "kube is being deleted")
 
 		err = <-errChan
 		if err != nil {
@@ -405,6 +471,12 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 			logrus.Errorf("delete kube %s caused %v", kubeID, err)
 			return
 		}
+		events.Emit(events.Event{
+			Type:    events.KubeDeleted,
+			KubeID:  kubeID,
+			TaskID:  t.ID,
+			Message: fmt.Sprintf("kube %s has been deleted", k.Name),
+		})
 
 		h.deleteClusterTasks(context.Background(), kubeID)
 	}(t)
@@ -412,6 +484,99 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 }
 
+// getDeletePlan lists cloud resources that would be deleted with the kube,
+// resources that have not been created by the kube are marked as foreign,
+// resources of kubes created before resources were tagged as untagged.
+func (h *Handler) getDeletePlan(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if provisioningDisabled(w, k) {
+		return
+	}
+
+	// NOTE: plan tells what the delete request with the same query would delete.
+	untagged, err := deleteUntagged(r)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	acc, err := h.accountService.Get(r.Context(), k.AccountName)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.AccountName, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	config, err := deleteClusterConfig(r.Context(), k, acc)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+	config.AWSConfig.DeleteUntagged = untagged
+
+	workflowName := h.workflowMap[acc.Provider].DeleteCluster
+	plan, err := workflows.MakePlan(r.Context(), workflowName, config)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, workflowName, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(plan); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// deleteUntagged is an opt-in of the delete request to delete cloud
+// resources of kubes created before resources were tagged, that have
+// no cluster tag, see steps.Resource.
+func deleteUntagged(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("deleteUntagged")
+	if value == "" {
+		return false, nil
+	}
+
+	untagged, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.Wrap(err, "parse deleteUntagged")
+	}
+
+	return untagged, nil
+}
+
+func deleteClusterConfig(ctx context.Context, k *model.Kube, acc *model.CloudAccount) (*steps.Config, error) {
+	config := &steps.Config{
+		Provider:         k.Provider,
+		ClusterID:        k.ID,
+		ClusterName:      k.Name,
+		CloudAccountName: k.AccountName,
+		Masters:          steps.NewMap(k.Masters),
+		Nodes:            steps.NewMap(k.Nodes),
+		RetryPolicies:    retryPolicies(k),
+	}
+
+	// Load things specific to cloud provider
+	if err := util.LoadCloudSpecificDataFromKube(k, config); err != nil {
+		return nil, err
+	}
+
+	if err := util.FillCloudAccountCredentials(ctx, acc, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
 func (h *Handler) getKubeconfig(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
@@ -516,6 +681,10 @@ func (h *Handler) addNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if provisioningDisabled(w, k) {
+		return
+	}
+
 	nodeProfiles := make([]profile.NodeProfile, 0)
 	err = json.NewDecoder(r.Body).Decode(&nodeProfiles)
 
@@ -536,58 +705,21 @@ func (h *Handler) addNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	kubeProfile := profile.Profile{
-		Provider:        acc.Provider,
-		Region:          k.Region,
-		Zone:            k.Zone,
-		Arch:            k.Arch,
-		OperatingSystem: k.OperatingSystem,
-		UbuntuVersion:   k.OperatingSystemVersion,
-		DockerVersion:   k.DockerVersion,
-		K8SVersion:      k.K8SVersion,
-		K8SServicesCIDR: k.ServicesCIDR,
-		HelmVersion:     k.HelmVersion,
-		User:            k.User,
-		Password:        k.Password,
-
-		NetworkType:           k.Networking.Type,
-		CIDR:                  k.Networking.CIDR,
-		FlannelVersion:        k.Networking.Version,
-		CloudSpecificSettings: k.CloudSpec,
-
-		NodesProfiles: []profile.NodeProfile{
-			{},
-		},
-
-		RBACEnabled: k.RBACEnabled,
-	}
-
-	config := steps.NewConfig(k.Name, "", k.AccountName, kubeProfile)
-	config.ClusterID = k.ID
-	config.CertificatesConfig.CAKey = k.Auth.CAKey
-	config.CertificatesConfig.CACert = k.Auth.CACert
-	config.CertificatesConfig.AdminCert = k.Auth.AdminCert
-	config.CertificatesConfig.AdminKey = k.Auth.AdminKey
-
-	if len(k.Masters) != 0 {
-		config.AddMaster(util.GetRandomNode(k.Masters))
-	} else {
-		http.Error(w, "no master found", http.StatusNotFound)
-		return
+	validationErrs := profile.ValidationErrors{}
+	for i, nodeProfile := range nodeProfiles {
+		_, err := profile.ParseNodeProfile(acc.Provider, fmt.Sprintf("[%d]", i), nodeProfile)
+		if errs, ok := err.(profile.ValidationErrors); ok {
+			validationErrs = append(validationErrs, errs...)
+		}
 	}
 
-	// Get cloud account fill appropriate config structure
-	// with cloud account credentials
-	err = util.FillCloudAccountCredentials(r.Context(), acc, config)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if len(validationErrs) > 0 {
+		message.SendValidationFailed(w, validationErrs)
 		return
 	}
 
 	ctx, _ := context.WithTimeout(context.Background(), time.Minute*10)
-	tasks, err := h.nodeProvisioner.ProvisionNodes(ctx, nodeProfiles,
-		k, config)
+	tasks, err := h.provisionNodes(ctx, k, acc, nodeProfiles, nil)
 
 	if err != nil && sgerrors.IsNotFound(err) {
 		http.Error(w, err.Error(), http.StatusNotFound)
@@ -616,6 +748,38 @@ func (h *Handler) addNode(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// provisionNodes adds workers to the kube, nodes are added to the pool
+// if it is not nil.
+func (h *Handler) provisionNodes(ctx context.Context, k *model.Kube, acc *model.CloudAccount,
+	nodeProfiles []profile.NodeProfile, pool *profile.NodePool) ([]string, error) {
+	kubeProfile := kubeProfileFrom(k, acc.Provider)
+	kubeProfile.NodesProfiles = []profile.NodeProfile{
+		{},
+	}
+
+	config := steps.NewConfig(k.Name, "", k.AccountName, kubeProfile)
+	config.ClusterID = k.ID
+	config.NodePool = pool
+	config.CertificatesConfig.CAKey = k.Auth.CAKey
+	config.CertificatesConfig.CACert = k.Auth.CACert
+	config.CertificatesConfig.AdminCert = k.Auth.AdminCert
+	config.CertificatesConfig.AdminKey = k.Auth.AdminKey
+
+	if len(k.Masters) != 0 {
+		config.AddMaster(util.GetRandomNode(k.Masters))
+	} else {
+		return nil, errors.Wrap(sgerrors.ErrNotFound, "no master found")
+	}
+
+	// Get cloud account fill appropriate config structure
+	// with cloud account credentials
+	if err := util.FillCloudAccountCredentials(ctx, acc, config); err != nil {
+		return nil, errors.Wrap(err, "fill cloud account credentials")
+	}
+
+	return h.nodeProvisioner.ProvisionNodes(ctx, nodeProfiles, k, config)
+}
+
 // TODO(stgleb): cover with unit tests
 func (h *Handler) deleteNode(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -623,6 +787,25 @@ func (h *Handler) deleteNode(w http.ResponseWriter, r *http.Request) {
 	kubeID := vars["kubeID"]
 	nodeName := vars["nodename"]
 
+	// NOTE: force deletes the machine without draining the node
+	force := false
+	if value := r.URL.Query().Get("force"); value != "" {
+		var err error
+		if force, err = strconv.ParseBool(value); err != nil {
+			message.SendValidationFailed(w, errors.Wrap(err, "parse force"))
+			return
+		}
+	}
+
+	drainTimeout := DefaultDrainTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		var err error
+		if drainTimeout, err = time.ParseDuration(value); err != nil || drainTimeout <= 0 {
+			message.SendValidationFailed(w, errors.Errorf("invalid drain timeout %q", value))
+			return
+		}
+	}
+
 	logrus.Debugf("Delete node %s from kube %s",
 		nodeName, kubeID)
 	k, err := h.svc.Get(r.Context(), kubeID)
@@ -635,6 +818,10 @@ func (h *Handler) deleteNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if provisioningDisabled(w, k) {
+		return
+	}
+
 	// TODO(stgleb): check whether we will have quorum of master nodes if node is deleted.
 	if _, ok := k.Masters[nodeName]; ok {
 		http.Error(w, "delete master node not allowed", http.StatusMethodNotAllowed)
@@ -661,88 +848,159 @@ func (h *Handler) deleteNode(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	t, err := workflows.NewTask(h.workflowMap[acc.Provider].DeleteNode, h.repo)
+	t, config, err := h.deleteNodeTask(r.Context(), k, acc, n)
 
 	if err != nil {
 		if sgerrors.IsNotFound(err) {
 			http.NotFound(w, r)
 			return
 		}
+		message.SendUnknownError(w, err)
+		return
+	}
 
+	writer, err := h.getWriter(util.MakeFileName(t.ID))
+
+	if err != nil {
 		message.SendUnknownError(w, err)
 		return
 	}
 
+	// Update cluster state when deletion completes
+	go h.removeNode(k, n, t, config, writer, force, drainTimeout)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// deleteNodeTask creates a task that deletes the worker machine.
+func (h *Handler) deleteNodeTask(ctx context.Context, k *model.Kube, acc *model.CloudAccount,
+	n *node.Node) (*workflows.Task, *steps.Config, error) {
+	t, err := workflows.NewTask(h.workflowMap[acc.Provider].DeleteNode, h.repo)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "new delete node task")
+	}
+
 	config := &steps.Config{
 		Provider:         k.Provider,
 		ClusterID:        k.ID,
 		ClusterName:      k.Name,
 		CloudAccountName: k.AccountName,
 		Node:             *n,
+		RetryPolicies:    retryPolicies(k),
 	}
 
-	err = util.FillCloudAccountCredentials(r.Context(), acc, config)
+	if err := util.FillCloudAccountCredentials(ctx, acc, config); err != nil {
+		return nil, nil, errors.Wrap(err, "fill cloud account credentials")
+	}
 
-	if err != nil {
-		if sgerrors.IsNotFound(err) {
-			http.NotFound(w, r)
-			return
+	if err := util.LoadCloudSpecificDataFromKube(k, config); err != nil {
+		return nil, nil, errors.Wrap(err, "load cloud specific data")
+	}
+
+	return t, config, nil
+}
+
+// removeNode drains the node unless force is set, then deletes the machine
+// and removes the node from the kube and from kubernetes API.
+func (h *Handler) removeNode(k *model.Kube, n *node.Node, t *workflows.Task,
+	config *steps.Config, out io.WriteCloser, force bool, drainTimeout time.Duration) error {
+	prevState := n.State
+
+	if err := h.setNodeState(k.ID, n.Name, node.StateDeleting); err != nil {
+		logrus.Errorf("update node %s of cluster %s caused %v", n.Name, k.ID, err)
+		abortTask(t, out, err)
+		return err
+	}
+
+	if !force {
+		if err := h.drainer.Drain(context.Background(), k, n, drainTimeout); err != nil {
+			logrus.Errorf("drain node %s of cluster %s caused %v, node is not deleted",
+				n.Name, k.ID, err)
+
+			if err := h.drainer.Uncordon(context.Background(), k, n); err != nil {
+				logrus.Errorf("uncordon node %s of cluster %s caused %v", n.Name, k.ID, err)
+			}
+
+			if err := h.setNodeState(k.ID, n.Name, prevState); err != nil {
+				logrus.Errorf("update node %s of cluster %s caused %v", n.Name, k.ID, err)
+			}
+
+			err = errors.Wrapf(err, "drain node %s", n.Name)
+			abortTask(t, out, err)
+			return err
 		}
-		message.SendUnknownError(w, err)
-		return
 	}
 
-	err = util.LoadCloudSpecificDataFromKube(k, config)
+	taskErr := <-t.RunConfig(context.Background(), config, out)
+
+	if taskErr != nil {
+		logrus.Errorf("delete node %s from cluster %s caused %v", n.Name, k.ID, taskErr)
+		// NOTE: task closes its output only when it succeeds.
+		if err := out.Close(); err != nil {
+			logrus.Errorf("close output of task %s caused %v", t.ID, err)
+		}
+	} else if err := h.drainer.DeleteNode(context.Background(), k, n); err != nil {
+		// Machine is gone, node object would stay NotReady forever
+		logrus.Errorf("delete kubernetes node %s of cluster %s caused %v", n.Name, k.ID, err)
+	}
+
+	// Reload kube, it could have been changed while node is being deleted
+	k, err := h.svc.Get(context.Background(), k.ID)
 
 	if err != nil {
-		message.SendUnknownError(w, err)
-		return
+		logrus.Errorf("get cluster %s caused %v", config.ClusterID, err)
+		return err
 	}
 
-	util.MakeFileName(t.ID)
-	writer, err := h.getWriter(t.ID)
+	// Delete node from cluster object
+	delete(k.Nodes, n.Name)
+	// Save cluster object to etcd
+	logrus.Infof("delete node %s from cluster %s", n.Name, k.ID)
+	err = h.svc.Create(context.Background(), k)
 
 	if err != nil {
-		message.SendUnknownError(w, err)
-		return
+		logrus.Errorf("update cluster %s caused %v", k.ID, err)
+		return err
 	}
 
-	errChan := t.Run(context.Background(), *config, writer)
+	events.Emit(events.Event{
+		Type:     events.NodeRemoved,
+		KubeID:   k.ID,
+		NodeName: n.Name,
+		TaskID:   t.ID,
+		Message:  fmt.Sprintf("node %s has been removed", n.Name),
+	})
 
-	// Update cluster state when deletion completes
-	go func() {
-		// Set node to deleting state
-		nodeToDelete, ok := k.Nodes[nodeName]
+	return taskErr
+}
 
-		if !ok {
-			logrus.Errorf("Node %s not found", nodeName)
-			return
-		}
-		nodeToDelete.State = node.StateDeleting
-		k.Nodes[nodeName] = nodeToDelete
-		err := h.svc.Create(context.Background(), k)
+// abortTask marks the task that is not going to run as failed
+// and closes its output.
+func abortTask(t *workflows.Task, out io.Closer, reason error) {
+	if err := t.Interrupt(context.Background(), reason.Error()); err != nil {
+		logrus.Errorf("save task %s caused %v", t.ID, err)
+	}
 
-		if err != nil {
-			logrus.Errorf("update cluster %s caused %v", kubeID, err)
-		}
+	if err := out.Close(); err != nil {
+		logrus.Errorf("close output of task %s caused %v", t.ID, err)
+	}
+}
 
-		err = <-errChan
+func (h *Handler) setNodeState(kubeID, nodeName string, state node.NodeState) error {
+	k, err := h.svc.Get(context.Background(), kubeID)
 
-		if err != nil {
-			logrus.Errorf("delete node %s from cluster %s caused %v", nodeName, kubeID, err)
-		}
+	if err != nil {
+		return errors.Wrapf(err, "get cluster %s", kubeID)
+	}
 
-		// Delete node from cluster object
-		delete(k.Nodes, nodeName)
-		// Save cluster object to etcd
-		logrus.Infof("delete node %s from cluster %s", nodeName, kubeID)
-		err = h.svc.Create(context.Background(), k)
+	n, ok := k.Nodes[nodeName]
 
-		if err != nil {
-			logrus.Errorf("update cluster %s caused %v", kubeID, err)
-		}
-	}()
-	w.WriteHeader(http.StatusAccepted)
+	if !ok {
+		return errors.Wrapf(sgerrors.ErrNotFound, "node %s", nodeName)
+	}
+	n.State = state
+
+	return h.svc.Create(context.Background(), k)
 }
 
 // TODO(stgleb): Create separte task service to manage task object lifecycle
@@ -819,6 +1077,17 @@ func (h *Handler) installRelease(w http.ResponseWriter, r *http.Request) {
 		message.SendUnknownError(w, err)
 		return
 	}
+	events.Emit(events.Event{
+		Type:    events.ReleaseInstalled,
+		KubeID:  kubeID,
+		Message: fmt.Sprintf("release %s of chart %s/%s has been installed", rls.GetName(), inp.RepoName, inp.ChartName),
+		Details: map[string]string{
+			"release":   rls.GetName(),
+			"namespace": rls.GetNamespace(),
+			"chart":     inp.RepoName + "/" + inp.ChartName,
+			"version":   inp.ChartVersion,
+		},
+	})
 
 	if err = json.NewEncoder(w).Encode(rls); err != nil {
 		logrus.Errorf("helm: install release: %s cluster: %s/%s: write response: %s",
@@ -877,6 +1146,15 @@ func (h *Handler) deleteReleases(w http.ResponseWriter, r *http.Request) {
 		message.SendUnknownError(w, err)
 		return
 	}
+	events.Emit(events.Event{
+		Type:    events.ReleaseDeleted,
+		KubeID:  kubeID,
+		Message: fmt.Sprintf("release %s has been deleted", rlsName),
+		Details: map[string]string{
+			"release": rlsName,
+			"purge":   strconv.FormatBool(purge),
+		},
+	})
 
 	if err = json.NewEncoder(w).Encode(rls); err != nil {
 		logrus.Errorf("helm: delete release: %s cluster: write response: %s", kubeID, err)
@@ -890,9 +1168,8 @@ func (h *Handler) getClusterMetrics(w http.ResponseWriter, r *http.Request) {
 			"cpu":    "api/v1/query?query=:node_cpu_utilisation:avg1m",
 			"memory": "api/v1/query?query=:node_memory_utilisation:",
 		}
-		masterNode *node.Node
-		response   = map[string]interface{}{}
-		baseUrl    = "api/v1/namespaces/default/services/prometheus-operated:9090/proxy"
+		response = map[string]interface{}{}
+		baseUrl  = "api/v1/namespaces/default/services/prometheus-operated:9090/proxy"
 	)
 
 	vars := mux.Vars(r)
@@ -908,14 +1185,14 @@ func (h *Handler) getClusterMetrics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for key := range k.Masters {
-		if k.Masters[key] != nil {
-			masterNode = k.Masters[key]
-		}
+	host, err := apiHost(k)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
 	}
 
 	for metricType, relUrl := range metricsRelUrls {
-		url := fmt.Sprintf("https://%s/%s/%s", masterNode.PublicIp, baseUrl, relUrl)
+		url := fmt.Sprintf("https://%s/%s/%s", host, baseUrl, relUrl)
 		metricResponse, err := h.getMetrics(url, k)
 
 		if err != nil {
@@ -937,16 +1214,6 @@ func (h *Handler) getClusterMetrics(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) getNodesMetrics(w http.ResponseWriter, r *http.Request) {
-	var (
-		metricsRelUrls = map[string]string{
-			"cpu":    "api/v1/query?query=node:node_cpu_utilisation:avg1m",
-			"memory": "api/v1/query?query=node:node_memory_utilisation:",
-		}
-		masterNode *node.Node
-		response   = map[string]map[string]interface{}{}
-		baseUrl    = "api/v1/namespaces/default/services/prometheus-operated:9090/proxy"
-	)
-
 	vars := mux.Vars(r)
 	kubeID := vars["kubeID"]
 
@@ -960,19 +1227,42 @@ func (h *Handler) getNodesMetrics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for key := range k.Masters {
-		if k.Masters[key] != nil {
-			masterNode = k.Masters[key]
+	response, err := h.nodesMetrics(k)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+}
+
+// nodesMetrics returns cpu and memory utilisation of kube nodes by node name.
+func (h *Handler) nodesMetrics(k *model.Kube) (map[string]map[string]interface{}, error) {
+	var (
+		metricsRelUrls = map[string]string{
+			"cpu":    "api/v1/query?query=node:node_cpu_utilisation:avg1m",
+			"memory": "api/v1/query?query=node:node_memory_utilisation:",
 		}
+		response = map[string]map[string]interface{}{}
+		baseUrl  = "api/v1/namespaces/default/services/prometheus-operated:9090/proxy"
+	)
+
+	host, err := apiHost(k)
+	if err != nil {
+		return nil, err
 	}
 
 	for metricType, relUrl := range metricsRelUrls {
-		url := fmt.Sprintf("https://%s/%s/%s", masterNode.PublicIp, baseUrl, relUrl)
+		url := fmt.Sprintf("https://%s/%s/%s", host, baseUrl, relUrl)
 		metricResponse, err := h.getMetrics(url, k)
 
 		if err != nil {
-			message.SendUnknownError(w, err)
-			return
+			return nil, err
 		}
 
 		for _, result := range metricResponse.Data.Result {
@@ -995,19 +1285,13 @@ func (h *Handler) getNodesMetrics(w http.ResponseWriter, r *http.Request) {
 		processAWSMetrics(k, response)
 	}
 
-	err = json.NewEncoder(w).Encode(response)
-
-	if err != nil {
-		message.SendUnknownError(w, err)
-		return
-	}
+	return response, nil
 }
 
 func (h *Handler) getServices(w http.ResponseWriter, r *http.Request) {
 	var (
 		servicesUrl = "api/v1/services"
 		kubeID      string
-		masterNode  *node.Node
 	)
 
 	vars := mux.Vars(r)
@@ -1023,20 +1307,18 @@ func (h *Handler) getServices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(k.Masters) == 0 {
-		message.SendNotFound(w, kubeID,
-			errors.New("no master node found"))
-		return
-	}
-
-	for key := range k.Masters {
-		if k.Masters[key] != nil {
-			masterNode = k.Masters[key]
+	host, err := apiHost(k)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
 		}
+		message.SendUnknownError(w, err)
+		return
 	}
 
 	k8sServices, err := h.getK8sServices(k,
-		servicesUrl, masterNode.PublicIp)
+		servicesUrl, host)
 
 	if err != nil {
 		message.SendUnknownError(w, err)
@@ -1069,14 +1351,11 @@ func (h *Handler) getServices(w http.ResponseWriter, r *http.Request) {
 			}
 			serviceInfos = append(serviceInfos, serviceInfo)
 
-			targetServices = append(targetServices, &proxy.Target{
-				ProxyID: kubeID + string(service.ObjectMeta.UID),
-				TargetURL: fmt.Sprintf("https://%s%s:%d/proxy",
-					masterNode.PublicIp, service.ObjectMeta.SelfLink, service.Spec.Ports[0].Port),
-				SelfLink: service.ObjectMeta.SelfLink,
-				User:     k.User,
-				Password: k.Password,
-			})
+			targetServices = append(targetServices, proxyTarget(k,
+				kubeID+string(service.ObjectMeta.UID),
+				fmt.Sprintf("https://%s%s:%d/proxy",
+					host, service.ObjectMeta.SelfLink, service.Spec.Ports[0].Port),
+				service.ObjectMeta.SelfLink))
 			continue
 		}
 
@@ -1091,14 +1370,11 @@ func (h *Handler) getServices(w http.ResponseWriter, r *http.Request) {
 					}
 					serviceInfos = append(serviceInfos, serviceInfo)
 
-					targetServices = append(targetServices, &proxy.Target{
-						ProxyID: kubeID + string(service.ObjectMeta.UID),
-						TargetURL: fmt.Sprintf("https://%s%s:%d/proxy",
-							masterNode.PublicIp, service.ObjectMeta.SelfLink, port.Port),
-						SelfLink: service.ObjectMeta.SelfLink,
-						User:     k.User,
-						Password: k.Password,
-					})
+					targetServices = append(targetServices, proxyTarget(k,
+						kubeID+string(service.ObjectMeta.UID),
+						fmt.Sprintf("https://%s%s:%d/proxy",
+							host, service.ObjectMeta.SelfLink, port.Port),
+						service.ObjectMeta.SelfLink))
 				}
 			}
 		}
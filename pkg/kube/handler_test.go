@@ -84,10 +84,12 @@ type mockNodeProvisioner struct {
 
 type bufferCloser struct {
 	bytes.Buffer
-	err error
+	err    error
+	closed bool
 }
 
 func (b *bufferCloser) Close() error {
+	b.closed = true
 	return b.err
 }
 
@@ -567,6 +569,141 @@ func TestHandler_deleteKube(t *testing.T) {
 	}
 }
 
+// namedStep is a step that is never run.
+type namedStep struct {
+	steps.Step
+	name string
+}
+
+func (s *namedStep) Name() string {
+	return s.name
+}
+
+func (s *namedStep) Depends() []string {
+	return nil
+}
+
+type planStep struct {
+	namedStep
+	resources []steps.Resource
+}
+
+func (p *planStep) Plan(context.Context, *steps.Config) ([]steps.Resource, error) {
+	return p.resources, nil
+}
+
+func TestHandler_getDeletePlan(t *testing.T) {
+	tcs := []struct {
+		description string
+		kubeName    string
+		query       string
+
+		kube         *model.Kube
+		getKubeError error
+
+		expectedStatus int
+	}{
+		{
+			description:    "kube not found",
+			kubeName:       "test",
+			getKubeError:   sgerrors.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description: "imported kube",
+			kubeName:    "test",
+			kube: &model.Kube{
+				ID:          "test",
+				Provider:    clouds.DigitalOcean,
+				AccountName: "test",
+				State:       model.StateImported,
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			description: "malformed deleteUntagged",
+			kubeName:    "test",
+			query:       "?deleteUntagged=maybe",
+			kube: &model.Kube{
+				ID:          "test",
+				Provider:    clouds.DigitalOcean,
+				AccountName: "test",
+				State:       model.StateOperational,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description: "success",
+			kubeName:    "test",
+			kube: &model.Kube{
+				ID:          "test",
+				Provider:    clouds.DigitalOcean,
+				AccountName: "test",
+				State:       model.StateOperational,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description: "success with untagged resources",
+			kubeName:    "test",
+			query:       "?deleteUntagged=true",
+			kube: &model.Kube{
+				ID:          "test",
+				Provider:    clouds.DigitalOcean,
+				AccountName: "test",
+				State:       model.StateOperational,
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.DigitalOceanDeleteCluster, []steps.Step{
+		&planStep{
+			namedStep: namedStep{name: "delete_machines"},
+			resources: []steps.Resource{
+				{Type: "droplet", ID: "1234"},
+			},
+		},
+		&namedStep{name: "cleanup"},
+	})
+
+	for _, tc := range tcs {
+		t.Log(tc.description)
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, tc.kubeName).Return(tc.kube, tc.getKubeError)
+
+		accSvc := new(accServiceMock)
+		accSvc.On(serviceGet, mock.Anything, "test").Return(&model.CloudAccount{
+			Name:     "test",
+			Provider: clouds.DigitalOcean,
+		}, nil)
+
+		h := NewHandler(svc, accSvc, nil, nil, nil)
+		router := mux.NewRouter()
+		h.Register(router)
+
+		req, _ := http.NewRequest(http.MethodGet, "/kubes/"+tc.kubeName+"/delete/plan"+tc.query, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if tc.expectedStatus != rr.Code {
+			t.Errorf("wrong response code expected %d actual %d",
+				tc.expectedStatus, rr.Code)
+			continue
+		}
+		if rr.Code != http.StatusOK {
+			continue
+		}
+
+		plan := &workflows.Plan{}
+		require.Nil(t, json.NewDecoder(rr.Body).Decode(plan))
+		require.Len(t, plan.Resources, 1)
+		require.Equal(t, "delete_machines", plan.Resources[0].Step)
+		require.Equal(t, []string{"cleanup"}, plan.Unplanned)
+	}
+}
+
 func TestHandler_listResources(t *testing.T) {
 	tcs := []struct {
 		kubeName string
@@ -772,6 +909,22 @@ func TestAddNodeToKube(t *testing.T) {
 			nil,
 			http.StatusAccepted,
 		},
+		{
+			"invalid node profile",
+			"test",
+			&model.Kube{
+				AccountName: "test",
+			},
+			nil,
+			"test",
+			&model.CloudAccount{
+				Name:     "test",
+				Provider: clouds.GCE,
+			},
+			nil,
+			nil,
+			http.StatusBadRequest,
+		},
 	}
 
 	nodeProfile := []profile.NodeProfile{
@@ -985,6 +1138,12 @@ func TestDeleteNodeFromKube(t *testing.T) {
 		mockRepo.On("Delete", mock.Anything, mock.Anything, mock.Anything).
 			Return(nil)
 
+		drainer := new(mockDrainer)
+		drainer.On("Drain", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		drainer.On("DeleteNode", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
 		handler := Handler{
 			svc:            svc,
 			accountService: accService,
@@ -994,6 +1153,7 @@ func TestDeleteNodeFromKube(t *testing.T) {
 			},
 			getWriter: testCase.getWriter,
 			repo:      mockRepo,
+			drainer:   drainer,
 		}
 
 		router := mux.NewRouter()
@@ -1224,9 +1384,17 @@ func TestGetTasks(t *testing.T) {
 				ID:    "test",
 				Tasks: []string{"1234"},
 			},
-			repoData:     []byte(``),
+			repoErr:      errFake,
 			expectedCode: http.StatusInternalServerError,
 		},
+		{
+			description: "no tasks",
+			kubeID:      "test",
+			kubeResp: &model.Kube{
+				ID: "test",
+			},
+			expectedCode: http.StatusNotFound,
+		},
 		{
 			description: "nothing found",
 			kubeID:      "test",
@@ -1234,7 +1402,17 @@ func TestGetTasks(t *testing.T) {
 				ID:    "test",
 				Tasks: []string{"1234"},
 			},
-			repoErr:      sgerrors.ErrInvalidJson,
+			repoErr:      sgerrors.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description: "malformed task is skipped",
+			kubeID:      "test",
+			kubeResp: &model.Kube{
+				ID:    "test",
+				Tasks: []string{"1234"},
+			},
+			repoData:     []byte(``),
 			expectedCode: http.StatusNotFound,
 		},
 		{
@@ -1244,7 +1422,7 @@ func TestGetTasks(t *testing.T) {
 				ID:    "test",
 				Tasks: []string{"1234"},
 			},
-			repoData:     []byte(`{"config": {"clusterId":"test"}}`),
+			repoData:     []byte(`{"id":"1234","config": {"clusterId":"test"}}`),
 			expectedCode: http.StatusOK,
 		},
 	}
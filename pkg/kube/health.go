@@ -0,0 +1,599 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/events"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	// DefaultHealthPrefix is a storage prefix of the latest health reports of kubes
+	DefaultHealthPrefix        = "/health/"
+	DefaultHealthCheckInterval = 5 * time.Minute
+
+	healthCheckTimeout = 2 * time.Minute
+)
+
+const (
+	HealthCheckAPI          = "api"
+	HealthCheckComponents   = "components"
+	HealthCheckNodes        = "nodes"
+	HealthCheckEtcd         = "etcd"
+	HealthCheckDNS          = "kube-dns"
+	HealthCheckTiller       = "tiller"
+	HealthCheckCertificates = "certificates"
+)
+
+const etcdHealthScript = `sudo ETCDCTL_API=3 etcdctl --endpoints=http://127.0.0.1:2379 endpoint health`
+
+var (
+	dnsSelector    = "k8s-app=kube-dns"
+	tillerSelector = "app=helm,name=tiller"
+)
+
+// HealthCheck is a result of a check, Status maps checked components,
+// nodes, etcd members or pods to their status.
+type HealthCheck struct {
+	Name     string            `json:"name"`
+	Healthy  bool              `json:"healthy"`
+	Status   map[string]string `json:"status,omitempty"`
+	Problems []string          `json:"problems,omitempty"`
+}
+
+func (c *HealthCheck) problem(format string, args ...interface{}) {
+	c.Healthy = false
+	c.Problems = append(c.Problems, fmt.Sprintf(format, args...))
+}
+
+// HealthReport aggregates checks of a kube, checks that need kubernetes
+// API are not run when it is unreachable.
+type HealthReport struct {
+	KubeID    string        `json:"kubeId"`
+	CheckedAt time.Time     `json:"checkedAt"`
+	Healthy   bool          `json:"healthy"`
+	Checks    []HealthCheck `json:"checks"`
+}
+
+// clusterProbe gathers state of a kube the checks are made of.
+type clusterProbe interface {
+	Version(k *model.Kube) (string, error)
+	ComponentStatuses(k *model.Kube) ([]corev1.ComponentStatus, error)
+	Nodes(k *model.Kube) ([]corev1.Node, error)
+	Pods(k *model.Kube, namespace, selector string) ([]corev1.Pod, error)
+	EtcdHealth(ctx context.Context, k *model.Kube, n *node.Node) (string, error)
+}
+
+// HealthChecker periodically checks operational kubes, a kube is moved
+// to degraded state when problems are found and back when they are gone.
+type HealthChecker struct {
+	h        *Handler
+	repo     storage.Interface
+	certs    *CertChecker
+	interval time.Duration
+	probe    clusterProbe
+	now      func() time.Time
+}
+
+func NewHealthChecker(h *Handler, repo storage.Interface, certs *CertChecker, interval time.Duration) *HealthChecker {
+	return &HealthChecker{
+		h:        h,
+		repo:     repo,
+		certs:    certs,
+		interval: interval,
+		probe:    apiProbe{},
+		now:      time.Now,
+	}
+}
+
+func (c *HealthChecker) Register(r *mux.Router) {
+	r.HandleFunc("/kubes/{kubeID}/health", c.getHealth).Methods(http.MethodGet)
+}
+
+// Run checks kubes until context is done.
+func (c *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.checkAll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *HealthChecker) checkAll(ctx context.Context) {
+	kubes, err := c.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("health: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+		if !operational(k) {
+			continue
+		}
+
+		report := c.check(ctx, k)
+		if err := saveHealthReport(ctx, c.repo, report); err != nil {
+			logrus.Errorf("health: kube %s: %v", k.ID, err)
+		}
+
+		if err := c.updateState(ctx, report); err != nil {
+			logrus.Errorf("health: kube %s: %v", k.ID, err)
+		}
+	}
+}
+
+// updateState moves the kube between operational and degraded states,
+// kubes that have been changed meanwhile are left as they are.
+func (c *HealthChecker) updateState(ctx context.Context, report *HealthReport) error {
+	k, err := c.h.svc.Get(ctx, report.KubeID)
+	if err != nil {
+		return errors.Wrap(err, "get kube")
+	}
+
+	e := events.Event{
+		KubeID:  k.ID,
+		Details: make(map[string]string),
+	}
+	state := k.State
+
+	switch {
+	case k.State == model.StateOperational && !report.Healthy:
+		failed := make([]string, 0)
+		for _, check := range report.Checks {
+			for _, problem := range check.Problems {
+				logrus.Warnf("health: kube %s: %s: %s", k.ID, check.Name, problem)
+			}
+
+			if !check.Healthy {
+				failed = append(failed, check.Name)
+				e.Details[check.Name] = strings.Join(check.Problems, "; ")
+			}
+		}
+		k.State = model.StateDegraded
+		e.Type = events.HealthDegraded
+		e.Message = "health checks have failed: " + strings.Join(failed, ", ")
+	case k.State == model.StateDegraded && report.Healthy:
+		logrus.Infof("health: kube %s has recovered", k.ID)
+		k.State = model.StateOperational
+		e.Type = events.HealthRecovered
+		e.Message = "all health checks have passed"
+	default:
+		return nil
+	}
+
+	if err := c.h.svc.Create(ctx, k); err != nil {
+		return errors.Wrap(err, "save kube")
+	}
+
+	events.Emit(e)
+	events.StateChanged(k.ID, state, k.State, e.Message)
+
+	return nil
+}
+
+// check runs checks of the kube, machines of imported kubes
+// are not reachable, so etcd and certificates are not checked.
+func (c *HealthChecker) check(ctx context.Context, k *model.Kube) *HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	report := &HealthReport{
+		KubeID:    k.ID,
+		CheckedAt: c.now(),
+		Healthy:   true,
+		Checks:    make([]HealthCheck, 0),
+	}
+
+	api := c.checkAPI(k)
+	report.Checks = append(report.Checks, api)
+	if api.Healthy {
+		report.Checks = append(report.Checks,
+			c.checkComponents(k),
+			c.checkNodes(k),
+			c.checkPods(k, HealthCheckDNS, dnsSelector),
+			c.checkPods(k, HealthCheckTiller, tillerSelector))
+	}
+
+	if k.State != model.StateImported {
+		report.Checks = append(report.Checks, c.checkEtcd(ctx, k), c.checkCertificates(ctx, k))
+	}
+
+	for _, check := range report.Checks {
+		report.Healthy = report.Healthy && check.Healthy
+	}
+
+	return report
+}
+
+func (c *HealthChecker) checkAPI(k *model.Kube) HealthCheck {
+	check := HealthCheck{
+		Name:    HealthCheckAPI,
+		Healthy: true,
+	}
+
+	version, err := c.probe.Version(k)
+	if err != nil {
+		check.problem("API is unreachable: %v", err)
+		return check
+	}
+	check.Status = map[string]string{"version": version}
+
+	return check
+}
+
+func (c *HealthChecker) checkComponents(k *model.Kube) HealthCheck {
+	check := HealthCheck{
+		Name:    HealthCheckComponents,
+		Healthy: true,
+		Status:  make(map[string]string),
+	}
+
+	statuses, err := c.probe.ComponentStatuses(k)
+	if err != nil {
+		check.problem("list component statuses: %v", err)
+		return check
+	}
+
+	for _, cs := range statuses {
+		healthy := false
+		reason := "no healthy condition"
+		for _, cond := range cs.Conditions {
+			if cond.Type != corev1.ComponentHealthy {
+				continue
+			}
+			healthy = cond.Status == corev1.ConditionTrue
+			reason = strings.TrimSpace(cond.Message + " " + cond.Error)
+		}
+
+		if healthy {
+			check.Status[cs.Name] = "Healthy"
+			continue
+		}
+		check.Status[cs.Name] = "Unhealthy"
+		check.problem("component %s is unhealthy: %s", cs.Name, reason)
+	}
+
+	return check
+}
+
+// checkNodes checks that active nodes of the kube have joined
+// the cluster and are Ready.
+func (c *HealthChecker) checkNodes(k *model.Kube) HealthCheck {
+	check := HealthCheck{
+		Name:    HealthCheckNodes,
+		Healthy: true,
+		Status:  make(map[string]string),
+	}
+
+	k8sNodes, err := c.probe.Nodes(k)
+	if err != nil {
+		check.problem("list nodes: %v", err)
+		return check
+	}
+
+	// Nodes of imported kubes are known to kubernetes only
+	nodes := append(sortedNodes(k.Masters), sortedNodes(k.Nodes)...)
+	if k.State == model.StateImported {
+		nodes = make([]*node.Node, 0, len(k8sNodes))
+		for _, k8sNode := range k8sNodes {
+			nodes = append(nodes, &node.Node{Name: k8sNode.Name, State: node.StateActive})
+		}
+	}
+
+	for _, n := range nodes {
+		if n.State != node.StateActive {
+			continue
+		}
+
+		k8sNode := matchK8sNode(k8sNodes, n)
+		if k8sNode == nil {
+			check.Status[n.Name] = "NotRegistered"
+			check.problem("node %s has not joined the cluster", n.Name)
+			continue
+		}
+
+		ready := false
+		for _, cond := range k8sNode.Status.Conditions {
+			if cond.Type == corev1.NodeReady {
+				ready = cond.Status == corev1.ConditionTrue
+			}
+		}
+
+		if ready {
+			check.Status[n.Name] = "Ready"
+			continue
+		}
+		check.Status[n.Name] = "NotReady"
+		check.problem("node %s is NotReady", n.Name)
+	}
+
+	return check
+}
+
+// checkPods checks that pods of the addon are running and ready.
+func (c *HealthChecker) checkPods(k *model.Kube, name, selector string) HealthCheck {
+	check := HealthCheck{
+		Name:    name,
+		Healthy: true,
+		Status:  make(map[string]string),
+	}
+
+	pods, err := c.probe.Pods(k, metav1.NamespaceSystem, selector)
+	if err != nil {
+		check.problem("list pods: %v", err)
+		return check
+	}
+
+	if len(pods) == 0 {
+		check.problem("no pods found by %s", selector)
+		return check
+	}
+
+	for _, pod := range pods {
+		ready := false
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady {
+				ready = cond.Status == corev1.ConditionTrue
+			}
+		}
+
+		if pod.Status.Phase == corev1.PodRunning && ready {
+			check.Status[pod.Name] = "Ready"
+			continue
+		}
+		check.Status[pod.Name] = string(pod.Status.Phase)
+		check.problem("pod %s is not ready", pod.Name)
+	}
+
+	return check
+}
+
+// checkEtcd checks etcd members of all active masters at the same time.
+func (c *HealthChecker) checkEtcd(ctx context.Context, k *model.Kube) HealthCheck {
+	check := HealthCheck{
+		Name:    HealthCheckEtcd,
+		Healthy: true,
+		Status:  make(map[string]string),
+	}
+
+	var (
+		m  sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, n := range sortedNodes(k.Masters) {
+		if n.State != node.StateActive {
+			continue
+		}
+
+		wg.Add(1)
+		go func(n *node.Node) {
+			defer wg.Done()
+
+			status, err := c.probe.EtcdHealth(ctx, k, n)
+
+			m.Lock()
+			defer m.Unlock()
+			if err != nil {
+				check.Status[n.Name] = "unhealthy"
+				check.problem("etcd member of %s: %v", n.Name, err)
+				return
+			}
+			check.Status[n.Name] = status
+		}(n)
+	}
+	wg.Wait()
+
+	if len(check.Status) == 0 {
+		check.problem("kube has no active masters")
+	}
+	sort.Strings(check.Problems)
+
+	return check
+}
+
+// checkCertificates uses the latest report of the certificate checker,
+// certificates are read from nodes if there is no report yet.
+func (c *HealthChecker) checkCertificates(ctx context.Context, k *model.Kube) HealthCheck {
+	check := HealthCheck{
+		Name:    HealthCheckCertificates,
+		Healthy: true,
+	}
+
+	report, err := loadCertsReport(ctx, c.repo, k.ID)
+	if sgerrors.IsNotFound(err) {
+		report, err = c.certs.report(ctx, k), nil
+		if saveErr := saveCertsReport(ctx, c.repo, report); saveErr != nil {
+			logrus.Errorf("health: kube %s: %v", k.ID, saveErr)
+		}
+	}
+	if err != nil {
+		check.problem("get certificates report: %v", err)
+		return check
+	}
+
+	expiring := report.Expiring()
+	if len(expiring) == 0 {
+		return check
+	}
+
+	check.Status = make(map[string]string, len(expiring))
+	for _, cert := range expiring {
+		name := cert.Name
+		if cert.Node != "" {
+			name = cert.Node + "/" + cert.Name
+		}
+
+		notAfter := cert.NotAfter.Format(time.RFC3339)
+		check.Status[name] = notAfter
+		check.problem("certificate %s expires at %s", name, notAfter)
+	}
+
+	return check
+}
+
+// operational reports whether the kube has been provisioned and is not
+// being changed, degraded kubes have failed health checks but are operational.
+func operational(k *model.Kube) bool {
+	return k.State == model.StateOperational || k.State == model.StateDegraded
+}
+
+func loadCertsReport(ctx context.Context, repo storage.Interface, kubeID string) (*CertsReport, error) {
+	data, err := repo.Get(ctx, DefaultCertsPrefix, kubeID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CertsReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, errors.Wrap(err, "unmarshal certificates report")
+	}
+
+	return report, nil
+}
+
+func saveHealthReport(ctx context.Context, repo storage.Interface, report *HealthReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "marshal health report")
+	}
+
+	return errors.Wrap(repo.Put(ctx, DefaultHealthPrefix, report.KubeID, data), "save health report")
+}
+
+// getHealth checks the kube, the latest report of the periodic
+// check is returned instead if cached query parameter is set.
+func (c *HealthChecker) getHealth(w http.ResponseWriter, r *http.Request) {
+	k, ok := c.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var report *HealthReport
+	if r.URL.Query().Get("cached") == "true" {
+		data, err := c.repo.Get(r.Context(), DefaultHealthPrefix, k.ID)
+		if err != nil {
+			if sgerrors.IsNotFound(err) {
+				message.SendNotFound(w, "health report of "+k.ID, err)
+				return
+			}
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		report = &HealthReport{}
+		if err := json.Unmarshal(data, report); err != nil {
+			message.SendUnknownError(w, err)
+			return
+		}
+	} else {
+		report = c.check(r.Context(), k)
+		if err := saveHealthReport(r.Context(), c.repo, report); err != nil {
+			logrus.Errorf("health: kube %s: %v", k.ID, err)
+		}
+	}
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// apiProbe reaches kubernetes API with admin credentials of the kube
+// and etcd members with bootstrap key of the kube.
+type apiProbe struct{}
+
+func (apiProbe) Version(k *model.Kube) (string, error) {
+	client, err := discoveryClient(k)
+	if err != nil {
+		return "", errors.Wrap(err, "build discovery client")
+	}
+
+	info, err := client.ServerVersion()
+	if err != nil {
+		return "", err
+	}
+
+	return info.GitVersion, nil
+}
+
+func (apiProbe) ComponentStatuses(k *model.Kube) ([]corev1.ComponentStatus, error) {
+	client, err := coreV1ClientFor(k)
+	if err != nil {
+		return nil, errors.Wrap(err, "build kubernetes client")
+	}
+
+	list, err := client.ComponentStatuses().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+func (apiProbe) Nodes(k *model.Kube) ([]corev1.Node, error) {
+	return listK8sNodes(k)
+}
+
+func (apiProbe) Pods(k *model.Kube, namespace, selector string) ([]corev1.Pod, error) {
+	client, err := coreV1ClientFor(k)
+	if err != nil {
+		return nil, errors.Wrap(err, "build kubernetes client")
+	}
+
+	list, err := client.Pods(namespace).List(metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+func (apiProbe) EtcdHealth(ctx context.Context, k *model.Kube, n *node.Node) (string, error) {
+	r, err := sshRunner(k, n)
+	if err != nil {
+		return "", errors.Wrap(err, "setup runner")
+	}
+
+	// etcdctl reports health of the endpoint to stderr
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err = r.Run(&runner.Command{
+		Ctx:    ctx,
+		Script: etcdHealthScript,
+		Out:    stdout,
+		Err:    stderr,
+	})
+	status := strings.TrimSpace(stdout.String() + stderr.String())
+	if err != nil {
+		return "", errors.Wrapf(err, "etcdctl endpoint health: %s", status)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,272 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+)
+
+type fakeProbe struct {
+	versionErr error
+	statuses   []corev1.ComponentStatus
+	nodes      []corev1.Node
+	pods       map[string][]corev1.Pod
+	etcdErr    map[string]error
+}
+
+func (f *fakeProbe) Version(*model.Kube) (string, error) {
+	return "v1.12.7", f.versionErr
+}
+
+func (f *fakeProbe) ComponentStatuses(*model.Kube) ([]corev1.ComponentStatus, error) {
+	return f.statuses, nil
+}
+
+func (f *fakeProbe) Nodes(*model.Kube) ([]corev1.Node, error) {
+	return f.nodes, nil
+}
+
+func (f *fakeProbe) Pods(k *model.Kube, namespace, selector string) ([]corev1.Pod, error) {
+	return f.pods[selector], nil
+}
+
+func (f *fakeProbe) EtcdHealth(ctx context.Context, k *model.Kube, n *node.Node) (string, error) {
+	if err := f.etcdErr[n.Name]; err != nil {
+		return "", err
+	}
+	return "127.0.0.1:2379 is healthy", nil
+}
+
+func readyPod(name string, ready bool) corev1.Pod {
+	status := corev1.ConditionTrue
+	if !ready {
+		status = corev1.ConditionFalse
+	}
+
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: status},
+			},
+		},
+	}
+}
+
+func healthyProbe() *fakeProbe {
+	now := time.Now()
+	return &fakeProbe{
+		statuses: []corev1.ComponentStatus{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "scheduler"},
+				Conditions: []corev1.ComponentCondition{
+					{Type: corev1.ComponentHealthy, Status: corev1.ConditionTrue},
+				},
+			},
+		},
+		nodes: []corev1.Node{
+			k8sNode("master-1", corev1.ConditionTrue, now),
+			k8sNode("node-1", corev1.ConditionTrue, now),
+		},
+		pods: map[string][]corev1.Pod{
+			dnsSelector:    {readyPod("kube-dns-1", true)},
+			tillerSelector: {readyPod("tiller-deploy-1", true)},
+		},
+	}
+}
+
+func healthKube() *model.Kube {
+	return &model.Kube{
+		ID:    "test",
+		State: model.StateOperational,
+		Masters: map[string]*node.Node{
+			"master-1": {Name: "master-1", State: node.StateActive},
+		},
+		Nodes: map[string]*node.Node{
+			"node-1": {Name: "node-1", State: node.StateActive},
+		},
+	}
+}
+
+func newTestHealthChecker(k *model.Kube, probe clusterProbe) (*HealthChecker, *kubeServiceMock, *memRepository) {
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).
+		Return(k, nil)
+	svc.On(serviceCreate, mock.Anything, mock.Anything).
+		Return(nil)
+
+	repo := &memRepository{data: map[string][]byte{}}
+	data, _ := json.Marshal(&CertsReport{
+		KubeID: k.ID,
+		Certificates: []CertExpiry{
+			{Name: "ca", NotAfter: time.Now().Add(time.Hour), Expiring: false},
+		},
+	})
+	repo.Put(context.Background(), DefaultCertsPrefix, k.ID, data)
+
+	c := NewHealthChecker(&Handler{svc: svc}, repo, nil, time.Minute)
+	c.probe = probe
+
+	return c, svc, repo
+}
+
+func findCheck(report *HealthReport, name string) *HealthCheck {
+	for i := range report.Checks {
+		if report.Checks[i].Name == name {
+			return &report.Checks[i]
+		}
+	}
+
+	return nil
+}
+
+func TestHealthCheckerCheck(t *testing.T) {
+	testCases := []struct {
+		description string
+		modify      func(*fakeProbe)
+		failed      []string
+	}{
+		{
+			description: "healthy",
+			modify:      func(*fakeProbe) {},
+		},
+		{
+			description: "api unreachable",
+			modify: func(p *fakeProbe) {
+				p.versionErr = errors.New("connection refused")
+			},
+			failed: []string{HealthCheckAPI},
+		},
+		{
+			description: "node not ready",
+			modify: func(p *fakeProbe) {
+				p.nodes[1] = k8sNode("node-1", corev1.ConditionFalse, time.Now())
+			},
+			failed: []string{HealthCheckNodes},
+		},
+		{
+			description: "node not registered",
+			modify: func(p *fakeProbe) {
+				p.nodes = p.nodes[:1]
+			},
+			failed: []string{HealthCheckNodes},
+		},
+		{
+			description: "tiller is missing, dns is not ready",
+			modify: func(p *fakeProbe) {
+				p.pods[tillerSelector] = nil
+				p.pods[dnsSelector] = []corev1.Pod{readyPod("kube-dns-1", false)}
+			},
+			failed: []string{HealthCheckDNS, HealthCheckTiller},
+		},
+		{
+			description: "etcd member unhealthy",
+			modify: func(p *fakeProbe) {
+				p.etcdErr = map[string]error{"master-1": errors.New("unhealthy cluster")}
+			},
+			failed: []string{HealthCheckEtcd},
+		},
+	}
+
+	for _, testCase := range testCases {
+		probe := healthyProbe()
+		testCase.modify(probe)
+
+		k := healthKube()
+		c, _, _ := newTestHealthChecker(k, probe)
+		report := c.check(context.Background(), k)
+
+		require.Equal(t, len(testCase.failed) == 0, report.Healthy, testCase.description)
+		for _, name := range testCase.failed {
+			check := findCheck(report, name)
+			require.NotNil(t, check, "%s: check %s", testCase.description, name)
+			require.False(t, check.Healthy, "%s: check %s", testCase.description, name)
+			require.NotEmpty(t, check.Problems, "%s: check %s", testCase.description, name)
+		}
+	}
+}
+
+func TestHealthCheckerCertificates(t *testing.T) {
+	k := healthKube()
+	c, _, repo := newTestHealthChecker(k, healthyProbe())
+
+	data, _ := json.Marshal(&CertsReport{
+		KubeID: k.ID,
+		Certificates: []CertExpiry{
+			{Node: "master-1", Name: "apiserver", NotAfter: time.Now().Add(time.Hour), Expiring: true},
+		},
+	})
+	repo.Put(context.Background(), DefaultCertsPrefix, k.ID, data)
+
+	check := c.checkCertificates(context.Background(), k)
+	require.False(t, check.Healthy)
+	require.Contains(t, check.Status, "master-1/apiserver")
+}
+
+func TestHealthCheckerUpdateState(t *testing.T) {
+	k := healthKube()
+	probe := healthyProbe()
+	c, svc, repo := newTestHealthChecker(k, probe)
+	svc.On(serviceListAll, mock.Anything).Return([]model.Kube{*k}, nil)
+
+	probe.etcdErr = map[string]error{"master-1": errors.New("unhealthy cluster")}
+	c.checkAll(context.Background())
+	require.Equal(t, model.StateDegraded, k.State)
+
+	_, err := repo.Get(context.Background(), DefaultHealthPrefix, k.ID)
+	require.Nil(t, err, "health report must be saved")
+
+	probe.etcdErr = nil
+	report := c.check(context.Background(), k)
+	require.True(t, report.Healthy)
+	require.Nil(t, c.updateState(context.Background(), report))
+	require.Equal(t, model.StateOperational, k.State)
+
+	// Kubes that are being changed are left as they are
+	k.State = model.StateUpgrading
+	probe.etcdErr = map[string]error{"master-1": errors.New("unhealthy cluster")}
+	require.Nil(t, c.updateState(context.Background(), c.check(context.Background(), k)))
+	require.Equal(t, model.StateUpgrading, k.State)
+}
+
+func TestGetHealth(t *testing.T) {
+	k := healthKube()
+	c, _, _ := newTestHealthChecker(k, healthyProbe())
+
+	router := mux.NewRouter()
+	c.Register(router)
+
+	req, _ := http.NewRequest(http.MethodGet, "/kubes/test/health?cached=true", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, "/kubes/test/health", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	report := &HealthReport{}
+	require.Nil(t, json.NewDecoder(rec.Body).Decode(report))
+	require.True(t, report.Healthy)
+	require.Len(t, report.Checks, 7)
+
+	req, _ = http.NewRequest(http.MethodGet, "/kubes/test/health?cached=true", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,288 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const (
+	labelRoleMaster   = "node-role.kubernetes.io/master"
+	labelRole         = "kubernetes.io/role"
+	labelInstanceType = "beta.kubernetes.io/instance-type"
+	labelRegion       = "failure-domain.beta.kubernetes.io/region"
+)
+
+// ImportRequest describes a cluster built outside of supergiant, it is
+// reachable either with a kubeconfig or with an endpoint and credentials.
+type ImportRequest struct {
+	Name string `json:"name"`
+
+	KubeConfig string `json:"kubeconfig,omitempty"`
+	// Context of the kubeconfig to use, current context is used if empty.
+	Context string `json:"context,omitempty"`
+
+	Endpoint   string `json:"endpoint,omitempty"`
+	CACert     string `json:"caCert,omitempty"`
+	ClientCert string `json:"clientCert,omitempty"`
+	ClientKey  string `json:"clientKey,omitempty"`
+	Token      string `json:"token,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+}
+
+// importKube saves a kube that is managed in read-only mode, nodes of the
+// kube are discovered with kubernetes API.
+func (h *Handler) importKube(w http.ResponseWriter, r *http.Request) {
+	req := &ImportRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, err := importedKube(req)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if err := h.discoverNodes(k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	logrus.Infof("kube %s has been imported as %s with %d masters and %d nodes",
+		k.Name, k.ID, len(k.Masters), len(k.Nodes))
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(k); err != nil {
+		logrus.Error(errors.Wrap(err, "marshal json"))
+	}
+}
+
+// rediscoverNodes refreshes nodes of an imported kube.
+func (h *Handler) rediscoverNodes(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if k.State != model.StateImported {
+		http.Error(w, "nodes of kube in state "+string(k.State)+" can't be discovered", http.StatusConflict)
+		return
+	}
+
+	if err := h.discoverNodes(k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(k); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// discoverNodes replaces masters and nodes of the kube with nodes
+// registered in kubernetes API.
+func (h *Handler) discoverNodes(k *model.Kube) error {
+	k8sNodes, err := h.getK8sNodes(k)
+	if err != nil {
+		return err
+	}
+
+	k.Masters = make(map[string]*node.Node)
+	k.Nodes = make(map[string]*node.Node)
+
+	for _, k8sNode := range k8sNodes {
+		n := nodeFromK8s(k8sNode, k.Provider)
+		if n.Role == node.RoleMaster {
+			k.Masters[n.Name] = n
+		} else {
+			k.Nodes[n.Name] = n
+		}
+	}
+
+	// Kubelets of masters are upgraded first, take version of workers
+	// only if masters are not visible, like in managed clusters.
+	version := ""
+	for _, k8sNode := range k8sNodes {
+		if version == "" || k.Masters[k8sNode.Name] != nil {
+			version = strings.TrimPrefix(k8sNode.Status.NodeInfo.KubeletVersion, "v")
+		}
+	}
+	if version != "" {
+		k.K8SVersion = version
+	}
+
+	return nil
+}
+
+// provisioningDisabled replies with conflict if machines of the kube
+// are not managed by supergiant.
+func provisioningDisabled(w http.ResponseWriter, k *model.Kube) bool {
+	if k.State != model.StateImported {
+		return false
+	}
+
+	http.Error(w, "kube "+k.ID+" is imported, provisioning is disabled", http.StatusConflict)
+	return true
+}
+
+// importedKube builds a kube from the request, credentials of the kubeconfig
+// must be embedded into it, files are not available to supergiant.
+func importedKube(req *ImportRequest) (*model.Kube, error) {
+	if req.Name == "" {
+		return nil, errors.New("name must not be empty")
+	}
+
+	if req.KubeConfig != "" {
+		if err := fillFromKubeConfig(req); err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint, err := url.Parse(req.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, errors.Errorf("endpoint %q must be an https url", req.Endpoint)
+	}
+
+	hasCert := req.ClientCert != "" && req.ClientKey != ""
+	hasBasicAuth := req.Username != "" && req.Password != ""
+	if !hasCert && req.Token == "" && !hasBasicAuth {
+		return nil, errors.New("client certificate and key, token or username and password must be provided")
+	}
+
+	return &model.Kube{
+		ID:          uuid.New()[:8],
+		Name:        req.Name,
+		State:       model.StateImported,
+		APIEndpoint: strings.TrimSuffix(req.Endpoint, "/"),
+		User:        req.Username,
+		Password:    req.Password,
+		Auth: model.Auth{
+			CACert:      req.CACert,
+			AdminCert:   req.ClientCert,
+			AdminKey:    req.ClientKey,
+			BearerToken: req.Token,
+		},
+		Masters: make(map[string]*node.Node),
+		Nodes:   make(map[string]*node.Node),
+		Tasks:   []string{},
+	}, nil
+}
+
+func fillFromKubeConfig(req *ImportRequest) error {
+	conf, err := clientcmd.Load([]byte(req.KubeConfig))
+	if err != nil {
+		return errors.Wrap(err, "parse kubeconfig")
+	}
+
+	contextName := req.Context
+	if contextName == "" {
+		contextName = conf.CurrentContext
+	}
+
+	kubeContext := conf.Contexts[contextName]
+	if kubeContext == nil {
+		return errors.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	cluster := conf.Clusters[kubeContext.Cluster]
+	if cluster == nil {
+		return errors.Errorf("cluster %q not found in kubeconfig", kubeContext.Cluster)
+	}
+
+	authInfo := conf.AuthInfos[kubeContext.AuthInfo]
+	if authInfo == nil {
+		return errors.Errorf("user %q not found in kubeconfig", kubeContext.AuthInfo)
+	}
+
+	if cluster.CertificateAuthority != "" || authInfo.ClientCertificate != "" ||
+		authInfo.ClientKey != "" || authInfo.TokenFile != "" {
+		return errors.New("certificates and tokens must be embedded into kubeconfig")
+	}
+
+	if authInfo.AuthProvider != nil || authInfo.Exec != nil {
+		return errors.New("auth providers and exec credentials are not supported")
+	}
+
+	req.Endpoint = cluster.Server
+	req.CACert = string(cluster.CertificateAuthorityData)
+	req.ClientCert = string(authInfo.ClientCertificateData)
+	req.ClientKey = string(authInfo.ClientKeyData)
+	req.Token = authInfo.Token
+	req.Username = authInfo.Username
+	req.Password = authInfo.Password
+
+	return nil
+}
+
+// nodeFromK8s converts kubernetes node to a machine of imported kube.
+func nodeFromK8s(k8sNode corev1.Node, provider clouds.Name) *node.Node {
+	n := &node.Node{
+		ID:        k8sNode.Spec.ProviderID,
+		Name:      k8sNode.Name,
+		Role:      node.RoleNode,
+		CreatedAt: k8sNode.CreationTimestamp.Unix(),
+		Size:      k8sNode.Labels[labelInstanceType],
+		Provider:  provider,
+		Region:    k8sNode.Labels[labelRegion],
+		State:     node.StateError,
+	}
+
+	if n.ID == "" {
+		n.ID = string(k8sNode.UID)
+	}
+
+	if _, ok := k8sNode.Labels[labelRoleMaster]; ok || k8sNode.Labels[labelRole] == string(node.RoleMaster) {
+		n.Role = node.RoleMaster
+	}
+
+	for _, addr := range k8sNode.Status.Addresses {
+		switch addr.Type {
+		case corev1.NodeExternalIP:
+			n.PublicIp = addr.Address
+		case corev1.NodeInternalIP:
+			n.PrivateIp = addr.Address
+		}
+	}
+
+	for _, cond := range k8sNode.Status.Conditions {
+		if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
+			n.State = node.StateActive
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,393 @@
+package kube
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://10.20.30.40:6443
+    certificate-authority-data: Y2EtY2VydA==
+users:
+- name: admin
+  user:
+    token: secret
+- name: file
+  user:
+    client-certificate: /etc/kubernetes/admin.crt
+    client-key: /etc/kubernetes/admin.key
+contexts:
+- name: admin@prod
+  context:
+    cluster: prod
+    user: admin
+- name: file@prod
+  context:
+    cluster: prod
+    user: file
+current-context: admin@prod
+`
+
+func importedK8sNodes() []corev1.Node {
+	return []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "master-1",
+				UID:  "uid-1",
+				Labels: map[string]string{
+					labelRoleMaster: "",
+				},
+			},
+			Status: corev1.NodeStatus{
+				NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.11.1"},
+				Addresses: []corev1.NodeAddress{
+					{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+					{Type: corev1.NodeExternalIP, Address: "10.20.30.40"},
+				},
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-1",
+				Labels: map[string]string{
+					labelInstanceType: "m4.large",
+				},
+			},
+			Spec: corev1.NodeSpec{
+				ProviderID: "aws:///us-west-1a/i-1",
+			},
+			Status: corev1.NodeStatus{
+				NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.10.5"},
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionFalse},
+				},
+			},
+		},
+	}
+}
+
+func TestImportedKube(t *testing.T) {
+	testCases := []struct {
+		description string
+		req         ImportRequest
+
+		expectedErr      bool
+		expectedEndpoint string
+		expectedToken    string
+	}{
+		{
+			description: "no name",
+			req: ImportRequest{
+				Endpoint: "https://10.20.30.40",
+				Token:    "secret",
+			},
+			expectedErr: true,
+		},
+		{
+			description: "kubeconfig",
+			req: ImportRequest{
+				Name:       "prod",
+				KubeConfig: testKubeConfig,
+			},
+			expectedEndpoint: "https://10.20.30.40:6443",
+			expectedToken:    "secret",
+		},
+		{
+			description: "unknown context",
+			req: ImportRequest{
+				Name:       "prod",
+				KubeConfig: testKubeConfig,
+				Context:    "dev",
+			},
+			expectedErr: true,
+		},
+		{
+			description: "certificate files",
+			req: ImportRequest{
+				Name:       "prod",
+				KubeConfig: testKubeConfig,
+				Context:    "file@prod",
+			},
+			expectedErr: true,
+		},
+		{
+			description: "malformed kubeconfig",
+			req: ImportRequest{
+				Name:       "prod",
+				KubeConfig: "{",
+			},
+			expectedErr: true,
+		},
+		{
+			description: "plain http endpoint",
+			req: ImportRequest{
+				Name:     "prod",
+				Endpoint: "http://10.20.30.40",
+				Token:    "secret",
+			},
+			expectedErr: true,
+		},
+		{
+			description: "no credentials",
+			req: ImportRequest{
+				Name:     "prod",
+				Endpoint: "https://10.20.30.40",
+			},
+			expectedErr: true,
+		},
+		{
+			description: "basic auth",
+			req: ImportRequest{
+				Name:     "prod",
+				Endpoint: "https://10.20.30.40/",
+				Username: "admin",
+				Password: "1234",
+			},
+			expectedEndpoint: "https://10.20.30.40",
+		},
+	}
+
+	for _, testCase := range testCases {
+		k, err := importedKube(&testCase.req)
+
+		if (err != nil) != testCase.expectedErr {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if k.State != model.StateImported || k.ID == "" {
+			t.Errorf("%s: wrong kube %v", testCase.description, k)
+		}
+
+		if k.APIEndpoint != testCase.expectedEndpoint {
+			t.Errorf("%s: wrong endpoint expected %s actual %s",
+				testCase.description, testCase.expectedEndpoint, k.APIEndpoint)
+		}
+
+		if k.Auth.BearerToken != testCase.expectedToken {
+			t.Errorf("%s: wrong token expected %s actual %s",
+				testCase.description, testCase.expectedToken, k.Auth.BearerToken)
+		}
+	}
+}
+
+func TestDiscoverNodes(t *testing.T) {
+	k := &model.Kube{
+		Masters: map[string]*node.Node{
+			"stale": {Name: "stale"},
+		},
+	}
+
+	h := Handler{
+		getK8sNodes: func(*model.Kube) ([]corev1.Node, error) {
+			return importedK8sNodes(), nil
+		},
+	}
+
+	if err := h.discoverNodes(k); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	master := k.Masters["master-1"]
+	if len(k.Masters) != 1 || master == nil {
+		t.Fatalf("wrong masters %v", k.Masters)
+	}
+
+	if master.ID != "uid-1" || master.PublicIp != "10.20.30.40" ||
+		master.PrivateIp != "10.0.0.1" || master.State != node.StateActive {
+		t.Errorf("wrong master %v", master)
+	}
+
+	worker := k.Nodes["node-1"]
+	if len(k.Nodes) != 1 || worker == nil {
+		t.Fatalf("wrong nodes %v", k.Nodes)
+	}
+
+	if worker.ID != "aws:///us-west-1a/i-1" || worker.Size != "m4.large" ||
+		worker.Role != node.RoleNode || worker.State != node.StateError {
+		t.Errorf("wrong node %v", worker)
+	}
+
+	if k.K8SVersion != "1.11.1" {
+		t.Errorf("wrong version expected 1.11.1 actual %s", k.K8SVersion)
+	}
+}
+
+func TestImportKube(t *testing.T) {
+	testCases := []struct {
+		description string
+		body        string
+		nodesErr    error
+
+		expectedCode int
+	}{
+		{
+			description:  "malformed body",
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "invalid request",
+			body:         `{"name": "prod"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "api is not reachable",
+			body:         `{"name": "prod", "endpoint": "https://10.20.30.40", "token": "secret"}`,
+			nodesErr:     errors.New("connection refused"),
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			description:  "success",
+			body:         `{"name": "prod", "endpoint": "https://10.20.30.40", "token": "secret"}`,
+			expectedCode: http.StatusCreated,
+		},
+	}
+
+	for _, testCase := range testCases {
+		svc := new(kubeServiceMock)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := Handler{
+			svc: svc,
+			getK8sNodes: func(*model.Kube) ([]corev1.Node, error) {
+				return importedK8sNodes(), testCase.nodesErr
+			},
+		}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/kubes/import", h.importKube)
+
+		req, _ := http.NewRequest(http.MethodPost, "/kubes/import",
+			bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusCreated {
+			svc.AssertNotCalled(t, serviceCreate, mock.Anything, mock.Anything)
+			continue
+		}
+
+		k := &model.Kube{}
+		if err := json.NewDecoder(rec.Body).Decode(k); err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		if k.State != model.StateImported || len(k.Masters) != 1 || len(k.Nodes) != 1 {
+			t.Errorf("%s: wrong kube %v", testCase.description, k)
+		}
+	}
+}
+
+func TestImportedKubeProvisioningDisabled(t *testing.T) {
+	k := &model.Kube{
+		ID:    "test",
+		State: model.StateImported,
+		Nodes: map[string]*node.Node{
+			"node-1": {Name: "node-1"},
+		},
+	}
+
+	testCases := []struct {
+		method string
+		url    string
+		body   string
+	}{
+		{http.MethodPost, "/kubes/test/nodes", `[{}]`},
+		{http.MethodDelete, "/kubes/test/nodes/node-1", ""},
+		{http.MethodPut, "/kubes/test/autoscaling", `{"enabled": true, "maxNodes": 3}`},
+		{http.MethodPut, "/kubes/test/autorepair", `{"enabled": true}`},
+	}
+
+	for _, testCase := range testCases {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(k, nil)
+
+		h := &Handler{
+			svc: svc,
+		}
+
+		router := mux.NewRouter()
+		h.Register(router)
+
+		req, _ := http.NewRequest(testCase.method, testCase.url,
+			bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("%s %s: wrong response code expected %d actual %d",
+				testCase.method, testCase.url, http.StatusConflict, rec.Code)
+		}
+	}
+}
+
+func TestDeleteImportedKube(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).
+		Return(&model.Kube{ID: "test", State: model.StateImported}, nil)
+	svc.On(serviceDelete, mock.Anything, "test").
+		Return(nil)
+
+	provisioner := new(mockNodeProvisioner)
+	provisioner.On("Cancel", mock.Anything).
+		Return(nil)
+
+	accService := new(accServiceMock)
+
+	h := Handler{
+		svc:             svc,
+		accountService:  accService,
+		nodeProvisioner: provisioner,
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/kubes/{kubeID}", h.deleteKube)
+
+	req, _ := http.NewRequest(http.MethodDelete, "/kubes/test", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("wrong response code expected %d actual %d",
+			http.StatusAccepted, rec.Code)
+	}
+
+	svc.AssertCalled(t, serviceDelete, mock.Anything, "test")
+	accService.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,265 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/account"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	// DefaultOrphansPrefix is a storage prefix of the latest
+	// orphaned resources reports of cloud accounts
+	DefaultOrphansPrefix       = "/orphans/"
+	DefaultOrphanCheckInterval = time.Hour
+)
+
+type accountLister interface {
+	GetAll(context.Context) ([]model.CloudAccount, error)
+}
+
+// Orphan is a cloud resource of a kube that doesn't exist or has failed.
+type Orphan struct {
+	account.CloudResource
+	// KubeState is empty when kube doesn't exist.
+	KubeState model.KubeState `json:"kubeState,omitempty"`
+	// FirstSeen is when the resource has been found orphaned first.
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// OrphansReport holds orphaned resources found in a cloud account.
+type OrphansReport struct {
+	AccountName string    `json:"accountName"`
+	CheckedAt   time.Time `json:"checkedAt"`
+	Orphans     []Orphan  `json:"orphans"`
+	// Skipped lists types of resources that have not been checked,
+	// account.ResourceAll means the provider is not supported.
+	Skipped []string `json:"skipped,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// OrphanCollector periodically finds resources that have been left in cloud
+// accounts by failed or deleted kubes. Resources are deleted if they stay
+// orphaned longer than grace period, zero grace period disables deletion.
+// NOTE: kubes of another supergiant that shares the cloud account look
+// deleted to this one, enable deletion only for accounts owned by it.
+type OrphanCollector struct {
+	h            *Handler
+	accounts     accountLister
+	repo         storage.Interface
+	interval     time.Duration
+	gracePeriod  time.Duration
+	collectorFor func(*model.CloudAccount) (account.ResourcesCollector, error)
+	now          func() time.Time
+}
+
+func NewOrphanCollector(h *Handler, accounts accountLister, repo storage.Interface,
+	interval, gracePeriod time.Duration) *OrphanCollector {
+	return &OrphanCollector{
+		h:            h,
+		accounts:     accounts,
+		repo:         repo,
+		interval:     interval,
+		gracePeriod:  gracePeriod,
+		collectorFor: account.NewResourcesCollector,
+		now:          time.Now,
+	}
+}
+
+func (c *OrphanCollector) Register(r *mux.Router) {
+	r.HandleFunc("/orphans", c.getOrphans).Methods(http.MethodGet)
+}
+
+// Run collects orphaned resources until context is done.
+func (c *OrphanCollector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := c.collect(ctx, c.gracePeriod > 0); err != nil {
+				logrus.Errorf("orphans: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// collect checks all cloud accounts and saves their reports, orphans
+// that are past grace period are deleted if deletion is requested.
+func (c *OrphanCollector) collect(ctx context.Context, deleteOrphans bool) ([]*OrphansReport, error) {
+	accounts, err := c.accounts.GetAll(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list accounts")
+	}
+
+	kubes, err := c.h.svc.ListAll(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list kubes")
+	}
+
+	states := make(map[string]model.KubeState, len(kubes))
+	for _, k := range kubes {
+		states[k.ID] = k.State
+	}
+
+	reports := make([]*OrphansReport, 0, len(accounts))
+	for i := range accounts {
+		acc := &accounts[i]
+
+		collector, err := c.collectorFor(acc)
+		if err != nil {
+			// Resources of some providers can't be listed
+			if errors.Cause(err) == account.ErrUnsupportedProvider {
+				reports = append(reports, c.saveReport(ctx, acc.Name, nil,
+					[]string{account.ResourceAll}, nil))
+				continue
+			}
+			reports = append(reports, c.saveReport(ctx, acc.Name, nil, nil, err))
+			continue
+		}
+
+		orphans, err := c.orphans(ctx, acc.Name, collector, states)
+		report := c.saveReport(ctx, acc.Name, orphans, collector.Skipped(), err)
+		reports = append(reports, report)
+
+		// Orphans of the previous report might have been deleted already
+		if deleteOrphans && err == nil {
+			c.deleteOrphans(ctx, acc.Name, collector, report.Orphans)
+		}
+	}
+
+	return reports, nil
+}
+
+// orphans returns resources of kubes that don't exist or have failed,
+// first seen time is kept from the previous report of the account.
+func (c *OrphanCollector) orphans(ctx context.Context, accountName string,
+	collector account.ResourcesCollector, states map[string]model.KubeState) ([]Orphan, error) {
+	resources, err := collector.ClusterResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	firstSeen := make(map[account.CloudResource]time.Time)
+	if prev, err := loadOrphansReport(ctx, c.repo, accountName); err == nil {
+		for _, o := range prev.Orphans {
+			firstSeen[o.CloudResource] = o.FirstSeen
+		}
+	} else if !sgerrors.IsNotFound(err) {
+		logrus.Errorf("orphans: account %s: %v", accountName, err)
+	}
+
+	orphans := make([]Orphan, 0)
+	for _, r := range resources {
+		state, ok := states[r.ClusterID]
+		if ok && state != model.StateFailed {
+			continue
+		}
+
+		seen, ok := firstSeen[r]
+		if !ok {
+			seen = c.now()
+		}
+
+		orphans = append(orphans, Orphan{
+			CloudResource: r,
+			KubeState:     state,
+			FirstSeen:     seen,
+		})
+	}
+
+	return orphans, nil
+}
+
+// deleteOrphans deletes resources in the order they have been listed,
+// resources that fail to be deleted are retried on the next run.
+func (c *OrphanCollector) deleteOrphans(ctx context.Context, accountName string,
+	collector account.ResourcesCollector, orphans []Orphan) {
+	for _, o := range orphans {
+		if c.now().Sub(o.FirstSeen) < c.gracePeriod {
+			continue
+		}
+
+		if err := collector.DeleteResource(ctx, o.CloudResource); err != nil {
+			logrus.Errorf("orphans: account %s: kube %s: %v", accountName, o.ClusterID, err)
+			continue
+		}
+
+		logrus.Infof("orphans: %s %s of kube %s has been deleted from account %s",
+			o.Type, o.ID, o.ClusterID, accountName)
+	}
+}
+
+func (c *OrphanCollector) saveReport(ctx context.Context, accountName string,
+	orphans []Orphan, skipped []string, err error) *OrphansReport {
+	report := &OrphansReport{
+		AccountName: accountName,
+		CheckedAt:   c.now(),
+		Orphans:     orphans,
+		Skipped:     skipped,
+	}
+	if report.Orphans == nil {
+		report.Orphans = make([]Orphan, 0)
+	}
+	if err != nil {
+		report.Error = err.Error()
+		logrus.Errorf("orphans: account %s: %v", accountName, err)
+
+		// Keep orphans of the previous report, they are still there
+		if prev, err := loadOrphansReport(ctx, c.repo, accountName); err == nil {
+			report.Orphans = prev.Orphans
+		}
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		logrus.Errorf("orphans: account %s: marshal report: %v", accountName, err)
+		return report
+	}
+
+	if err := c.repo.Put(ctx, DefaultOrphansPrefix, accountName, data); err != nil {
+		logrus.Errorf("orphans: account %s: save report: %v", accountName, err)
+	}
+
+	return report
+}
+
+func loadOrphansReport(ctx context.Context, repo storage.Interface, accountName string) (*OrphansReport, error) {
+	data, err := repo.Get(ctx, DefaultOrphansPrefix, accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &OrphansReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, errors.Wrap(err, "unmarshal orphans report")
+	}
+
+	return report, nil
+}
+
+// getOrphans checks cloud accounts for orphaned resources, nothing is deleted.
+func (c *OrphanCollector) getOrphans(w http.ResponseWriter, r *http.Request) {
+	reports, err := c.collect(r.Context(), false)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(reports); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
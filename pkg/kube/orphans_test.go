@@ -0,0 +1,137 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/account"
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+)
+
+type fakeAccounts struct {
+	accounts []model.CloudAccount
+}
+
+func (f *fakeAccounts) GetAll(context.Context) ([]model.CloudAccount, error) {
+	return f.accounts, nil
+}
+
+type fakeResources struct {
+	resources []account.CloudResource
+	deleted   []string
+}
+
+func (f *fakeResources) ClusterResources(context.Context) ([]account.CloudResource, error) {
+	return f.resources, nil
+}
+
+func (f *fakeResources) Skipped() []string {
+	return []string{account.ResourceKeyPair}
+}
+
+func (f *fakeResources) DeleteResource(ctx context.Context, r account.CloudResource) error {
+	f.deleted = append(f.deleted, r.ID)
+	return nil
+}
+
+func TestOrphanCollector(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On(serviceListAll, mock.Anything).Return([]model.Kube{
+		{ID: "operational", State: model.StateOperational},
+		{ID: "failed", State: model.StateFailed},
+	}, nil)
+
+	resources := &fakeResources{
+		resources: []account.CloudResource{
+			{ClusterID: "operational", Type: account.ResourceInstance, ID: "i-1"},
+			{ClusterID: "failed", Type: account.ResourceInstance, ID: "i-2"},
+			{ClusterID: "deleted", Type: account.ResourceVPC, ID: "vpc-1"},
+		},
+	}
+
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &memRepository{data: map[string][]byte{}}
+	c := NewOrphanCollector(&Handler{svc: svc}, &fakeAccounts{
+		accounts: []model.CloudAccount{
+			{Name: "aws", Provider: clouds.AWS},
+			{Name: "gce", Provider: clouds.GCE},
+		},
+	}, repo, time.Hour, time.Hour)
+	c.collectorFor = func(acc *model.CloudAccount) (account.ResourcesCollector, error) {
+		if acc.Provider != clouds.AWS {
+			return nil, account.ErrUnsupportedProvider
+		}
+		return resources, nil
+	}
+	c.now = func() time.Time {
+		return now
+	}
+
+	reports, err := c.collect(context.Background(), true)
+	require.Nil(t, err)
+	require.Len(t, reports, 2)
+	require.Equal(t, "aws", reports[0].AccountName)
+	require.Equal(t, []string{account.ResourceKeyPair}, reports[0].Skipped)
+	require.Equal(t, "gce", reports[1].AccountName)
+	require.Equal(t, []string{account.ResourceAll}, reports[1].Skipped)
+	require.Empty(t, reports[1].Orphans)
+	require.Len(t, reports[0].Orphans, 2)
+	require.Equal(t, model.StateFailed, reports[0].Orphans[0].KubeState)
+	require.Equal(t, model.KubeState(""), reports[0].Orphans[1].KubeState)
+	require.Empty(t, resources.deleted, "orphans must not be deleted within grace period")
+
+	// First seen time is kept between checks
+	now = now.Add(2 * time.Hour)
+	reports, err = c.collect(context.Background(), false)
+	require.Nil(t, err)
+	require.Equal(t, now.Add(-2*time.Hour), reports[0].Orphans[0].FirstSeen)
+	require.Empty(t, resources.deleted)
+
+	_, err = c.collect(context.Background(), true)
+	require.Nil(t, err)
+	require.Equal(t, []string{"i-2", "vpc-1"}, resources.deleted)
+}
+
+func TestOrphanCollector_getOrphans(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On(serviceListAll, mock.Anything).Return([]model.Kube{}, nil)
+
+	resources := &fakeResources{
+		resources: []account.CloudResource{
+			{ClusterID: "deleted", Type: account.ResourceDroplet, ID: "1"},
+		},
+	}
+
+	c := NewOrphanCollector(&Handler{svc: svc}, &fakeAccounts{
+		accounts: []model.CloudAccount{
+			{Name: "do", Provider: clouds.DigitalOcean},
+		},
+	}, &memRepository{data: map[string][]byte{}}, time.Hour, time.Nanosecond)
+	c.collectorFor = func(*model.CloudAccount) (account.ResourcesCollector, error) {
+		return resources, nil
+	}
+
+	router := mux.NewRouter()
+	c.Register(router)
+
+	req, _ := http.NewRequest(http.MethodGet, "/orphans", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	reports := make([]OrphansReport, 0)
+	require.Nil(t, json.NewDecoder(rr.Body).Decode(&reports))
+	require.Len(t, reports, 1)
+	require.Len(t, reports[0].Orphans, 1)
+	require.Empty(t, resources.deleted, "orphans must not be deleted on request")
+}
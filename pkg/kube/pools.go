@@ -0,0 +1,301 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// NodePool is a node pool of the kube with names of nodes that belong to it.
+type NodePool struct {
+	profile.NodePool
+	Nodes []string `json:"nodes"`
+}
+
+type ScalePoolRequest struct {
+	Count int `json:"count"`
+}
+
+type ScalePoolResponse struct {
+	Tasks []string `json:"tasks"`
+}
+
+// deleteNodeStep is a deletion of a single node of the pool.
+type deleteNodeStep struct {
+	task   *workflows.Task
+	config *steps.Config
+	kube   *model.Kube
+	node   *node.Node
+}
+
+func (h *Handler) listPools(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	names := make([]string, 0, len(k.NodePools))
+	for name := range k.NodePools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pools := make([]NodePool, 0, len(names))
+	for _, name := range names {
+		pools = append(pools, nodePoolFrom(k, k.NodePools[name]))
+	}
+
+	if err := json.NewEncoder(w).Encode(pools); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) getPool(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID, poolName := vars["kubeID"], vars["pool"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	pool, ok := k.NodePools[poolName]
+	if !ok || pool == nil {
+		message.SendNotFound(w, poolName, sgerrors.ErrNotFound)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(nodePoolFrom(k, pool)); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// scalePool sets desired count of the pool, missing nodes are provisioned
+// with the pool profile and extra nodes are deleted one by one.
+func (h *Handler) scalePool(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID, poolName := vars["kubeID"], vars["pool"]
+
+	req := &ScalePoolRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if req.Count < 0 {
+		message.SendValidationFailed(w, errors.Errorf("count must not be negative, got %d", req.Count))
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	pool, ok := k.NodePools[poolName]
+	if !ok || pool == nil {
+		message.SendNotFound(w, poolName, sgerrors.ErrNotFound)
+		return
+	}
+
+	if !operational(k) {
+		http.Error(w, "kube in state "+string(k.State)+" can't be scaled", http.StatusConflict)
+		return
+	}
+
+	acc, err := h.accountService.Get(r.Context(), k.AccountName)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.AccountName, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	resp := ScalePoolResponse{
+		Tasks: []string{},
+	}
+	nodes := poolNodes(k, poolName)
+
+	var plan []deleteNodeStep
+
+	switch {
+	case req.Count > len(nodes):
+		nodeProfiles := make([]profile.NodeProfile, 0, req.Count-len(nodes))
+		for i := len(nodes); i < req.Count; i++ {
+			nodeProfiles = append(nodeProfiles, pool.Profile)
+		}
+
+		resp.Tasks, err = h.provisionNodes(context.Background(), k, acc, nodeProfiles, pool)
+		if err != nil {
+			if sgerrors.IsNotFound(err) {
+				message.SendNotFound(w, poolName, err)
+				return
+			}
+			message.SendUnknownError(w, err)
+			return
+		}
+	case req.Count < len(nodes):
+		// Nodes are sorted by name, the last ones are deleted
+		for _, n := range nodes[req.Count:] {
+			t, config, err := h.deleteNodeTask(r.Context(), k, acc, n)
+			if err != nil {
+				if sgerrors.IsNotFound(err) {
+					message.SendNotFound(w, n.Name, err)
+					return
+				}
+				message.SendUnknownError(w, err)
+				return
+			}
+
+			plan = append(plan, deleteNodeStep{
+				task:   t,
+				config: config,
+				kube:   k,
+				node:   n,
+			})
+			resp.Tasks = append(resp.Tasks, t.ID)
+		}
+	}
+
+	// Kube is saved before nodes are deleted, removal updates the kube on its own
+	pool.Count = req.Count
+	k.Tasks = append(k.Tasks, resp.Tasks...)
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if len(plan) > 0 {
+		go h.runPoolScaleDown(kubeID, poolName, plan)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Error(errors.Wrap(err, "marshal json"))
+	}
+}
+
+// runPoolScaleDown deletes nodes of the pool one at a time,
+// scale down stops on the first failure and the rest of tasks
+// are interrupted.
+func (h *Handler) runPoolScaleDown(kubeID, poolName string, plan []deleteNodeStep) {
+	for i, s := range plan {
+		writer, err := h.getWriter(util.MakeFileName(s.task.ID))
+		if err != nil {
+			err = errors.Wrapf(err, "get writer for task %s", s.task.ID)
+			logrus.Errorf("scale pool %s of kube %s: %v", poolName, kubeID, err)
+			interruptTasks(plan[i:], err)
+			h.restorePoolCount(kubeID, poolName, plan[i:])
+			return
+		}
+
+		err = h.removeNode(s.kube, s.node, s.task, s.config, writer, false, DefaultDrainTimeout)
+		if err != nil {
+			logrus.Errorf("scale pool %s of kube %s: delete node %s caused %v",
+				poolName, kubeID, s.node.Name, err)
+			interruptTasks(plan[i+1:], errors.Wrapf(err, "delete node %s", s.node.Name))
+			h.restorePoolCount(kubeID, poolName, plan[i:])
+			return
+		}
+	}
+}
+
+// restorePoolCount gives count of the pool back for nodes of the plan
+// that are still in the kube. Removal deletes the node from the kube
+// unless it has failed before the machine is touched.
+func (h *Handler) restorePoolCount(kubeID, poolName string, plan []deleteNodeStep) {
+	k, err := h.svc.Get(context.Background(), kubeID)
+	if err != nil {
+		logrus.Errorf("scale pool %s of kube %s: get kube caused %v", poolName, kubeID, err)
+		return
+	}
+
+	pool := k.NodePools[poolName]
+	if pool == nil {
+		return
+	}
+
+	restored := 0
+	for _, s := range plan {
+		if k.Nodes[s.node.Name] != nil {
+			restored++
+		}
+	}
+
+	if restored == 0 {
+		return
+	}
+
+	pool.Count += restored
+	if err := h.svc.Create(context.Background(), k); err != nil {
+		logrus.Errorf("scale pool %s of kube %s: save kube caused %v", poolName, kubeID, err)
+	}
+}
+
+// interruptTasks marks tasks of the plan that are not going to run as failed.
+func interruptTasks(plan []deleteNodeStep, reason error) {
+	for _, s := range plan {
+		if err := s.task.Interrupt(context.Background(), reason.Error()); err != nil {
+			logrus.Errorf("save task %s caused %v", s.task.ID, err)
+		}
+	}
+}
+
+func nodePoolFrom(k *model.Kube, pool *profile.NodePool) NodePool {
+	p := NodePool{
+		NodePool: *pool,
+		Nodes:    []string{},
+	}
+
+	for _, n := range poolNodes(k, pool.Name) {
+		p.Nodes = append(p.Nodes, n.Name)
+	}
+
+	return p
+}
+
+// poolNodes returns workers of the pool sorted by name.
+func poolNodes(k *model.Kube, poolName string) []*node.Node {
+	nodes := make([]*node.Node, 0)
+
+	for _, n := range sortedNodes(k.Nodes) {
+		if n.Pool == poolName {
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
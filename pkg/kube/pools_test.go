@@ -0,0 +1,351 @@
+package kube
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func pooledKube() *model.Kube {
+	return &model.Kube{
+		ID:          "test",
+		State:       model.StateOperational,
+		AccountName: "test",
+		Masters: map[string]*node.Node{
+			"master-1": {Name: "master-1", State: node.StateActive},
+		},
+		Nodes: map[string]*node.Node{
+			"node-1": {Name: "node-1"},
+			"gpu-2":  {Name: "gpu-2", Pool: "gpu"},
+			"gpu-1":  {Name: "gpu-1", Pool: "gpu"},
+		},
+		NodePools: map[string]*profile.NodePool{
+			"gpu": {
+				Name:  "gpu",
+				Count: 2,
+				Profile: profile.NodeProfile{
+					"size":  "s-4vcpu-8gb",
+					"image": "ubuntu-16-04-x64",
+				},
+				Taints: []string{"dedicated=gpu:NoSchedule"},
+			},
+			"spot": {
+				Name: "spot",
+			},
+		},
+	}
+}
+
+func TestListPools(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).
+		Return(pooledKube(), nil)
+
+	h := Handler{
+		svc: svc,
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/kubes/{kubeID}/pools", h.listPools)
+
+	req, _ := http.NewRequest(http.MethodGet, "/kubes/test/pools", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("wrong response code expected %d actual %d",
+			http.StatusOK, rec.Code)
+	}
+
+	pools := make([]NodePool, 0)
+	if err := json.NewDecoder(rec.Body).Decode(&pools); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(pools) != 2 || pools[0].Name != "gpu" || pools[1].Name != "spot" {
+		t.Fatalf("wrong pools %v", pools)
+	}
+
+	if len(pools[0].Nodes) != 2 || pools[0].Nodes[0] != "gpu-1" || pools[0].Nodes[1] != "gpu-2" {
+		t.Errorf("wrong nodes of pool gpu %v", pools[0].Nodes)
+	}
+
+	if len(pools[1].Nodes) != 0 {
+		t.Errorf("wrong nodes of pool spot %v", pools[1].Nodes)
+	}
+}
+
+func TestScalePool(t *testing.T) {
+	testCases := []struct {
+		description string
+
+		pool           string
+		body           string
+		kube           *model.Kube
+		kubeServiceErr error
+		account        *model.CloudAccount
+		accountErr     error
+		provisionErr   error
+
+		expectedCode     int
+		expectedProfiles int
+		expectedTasks    int
+	}{
+		{
+			description:  "malformed body",
+			pool:         "gpu",
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "negative count",
+			pool:         "gpu",
+			body:         `{"count": -1}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:    "kube not found",
+			pool:           "gpu",
+			body:           `{"count": 3}`,
+			kubeServiceErr: sgerrors.ErrNotFound,
+			expectedCode:   http.StatusNotFound,
+		},
+		{
+			description:  "pool not found",
+			pool:         "cpu",
+			body:         `{"count": 3}`,
+			kube:         pooledKube(),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description: "kube is not operational",
+			pool:        "gpu",
+			body:        `{"count": 3}`,
+			kube: func() *model.Kube {
+				k := pooledKube()
+				k.State = model.StateUpgrading
+				return k
+			}(),
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description:  "account not found",
+			pool:         "gpu",
+			body:         `{"count": 3}`,
+			kube:         pooledKube(),
+			accountErr:   sgerrors.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description: "provision error",
+			pool:        "gpu",
+			body:        `{"count": 3}`,
+			kube:        pooledKube(),
+			account: &model.CloudAccount{
+				Provider: clouds.DigitalOcean,
+			},
+			provisionErr:     sgerrors.ErrNotFound,
+			expectedCode:     http.StatusNotFound,
+			expectedProfiles: 1,
+		},
+		{
+			description: "scale up",
+			pool:        "gpu",
+			body:        `{"count": 4}`,
+			kube:        pooledKube(),
+			account: &model.CloudAccount{
+				Provider: clouds.DigitalOcean,
+			},
+			expectedCode:     http.StatusAccepted,
+			expectedProfiles: 2,
+			expectedTasks:    2,
+		},
+		{
+			description: "scale down",
+			pool:        "gpu",
+			body:        `{"count": 0}`,
+			kube:        pooledKube(),
+			account: &model.CloudAccount{
+				Provider: clouds.DigitalOcean,
+			},
+			expectedCode:  http.StatusAccepted,
+			expectedTasks: 2,
+		},
+		{
+			description: "nothing to scale",
+			pool:        "gpu",
+			body:        `{"count": 2}`,
+			kube:        pooledKube(),
+			account: &model.CloudAccount{
+				Provider: clouds.DigitalOcean,
+			},
+			expectedCode: http.StatusAccepted,
+		},
+	}
+
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.DigitalOceanDeleteNode, []steps.Step{})
+
+	for _, testCase := range testCases {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(testCase.kube, testCase.kubeServiceErr)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		accService := new(accServiceMock)
+		accService.On("Get", mock.Anything, mock.Anything).
+			Return(testCase.account, testCase.accountErr)
+
+		var provisioned []profile.NodeProfile
+		provisioner := new(mockNodeProvisioner)
+		provisioner.On("ProvisionNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				provisioned = args.Get(1).([]profile.NodeProfile)
+				if config := args.Get(3).(*steps.Config); config.PoolName() != testCase.pool {
+					t.Errorf("%s: wrong pool of config expected %s actual %s",
+						testCase.description, testCase.pool, config.PoolName())
+				}
+			}).
+			Return([]string{"task-1", "task-2"}, testCase.provisionErr)
+
+		mockRepo := new(testutils.MockStorage)
+		mockRepo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		drainer := new(mockDrainer)
+		drainer.On("Drain", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		drainer.On("DeleteNode", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := Handler{
+			svc:             svc,
+			accountService:  accService,
+			nodeProvisioner: provisioner,
+			workflowMap: map[clouds.Name]workflows.WorkflowSet{
+				clouds.DigitalOcean: {
+					DeleteNode: workflows.DigitalOceanDeleteNode,
+				},
+			},
+			repo:    mockRepo,
+			drainer: drainer,
+			getWriter: func(string) (io.WriteCloser, error) {
+				return &bufferCloser{}, nil
+			},
+		}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/kubes/{kubeID}/pools/{pool}", h.scalePool)
+
+		req, _ := http.NewRequest(http.MethodPut, "/kubes/test/pools/"+testCase.pool,
+			bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if len(provisioned) != testCase.expectedProfiles {
+			t.Errorf("%s: wrong count of provisioned nodes expected %d actual %d",
+				testCase.description, testCase.expectedProfiles, len(provisioned))
+		}
+
+		if rec.Code != http.StatusAccepted {
+			continue
+		}
+
+		resp := ScalePoolResponse{}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		if len(resp.Tasks) != testCase.expectedTasks {
+			t.Errorf("%s: wrong task count expected %d actual %v",
+				testCase.description, testCase.expectedTasks, resp.Tasks)
+		}
+	}
+}
+
+func TestRunPoolScaleDownFailure(t *testing.T) {
+	k := pooledKube()
+	// Handler has saved the kube with the target count
+	k.NodePools["gpu"].Count = 0
+
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).Return(k, nil)
+	svc.On(serviceCreate, mock.Anything, mock.Anything).Return(nil)
+
+	mockRepo := new(testutils.MockStorage)
+	mockRepo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	drainer := new(mockDrainer)
+	drainer.On("Drain", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(errors.New("pods can't be evicted"))
+	drainer.On("Uncordon", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	h := Handler{
+		svc:     svc,
+		repo:    mockRepo,
+		drainer: drainer,
+		getWriter: func(string) (io.WriteCloser, error) {
+			return &bufferCloser{}, nil
+		},
+	}
+
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.DigitalOceanDeleteNode, []steps.Step{})
+
+	plan := make([]deleteNodeStep, 0)
+	for _, n := range poolNodes(k, "gpu") {
+		task, err := workflows.NewTask(workflows.DigitalOceanDeleteNode, mockRepo)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		plan = append(plan, deleteNodeStep{
+			task:   task,
+			config: &steps.Config{},
+			kube:   k,
+			node:   n,
+		})
+	}
+
+	h.runPoolScaleDown(k.ID, "gpu", plan)
+
+	for _, s := range plan {
+		if s.task.Status != statuses.Error {
+			t.Errorf("task of node %s must be interrupted, actual status %s",
+				s.node.Name, s.task.Status)
+		}
+	}
+
+	if count := k.NodePools["gpu"].Count; count != 2 {
+		t.Errorf("count of nodes that haven't been deleted must be restored, expected 2 actual %d", count)
+	}
+}
@@ -0,0 +1,444 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/util"
+)
+
+const (
+	// DefaultRepairPrefix is a storage prefix of auto repair history of kubes
+	DefaultRepairPrefix   = "/repair/"
+	DefaultRepairInterval = time.Minute
+
+	defaultNotReadyTimeout     = "10m"
+	defaultMaxUnhealthyPercent = 40
+	defaultMaxRepairsPerHour   = 2
+
+	// maxRepairEvents is a number of latest events kept per kube
+	maxRepairEvents = 100
+)
+
+type RepairAction string
+
+const (
+	RepairReplaced RepairAction = "replaced"
+	RepairFailed   RepairAction = "failed"
+	RepairSkipped  RepairAction = "skipped"
+)
+
+// RepairEvent is a decision made by auto repair for a kube.
+type RepairEvent struct {
+	Time   time.Time    `json:"time"`
+	Node   string       `json:"node,omitempty"`
+	Action RepairAction `json:"action"`
+	Reason string       `json:"reason"`
+	Tasks  []string     `json:"tasks,omitempty"`
+}
+
+// unhealthyNode is a worker that should be replaced.
+type unhealthyNode struct {
+	node   *node.Node
+	reason string
+}
+
+// Repairer periodically checks workers of kubes that have auto repair
+// enabled and replaces unhealthy ones with delete node and add node workflows.
+type Repairer struct {
+	h        *Handler
+	repo     storage.Interface
+	interval time.Duration
+	k8sNodes func(*model.Kube) ([]corev1.Node, error)
+	now      func() time.Time
+	// removing tracks unhealthy nodes that are being deleted
+	removing sync.WaitGroup
+}
+
+func NewRepairer(h *Handler, repo storage.Interface, interval time.Duration) *Repairer {
+	return &Repairer{
+		h:        h,
+		repo:     repo,
+		interval: interval,
+		k8sNodes: listK8sNodes,
+		now:      time.Now,
+	}
+}
+
+// Run checks kubes until context is done.
+func (r *Repairer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.repairAll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Repairer) repairAll(ctx context.Context) {
+	kubes, err := r.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("auto repair: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+		if !k.AutoRepair.Enabled || !operational(k) {
+			continue
+		}
+
+		if err := r.repairKube(ctx, k); err != nil {
+			logrus.Errorf("auto repair: kube %s: %v", k.ID, err)
+		}
+	}
+}
+
+// repairKube replaces at most one unhealthy worker of the kube per check,
+// so that replacement has time to join before the next one is touched.
+func (r *Repairer) repairKube(ctx context.Context, k *model.Kube) error {
+	settings := repairSettings(k.AutoRepair)
+
+	k8sNodes, err := r.k8sNodes(k)
+	if err != nil {
+		return errors.Wrap(err, "get kubernetes nodes")
+	}
+
+	now := r.now()
+	// NOTE: settings are validated when they are updated.
+	timeout, err := time.ParseDuration(settings.NotReadyTimeout)
+	if err != nil {
+		return errors.Wrap(err, "parse not ready timeout")
+	}
+
+	unhealthy := unhealthyNodes(k, k8sNodes, timeout, now)
+	if len(unhealthy) == 0 {
+		return nil
+	}
+
+	events, err := loadRepairEvents(ctx, r.repo, k.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(unhealthy)*100 > settings.MaxUnhealthyPercent*len(k.Nodes) {
+		return r.record(ctx, k.ID, events, RepairEvent{
+			Time:   now,
+			Action: RepairSkipped,
+			Reason: fmt.Sprintf("%d of %d workers are unhealthy, more than %d%% allowed",
+				len(unhealthy), len(k.Nodes), settings.MaxUnhealthyPercent),
+		})
+	}
+
+	if repairsSince(events, now.Add(-time.Hour)) >= settings.MaxRepairsPerHour {
+		return r.record(ctx, k.ID, events, RepairEvent{
+			Time:   now,
+			Action: RepairSkipped,
+			Reason: fmt.Sprintf("limit of %d repairs per hour is reached",
+				settings.MaxRepairsPerHour),
+		})
+	}
+
+	target := unhealthy[0]
+	logrus.Infof("auto repair: replace node %s of kube %s: %s",
+		target.node.Name, k.ID, target.reason)
+
+	event := RepairEvent{
+		Time:   now,
+		Node:   target.node.Name,
+		Action: RepairReplaced,
+		Reason: target.reason,
+	}
+
+	event.Tasks, err = r.replace(ctx, k, target.node)
+	if err != nil {
+		event.Action = RepairFailed
+		event.Reason = fmt.Sprintf("%s: %v", target.reason, err)
+	}
+
+	return r.record(ctx, k.ID, events, event)
+}
+
+// replace provisions a node with the same profile and deletes the unhealthy
+// one without draining, pods of the broken node can't be evicted gracefully.
+// Node is deleted in background, so that a slow delete doesn't hold repair
+// of other kubes.
+func (r *Repairer) replace(ctx context.Context, k *model.Kube, n *node.Node) ([]string, error) {
+	acc, err := r.h.accountService.Get(ctx, k.AccountName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get account %s", k.AccountName)
+	}
+
+	nodeProfile, pool, err := replacementProfile(k, n)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := r.h.provisionNodes(ctx, k, acc, []profile.NodeProfile{nodeProfile}, pool)
+	if err != nil {
+		return nil, errors.Wrap(err, "provision node")
+	}
+
+	t, config, err := r.h.deleteNodeTask(ctx, k, acc, n)
+	if err != nil {
+		return tasks, errors.Wrap(err, "delete node")
+	}
+	tasks = append(tasks, t.ID)
+
+	writer, err := r.h.getWriter(util.MakeFileName(t.ID))
+	if err != nil {
+		return tasks, errors.Wrap(err, "get writer")
+	}
+
+	// Node that is being deleted is not picked by the next check
+	if err := r.h.setNodeState(k.ID, n.Name, node.StateDeleting); err != nil {
+		abortTask(t, writer, err)
+		return tasks, errors.Wrap(err, "delete node")
+	}
+
+	r.removing.Add(1)
+	go func() {
+		defer r.removing.Done()
+		if err := r.h.removeNode(k, n, t, config, writer, true, 0); err != nil {
+			logrus.Errorf("auto repair: delete node %s of kube %s: %v", n.Name, k.ID, err)
+		}
+	}()
+
+	return tasks, nil
+}
+
+// record appends event to the kube history, skip event that repeats
+// the latest one is not recorded to keep history readable.
+func (r *Repairer) record(ctx context.Context, kubeID string, events []RepairEvent, event RepairEvent) error {
+	if n := len(events); n > 0 && event.Action == RepairSkipped &&
+		events[n-1].Action == event.Action && events[n-1].Reason == event.Reason {
+		return nil
+	}
+
+	events = append(events, event)
+	if len(events) > maxRepairEvents {
+		events = events[len(events)-maxRepairEvents:]
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return errors.Wrap(err, "marshal repair events")
+	}
+
+	return errors.Wrap(r.repo.Put(ctx, DefaultRepairPrefix, kubeID, data), "save repair events")
+}
+
+func loadRepairEvents(ctx context.Context, repo storage.Interface, kubeID string) ([]RepairEvent, error) {
+	events := make([]RepairEvent, 0)
+
+	data, err := repo.Get(ctx, DefaultRepairPrefix, kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return events, nil
+		}
+		return nil, errors.Wrap(err, "get repair events")
+	}
+
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, errors.Wrap(err, "unmarshal repair events")
+	}
+
+	return events, nil
+}
+
+// unhealthyNodes returns workers sorted by name that have failed to provision,
+// are NotReady for longer than timeout or have not joined the cluster in time.
+func unhealthyNodes(k *model.Kube, k8sNodes []corev1.Node, timeout time.Duration, now time.Time) []unhealthyNode {
+	unhealthy := make([]unhealthyNode, 0)
+
+	for _, n := range sortedNodes(k.Nodes) {
+		if n.State == node.StateError {
+			unhealthy = append(unhealthy, unhealthyNode{n, "node has failed to provision"})
+			continue
+		}
+
+		if n.State != node.StateActive {
+			continue
+		}
+
+		k8sNode := matchK8sNode(k8sNodes, n)
+		if k8sNode == nil {
+			if n.CreatedAt > 0 && now.Sub(time.Unix(n.CreatedAt, 0)) > timeout {
+				unhealthy = append(unhealthy, unhealthyNode{n, "node has not joined the cluster"})
+			}
+			continue
+		}
+
+		for _, cond := range k8sNode.Status.Conditions {
+			if cond.Type != corev1.NodeReady || cond.Status == corev1.ConditionTrue {
+				continue
+			}
+
+			if notReady := now.Sub(cond.LastTransitionTime.Time); notReady > timeout {
+				unhealthy = append(unhealthy, unhealthyNode{n,
+					fmt.Sprintf("node is NotReady for %s", notReady.Round(time.Second))})
+			}
+		}
+	}
+
+	return unhealthy
+}
+
+// replacementProfile finds a profile the node has been provisioned with.
+func replacementProfile(k *model.Kube, n *node.Node) (profile.NodeProfile, *profile.NodePool, error) {
+	if n.Pool != "" {
+		pool := k.NodePools[n.Pool]
+		if pool == nil {
+			return nil, nil, errors.Wrapf(sgerrors.ErrNotFound, "pool %s", n.Pool)
+		}
+		return pool.Profile, pool, nil
+	}
+
+	if k.Profile == nil || len(k.Profile.NodesProfiles) == 0 {
+		return nil, nil, errors.Wrapf(sgerrors.ErrNotFound, "node profile of %s", n.Name)
+	}
+
+	for _, nodeProfile := range k.Profile.NodesProfiles {
+		if nodeProfile["size"] == n.Size {
+			return nodeProfile, nil, nil
+		}
+	}
+
+	return k.Profile.NodesProfiles[0], nil, nil
+}
+
+func repairsSince(events []RepairEvent, since time.Time) int {
+	count := 0
+	for _, event := range events {
+		if event.Action != RepairSkipped && event.Time.After(since) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// repairSettings fills settings that are not set with defaults.
+func repairSettings(settings model.AutoRepair) model.AutoRepair {
+	if settings.NotReadyTimeout == "" {
+		settings.NotReadyTimeout = defaultNotReadyTimeout
+	}
+	if settings.MaxUnhealthyPercent == 0 {
+		settings.MaxUnhealthyPercent = defaultMaxUnhealthyPercent
+	}
+	if settings.MaxRepairsPerHour == 0 {
+		settings.MaxRepairsPerHour = defaultMaxRepairsPerHour
+	}
+
+	return settings
+}
+
+func (h *Handler) getAutoRepair(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(repairSettings(k.AutoRepair)); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) updateAutoRepair(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	settings := model.AutoRepair{}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if settings.MaxRepairsPerHour < 0 ||
+		settings.MaxUnhealthyPercent < 0 || settings.MaxUnhealthyPercent > 100 {
+		message.SendValidationFailed(w, errors.New("repair limit must not be negative, "+
+			"unhealthy percent must be between 0 and 100"))
+		return
+	}
+
+	if settings.NotReadyTimeout != "" {
+		if d, err := time.ParseDuration(settings.NotReadyTimeout); err != nil || d < 0 {
+			message.SendValidationFailed(w, errors.Errorf("%q is not a valid timeout",
+				settings.NotReadyTimeout))
+			return
+		}
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if settings.Enabled && provisioningDisabled(w, k) {
+		return
+	}
+
+	k.AutoRepair = repairSettings(settings)
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(k.AutoRepair); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) getRepairEvents(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	if _, err := h.svc.Get(r.Context(), kubeID); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	events, err := loadRepairEvents(r.Context(), h.repo, kubeID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
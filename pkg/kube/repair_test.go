@@ -0,0 +1,392 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func k8sNode(name string, ready corev1.ConditionStatus, since time.Time) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{
+					Type:               corev1.NodeReady,
+					Status:             ready,
+					LastTransitionTime: metav1.NewTime(since),
+				},
+			},
+		},
+	}
+}
+
+func repairKubeFixture() *model.Kube {
+	return &model.Kube{
+		ID:          "test",
+		State:       model.StateOperational,
+		AccountName: "test",
+		Masters: map[string]*node.Node{
+			"master-1": {Name: "master-1", State: node.StateActive},
+		},
+		Nodes: map[string]*node.Node{
+			"node-1": {Name: "node-1", State: node.StateActive},
+			"node-2": {Name: "node-2", State: node.StateActive},
+			"node-3": {Name: "node-3", State: node.StateActive},
+			"node-4": {Name: "node-4", State: node.StateActive},
+			"node-5": {Name: "node-5", State: node.StateActive},
+		},
+		Profile: &profile.Profile{
+			NodesProfiles: []profile.NodeProfile{
+				{"size": "s-2vcpu-4gb"},
+			},
+		},
+	}
+}
+
+func TestUnhealthyNodes(t *testing.T) {
+	now := time.Now()
+	k := &model.Kube{
+		Nodes: map[string]*node.Node{
+			"ready":       {Name: "ready", State: node.StateActive},
+			"not-ready":   {Name: "not-ready", State: node.StateActive},
+			"flapping":    {Name: "flapping", State: node.StateActive},
+			"failed":      {Name: "failed", State: node.StateError},
+			"provisioned": {Name: "provisioned", State: node.StateProvisioning},
+			"lost":        {Name: "lost", State: node.StateActive, CreatedAt: now.Add(-time.Hour).Unix()},
+			"joining":     {Name: "joining", State: node.StateActive, CreatedAt: now.Unix()},
+		},
+	}
+
+	k8sNodes := []corev1.Node{
+		k8sNode("ready", corev1.ConditionTrue, now.Add(-time.Hour)),
+		k8sNode("not-ready", corev1.ConditionFalse, now.Add(-time.Hour)),
+		k8sNode("flapping", corev1.ConditionUnknown, now.Add(-time.Minute)),
+	}
+
+	unhealthy := unhealthyNodes(k, k8sNodes, 10*time.Minute, now)
+
+	expected := []string{"failed", "lost", "not-ready"}
+	if len(unhealthy) != len(expected) {
+		t.Fatalf("wrong unhealthy nodes expected %v actual %v", expected, unhealthy)
+	}
+
+	for i, name := range expected {
+		if unhealthy[i].node.Name != name {
+			t.Errorf("wrong unhealthy node %d expected %s actual %s",
+				i, name, unhealthy[i].node.Name)
+		}
+	}
+}
+
+func TestReplacementProfile(t *testing.T) {
+	k := pooledKube()
+	k.Profile = &profile.Profile{
+		NodesProfiles: []profile.NodeProfile{
+			{"size": "s-1vcpu-2gb"},
+			{"size": "s-2vcpu-4gb"},
+		},
+	}
+
+	nodeProfile, pool, err := replacementProfile(k, &node.Node{Name: "gpu-1", Pool: "gpu"})
+	if err != nil || pool == nil || pool.Name != "gpu" || nodeProfile["size"] != "s-4vcpu-8gb" {
+		t.Errorf("wrong profile of pool node %v %v %v", nodeProfile, pool, err)
+	}
+
+	nodeProfile, pool, err = replacementProfile(k, &node.Node{Name: "node-1", Size: "s-2vcpu-4gb"})
+	if err != nil || pool != nil || nodeProfile["size"] != "s-2vcpu-4gb" {
+		t.Errorf("wrong profile of node %v %v %v", nodeProfile, pool, err)
+	}
+
+	nodeProfile, _, err = replacementProfile(k, &node.Node{Name: "node-2", Size: "unknown"})
+	if err != nil || nodeProfile["size"] != "s-1vcpu-2gb" {
+		t.Errorf("wrong default profile %v %v", nodeProfile, err)
+	}
+
+	_, _, err = replacementProfile(k, &node.Node{Name: "spot-1", Pool: "removed"})
+	if !sgerrors.IsNotFound(err) {
+		t.Errorf("expected not found error actual %v", err)
+	}
+}
+
+func TestRepairKube(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		description string
+		notReady    []string
+		events      []RepairEvent
+
+		expectedAction RepairAction
+		expectReplace  bool
+	}{
+		{
+			description: "healthy",
+		},
+		{
+			description:    "replace",
+			notReady:       []string{"node-2"},
+			expectedAction: RepairReplaced,
+			expectReplace:  true,
+		},
+		{
+			description:    "too many unhealthy",
+			notReady:       []string{"node-1", "node-2", "node-3"},
+			expectedAction: RepairSkipped,
+		},
+		{
+			description: "rate limit",
+			notReady:    []string{"node-2"},
+			events: []RepairEvent{
+				{Time: now.Add(-2 * time.Hour), Action: RepairReplaced},
+				{Time: now.Add(-30 * time.Minute), Action: RepairReplaced},
+				{Time: now.Add(-20 * time.Minute), Action: RepairFailed},
+			},
+			expectedAction: RepairSkipped,
+		},
+	}
+
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.DigitalOceanDeleteNode, []steps.Step{})
+
+	for _, testCase := range testCases {
+		k := repairKubeFixture()
+		k.AutoRepair = model.AutoRepair{
+			Enabled: true,
+		}
+
+		k8sNodes := make([]corev1.Node, 0, len(k.Nodes))
+		for name := range k.Nodes {
+			k8sNodes = append(k8sNodes, k8sNode(name, corev1.ConditionTrue, now.Add(-time.Hour)))
+		}
+		for _, name := range testCase.notReady {
+			for i := range k8sNodes {
+				if k8sNodes[i].Name == name {
+					k8sNodes[i] = k8sNode(name, corev1.ConditionFalse, now.Add(-time.Hour))
+				}
+			}
+		}
+
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(k, nil)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		accService := new(accServiceMock)
+		accService.On("Get", mock.Anything, mock.Anything).
+			Return(&model.CloudAccount{Provider: clouds.DigitalOcean}, nil)
+
+		provisioner := new(mockNodeProvisioner)
+		provisioner.On("ProvisionNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]string{"task-1"}, nil)
+
+		drainer := new(mockDrainer)
+		drainer.On("DeleteNode", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		var saved []RepairEvent
+		mockRepo := new(testutils.MockStorage)
+		if testCase.events != nil {
+			data, _ := json.Marshal(testCase.events)
+			mockRepo.On("Get", mock.Anything, DefaultRepairPrefix, k.ID).
+				Return(data, nil)
+		} else {
+			mockRepo.On("Get", mock.Anything, DefaultRepairPrefix, k.ID).
+				Return(nil, sgerrors.ErrNotFound)
+		}
+		mockRepo.On("Put", mock.Anything, DefaultRepairPrefix, k.ID, mock.Anything).
+			Run(func(args mock.Arguments) {
+				json.Unmarshal(args.Get(3).([]byte), &saved)
+			}).
+			Return(nil)
+		mockRepo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := &Handler{
+			svc:             svc,
+			accountService:  accService,
+			nodeProvisioner: provisioner,
+			workflowMap: map[clouds.Name]workflows.WorkflowSet{
+				clouds.DigitalOcean: {
+					DeleteNode: workflows.DigitalOceanDeleteNode,
+				},
+			},
+			repo:    mockRepo,
+			drainer: drainer,
+			getWriter: func(string) (io.WriteCloser, error) {
+				return &bufferCloser{}, nil
+			},
+		}
+
+		r := NewRepairer(h, mockRepo, time.Minute)
+		r.k8sNodes = func(*model.Kube) ([]corev1.Node, error) {
+			return k8sNodes, nil
+		}
+		r.now = func() time.Time {
+			return now
+		}
+
+		if err := r.repairKube(context.Background(), k); err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+		r.removing.Wait()
+
+		if testCase.expectedAction == "" {
+			if saved != nil {
+				t.Errorf("%s: unexpected events %v", testCase.description, saved)
+			}
+			continue
+		}
+
+		if len(saved) != len(testCase.events)+1 {
+			t.Errorf("%s: wrong count of events expected %d actual %d",
+				testCase.description, len(testCase.events)+1, len(saved))
+			continue
+		}
+
+		if event := saved[len(saved)-1]; event.Action != testCase.expectedAction {
+			t.Errorf("%s: wrong action expected %s actual %s %s",
+				testCase.description, testCase.expectedAction, event.Action, event.Reason)
+		}
+
+		_, exists := k.Nodes["node-2"]
+		if testCase.expectReplace {
+			provisioner.AssertCalled(t, "ProvisionNodes", mock.Anything,
+				[]profile.NodeProfile{{"size": "s-2vcpu-4gb"}}, mock.Anything, mock.Anything)
+			drainer.AssertNotCalled(t, "Drain", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			if exists {
+				t.Errorf("%s: node-2 must be deleted", testCase.description)
+			}
+		} else {
+			provisioner.AssertNotCalled(t, "ProvisionNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			if !exists {
+				t.Errorf("%s: node-2 must not be deleted", testCase.description)
+			}
+		}
+	}
+}
+
+func TestUpdateAutoRepair(t *testing.T) {
+	testCases := []struct {
+		description string
+		body        string
+		kubeErr     error
+
+		expectedCode     int
+		expectedSettings model.AutoRepair
+	}{
+		{
+			description:  "malformed body",
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "invalid percent",
+			body:         `{"enabled": true, "maxUnhealthyPercent": 120}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "negative limit",
+			body:         `{"enabled": true, "maxRepairsPerHour": -1}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "invalid timeout",
+			body:         `{"enabled": true, "notReadyTimeout": 600000000000}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "negative timeout",
+			body:         `{"enabled": true, "notReadyTimeout": "-10m"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "kube not found",
+			body:         `{"enabled": true}`,
+			kubeErr:      sgerrors.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description:  "defaults",
+			body:         `{"enabled": true, "maxRepairsPerHour": 5}`,
+			expectedCode: http.StatusOK,
+			expectedSettings: model.AutoRepair{
+				Enabled:             true,
+				NotReadyTimeout:     defaultNotReadyTimeout,
+				MaxUnhealthyPercent: defaultMaxUnhealthyPercent,
+				MaxRepairsPerHour:   5,
+			},
+		},
+		{
+			description:  "timeout",
+			body:         `{"enabled": true, "notReadyTimeout": "30m"}`,
+			expectedCode: http.StatusOK,
+			expectedSettings: model.AutoRepair{
+				Enabled:             true,
+				NotReadyTimeout:     "30m",
+				MaxUnhealthyPercent: defaultMaxUnhealthyPercent,
+				MaxRepairsPerHour:   defaultMaxRepairsPerHour,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		k := &model.Kube{ID: "test"}
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(k, testCase.kubeErr)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := Handler{
+			svc: svc,
+		}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/kubes/{kubeID}/autorepair", h.updateAutoRepair)
+
+		req, _ := http.NewRequest(http.MethodPut, "/kubes/test/autorepair",
+			bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		if k.AutoRepair != testCase.expectedSettings {
+			t.Errorf("%s: wrong settings expected %v actual %v",
+				testCase.description, testCase.expectedSettings, k.AutoRepair)
+		}
+	}
+}
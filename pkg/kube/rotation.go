@@ -0,0 +1,185 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/events"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// rotateCertificates re-issues api server, kubelet and admin certificates
+// with the CA of the kube, nodes are restarted one by one masters first.
+func (h *Handler) rotateCertificates(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if provisioningDisabled(w, k) {
+		return
+	}
+
+	if !operational(k) {
+		http.Error(w, "kube in state "+string(k.State)+" can't rotate certificates", http.StatusConflict)
+		return
+	}
+
+	if k.Auth.CACert == "" || k.Auth.CAKey == "" {
+		http.Error(w, "kube "+k.ID+" has no CA to issue certificates", http.StatusConflict)
+		return
+	}
+
+	acc, err := h.accountService.Get(r.Context(), k.AccountName)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.AccountName, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	admin, err := pki.NewAdminPair(&pki.PairPEM{
+		Cert: []byte(k.Auth.CACert),
+		Key:  []byte(k.Auth.CAKey),
+	})
+	if err != nil {
+		message.SendUnknownError(w, errors.Wrap(err, "issue admin certificate"))
+		return
+	}
+	k.Auth.AdminCert = string(admin.Cert)
+	k.Auth.AdminKey = string(admin.Key)
+
+	resp := UpgradeResponse{
+		Tasks: map[string][]string{
+			string(node.RoleMaster): {},
+			string(node.RoleNode):   {},
+		},
+	}
+	plan := make([]nodeTask, 0, len(k.Masters)+len(k.Nodes))
+
+	// Masters go first, kubelets must trust api server certificate
+	// before they are restarted with the new one.
+	for _, role := range []node.Role{node.RoleMaster, node.RoleNode} {
+		nodes, isMaster := k.Nodes, false
+		if role == node.RoleMaster {
+			nodes, isMaster = k.Masters, true
+		}
+
+		for _, n := range sortedNodes(nodes) {
+			t, err := workflows.NewTask(workflows.RotateCertificates, h.repo)
+			if err != nil {
+				if sgerrors.IsNotFound(err) {
+					message.SendNotFound(w, workflows.RotateCertificates, err)
+					return
+				}
+				message.SendUnknownError(w, err)
+				return
+			}
+
+			config := rotationConfig(k, acc, n, isMaster)
+			config.TaskID = t.ID
+
+			plan = append(plan, nodeTask{
+				task:   t,
+				config: config,
+			})
+			resp.Tasks[string(role)] = append(resp.Tasks[string(role)], t.ID)
+			k.Tasks = append(k.Tasks, t.ID)
+		}
+	}
+
+	state := k.State
+	k.State = model.StateRotating
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+	events.StateChanged(k.ID, state, k.State, "certificates are being rotated")
+
+	go h.runRotation(k.ID, plan)
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Error(errors.Wrap(err, "marshal json"))
+	}
+}
+
+// runRotation rotates certificates of nodes one at a time and stops
+// on the first failed node, the rest of nodes keep old certificates
+// that are signed by the same CA.
+func (h *Handler) runRotation(kubeID string, plan []nodeTask) {
+	for i, s := range plan {
+		writer, err := h.getWriter(util.MakeFileName(s.task.ID))
+		if err != nil {
+			logrus.Errorf("rotate certificates of kube %s: get writer for task %s: %v",
+				kubeID, s.task.ID, err)
+			interruptPlan(plan[i:], errors.Wrapf(err, "get writer for task %s", s.task.ID))
+			break
+		}
+
+		errChan := s.task.RunConfig(context.Background(), s.config, writer)
+		if err := <-errChan; err != nil {
+			logrus.Errorf("rotate certificates of node %s of kube %s caused %v, abort rotation",
+				s.config.Node.Name, kubeID, err)
+			interruptPlan(plan[i+1:], errors.Wrapf(err, "rotate certificates of node %s", s.config.Node.Name))
+			break
+		}
+
+		logrus.Infof("certificates of node %s of kube %s have been rotated",
+			s.config.Node.Name, kubeID)
+	}
+
+	k, err := h.svc.Get(context.Background(), kubeID)
+	if err != nil {
+		logrus.Errorf("rotate certificates of kube %s: %v", kubeID, err)
+		return
+	}
+
+	k.State = model.StateOperational
+	if err := h.svc.Create(context.Background(), k); err != nil {
+		logrus.Errorf("update kube %s caused %v", kubeID, err)
+		return
+	}
+	events.StateChanged(k.ID, model.StateRotating, k.State, "certificate rotation has finished")
+}
+
+func rotationConfig(k *model.Kube, acc *model.CloudAccount, n *node.Node, isMaster bool) *steps.Config {
+	config := upgradeConfig(k, acc, k.K8SVersion, n, isMaster)
+
+	config.CertificatesConfig.CACert = k.Auth.CACert
+	config.CertificatesConfig.CAKey = k.Auth.CAKey
+	config.CertificatesConfig.AdminCert = k.Auth.AdminCert
+	config.CertificatesConfig.AdminKey = k.Auth.AdminKey
+
+	// NOTE: certificates step rewrites static auth files of api server,
+	// users that have been added on provisioning must be kept there.
+	if k.Profile != nil {
+		config.CertificatesConfig.StaticAuth = k.Profile.StaticAuth
+	}
+	if len(config.CertificatesConfig.StaticAuth.BasicAuth) == 0 && k.User != "" {
+		config.CertificatesConfig.StaticAuth.BasicAuth = []profile.BasicAuthUser{
+			{
+				Name:     k.User,
+				ID:       k.User,
+				Password: k.Password,
+				Groups:   []string{pki.MastersGroup},
+			},
+		}
+	}
+
+	return config
+}
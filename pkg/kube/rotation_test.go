@@ -0,0 +1,234 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestRotateCertificates(t *testing.T) {
+	ca, err := pki.NewCAPair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operationalKube := func() *model.Kube {
+		return &model.Kube{
+			ID:          "test",
+			State:       model.StateOperational,
+			AccountName: "test",
+			K8SVersion:  "1.11.5",
+			Auth: model.Auth{
+				CACert:    string(ca.Cert),
+				CAKey:     string(ca.Key),
+				AdminCert: "old",
+			},
+			Masters: map[string]*node.Node{
+				"master-1": {Name: "master-1", State: node.StateActive},
+			},
+			Nodes: map[string]*node.Node{
+				"node-2": {Name: "node-2"},
+				"node-1": {Name: "node-1"},
+			},
+		}
+	}
+
+	testCases := []struct {
+		description string
+
+		kube           *model.Kube
+		kubeServiceErr error
+		account        *model.CloudAccount
+		accountErr     error
+
+		expectedCode  int
+		expectedTasks int
+	}{
+		{
+			description:    "kube not found",
+			kubeServiceErr: sgerrors.ErrNotFound,
+			expectedCode:   http.StatusNotFound,
+		},
+		{
+			description: "kube is imported",
+			kube: &model.Kube{
+				State: model.StateImported,
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description: "kube is not operational",
+			kube: &model.Kube{
+				State: model.StateUpgrading,
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description: "no CA",
+			kube: &model.Kube{
+				State: model.StateOperational,
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description:  "account not found",
+			kube:         operationalKube(),
+			accountErr:   sgerrors.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description: "success",
+			kube:        operationalKube(),
+			account: &model.CloudAccount{
+				Provider: clouds.DigitalOcean,
+			},
+			expectedCode:  http.StatusAccepted,
+			expectedTasks: 3,
+		},
+	}
+
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.RotateCertificates, []steps.Step{})
+
+	for _, testCase := range testCases {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(testCase.kube, testCase.kubeServiceErr)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		accService := new(accServiceMock)
+		accService.On("Get", mock.Anything, mock.Anything).
+			Return(testCase.account, testCase.accountErr)
+
+		mockRepo := new(testutils.MockStorage)
+		mockRepo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := Handler{
+			svc:            svc,
+			accountService: accService,
+			repo:           mockRepo,
+			getWriter: func(string) (io.WriteCloser, error) {
+				return &bufferCloser{}, nil
+			},
+		}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/kubes/{kubeID}/certificates/rotate", h.rotateCertificates)
+
+		req, _ := http.NewRequest(http.MethodPost,
+			fmt.Sprintf("/kubes/%s/certificates/rotate", "test"), nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusAccepted {
+			continue
+		}
+
+		resp := UpgradeResponse{}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		masterTasks := resp.Tasks[string(node.RoleMaster)]
+		nodeTasks := resp.Tasks[string(node.RoleNode)]
+		if len(masterTasks)+len(nodeTasks) != testCase.expectedTasks {
+			t.Errorf("%s: wrong task count expected %d actual %v",
+				testCase.description, testCase.expectedTasks, resp.Tasks)
+		}
+
+		if testCase.kube.Auth.AdminCert == "old" {
+			t.Errorf("%s: admin certificate has not been issued", testCase.description)
+		}
+	}
+}
+
+func TestRotationConfig(t *testing.T) {
+	acc := &model.CloudAccount{
+		Provider: clouds.DigitalOcean,
+	}
+	n := &node.Node{Name: "master-1"}
+
+	k := &model.Kube{
+		K8SVersion: "1.11.5",
+		User:       "user",
+		Password:   "password",
+		Auth: model.Auth{
+			CACert:    "ca",
+			CAKey:     "caKey",
+			AdminCert: "admin",
+			AdminKey:  "adminKey",
+		},
+		Masters: map[string]*node.Node{
+			"master-1": n,
+		},
+	}
+
+	config := rotationConfig(k, acc, n, true)
+
+	if config.CertificatesConfig.CACert != "ca" || config.CertificatesConfig.AdminKey != "adminKey" {
+		t.Errorf("certificates have not been set %+v", config.CertificatesConfig)
+	}
+
+	if config.UpgradeConfig.K8SVersion != k.K8SVersion {
+		t.Errorf("wrong version expected %s actual %s",
+			k.K8SVersion, config.UpgradeConfig.K8SVersion)
+	}
+
+	// Kubes provisioned before ssh port has been stored
+	if config.SshConfig.Port != ssh.DefaultPort {
+		t.Errorf("wrong ssh port expected %s actual %s",
+			ssh.DefaultPort, config.SshConfig.Port)
+	}
+
+	basicAuth := config.CertificatesConfig.StaticAuth.BasicAuth
+	if len(basicAuth) != 1 || basicAuth[0].Name != "user" || basicAuth[0].Password != "password" {
+		t.Errorf("kube user has not been kept %+v", basicAuth)
+	}
+
+	k.Profile = &profile.Profile{
+		StaticAuth: profile.StaticAuth{
+			BasicAuth: []profile.BasicAuthUser{
+				{Name: "user"},
+				{Name: "other"},
+			},
+			Tokens: []profile.TokenAuthUser{
+				{Name: "token"},
+			},
+		},
+	}
+
+	config = rotationConfig(k, acc, n, true)
+
+	if len(config.CertificatesConfig.StaticAuth.BasicAuth) != 2 ||
+		len(config.CertificatesConfig.StaticAuth.Tokens) != 1 {
+		t.Errorf("static auth of profile has not been kept %+v",
+			config.CertificatesConfig.StaticAuth)
+	}
+}
@@ -0,0 +1,191 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// RunRequest runs either a registered workflow or a list of registered
+// steps on the nodes of the kube, nodes are referred to by name.
+type RunRequest struct {
+	Workflow string   `json:"workflow,omitempty"`
+	Steps    []string `json:"steps,omitempty"`
+	Nodes    []string `json:"nodes"`
+}
+
+// nodeTask is a task that runs on a single node of the kube,
+// e.g. an upgrade or a rotation of certificates of the node.
+type nodeTask struct {
+	task   *workflows.Task
+	config *steps.Config
+}
+
+// RunResponse maps names of nodes to ids of tasks that run on them.
+type RunResponse struct {
+	Tasks map[string]string `json:"tasks"`
+}
+
+// runTasks runs a workflow or steps chosen by user on the nodes of the kube,
+// e.g. to re-issue certificates of a single node. Only steps that change
+// a single existing node are allowed. Every node gets a task of its own,
+// tasks run at once and the kube state is not changed.
+func (h *Handler) runTasks(w http.ResponseWriter, r *http.Request) {
+	req := &RunRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if (req.Workflow == "") == (len(req.Steps) == 0) {
+		message.SendValidationFailed(w, errors.New("either workflow or steps must be set"))
+		return
+	}
+
+	if len(req.Nodes) == 0 {
+		message.SendValidationFailed(w, errors.New("no nodes"))
+		return
+	}
+
+	var wf workflows.Workflow
+	if req.Workflow != "" {
+		if wf = workflows.GetWorkflow(req.Workflow); wf == nil {
+			message.SendNotFound(w, req.Workflow, sgerrors.ErrNotFound)
+			return
+		}
+	} else {
+		var err error
+		if wf, err = workflows.BuildWorkflow(req.Steps); err != nil {
+			message.SendValidationFailed(w, err)
+			return
+		}
+	}
+
+	// NOTE: tasks run on nodes of a running kube, workflows that create
+	// or delete machines or change the whole kube are rejected.
+	if err := workflows.NodeScoped(wf); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, ok := h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if provisioningDisabled(w, k) {
+		return
+	}
+
+	if !operational(k) {
+		http.Error(w, "kube in state "+string(k.State)+" can't run tasks", http.StatusConflict)
+		return
+	}
+
+	acc, err := h.accountService.Get(r.Context(), k.AccountName)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.AccountName, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	resp := RunResponse{
+		Tasks: make(map[string]string, len(req.Nodes)),
+	}
+	plan := make([]nodeTask, 0, len(req.Nodes))
+
+	for _, name := range req.Nodes {
+		if _, ok := resp.Tasks[name]; ok {
+			message.SendValidationFailed(w, errors.Errorf("node %s is duplicated", name))
+			return
+		}
+
+		n, isMaster := k.Masters[name], true
+		if n == nil {
+			n, isMaster = k.Nodes[name], false
+		}
+		if n == nil {
+			message.SendNotFound(w, name, sgerrors.ErrNotFound)
+			return
+		}
+
+		var t *workflows.Task
+		if req.Workflow != "" {
+			t, err = workflows.NewTask(req.Workflow, h.repo)
+		} else {
+			t, err = workflows.NewCustomTask(req.Steps, h.repo)
+		}
+		if err != nil {
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		// NOTE: config carries certificates of the kube,
+		// so that certificates step can be run again.
+		config := rotationConfig(k, acc, n, isMaster)
+		config.TaskID = t.ID
+
+		plan = append(plan, nodeTask{
+			task:   t,
+			config: config,
+		})
+		resp.Tasks[name] = t.ID
+		k.Tasks = append(k.Tasks, t.ID)
+	}
+
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	for _, s := range plan {
+		go h.runTask(k.ID, s)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Error(errors.Wrap(err, "marshal json"))
+	}
+}
+
+func (h *Handler) runTask(kubeID string, s nodeTask) {
+	writer, err := h.getWriter(util.MakeFileName(s.task.ID))
+	if err != nil {
+		logrus.Errorf("run task %s on node %s of kube %s: get writer: %v",
+			s.task.ID, s.config.Node.Name, kubeID, err)
+		interruptPlan([]nodeTask{s}, errors.Wrap(err, "get writer"))
+		return
+	}
+
+	errChan := s.task.RunConfig(context.Background(), s.config, writer)
+	if err := <-errChan; err != nil {
+		logrus.Errorf("task %s on node %s of kube %s caused %v",
+			s.task.ID, s.config.Node.Name, kubeID, err)
+		return
+	}
+
+	logrus.Infof("task %s on node %s of kube %s has finished",
+		s.task.ID, s.config.Node.Name, kubeID)
+}
+
+// interruptPlan marks tasks of the plan that are not going to run
+// as failed, e.g. when a previous node of the plan has failed.
+func interruptPlan(plan []nodeTask, reason error) {
+	for _, s := range plan {
+		if err := s.task.Interrupt(context.Background(), reason.Error()); err != nil {
+			logrus.Errorf("save task %s caused %v", s.task.ID, err)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/restart"
+)
+
+const (
+	// NOTE: only steps that change a single node can be run
+	runTestStep            = restart.StepName
+	runTestWorkflow        = "RunTestWorkflow"
+	runTestClusterStep     = "run_test_cluster_step"
+	runTestClusterWorkflow = "RunTestClusterWorkflow"
+)
+
+// noopStep is a step that does nothing.
+type noopStep struct {
+	namedStep
+}
+
+func (s *noopStep) Run(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *noopStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *noopStep) Description() string {
+	return "does nothing"
+}
+
+func TestRunTasks(t *testing.T) {
+	operationalKube := func() *model.Kube {
+		return &model.Kube{
+			ID:          "test",
+			State:       model.StateOperational,
+			AccountName: "test",
+			K8SVersion:  "1.11.5",
+			Masters: map[string]*node.Node{
+				"master-1": {Name: "master-1", State: node.StateActive},
+			},
+			Nodes: map[string]*node.Node{
+				"node-1": {Name: "node-1"},
+			},
+		}
+	}
+	account := &model.CloudAccount{
+		Provider: clouds.DigitalOcean,
+	}
+
+	testCases := []struct {
+		description string
+
+		body           string
+		kube           *model.Kube
+		kubeServiceErr error
+		account        *model.CloudAccount
+		accountErr     error
+
+		expectedCode  int
+		expectedTasks int
+	}{
+		{
+			description:  "invalid json",
+			body:         "{",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "neither workflow nor steps",
+			body:         `{"nodes":["node-1"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "both workflow and steps",
+			body:         `{"workflow":"RunTestWorkflow","steps":["restart"],"nodes":["node-1"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "no nodes",
+			body:         `{"steps":["restart"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "workflow not found",
+			body:         `{"workflow":"unknown","nodes":["node-1"]}`,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description:  "step not registered",
+			body:         `{"steps":["restart","unknown"],"nodes":["node-1"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "workflow is not node scoped",
+			body:         `{"workflow":"RunTestClusterWorkflow","nodes":["node-1"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "step is not node scoped",
+			body:         `{"steps":["restart","run_test_cluster_step"],"nodes":["node-1"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:    "kube not found",
+			body:           `{"steps":["restart"],"nodes":["node-1"]}`,
+			kubeServiceErr: sgerrors.ErrNotFound,
+			expectedCode:   http.StatusNotFound,
+		},
+		{
+			description: "kube is not operational",
+			body:        `{"steps":["restart"],"nodes":["node-1"]}`,
+			kube: &model.Kube{
+				State: model.StateUpgrading,
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description:  "account not found",
+			body:         `{"steps":["restart"],"nodes":["node-1"]}`,
+			kube:         operationalKube(),
+			accountErr:   sgerrors.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description:  "node not found",
+			body:         `{"steps":["restart"],"nodes":["node-2"]}`,
+			kube:         operationalKube(),
+			account:      account,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description:  "node is duplicated",
+			body:         `{"steps":["restart"],"nodes":["node-1","node-1"]}`,
+			kube:         operationalKube(),
+			account:      account,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:   "run workflow",
+			body:          `{"workflow":"RunTestWorkflow","nodes":["master-1","node-1"]}`,
+			kube:          operationalKube(),
+			account:       account,
+			expectedCode:  http.StatusAccepted,
+			expectedTasks: 2,
+		},
+		{
+			description:   "run steps",
+			body:          `{"steps":["restart"],"nodes":["node-1"]}`,
+			kube:          operationalKube(),
+			account:       account,
+			expectedCode:  http.StatusAccepted,
+			expectedTasks: 1,
+		},
+	}
+
+	workflows.Init()
+	steps.RegisterStep(runTestStep, &noopStep{namedStep{name: runTestStep}})
+	steps.RegisterStep(runTestClusterStep, &noopStep{namedStep{name: runTestClusterStep}})
+	workflows.RegisterWorkFlow(runTestWorkflow, []steps.Step{steps.GetStep(runTestStep)})
+	workflows.RegisterWorkFlow(runTestClusterWorkflow, []steps.Step{steps.GetStep(runTestClusterStep)})
+
+	for _, testCase := range testCases {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(testCase.kube, testCase.kubeServiceErr)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		accService := new(accServiceMock)
+		accService.On("Get", mock.Anything, mock.Anything).
+			Return(testCase.account, testCase.accountErr)
+
+		mockRepo := new(testutils.MockStorage)
+		mockRepo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := Handler{
+			svc:            svc,
+			accountService: accService,
+			repo:           mockRepo,
+			getWriter: func(string) (io.WriteCloser, error) {
+				return &bufferCloser{}, nil
+			},
+		}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/kubes/{kubeID}/tasks", h.runTasks)
+
+		req, _ := http.NewRequest(http.MethodPost, "/kubes/test/tasks",
+			bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusAccepted {
+			continue
+		}
+
+		resp := RunResponse{}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		if len(resp.Tasks) != testCase.expectedTasks {
+			t.Errorf("%s: wrong task count expected %d actual %v",
+				testCase.description, testCase.expectedTasks, resp.Tasks)
+		}
+
+		if len(testCase.kube.Tasks) != testCase.expectedTasks {
+			t.Errorf("%s: tasks have not been added to kube %v",
+				testCase.description, testCase.kube.Tasks)
+		}
+	}
+}
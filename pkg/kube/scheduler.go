@@ -0,0 +1,966 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	// DefaultSchedulesPrefix is a storage prefix of schedules of kubes
+	DefaultSchedulesPrefix = "/schedules/"
+	// DefaultScheduleRunsPrefix is a storage prefix of runs of schedules
+	DefaultScheduleRunsPrefix = "/scheduleruns/"
+	// DefaultScheduleClaimsPrefix is a storage prefix of the latest fire
+	// times of schedules claimed by controlplanes
+	DefaultScheduleClaimsPrefix = "/scheduleclaims/"
+	// DefaultParkedNodesPrefix is a storage prefix of workers deleted
+	// by schedules that can be re-added
+	DefaultParkedNodesPrefix = "/parkednodes/"
+	DefaultSchedulerInterval = 30 * time.Second
+
+	// maxScheduleDelay is how late a schedule may run, fires that have
+	// been missed longer e.g. while controlplane was down are skipped
+	maxScheduleDelay = 15 * time.Minute
+	// maxScheduleRuns is a number of latest runs kept per schedule
+	maxScheduleRuns = 100
+)
+
+type ScheduledAction string
+
+const (
+	// ScheduleScale sets count of workers of the pool
+	ScheduleScale ScheduledAction = "scale"
+	// ScheduleDelete deletes workers and parks them to be re-added
+	ScheduleDelete ScheduledAction = "delete"
+	// ScheduleReAdd provisions parked workers again
+	ScheduleReAdd ScheduledAction = "readd"
+)
+
+type ScheduleRunStatus string
+
+const (
+	ScheduleRunSucceeded ScheduleRunStatus = "succeeded"
+	ScheduleRunFailed    ScheduleRunStatus = "failed"
+	ScheduleRunSkipped   ScheduleRunStatus = "skipped"
+)
+
+// Schedule runs an operation on workers of the kube at times of the cron
+// expression, expression is evaluated in the timezone of the schedule.
+type Schedule struct {
+	ID     string `json:"id"`
+	KubeID string `json:"kubeId"`
+	// Cron is a five field cron expression or a descriptor like @daily.
+	Cron string `json:"cron"`
+	// Timezone is an IANA location name, UTC is used if it's empty.
+	Timezone string          `json:"timezone,omitempty"`
+	Paused   bool            `json:"paused"`
+	Action   ScheduledAction `json:"action"`
+	// Pool is scaled by scale action, workers out of pools are scaled
+	// if it's empty.
+	Pool  string `json:"pool,omitempty"`
+	Count int    `json:"count,omitempty"`
+	// Nodes are deleted by delete action and re-added by readd action,
+	// readd re-adds all parked workers of the kube if it's empty.
+	Nodes     []string  `json:"nodes,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// NextRun is filled in responses only.
+	NextRun *time.Time `json:"nextRun,omitempty"`
+}
+
+// ScheduleRun is a fire of the schedule, Nodes are names of workers
+// that have been deleted or re-added.
+type ScheduleRun struct {
+	ScheduleID string            `json:"scheduleId"`
+	Action     ScheduledAction   `json:"action"`
+	FireTime   time.Time         `json:"fireTime"`
+	StartedAt  time.Time         `json:"startedAt"`
+	Status     ScheduleRunStatus `json:"status"`
+	Nodes      []string          `json:"nodes,omitempty"`
+	Tasks      []string          `json:"tasks,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+}
+
+// ParkedNode is a worker deleted by a schedule, it is provisioned again
+// with the same profile and pool when it's re-added.
+type ParkedNode struct {
+	Name       string              `json:"name"`
+	Pool       string              `json:"pool,omitempty"`
+	Profile    profile.NodeProfile `json:"profile"`
+	ScheduleID string              `json:"scheduleId"`
+	DeletedAt  time.Time           `json:"deletedAt"`
+}
+
+// Scheduler runs due schedules of operational kubes. Each fire is claimed
+// in the storage before it runs, so that schedules don't fire twice when
+// several controlplanes share the storage.
+type Scheduler struct {
+	h        *Handler
+	repo     storage.Interface
+	interval time.Duration
+	now      func() time.Time
+
+	// m guards read-modify-write of schedules and parked nodes
+	m sync.Mutex
+}
+
+func NewScheduler(h *Handler, repo storage.Interface, interval time.Duration) *Scheduler {
+	if _, ok := repo.(storage.Swapper); !ok {
+		logrus.Warn("scheduler: storage can't claim schedules atomically, " +
+			"schedules may fire twice if controlplane is replicated")
+	}
+
+	return &Scheduler{
+		h:        h,
+		repo:     repo,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+func (s *Scheduler) Register(r *mux.Router) {
+	r.HandleFunc("/kubes/{kubeID}/schedules", s.listSchedules).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/schedules", s.createSchedule).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/schedules/{scheduleID}", s.getSchedule).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/schedules/{scheduleID}", s.updateSchedule).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/schedules/{scheduleID}", s.deleteSchedule).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/schedules/{scheduleID}/runs", s.listRuns).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/parked", s.listParkedNodes).Methods(http.MethodGet)
+}
+
+// Run checks schedules until context is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runAll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Scheduler) runAll(ctx context.Context) {
+	kubes, err := s.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("scheduler: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+		if k.State == model.StateImported {
+			continue
+		}
+
+		schedules, err := loadSchedules(ctx, s.repo, k.ID)
+		if err != nil {
+			logrus.Errorf("scheduler: kube %s: %v", k.ID, err)
+			continue
+		}
+
+		for _, sch := range schedules {
+			if sch.Paused {
+				continue
+			}
+
+			if err := s.runDue(ctx, k.ID, sch); err != nil {
+				logrus.Errorf("scheduler: kube %s: schedule %s: %v", k.ID, sch.ID, err)
+			}
+		}
+	}
+}
+
+// runDue runs the latest fire of the schedule that has not been claimed yet.
+func (s *Scheduler) runDue(ctx context.Context, kubeID string, sch Schedule) error {
+	cron, loc, err := parseSchedule(sch)
+	if err != nil {
+		return err
+	}
+
+	claimed, last, err := s.lastClaim(ctx, sch)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	// Schedule must not fire for times it has been changed after
+	if sch.UpdatedAt.After(last) {
+		last = sch.UpdatedAt
+	}
+	fire := latestFire(cron, last.In(loc), now)
+	if fire.IsZero() {
+		return nil
+	}
+
+	if err := s.claim(ctx, sch, claimed, fire); err != nil {
+		if errors.Cause(err) == storage.ErrValueChanged {
+			// Another controlplane runs it
+			return nil
+		}
+		return errors.Wrap(err, "claim schedule")
+	}
+
+	run := ScheduleRun{
+		ScheduleID: sch.ID,
+		Action:     sch.Action,
+		FireTime:   fire,
+		StartedAt:  now,
+		Status:     ScheduleRunSucceeded,
+	}
+
+	// Kube might have changed since it was listed
+	k, err := s.h.svc.Get(ctx, kubeID)
+	switch {
+	case err != nil:
+		run.Status, run.Reason = ScheduleRunFailed, errors.Wrap(err, "get kube").Error()
+	case now.Sub(fire) > maxScheduleDelay:
+		run.Status, run.Reason = ScheduleRunSkipped, "missed by "+now.Sub(fire).String()
+	case !operational(k):
+		run.Status, run.Reason = ScheduleRunSkipped, "kube is "+string(k.State)
+	default:
+		logrus.Infof("scheduler: %s kube %s by schedule %s fired at %s", sch.Action, k.ID, sch.ID, fire)
+
+		run.Nodes, run.Tasks, err = s.apply(ctx, k, sch)
+		if err != nil {
+			run.Status, run.Reason = ScheduleRunFailed, err.Error()
+		}
+	}
+
+	return s.record(ctx, sch, run)
+}
+
+// lastClaim returns the stored claim and the latest claimed fire time,
+// nil claim means that the schedule has never fired.
+func (s *Scheduler) lastClaim(ctx context.Context, sch Schedule) ([]byte, time.Time, error) {
+	data, err := s.repo.Get(ctx, DefaultScheduleClaimsPrefix, scheduleKey(sch))
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return nil, time.Time{}, nil
+		}
+		return nil, time.Time{}, errors.Wrap(err, "get claim")
+	}
+
+	var last time.Time
+	if err := last.UnmarshalText(data); err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "unmarshal claim")
+	}
+
+	return data, last, nil
+}
+
+// claim replaces the previous claim with the fire time, it fails with
+// storage.ErrValueChanged if another controlplane has claimed it first.
+func (s *Scheduler) claim(ctx context.Context, sch Schedule, prev []byte, fire time.Time) error {
+	data, err := fire.UTC().MarshalText()
+	if err != nil {
+		return err
+	}
+
+	if swapper, ok := s.repo.(storage.Swapper); ok {
+		return swapper.CompareAndSwap(ctx, DefaultScheduleClaimsPrefix, scheduleKey(sch), prev, data)
+	}
+
+	return s.repo.Put(ctx, DefaultScheduleClaimsPrefix, scheduleKey(sch), data)
+}
+
+func (s *Scheduler) apply(ctx context.Context, k *model.Kube, sch Schedule) ([]string, []string, error) {
+	acc, err := s.h.accountService.Get(ctx, k.AccountName)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "get account %s", k.AccountName)
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	switch sch.Action {
+	case ScheduleScale:
+		tasks, err := s.scale(ctx, k, acc, sch)
+		return nil, tasks, err
+	case ScheduleDelete:
+		return s.deleteNodes(ctx, k, acc, sch)
+	case ScheduleReAdd:
+		return s.readdNodes(ctx, k, acc, sch)
+	}
+
+	return nil, nil, errors.Errorf("unknown action %s", sch.Action)
+}
+
+// scale provisions workers with the profile of existing ones or deletes
+// the last workers by name until the pool has count of the schedule.
+func (s *Scheduler) scale(ctx context.Context, k *model.Kube, acc *model.CloudAccount, sch Schedule) ([]string, error) {
+	pool := k.NodePools[sch.Pool]
+	if sch.Pool != "" && pool == nil {
+		return nil, errors.Wrapf(sgerrors.ErrNotFound, "pool %s", sch.Pool)
+	}
+
+	workers := make([]*node.Node, 0)
+	for _, n := range poolNodes(k, sch.Pool) {
+		if n.State != node.StateDeleting {
+			workers = append(workers, n)
+		}
+	}
+
+	tasks := make([]string, 0)
+	var plan []deleteNodeStep
+
+	switch {
+	case sch.Count > len(workers):
+		template := &node.Node{Pool: sch.Pool}
+		if len(workers) > 0 {
+			template = workers[len(workers)-1]
+		}
+
+		nodeProfile, _, err := replacementProfile(k, template)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeProfiles := make([]profile.NodeProfile, 0, sch.Count-len(workers))
+		for i := len(workers); i < sch.Count; i++ {
+			nodeProfiles = append(nodeProfiles, nodeProfile)
+		}
+
+		tasks, err = s.h.provisionNodes(ctx, k, acc, nodeProfiles, pool)
+		if err != nil {
+			return nil, errors.Wrap(err, "provision nodes")
+		}
+	case sch.Count < len(workers):
+		for _, n := range workers[sch.Count:] {
+			t, config, err := s.h.deleteNodeTask(ctx, k, acc, n)
+			if err != nil {
+				return nil, errors.Wrapf(err, "delete node %s", n.Name)
+			}
+
+			plan = append(plan, deleteNodeStep{
+				task:   t,
+				config: config,
+				kube:   k,
+				node:   n,
+			})
+			tasks = append(tasks, t.ID)
+		}
+	default:
+		return tasks, nil
+	}
+
+	if pool != nil {
+		pool.Count = sch.Count
+	}
+
+	k.Tasks = append(k.Tasks, tasks...)
+	if err := s.h.svc.Create(ctx, k); err != nil {
+		return tasks, errors.Wrap(err, "save kube")
+	}
+
+	if len(plan) > 0 {
+		go s.h.runPoolScaleDown(k.ID, sch.Pool, plan)
+	}
+
+	return tasks, nil
+}
+
+// deleteNodes parks workers of the schedule and deletes them, workers
+// that don't exist anymore are reported but don't stop the rest.
+// NOTE: workers are parked when deletion starts, a worker that fails
+// to be drained stays in the kube and is re-added nevertheless.
+func (s *Scheduler) deleteNodes(ctx context.Context, k *model.Kube, acc *model.CloudAccount,
+	sch Schedule) ([]string, []string, error) {
+	parked, err := loadParkedNodes(ctx, s.repo, k.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodes, tasks, missing := make([]string, 0), make([]string, 0), make([]string, 0)
+	plans := make(map[string][]deleteNodeStep)
+
+	for _, name := range sch.Nodes {
+		n := k.Nodes[name]
+		if n == nil || n.State == node.StateDeleting {
+			missing = append(missing, name)
+			continue
+		}
+
+		nodeProfile, pool, err := replacementProfile(k, n)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "profile of node %s", name)
+		}
+
+		t, config, err := s.h.deleteNodeTask(ctx, k, acc, n)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "delete node %s", name)
+		}
+
+		plans[n.Pool] = append(plans[n.Pool], deleteNodeStep{
+			task:   t,
+			config: config,
+			kube:   k,
+			node:   n,
+		})
+		parked = append(parked, ParkedNode{
+			Name:       n.Name,
+			Pool:       n.Pool,
+			Profile:    nodeProfile,
+			ScheduleID: sch.ID,
+			DeletedAt:  s.now(),
+		})
+		if pool != nil && pool.Count > 0 {
+			pool.Count--
+		}
+
+		nodes = append(nodes, n.Name)
+		tasks = append(tasks, t.ID)
+	}
+
+	if len(nodes) > 0 {
+		k.Tasks = append(k.Tasks, tasks...)
+		if err := s.h.svc.Create(ctx, k); err != nil {
+			return nil, nil, errors.Wrap(err, "save kube")
+		}
+
+		if err := saveParkedNodes(ctx, s.repo, k.ID, parked); err != nil {
+			return nil, nil, err
+		}
+
+		for poolName, plan := range plans {
+			go s.h.runPoolScaleDown(k.ID, poolName, plan)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nodes, tasks, errors.Errorf("nodes %s not found", strings.Join(missing, ", "))
+	}
+
+	return nodes, tasks, nil
+}
+
+// readdNodes provisions parked workers of the schedule, or all parked
+// workers of the kube, with their profiles. Workers get new names.
+func (s *Scheduler) readdNodes(ctx context.Context, k *model.Kube, acc *model.CloudAccount,
+	sch Schedule) ([]string, []string, error) {
+	parked, err := loadParkedNodes(ctx, s.repo, k.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selected := make(map[string]bool, len(sch.Nodes))
+	for _, name := range sch.Nodes {
+		selected[name] = true
+	}
+
+	kept := make([]ParkedNode, 0)
+	byPool := make(map[string][]ParkedNode)
+	for _, p := range parked {
+		if len(selected) > 0 && !selected[p.Name] {
+			kept = append(kept, p)
+			continue
+		}
+		byPool[p.Pool] = append(byPool[p.Pool], p)
+	}
+
+	poolNames := make([]string, 0, len(byPool))
+	for name := range byPool {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+
+	nodes, tasks := make([]string, 0), make([]string, 0)
+	var errs []string
+
+	for _, poolName := range poolNames {
+		pool := k.NodePools[poolName]
+		if poolName != "" && pool == nil {
+			errs = append(errs, "pool "+poolName+" not found")
+			kept = append(kept, byPool[poolName]...)
+			continue
+		}
+
+		nodeProfiles := make([]profile.NodeProfile, 0, len(byPool[poolName]))
+		for _, p := range byPool[poolName] {
+			nodeProfiles = append(nodeProfiles, p.Profile)
+		}
+
+		poolTasks, err := s.h.provisionNodes(ctx, k, acc, nodeProfiles, pool)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "provision nodes").Error())
+			kept = append(kept, byPool[poolName]...)
+			continue
+		}
+
+		if pool != nil {
+			pool.Count += len(nodeProfiles)
+		}
+		for _, p := range byPool[poolName] {
+			nodes = append(nodes, p.Name)
+		}
+		tasks = append(tasks, poolTasks...)
+	}
+
+	if len(nodes) > 0 {
+		k.Tasks = append(k.Tasks, tasks...)
+		if err := s.h.svc.Create(ctx, k); err != nil {
+			return nodes, tasks, errors.Wrap(err, "save kube")
+		}
+
+		if err := saveParkedNodes(ctx, s.repo, k.ID, kept); err != nil {
+			return nodes, tasks, err
+		}
+	}
+
+	if len(errs) > 0 {
+		return nodes, tasks, errors.New(strings.Join(errs, "; "))
+	}
+
+	return nodes, tasks, nil
+}
+
+func (s *Scheduler) record(ctx context.Context, sch Schedule, run ScheduleRun) error {
+	runs, err := loadScheduleRuns(ctx, s.repo, sch)
+	if err != nil {
+		return err
+	}
+
+	runs = append(runs, run)
+	if len(runs) > maxScheduleRuns {
+		runs = runs[len(runs)-maxScheduleRuns:]
+	}
+
+	data, err := json.Marshal(runs)
+	if err != nil {
+		return errors.Wrap(err, "marshal schedule runs")
+	}
+
+	return errors.Wrap(s.repo.Put(ctx, DefaultScheduleRunsPrefix, scheduleKey(sch), data), "save schedule runs")
+}
+
+// latestFire returns the latest fire time after the last one that is not
+// later than now, zero time is returned if the schedule is not due.
+func latestFire(cron *cronSchedule, last, now time.Time) time.Time {
+	// Fires missed that long ago are not worth iterating over
+	if oldest := now.Add(-24 * time.Hour).In(last.Location()); last.Before(oldest) {
+		last = oldest
+	}
+
+	fire := cron.Next(last)
+	if fire.IsZero() || fire.After(now) {
+		return time.Time{}
+	}
+
+	for {
+		next := cron.Next(fire)
+		if next.IsZero() || next.After(now) {
+			return fire
+		}
+		fire = next
+	}
+}
+
+func parseSchedule(sch Schedule) (*cronSchedule, *time.Location, error) {
+	cron, err := parseCron(sch.Cron)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loc, err := time.LoadLocation(sch.Timezone)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "timezone %s", sch.Timezone)
+	}
+
+	return cron, loc, nil
+}
+
+func validateSchedule(k *model.Kube, sch Schedule) error {
+	cron, _, err := parseSchedule(sch)
+	if err != nil {
+		return err
+	}
+
+	if cron.Next(time.Now()).IsZero() {
+		return errors.Errorf("cron expression %q never matches", sch.Cron)
+	}
+
+	switch sch.Action {
+	case ScheduleScale:
+		if sch.Count < 0 {
+			return errors.Errorf("count must not be negative, got %d", sch.Count)
+		}
+		if sch.Pool != "" && k.NodePools[sch.Pool] == nil {
+			return errors.Errorf("node pool %s not found", sch.Pool)
+		}
+	case ScheduleDelete:
+		if len(sch.Nodes) == 0 {
+			return errors.New("nodes to delete must not be empty")
+		}
+		for _, name := range sch.Nodes {
+			if k.Nodes[name] == nil {
+				return errors.Errorf("worker %s not found", name)
+			}
+		}
+	case ScheduleReAdd:
+	default:
+		return errors.Errorf("action must be one of %s, %s, %s", ScheduleScale, ScheduleDelete, ScheduleReAdd)
+	}
+
+	return nil
+}
+
+// withNextRun returns a copy of the schedule with the next run time.
+func withNextRun(sch Schedule, now time.Time) Schedule {
+	sch.NextRun = nil
+	if sch.Paused {
+		return sch
+	}
+
+	cron, loc, err := parseSchedule(sch)
+	if err != nil {
+		return sch
+	}
+
+	if next := cron.Next(now.In(loc)); !next.IsZero() {
+		sch.NextRun = &next
+	}
+
+	return sch
+}
+
+func scheduleKey(sch Schedule) string {
+	return sch.KubeID + "/" + sch.ID
+}
+
+func findSchedule(schedules []Schedule, id string) (int, bool) {
+	for i, sch := range schedules {
+		if sch.ID == id {
+			return i, true
+		}
+	}
+
+	return -1, false
+}
+
+func loadSchedules(ctx context.Context, repo storage.Interface, kubeID string) ([]Schedule, error) {
+	schedules := make([]Schedule, 0)
+
+	data, err := repo.Get(ctx, DefaultSchedulesPrefix, kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return schedules, nil
+		}
+		return nil, errors.Wrap(err, "get schedules")
+	}
+
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, errors.Wrap(err, "unmarshal schedules")
+	}
+
+	return schedules, nil
+}
+
+func saveSchedules(ctx context.Context, repo storage.Interface, kubeID string, schedules []Schedule) error {
+	data, err := json.Marshal(schedules)
+	if err != nil {
+		return errors.Wrap(err, "marshal schedules")
+	}
+
+	return errors.Wrap(repo.Put(ctx, DefaultSchedulesPrefix, kubeID, data), "save schedules")
+}
+
+func loadScheduleRuns(ctx context.Context, repo storage.Interface, sch Schedule) ([]ScheduleRun, error) {
+	runs := make([]ScheduleRun, 0)
+
+	data, err := repo.Get(ctx, DefaultScheduleRunsPrefix, scheduleKey(sch))
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return runs, nil
+		}
+		return nil, errors.Wrap(err, "get schedule runs")
+	}
+
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, errors.Wrap(err, "unmarshal schedule runs")
+	}
+
+	return runs, nil
+}
+
+func loadParkedNodes(ctx context.Context, repo storage.Interface, kubeID string) ([]ParkedNode, error) {
+	parked := make([]ParkedNode, 0)
+
+	data, err := repo.Get(ctx, DefaultParkedNodesPrefix, kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return parked, nil
+		}
+		return nil, errors.Wrap(err, "get parked nodes")
+	}
+
+	if err := json.Unmarshal(data, &parked); err != nil {
+		return nil, errors.Wrap(err, "unmarshal parked nodes")
+	}
+
+	return parked, nil
+}
+
+func saveParkedNodes(ctx context.Context, repo storage.Interface, kubeID string, parked []ParkedNode) error {
+	data, err := json.Marshal(parked)
+	if err != nil {
+		return errors.Wrap(err, "marshal parked nodes")
+	}
+
+	return errors.Wrap(repo.Put(ctx, DefaultParkedNodesPrefix, kubeID, data), "save parked nodes")
+}
+
+func (s *Scheduler) listSchedules(w http.ResponseWriter, r *http.Request) {
+	k, ok := s.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	schedules, err := loadSchedules(r.Context(), s.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	now := s.now()
+	for i := range schedules {
+		schedules[i] = withNextRun(schedules[i], now)
+	}
+
+	if err := json.NewEncoder(w).Encode(schedules); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (s *Scheduler) createSchedule(w http.ResponseWriter, r *http.Request) {
+	sch := Schedule{}
+	if err := json.NewDecoder(r.Body).Decode(&sch); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, ok := s.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if provisioningDisabled(w, k) {
+		return
+	}
+
+	if err := validateSchedule(k, sch); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	now := s.now()
+	sch.ID = uuid.New()[:8]
+	sch.KubeID = k.ID
+	sch.CreatedAt = now
+	sch.UpdatedAt = now
+	sch.NextRun = nil
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	schedules, err := loadSchedules(r.Context(), s.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := saveSchedules(r.Context(), s.repo, k.ID, append(schedules, sch)); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(withNextRun(sch, now)); err != nil {
+		logrus.Error(errors.Wrap(err, "marshal json"))
+	}
+}
+
+func (s *Scheduler) getSchedule(w http.ResponseWriter, r *http.Request) {
+	k, ok := s.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	scheduleID := mux.Vars(r)["scheduleID"]
+	schedules, err := loadSchedules(r.Context(), s.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	i, ok := findSchedule(schedules, scheduleID)
+	if !ok {
+		message.SendNotFound(w, scheduleID, sgerrors.ErrNotFound)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(withNextRun(schedules[i], s.now())); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// updateSchedule replaces the schedule, fires that have been due
+// before the update are not run.
+func (s *Scheduler) updateSchedule(w http.ResponseWriter, r *http.Request) {
+	sch := Schedule{}
+	if err := json.NewDecoder(r.Body).Decode(&sch); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, ok := s.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if provisioningDisabled(w, k) {
+		return
+	}
+
+	if err := validateSchedule(k, sch); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	scheduleID := mux.Vars(r)["scheduleID"]
+	schedules, err := loadSchedules(r.Context(), s.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	i, ok := findSchedule(schedules, scheduleID)
+	if !ok {
+		message.SendNotFound(w, scheduleID, sgerrors.ErrNotFound)
+		return
+	}
+
+	sch.ID = scheduleID
+	sch.KubeID = k.ID
+	sch.CreatedAt = schedules[i].CreatedAt
+	sch.UpdatedAt = s.now()
+	sch.NextRun = nil
+	schedules[i] = sch
+
+	if err := saveSchedules(r.Context(), s.repo, k.ID, schedules); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(withNextRun(sch, sch.UpdatedAt)); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// deleteSchedule deletes the schedule with its runs, workers
+// it has parked can still be re-added by other schedules.
+func (s *Scheduler) deleteSchedule(w http.ResponseWriter, r *http.Request) {
+	k, ok := s.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	scheduleID := mux.Vars(r)["scheduleID"]
+	schedules, err := loadSchedules(r.Context(), s.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	i, ok := findSchedule(schedules, scheduleID)
+	if !ok {
+		message.SendNotFound(w, scheduleID, sgerrors.ErrNotFound)
+		return
+	}
+	sch := schedules[i]
+
+	if err := saveSchedules(r.Context(), s.repo, k.ID, append(schedules[:i], schedules[i+1:]...)); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	for _, prefix := range []string{DefaultScheduleRunsPrefix, DefaultScheduleClaimsPrefix} {
+		if err := s.repo.Delete(r.Context(), prefix, scheduleKey(sch)); err != nil {
+			logrus.Errorf("scheduler: delete %s of schedule %s: %v", prefix, sch.ID, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Scheduler) listRuns(w http.ResponseWriter, r *http.Request) {
+	k, ok := s.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	scheduleID := mux.Vars(r)["scheduleID"]
+	schedules, err := loadSchedules(r.Context(), s.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	i, ok := findSchedule(schedules, scheduleID)
+	if !ok {
+		message.SendNotFound(w, scheduleID, sgerrors.ErrNotFound)
+		return
+	}
+
+	runs, err := loadScheduleRuns(r.Context(), s.repo, schedules[i])
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(runs); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (s *Scheduler) listParkedNodes(w http.ResponseWriter, r *http.Request) {
+	k, ok := s.h.kubeFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	parked, err := loadParkedNodes(r.Context(), s.repo, k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(parked); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
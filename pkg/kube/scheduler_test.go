@@ -0,0 +1,291 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// swapRepository is a memRepository that claims values atomically.
+type swapRepository struct {
+	*memRepository
+}
+
+func (r *swapRepository) CompareAndSwap(ctx context.Context, prefix string, key string, old, value []byte) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	current, ok := r.data[prefix+key]
+	if ok != (old != nil) || !bytes.Equal(current, old) {
+		return storage.ErrValueChanged
+	}
+	r.data[prefix+key] = value
+	return nil
+}
+
+func schedulerHandler(k *model.Kube, repo storage.Interface) (*Handler, *mockNodeProvisioner) {
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).
+		Return(k, nil)
+	svc.On(serviceCreate, mock.Anything, mock.Anything).
+		Return(nil)
+
+	accService := new(accServiceMock)
+	accService.On("Get", mock.Anything, mock.Anything).
+		Return(&model.CloudAccount{Provider: clouds.DigitalOcean}, nil)
+
+	provisioner := new(mockNodeProvisioner)
+	provisioner.On("ProvisionNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]string{"task-1"}, nil)
+
+	return &Handler{
+		svc:             svc,
+		accountService:  accService,
+		nodeProvisioner: provisioner,
+		repo:            repo,
+	}, provisioner
+}
+
+func TestLatestFire(t *testing.T) {
+	cron, _ := parseCron("0 * * * *")
+	now := time.Date(2019, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	fire := latestFire(cron, now.Add(-3*time.Hour), now)
+	require.Equal(t, time.Date(2019, 1, 1, 10, 0, 0, 0, time.UTC), fire)
+
+	fire = latestFire(cron, time.Date(2019, 1, 1, 10, 0, 0, 0, time.UTC), now)
+	require.True(t, fire.IsZero(), "fire must not be run twice")
+
+	// Missed fires are not searched for longer than a day
+	fire = latestFire(cron, time.Time{}, now)
+	require.Equal(t, time.Date(2019, 1, 1, 10, 0, 0, 0, time.UTC), fire)
+}
+
+func TestSchedulerRunDue(t *testing.T) {
+	k := pooledKube()
+	repo := &swapRepository{&memRepository{data: map[string][]byte{}}}
+	h, provisioner := schedulerHandler(k, repo)
+
+	created := time.Date(2019, 1, 1, 6, 50, 0, 0, time.UTC)
+	fire := time.Date(2019, 1, 1, 7, 0, 0, 0, time.UTC)
+	sch := Schedule{
+		ID:        "morning",
+		KubeID:    k.ID,
+		Cron:      "0 8 * * *",
+		Timezone:  "Europe/Berlin",
+		Action:    ScheduleScale,
+		Pool:      "gpu",
+		Count:     3,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	require.Nil(t, saveSchedules(context.Background(), repo, k.ID, []Schedule{sch}))
+
+	// Two controlplanes share the storage
+	now := fire.Add(2 * time.Minute)
+	first, second := NewScheduler(h, repo, time.Minute), NewScheduler(h, repo, time.Minute)
+	for _, s := range []*Scheduler{first, second} {
+		s.now = func() time.Time {
+			return now
+		}
+	}
+
+	// 8:00 in Berlin is 7:00 UTC
+	require.Nil(t, first.runDue(context.Background(), k.ID, sch))
+	require.Nil(t, second.runDue(context.Background(), k.ID, sch))
+	provisioner.AssertNumberOfCalls(t, "ProvisionNodes", 1)
+	require.Equal(t, 3, k.NodePools["gpu"].Count)
+
+	runs, err := loadScheduleRuns(context.Background(), repo, sch)
+	require.Nil(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, ScheduleRunSucceeded, runs[0].Status)
+	require.Equal(t, fire, runs[0].FireTime.UTC())
+	require.Equal(t, []string{"task-1"}, runs[0].Tasks)
+
+	// The next fire has been missed while controlplanes were down
+	now = fire.Add(24*time.Hour + time.Hour)
+	require.Nil(t, first.runDue(context.Background(), k.ID, sch))
+	provisioner.AssertNumberOfCalls(t, "ProvisionNodes", 1)
+
+	runs, err = loadScheduleRuns(context.Background(), repo, sch)
+	require.Nil(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, ScheduleRunSkipped, runs[1].Status)
+}
+
+func TestSchedulerDeleteAndReAdd(t *testing.T) {
+	k := repairKubeFixture()
+	repo := &memRepository{data: map[string][]byte{}}
+	h, provisioner := schedulerHandler(k, repo)
+
+	// NOTE: kube is shared with the goroutine that deletes the node,
+	// test waits until the node is removed from the saved kube.
+	deleted := make(chan struct{})
+	once := sync.Once{}
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).
+		Return(k, nil)
+	svc.On(serviceCreate, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			if _, ok := args.Get(1).(*model.Kube).Nodes["node-5"]; !ok {
+				once.Do(func() { close(deleted) })
+			}
+		}).
+		Return(nil)
+
+	drainer := new(mockDrainer)
+	drainer.On("Drain", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	drainer.On("DeleteNode", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.DigitalOceanDeleteNode, []steps.Step{})
+
+	h.svc = svc
+	h.drainer = drainer
+	h.workflowMap = map[clouds.Name]workflows.WorkflowSet{
+		clouds.DigitalOcean: {
+			DeleteNode: workflows.DigitalOceanDeleteNode,
+		},
+	}
+	h.getWriter = func(string) (io.WriteCloser, error) {
+		return &bufferCloser{}, nil
+	}
+
+	s := NewScheduler(h, repo, time.Minute)
+
+	nodes, tasks, err := s.apply(context.Background(), k, Schedule{
+		ID:     "night",
+		Action: ScheduleDelete,
+		Nodes:  []string{"node-5", "node-6"},
+	})
+	require.NotNil(t, err, "missing node must be reported")
+	require.Equal(t, []string{"node-5"}, nodes)
+	require.Len(t, tasks, 1)
+
+	select {
+	case <-deleted:
+	case <-time.After(time.Second * 5):
+		t.Fatal("node has not been deleted")
+	}
+
+	parked, err := loadParkedNodes(context.Background(), repo, k.ID)
+	require.Nil(t, err)
+	require.Len(t, parked, 1)
+	require.Equal(t, profile.NodeProfile{"size": "s-2vcpu-4gb"}, parked[0].Profile)
+
+	nodes, _, err = s.apply(context.Background(), k, Schedule{
+		ID:     "morning",
+		Action: ScheduleReAdd,
+	})
+	require.Nil(t, err)
+	require.Equal(t, []string{"node-5"}, nodes)
+	provisioner.AssertCalled(t, "ProvisionNodes", mock.Anything,
+		[]profile.NodeProfile{{"size": "s-2vcpu-4gb"}}, mock.Anything, mock.Anything)
+
+	parked, err = loadParkedNodes(context.Background(), repo, k.ID)
+	require.Nil(t, err)
+	require.Empty(t, parked)
+}
+
+func TestCreateSchedule(t *testing.T) {
+	testCases := []struct {
+		description  string
+		state        model.KubeState
+		body         string
+		expectedCode int
+	}{
+		{
+			description:  "invalid json",
+			body:         "{",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "invalid cron",
+			body:         `{"cron":"* * *","action":"scale","count":1}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "unknown timezone",
+			body:         `{"cron":"@daily","timezone":"Mars/Olympus","action":"scale","count":1}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "unknown pool",
+			body:         `{"cron":"@daily","action":"scale","pool":"cpu","count":1}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "unknown worker",
+			body:         `{"cron":"@daily","action":"delete","nodes":["node-9"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "imported kube",
+			state:        model.StateImported,
+			body:         `{"cron":"@daily","action":"readd"}`,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description:  "success",
+			body:         `{"cron":"0 20 * * 1-5","timezone":"Europe/Berlin","action":"delete","nodes":["gpu-1"]}`,
+			expectedCode: http.StatusCreated,
+		},
+	}
+
+	for _, testCase := range testCases {
+		k := pooledKube()
+		if testCase.state != "" {
+			k.State = testCase.state
+		}
+
+		repo := &memRepository{data: map[string][]byte{}}
+		h, _ := schedulerHandler(k, repo)
+
+		router := mux.NewRouter()
+		NewScheduler(h, repo, time.Minute).Register(router)
+
+		req, _ := http.NewRequest(http.MethodPost, "/kubes/test/schedules", bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong status code expected %d actual %d %s",
+				testCase.description, testCase.expectedCode, rec.Code, rec.Body.String())
+			continue
+		}
+
+		if rec.Code != http.StatusCreated {
+			continue
+		}
+
+		sch := Schedule{}
+		require.Nil(t, json.NewDecoder(rec.Body).Decode(&sch))
+		require.NotEmpty(t, sch.ID)
+		require.NotNil(t, sch.NextRun, "%s: next run must be set", testCase.description)
+
+		schedules, err := loadSchedules(context.Background(), repo, k.ID)
+		require.Nil(t, err)
+		require.Len(t, schedules, 1)
+		require.Nil(t, schedules[0].NextRun, "next run must not be stored")
+	}
+}
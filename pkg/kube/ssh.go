@@ -0,0 +1,38 @@
+package kube
+
+import (
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// sshRunner runs commands on the node with bootstrap key of the kube.
+func sshRunner(k *model.Kube, n *node.Node) (runner.Runner, error) {
+	return ssh.NewRunner(ssh.Config{
+		Host:    n.PublicIp,
+		Port:    sshPort(k),
+		User:    k.SshUser,
+		Timeout: 10,
+		Key:     k.BootstrapPrivateKey,
+	})
+}
+
+// useBootstrapSSH makes steps of the config reach nodes of the kube
+// with its bootstrap key.
+func useBootstrapSSH(config *steps.Config, k *model.Kube) {
+	config.SshConfig.User = k.SshUser
+	config.SshConfig.Port = sshPort(k)
+	config.SshConfig.BootstrapPrivateKey = string(k.BootstrapPrivateKey)
+}
+
+// sshPort is the port nodes of the kube listen ssh on, e.g. bare metal
+// machines may have been added with a port of their own.
+func sshPort(k *model.Kube) string {
+	if k.SshPort == "" {
+		return ssh.DefaultPort
+	}
+
+	return k.SshPort
+}
@@ -0,0 +1,309 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/asaskevich/govalidator.v8"
+
+	"github.com/supergiant/control/pkg/events"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type UpgradeRequest struct {
+	K8SVersion string `json:"K8SVersion" valid:"required"`
+}
+
+type UpgradeResponse struct {
+	Tasks map[string][]string `json:"tasks"`
+}
+
+// upgradeKube upgrades kubernetes components of the kube node by node,
+// masters go first then workers are drained and upgraded one by one.
+func (h *Handler) upgradeKube(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	req := &UpgradeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if ok, err := govalidator.ValidateStruct(req); !ok {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if !operational(k) {
+		http.Error(w, "kube in state "+string(k.State)+" can't be upgraded", http.StatusConflict)
+		return
+	}
+
+	if err := checkUpgradeVersion(k.K8SVersion, req.K8SVersion); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	acc, err := h.accountService.Get(r.Context(), k.AccountName)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.AccountName, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	workflowSet := h.workflowMap[acc.Provider]
+	resp := UpgradeResponse{
+		Tasks: map[string][]string{
+			string(node.RoleMaster): {},
+			string(node.RoleNode):   {},
+		},
+	}
+	plan := make([]nodeTask, 0, len(k.Masters)+len(k.Nodes))
+
+	// Masters are upgraded first, api server must be newer than kubelets
+	for _, role := range []node.Role{node.RoleMaster, node.RoleNode} {
+		workflowName, nodes, isMaster := workflowSet.UpgradeNode, k.Nodes, false
+		if role == node.RoleMaster {
+			workflowName, nodes, isMaster = workflowSet.UpgradeMaster, k.Masters, true
+		}
+
+		for _, n := range sortedNodes(nodes) {
+			t, err := workflows.NewTask(workflowName, h.repo)
+			if err != nil {
+				if sgerrors.IsNotFound(err) {
+					message.SendNotFound(w, workflowName, err)
+					return
+				}
+				message.SendUnknownError(w, err)
+				return
+			}
+
+			config := upgradeConfig(k, acc, req.K8SVersion, n, isMaster)
+			config.TaskID = t.ID
+
+			plan = append(plan, nodeTask{
+				task:   t,
+				config: config,
+			})
+			resp.Tasks[string(role)] = append(resp.Tasks[string(role)], t.ID)
+			k.Tasks = append(k.Tasks, t.ID)
+		}
+	}
+
+	state := k.State
+	k.State = model.StateUpgrading
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+	events.StateChanged(k.ID, state, k.State, "kube is being upgraded to "+req.K8SVersion)
+
+	go h.runUpgrade(k, req.K8SVersion, plan)
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Error(errors.Wrap(err, "marshal json"))
+	}
+}
+
+// runUpgrade upgrades nodes one at a time, next node is not touched until
+// previous one is healthy. Upgrade stops on the first failed node, so that
+// the rest of the cluster keeps running previous version.
+func (h *Handler) runUpgrade(k *model.Kube, version string, plan []nodeTask) {
+	kubeID := k.ID
+	upgraded := true
+
+	for i, s := range plan {
+		writer, err := h.getWriter(util.MakeFileName(s.task.ID))
+		if err != nil {
+			logrus.Errorf("upgrade kube %s: get writer for task %s: %v",
+				kubeID, s.task.ID, err)
+			interruptPlan(plan[i:], errors.Wrapf(err, "get writer for task %s", s.task.ID))
+			upgraded = false
+			break
+		}
+
+		if err := h.upgradeNode(k, s, writer); err != nil {
+			logrus.Errorf("upgrade node %s of kube %s to %s caused %v, abort upgrade",
+				s.config.Node.Name, kubeID, version, err)
+			interruptPlan(plan[i+1:], errors.Wrapf(err, "upgrade node %s", s.config.Node.Name))
+			upgraded = false
+			break
+		}
+
+		logrus.Infof("node %s of kube %s has been upgraded to %s",
+			s.config.Node.Name, kubeID, version)
+	}
+
+	// Reload kube, it could have been changed while upgrade is running
+	k, err := h.svc.Get(context.Background(), kubeID)
+	if err != nil {
+		logrus.Errorf("upgrade kube %s: %v", kubeID, err)
+		return
+	}
+
+	// NOTE: kube stays operational when upgrade has been aborted, nodes
+	// that have not been upgraded keep working with previous version.
+	k.State = model.StateOperational
+	if upgraded {
+		k.K8SVersion = version
+		if k.Profile != nil {
+			k.Profile.K8SVersion = version
+		}
+	}
+
+	if err := h.svc.Create(context.Background(), k); err != nil {
+		logrus.Errorf("update kube %s caused %v", kubeID, err)
+		return
+	}
+
+	reason := "upgrade to " + version + " has been aborted"
+	if upgraded {
+		reason = "kube has been upgraded to " + version
+	}
+	events.StateChanged(k.ID, model.StateUpgrading, k.State, reason)
+}
+
+// upgradeNode runs the upgrade task of the node, workers are drained
+// before the task and uncordoned when the node is healthy again.
+func (h *Handler) upgradeNode(k *model.Kube, s nodeTask, out io.WriteCloser) error {
+	n := &s.config.Node
+
+	if !s.config.IsMaster {
+		if err := h.drainer.Drain(context.Background(), k, n, DefaultDrainTimeout); err != nil {
+			if err := h.drainer.Uncordon(context.Background(), k, n); err != nil {
+				logrus.Errorf("uncordon node %s of kube %s caused %v", n.Name, k.ID, err)
+			}
+
+			err = errors.Wrapf(err, "drain node %s", n.Name)
+			abortTask(s.task, out, err)
+			return err
+		}
+	}
+
+	if err := <-s.task.RunConfig(context.Background(), s.config, out); err != nil {
+		return err
+	}
+
+	// NOTE: worker that has failed to be upgraded stays cordoned.
+	if !s.config.IsMaster {
+		if err := h.drainer.Uncordon(context.Background(), k, n); err != nil {
+			return errors.Wrapf(err, "uncordon node %s", n.Name)
+		}
+	}
+
+	return nil
+}
+
+func upgradeConfig(k *model.Kube, acc *model.CloudAccount, version string,
+	n *node.Node, isMaster bool) *steps.Config {
+	kubeProfile := kubeProfileFrom(k, acc.Provider)
+	kubeProfile.K8SVersion = version
+
+	config := steps.NewConfig(k.Name, "", k.AccountName, kubeProfile)
+	config.ClusterID = k.ID
+	config.IsMaster = isMaster
+	config.Node = *n
+	// Kubelet keeps labels and taints of the pool
+	config.NodePool = k.NodePools[n.Pool]
+	useBootstrapSSH(config, k)
+
+	// Workers keep reaching masters through the load balancer
+	if lbHost, err := util.LoadBalancerHost(k); err == nil {
+		config.LoadBalancerConfig.Host = lbHost
+	}
+
+	for _, master := range k.Masters {
+		config.AddMaster(master)
+	}
+
+	return config
+}
+
+func sortedNodes(nodes map[string]*node.Node) []*node.Node {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		if nodes[name] != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	sorted := make([]*node.Node, 0, len(names))
+	for _, name := range names {
+		sorted = append(sorted, nodes[name])
+	}
+
+	return sorted
+}
+
+// checkUpgradeVersion follows kubernetes version skew policy
+// that allows to upgrade by one minor version at a time.
+func checkUpgradeVersion(current, target string) error {
+	cur, err := parseVersion(current)
+	if err != nil {
+		return errors.Wrap(err, "current version")
+	}
+
+	tgt, err := parseVersion(target)
+	if err != nil {
+		return errors.Wrap(err, "target version")
+	}
+
+	if cur[0] != tgt[0] || tgt[1]-cur[1] > 1 {
+		return errors.Errorf("can't upgrade from %s to %s, upgrade by one minor version at a time",
+			current, target)
+	}
+
+	if tgt[1] < cur[1] || (tgt[1] == cur[1] && tgt[2] <= cur[2]) {
+		return errors.Errorf("version %s is not newer than %s", target, current)
+	}
+
+	return nil
+}
+
+func parseVersion(version string) ([3]int, error) {
+	var v [3]int
+
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) != 3 {
+		return v, errors.Errorf("malformed version %q", version)
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, errors.Errorf("malformed version %q", version)
+		}
+		v[i] = n
+	}
+
+	return v, nil
+}
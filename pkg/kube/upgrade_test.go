@@ -0,0 +1,359 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestCheckUpgradeVersion(t *testing.T) {
+	testCases := []struct {
+		current   string
+		target    string
+		expectErr bool
+	}{
+		{"1.11.5", "1.11.6", false},
+		{"1.11.5", "1.12.3", false},
+		{"1.11.5", "v1.12.3", false},
+		{"1.11.5", "1.11.5", true},
+		{"1.12.3", "1.11.5", true},
+		{"1.11.5", "1.13.1", true},
+		{"1.11.5", "2.0.0", true},
+		{"1.11.5", "1.12", true},
+		{"1.11.5", "latest", true},
+	}
+
+	for _, testCase := range testCases {
+		err := checkUpgradeVersion(testCase.current, testCase.target)
+
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("upgrade from %s to %s: expected error %v actual %v",
+				testCase.current, testCase.target, testCase.expectErr, err)
+		}
+	}
+}
+
+func TestUpgradeKube(t *testing.T) {
+	operationalKube := func() *model.Kube {
+		return &model.Kube{
+			ID:          "test",
+			State:       model.StateOperational,
+			AccountName: "test",
+			K8SVersion:  "1.11.5",
+			Masters: map[string]*node.Node{
+				"master-1": {Name: "master-1", State: node.StateActive},
+			},
+			Nodes: map[string]*node.Node{
+				"node-2": {Name: "node-2"},
+				"node-1": {Name: "node-1"},
+			},
+		}
+	}
+
+	testCases := []struct {
+		description string
+
+		body           string
+		kube           *model.Kube
+		kubeServiceErr error
+		account        *model.CloudAccount
+		accountErr     error
+
+		expectedCode  int
+		expectedTasks int
+	}{
+		{
+			description:  "malformed body",
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:    "kube not found",
+			body:           `{"K8SVersion": "1.12.3"}`,
+			kubeServiceErr: sgerrors.ErrNotFound,
+			expectedCode:   http.StatusNotFound,
+		},
+		{
+			description: "kube is not operational",
+			body:        `{"K8SVersion": "1.12.3"}`,
+			kube: &model.Kube{
+				State:      model.StateProvisioning,
+				K8SVersion: "1.11.5",
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description:  "skip minor version",
+			body:         `{"K8SVersion": "1.13.1"}`,
+			kube:         operationalKube(),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "account not found",
+			body:         `{"K8SVersion": "1.12.3"}`,
+			kube:         operationalKube(),
+			accountErr:   sgerrors.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description: "no upgrade workflow for provider",
+			body:        `{"K8SVersion": "1.12.3"}`,
+			kube:        operationalKube(),
+			account: &model.CloudAccount{
+				Provider: clouds.Packet,
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description: "success",
+			body:        `{"K8SVersion": "1.12.3"}`,
+			kube:        operationalKube(),
+			account: &model.CloudAccount{
+				Provider: clouds.DigitalOcean,
+			},
+			expectedCode:  http.StatusAccepted,
+			expectedTasks: 3,
+		},
+		{
+			description: "degraded kube",
+			body:        `{"K8SVersion": "1.12.3"}`,
+			kube: func() *model.Kube {
+				k := operationalKube()
+				k.State = model.StateDegraded
+				return k
+			}(),
+			account: &model.CloudAccount{
+				Provider: clouds.DigitalOcean,
+			},
+			expectedCode:  http.StatusAccepted,
+			expectedTasks: 3,
+		},
+	}
+
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.UpgradeMaster, []steps.Step{})
+	workflows.RegisterWorkFlow(workflows.UpgradeNode, []steps.Step{})
+
+	for _, testCase := range testCases {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).
+			Return(testCase.kube, testCase.kubeServiceErr)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).
+			Return(nil)
+
+		accService := new(accServiceMock)
+		accService.On("Get", mock.Anything, mock.Anything).
+			Return(testCase.account, testCase.accountErr)
+
+		mockRepo := new(testutils.MockStorage)
+		mockRepo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		drainer := new(mockDrainer)
+		drainer.On("Drain", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		drainer.On("Uncordon", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := Handler{
+			svc:            svc,
+			accountService: accService,
+			drainer:        drainer,
+			workflowMap: map[clouds.Name]workflows.WorkflowSet{
+				clouds.DigitalOcean: {
+					UpgradeMaster: workflows.UpgradeMaster,
+					UpgradeNode:   workflows.UpgradeNode,
+				},
+			},
+			repo: mockRepo,
+			getWriter: func(string) (io.WriteCloser, error) {
+				return &bufferCloser{}, nil
+			},
+		}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/kubes/{kubeID}/upgrade", h.upgradeKube)
+
+		req, _ := http.NewRequest(http.MethodPost,
+			fmt.Sprintf("/kubes/%s/upgrade", "test"),
+			bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusAccepted {
+			continue
+		}
+
+		resp := UpgradeResponse{}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		masterTasks := resp.Tasks[string(node.RoleMaster)]
+		nodeTasks := resp.Tasks[string(node.RoleNode)]
+		if len(masterTasks)+len(nodeTasks) != testCase.expectedTasks {
+			t.Errorf("%s: wrong task count expected %d actual %v",
+				testCase.description, testCase.expectedTasks, resp.Tasks)
+		}
+	}
+}
+
+func TestUpgradeConfig(t *testing.T) {
+	acc := &model.CloudAccount{
+		Provider: clouds.DigitalOcean,
+	}
+	k := pooledKube()
+
+	config := upgradeConfig(k, acc, "1.12.7", k.Nodes["gpu-1"], false)
+	if config.NodePool != k.NodePools["gpu"] {
+		t.Errorf("pool of the node has not been set %v", config.NodePool)
+	}
+
+	if config.UpgradeConfig.K8SVersion != "1.12.7" {
+		t.Errorf("wrong version expected 1.12.7 actual %s", config.UpgradeConfig.K8SVersion)
+	}
+
+	config = upgradeConfig(k, acc, "1.12.7", k.Masters["master-1"], true)
+	if config.NodePool != nil {
+		t.Errorf("master must not belong to a pool %v", config.NodePool)
+	}
+}
+
+// failingStep is a step that always fails.
+type failingStep struct {
+	noopStep
+}
+
+func (s *failingStep) Run(context.Context, io.Writer, *steps.Config) error {
+	return errors.New("node is not healthy")
+}
+
+func TestRunUpgradeAbort(t *testing.T) {
+	const (
+		failingWorkflow = "UpgradeAbortTestWorkflow"
+		noopWorkflow    = "UpgradeTestWorkflow"
+	)
+	workflows.RegisterWorkFlow(failingWorkflow, []steps.Step{
+		&failingStep{noopStep{namedStep: namedStep{name: "failing"}}},
+	})
+	workflows.RegisterWorkFlow(noopWorkflow, []steps.Step{
+		&noopStep{namedStep: namedStep{name: "noop"}},
+	})
+
+	testCases := []struct {
+		description string
+
+		workflow string
+		drainErr error
+
+		expectedReason   string
+		expectedUncordon int
+	}{
+		{
+			description:      "upgrade error",
+			workflow:         failingWorkflow,
+			expectedReason:   "upgrade node gpu-1",
+			expectedUncordon: 0,
+		},
+		{
+			description:      "drain error",
+			workflow:         noopWorkflow,
+			drainErr:         errors.New("pods can't be evicted"),
+			expectedReason:   "drain node gpu-1",
+			expectedUncordon: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		k := pooledKube()
+		acc := &model.CloudAccount{
+			Provider: clouds.DigitalOcean,
+		}
+
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).Return(k, nil)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).Return(nil)
+
+		repo := new(testutils.MockStorage)
+		repo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		drainer := new(mockDrainer)
+		drainer.On("Drain", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(testCase.drainErr)
+		drainer.On("Uncordon", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		h := Handler{
+			svc:     svc,
+			repo:    repo,
+			drainer: drainer,
+			getWriter: func(string) (io.WriteCloser, error) {
+				return &bufferCloser{}, nil
+			},
+		}
+
+		plan := make([]nodeTask, 0)
+		for _, n := range poolNodes(k, "gpu") {
+			task, err := workflows.NewTask(testCase.workflow, repo)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			plan = append(plan, nodeTask{
+				task:   task,
+				config: upgradeConfig(k, acc, "1.12.7", n, false),
+			})
+		}
+
+		h.runUpgrade(k, "1.12.7", plan)
+
+		for _, s := range plan {
+			if s.task.Status != statuses.Error {
+				t.Errorf("%s: task of node %s must fail, actual status %s",
+					testCase.description, s.config.Node.Name, s.task.Status)
+			}
+		}
+
+		rest := plan[1].task
+		if len(rest.StepStatuses) != 1 ||
+			!strings.Contains(rest.StepStatuses[0].ErrMsg, testCase.expectedReason) {
+			t.Errorf("%s: task that has not been run must be interrupted with %s %+v",
+				testCase.description, testCase.expectedReason, rest.StepStatuses)
+		}
+
+		drainer.AssertNumberOfCalls(t, "Uncordon", testCase.expectedUncordon)
+
+		if k.State != model.StateOperational || k.K8SVersion == "1.12.7" {
+			t.Errorf("%s: kube must not be upgraded, state %s version %s",
+				testCase.description, k.State, k.K8SVersion)
+		}
+	}
+}
@@ -4,9 +4,49 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
 )
 
+// kubeProfileFrom builds a profile that describes settings
+// of running kube to create provisioning config.
+func kubeProfileFrom(k *model.Kube, provider clouds.Name) profile.Profile {
+	return profile.Profile{
+		Provider:        provider,
+		Region:          k.Region,
+		Zone:            k.Zone,
+		Arch:            k.Arch,
+		OperatingSystem: k.OperatingSystem,
+		UbuntuVersion:   k.OperatingSystemVersion,
+		DockerVersion:   k.DockerVersion,
+		K8SVersion:      k.K8SVersion,
+		K8SServicesCIDR: k.ServicesCIDR,
+		HelmVersion:     k.HelmVersion,
+		User:            k.User,
+		Password:        k.Password,
+
+		NetworkType:           k.Networking.Type,
+		CIDR:                  k.Networking.CIDR,
+		FlannelVersion:        k.Networking.Version,
+		CloudSpecificSettings: k.CloudSpec,
+
+		RBACEnabled: k.RBACEnabled,
+
+		RetryPolicies: retryPolicies(k),
+	}
+}
+
+// retryPolicies returns overrides of step retry policies of the profile
+// the kube has been provisioned from.
+func retryPolicies(k *model.Kube) map[string]profile.RetryPolicy {
+	if k.Profile == nil {
+		return nil
+	}
+
+	return k.Profile.RetryPolicies
+}
+
 func processAWSMetrics(k *model.Kube, metrics map[string]map[string]interface{}) {
 	for _, masterNode := range k.Masters {
 		// After some amount of time prometheus start using region in metric name
@@ -8,6 +8,6 @@ import (
 // Name should be unique.
 type CloudAccount struct {
 	Name        string            `json:"name" valid:"required, length(1|32)"`
-	Provider    clouds.Name       `json:"provider" valid:"in(aws|digitalocean|packet|gce|openstack)"`
+	Provider    clouds.Name       `json:"provider" valid:"in(aws|digitalocean|packet|gce|openstack|baremetal)"`
 	Credentials map[string]string `json:"credentials" valid:"optional"`
 }
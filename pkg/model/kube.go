@@ -13,6 +13,15 @@ const (
 	StateFailed       KubeState = "failed"
 	StateOperational  KubeState = "operational"
 	StateDeleting     KubeState = "deleting"
+	StateUpgrading    KubeState = "upgrading"
+	StateRestoring    KubeState = "restoring"
+	StateRotating     KubeState = "rotating"
+	// StateImported is a state of kubes that were built outside of supergiant,
+	// their machines are not managed, so provisioning operations are disabled.
+	StateImported KubeState = "imported"
+	// StateDegraded is a state of operational kubes that have failed
+	// health checks, they become operational again once checks pass.
+	StateDegraded KubeState = "degraded"
 )
 
 // Kube represents a kubernetes cluster.
@@ -20,15 +29,22 @@ type Kube struct {
 	ID           string      `json:"id" valid:"-"`
 	State        KubeState   `json:"state"`
 	Name         string      `json:"name" valid:"required"`
-	Provider     clouds.Name `json:"provider" valid:"in(aws|digitalocean|packet|gce|openstack)"`
+	Provider     clouds.Name `json:"provider" valid:"in(aws|digitalocean|packet|gce|openstack|baremetal)"`
 	RBACEnabled  bool        `json:"rbacEnabled"`
 	AccountName  string      `json:"accountName"`
 	Region       string      `json:"region"`
 	Zone         string      `json:"zone" valid:"-"`
 	ServicesCIDR string      `json:"servicesCIDR"`
 	APIPort      string      `json:"apiPort"`
-	Auth         Auth        `json:"auth"`
-	SshUser      string      `json:"sshUser"`
+	// APIEndpoint is an url of kubernetes API of imported kubes and of
+	// provisioned kubes with the load balancer in front of masters,
+	// API of other provisioned kubes is reachable at master nodes.
+	APIEndpoint string `json:"apiEndpoint,omitempty" valid:"-"`
+	Auth        Auth   `json:"auth"`
+	SshUser     string `json:"sshUser"`
+	// SshPort of nodes, kubes provisioned before the port
+	// has been stored use the default one.
+	SshPort string `json:"sshPort,omitempty"`
 
 	SshPublicKey        []byte `json:"sshKey"`
 	BootstrapPublicKey  []byte `json:"bootstrapPublicKey"`
@@ -47,11 +63,63 @@ type Kube struct {
 	Subnets                map[string]string `json:"subnets"`
 
 	CloudSpec profile.CloudSpecificSettings `json:"cloudSpec" valid:"-"`
+	// Profile is a resolved profile the kube has been provisioned from.
+	Profile *profile.Profile `json:"profile,omitempty" valid:"-"`
 
 	Masters map[string]*node.Node `json:"masters"`
 	Nodes   map[string]*node.Node `json:"nodes"`
+	// NodePools maps pool name to the pool, count of the pool
+	// is a desired number of nodes that belong to it.
+	NodePools map[string]*profile.NodePool `json:"nodePools,omitempty" valid:"-"`
 	// Store taskIds of tasks that are made to provision this kube
 	Tasks []string `json:"tasks"`
+
+	AutoRepair  AutoRepair  `json:"autoRepair" valid:"-"`
+	Autoscaling Autoscaling `json:"autoscaling" valid:"-"`
+	Backup      Backup      `json:"backup" valid:"-"`
+}
+
+// AutoRepair is an opt-in replacement of workers that are NotReady
+// for too long or have failed to provision.
+type AutoRepair struct {
+	Enabled bool `json:"enabled"`
+	// NotReadyTimeout is how long a node may stay NotReady before it is
+	// replaced, e.g. "10m".
+	NotReadyTimeout string `json:"notReadyTimeout"`
+	// MaxUnhealthyPercent stops repair when too many workers are unhealthy,
+	// it usually means a cluster wide problem that new machines won't fix.
+	MaxUnhealthyPercent int `json:"maxUnhealthyPercent"`
+	// MaxRepairsPerHour limits how many workers are replaced within an hour.
+	MaxRepairsPerHour int `json:"maxRepairsPerHour"`
+}
+
+// Autoscaling keeps count of workers between MinNodes and MaxNodes,
+// workers are added for unschedulable pods and removed when underutilized.
+type Autoscaling struct {
+	Enabled bool `json:"enabled"`
+	// DryRun only records decisions without provisioning or deleting nodes.
+	DryRun bool `json:"dryRun"`
+	// Pool is a node pool that is scaled, workers without pool are scaled if empty.
+	Pool     string `json:"pool,omitempty"`
+	MinNodes int    `json:"minNodes"`
+	MaxNodes int    `json:"maxNodes"`
+	// ScaleDownUtilization is cpu and memory utilization in percents
+	// below which a node is considered underutilized.
+	ScaleDownUtilization int `json:"scaleDownUtilization"`
+	// ScaleUpCooldown and ScaleDownCooldown are minimal delays between
+	// decisions, e.g. "3m".
+	ScaleUpCooldown   string `json:"scaleUpCooldown"`
+	ScaleDownCooldown string `json:"scaleDownCooldown"`
+}
+
+// Backup is a schedule of etcd snapshots of the kube.
+type Backup struct {
+	Enabled bool `json:"enabled"`
+	// Interval is how often a snapshot is taken, e.g. "6h".
+	Interval string `json:"interval"`
+	// Retention is a number of latest snapshots that are kept,
+	// older ones are deleted from the store.
+	Retention int `json:"retention"`
 }
 
 // Auth holds all possible auth parameters.
@@ -62,6 +130,9 @@ type Auth struct {
 	CACert    string `json:"caCert"`
 	AdminCert string `json:"adminCert"`
 	AdminKey  string `json:"adminKey"`
+	// BearerToken is used by imported kubes that don't authenticate
+	// with client certificates.
+	BearerToken string `json:"bearerToken,omitempty"`
 }
 
 type Networking struct {
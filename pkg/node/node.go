@@ -36,6 +36,8 @@ type Node struct {
 	PrivateIp        string      `json:"privateIp"`
 	State            NodeState   `json:"state"`
 	Name             string      `json:"name"`
+	// Pool is a name of the node pool the worker belongs to
+	Pool string `json:"pool,omitempty"`
 }
 
 func (n Node) String() string {
@@ -9,6 +9,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"gopkg.in/asaskevich/govalidator.v8"
 
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/message"
 	"github.com/supergiant/control/pkg/sgerrors"
 )
 
@@ -23,6 +25,8 @@ func NewHandler(service *Service) *Handler {
 }
 
 func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/kubeprofiles/nodespecs", h.GetNodeSpecs).Methods(http.MethodGet)
+	r.HandleFunc("/kubeprofiles/nodespecs/{provider}", h.GetNodeSpec).Methods(http.MethodGet)
 	r.HandleFunc("/kubeprofiles/{id}", h.GetProfile).Methods(http.MethodGet)
 	r.HandleFunc("/kubeprofiles", h.CreateProfile).Methods(http.MethodPost)
 	r.HandleFunc("/kubeprofiles", h.GetProfiles).Methods(http.MethodGet)
@@ -64,6 +68,36 @@ func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	errs := ValidateParameters(profile.Parameters)
+	// Provider can be inherited from the base profile.
+	if profile.Provider == "" && profile.BaseProfileID == "" {
+		errs.Add("provider", "is required")
+	}
+	// NOTE: node profiles and pools of parameterized profile may contain
+	// templates, they are validated when cluster is provisioned.
+	if len(profile.Parameters) == 0 {
+		errs = append(errs, ValidateNodeProfiles(profile)...)
+		errs = append(errs, ValidateNodePools(profile.NodePools)...)
+		errs = append(errs, ValidateRetryPolicies(profile.RetryPolicies)...)
+	}
+
+	if len(errs) > 0 {
+		message.SendValidationFailed(w, errs)
+		return
+	}
+
+	if profile.BaseProfileID != "" {
+		if _, err := h.service.Get(r.Context(), profile.BaseProfileID); err != nil {
+			if sgerrors.IsNotFound(err) {
+				message.SendNotFound(w, profile.BaseProfileID, err)
+				return
+			}
+			logrus.Error(err)
+			message.SendUnknownError(w, err)
+			return
+		}
+	}
+
 	if err := h.service.Create(r.Context(), profile); err != nil {
 		logrus.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -86,3 +120,42 @@ func (h *Handler) GetProfiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// GetNodeSpecs returns JSON schemas of node profiles for all supported providers.
+func (h *Handler) GetNodeSpecs(w http.ResponseWriter, r *http.Request) {
+	schemas := make(map[clouds.Name]*Schema)
+
+	for _, provider := range NodeSpecProviders() {
+		schema, err := NodeSpecSchema(provider)
+		if err != nil {
+			message.SendUnknownError(w, err)
+			return
+		}
+		schemas[provider] = schema
+	}
+
+	if err := json.NewEncoder(w).Encode(schemas); err != nil {
+		logrus.Error(err)
+		message.SendUnknownError(w, err)
+	}
+}
+
+// GetNodeSpec returns JSON schema of a node profile for the provider.
+func (h *Handler) GetNodeSpec(w http.ResponseWriter, r *http.Request) {
+	provider := clouds.Name(mux.Vars(r)["provider"])
+
+	schema, err := NodeSpecSchema(provider)
+	if err != nil {
+		if sgerrors.IsUnsupportedProvider(err) {
+			message.SendNotFound(w, string(provider), err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(schema); err != nil {
+		logrus.Error(err)
+		message.SendUnknownError(w, err)
+	}
+}
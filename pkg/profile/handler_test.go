@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -14,6 +15,7 @@ import (
 	"gopkg.in/asaskevich/govalidator.v8"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/message"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/testutils"
 )
@@ -47,11 +49,11 @@ func TestKubeProfileEndpointCreateProfileSuccess(t *testing.T) {
 		ID: "key",
 
 		MasterProfiles: []NodeProfile{
-			{"hello": "world"},
+			{"size": "m4.large"},
 		},
 		NodesProfiles: []NodeProfile{
-			{"hello1": "world1"},
-			{"hello2": "world2"},
+			{"size": "m4.large", "volumeSize": "80"},
+			{"size": "m4.xlarge", "hasPublicAddr": "true"},
 		},
 
 		K8SVersion:            "1.11.1",
@@ -120,11 +122,11 @@ func TestKubeProfileEndpointCreateProfileInternalError(t *testing.T) {
 		ID: "key",
 
 		MasterProfiles: []NodeProfile{
-			{"hello": "world"},
+			{"size": "m4.large"},
 		},
 		NodesProfiles: []NodeProfile{
-			{"hello1": "world1"},
-			{"hello2": "world2"},
+			{"size": "m4.large", "volumeSize": "80"},
+			{"size": "m4.xlarge", "hasPublicAddr": "true"},
 		},
 
 		K8SVersion:      "1.11.1",
@@ -164,6 +166,41 @@ func TestKubeProfileEndpointCreateProfileInternalError(t *testing.T) {
 	}
 }
 
+func TestKubeProfileEndpointCreateProfileInvalidNodeProfile(t *testing.T) {
+	kubeProfile := &Profile{
+		MasterProfiles: []NodeProfile{
+			{"sise": "m4.large"},
+		},
+		K8SVersion: "1.11.1",
+		Provider:   clouds.AWS,
+		Region:     "fra1",
+	}
+
+	data, _ := json.Marshal(kubeProfile)
+	endpoint := &Handler{}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost,
+		"/kubeprofile", bytes.NewReader(data))
+
+	handler := http.HandlerFunc(endpoint.CreateProfile)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Wrong response code, expected %d actual %d",
+			http.StatusBadRequest, rr.Code)
+	}
+
+	msg := message.Message{}
+	if err := json.NewDecoder(rr.Body).Decode(&msg); err != nil {
+		t.Errorf("Unexpected error decoding message %v", err)
+	}
+
+	if !strings.Contains(msg.DevMessage, "masterProfiles[0].sise") {
+		t.Errorf("Field with typo not found in message %s", msg.DevMessage)
+	}
+}
+
 func TestNewKubeProfileHandler(t *testing.T) {
 	svc := &Service{}
 	h := NewHandler(svc)
@@ -177,7 +214,7 @@ func TestHandler_Register(t *testing.T) {
 	r := mux.NewRouter()
 	h := Handler{}
 	h.Register(r)
-	expectedRouteCount := 3
+	expectedRouteCount := 5
 	routes := []*mux.Route{}
 
 	walkFn := func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
@@ -340,3 +377,113 @@ func TestService_GetAll(t *testing.T) {
 		}
 	}
 }
+
+func TestHandler_GetNodeSpec(t *testing.T) {
+	testCases := []struct {
+		provider     string
+		expectedCode int
+	}{
+		{
+			provider:     string(clouds.AWS),
+			expectedCode: http.StatusOK,
+		},
+		{
+			provider:     string(clouds.DigitalOcean),
+			expectedCode: http.StatusOK,
+		},
+		{
+			provider:     "unknown",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		h := Handler{}
+		router := mux.NewRouter()
+		h.Register(router)
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet,
+			fmt.Sprintf("/kubeprofiles/nodespecs/%s", testCase.provider), nil)
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong response code expected %d actual %d",
+				testCase.provider, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		schema := &Schema{}
+		if err := json.NewDecoder(rec.Body).Decode(schema); err != nil {
+			t.Errorf("%s: unexpected error decoding schema %v",
+				testCase.provider, err)
+			continue
+		}
+
+		if _, ok := schema.Properties["size"]; !ok {
+			t.Errorf("%s: size property not found in schema", testCase.provider)
+		}
+	}
+}
+
+func TestHandler_GetNodeSpecs(t *testing.T) {
+	h := Handler{}
+	router := mux.NewRouter()
+	h.Register(router)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/kubeprofiles/nodespecs", nil)
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Wrong response code expected %d actual %d",
+			http.StatusOK, rec.Code)
+	}
+
+	schemas := map[clouds.Name]*Schema{}
+	if err := json.NewDecoder(rec.Body).Decode(&schemas); err != nil {
+		t.Errorf("Unexpected error decoding schemas %v", err)
+	}
+
+	if len(schemas) != len(NodeSpecProviders()) {
+		t.Errorf("Wrong schema count expected %d actual %d",
+			len(NodeSpecProviders()), len(schemas))
+	}
+}
+
+func TestKubeProfileEndpointCreateProfileMissingBase(t *testing.T) {
+	kubeProfile := &Profile{
+		BaseProfileID: "base",
+		Region:        "{{ .region }}",
+		Parameters: []Parameter{
+			{
+				Name:    "region",
+				Default: "fra1",
+			},
+		},
+	}
+
+	mockRepo := &testutils.MockStorage{}
+	mockRepo.On("Get", mock.Anything, "prefix", "base").
+		Return(nil, sgerrors.ErrNotFound)
+	endpoint := &Handler{
+		service: NewService("prefix", mockRepo),
+	}
+
+	data, _ := json.Marshal(kubeProfile)
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost,
+		"/kubeprofile", bytes.NewReader(data))
+
+	handler := http.HandlerFunc(endpoint.CreateProfile)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Wrong response code, expected %d actual %d",
+			http.StatusNotFound, rr.Code)
+	}
+}
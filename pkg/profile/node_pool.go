@@ -0,0 +1,77 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var taintEffects = []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}
+
+// ValidateNodePools checks that pool names are unique and labels
+// and taints of pools are accepted by kubelet.
+func ValidateNodePools(pools []NodePool) ValidationErrors {
+	errs := ValidationErrors{}
+	names := make(map[string]struct{}, len(pools))
+
+	for i, pool := range pools {
+		prefix := fmt.Sprintf("nodePools[%d]", i)
+
+		if msgs := validation.IsDNS1123Label(pool.Name); len(msgs) > 0 {
+			errs.Add(prefix+".name", "%q is not a valid pool name: %s",
+				pool.Name, strings.Join(msgs, ", "))
+		} else if _, ok := names[pool.Name]; ok {
+			errs.Add(prefix+".name", "duplicate pool %s", pool.Name)
+		}
+		names[pool.Name] = struct{}{}
+
+		if pool.Count < 0 {
+			errs.Add(prefix+".count", "must not be negative, got %d", pool.Count)
+		}
+
+		for key, value := range pool.Labels {
+			field := prefix + ".labels." + key
+			if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+				errs.Add(field, "invalid label key: %s", strings.Join(msgs, ", "))
+			}
+			if msgs := validation.IsValidLabelValue(value); len(msgs) > 0 {
+				errs.Add(field, "invalid label value: %s", strings.Join(msgs, ", "))
+			}
+		}
+
+		for j, taint := range pool.Taints {
+			if err := validateTaint(taint); err != nil {
+				errs.Add(fmt.Sprintf("%s.taints[%d]", prefix, j), "%v", err)
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateTaint checks taint written as key=value:Effect or key:Effect.
+func validateTaint(taint string) error {
+	parts := strings.Split(taint, ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("taint %q must be in format key=value:Effect", taint)
+	}
+
+	if !oneOf(taintEffects, parts[1]) {
+		return fmt.Errorf("unknown taint effect %q, use one of %s",
+			parts[1], strings.Join(taintEffects, ", "))
+	}
+
+	keyValue := strings.SplitN(parts[0], "=", 2)
+	if msgs := validation.IsQualifiedName(keyValue[0]); len(msgs) > 0 {
+		return fmt.Errorf("invalid taint key %q: %s", keyValue[0], strings.Join(msgs, ", "))
+	}
+
+	if len(keyValue) == 2 {
+		if msgs := validation.IsValidLabelValue(keyValue[1]); len(msgs) > 0 {
+			return fmt.Errorf("invalid taint value %q: %s", keyValue[1], strings.Join(msgs, ", "))
+		}
+	}
+
+	return nil
+}
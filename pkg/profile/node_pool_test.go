@@ -0,0 +1,140 @@
+package profile
+
+import (
+	"testing"
+)
+
+func TestValidateNodePools(t *testing.T) {
+	testCases := []struct {
+		description    string
+		pools          []NodePool
+		expectedFields []string
+	}{
+		{
+			description: "valid",
+			pools: []NodePool{
+				{
+					Name:  "gpu",
+					Count: 2,
+					Labels: map[string]string{
+						"accelerator": "nvidia",
+					},
+					Taints: []string{"dedicated=gpu:NoSchedule", "nvidia.com/gpu:NoExecute"},
+				},
+				{
+					Name: "spot",
+				},
+			},
+		},
+		{
+			description: "invalid name",
+			pools: []NodePool{
+				{
+					Name: "GPU pool",
+				},
+			},
+			expectedFields: []string{"nodePools[0].name"},
+		},
+		{
+			description: "duplicate name",
+			pools: []NodePool{
+				{
+					Name: "gpu",
+				},
+				{
+					Name: "gpu",
+				},
+			},
+			expectedFields: []string{"nodePools[1].name"},
+		},
+		{
+			description: "negative count",
+			pools: []NodePool{
+				{
+					Name:  "gpu",
+					Count: -1,
+				},
+			},
+			expectedFields: []string{"nodePools[0].count"},
+		},
+		{
+			description: "invalid label",
+			pools: []NodePool{
+				{
+					Name: "gpu",
+					Labels: map[string]string{
+						"accelerator": "nvidia tesla",
+					},
+				},
+			},
+			expectedFields: []string{"nodePools[0].labels.accelerator"},
+		},
+		{
+			description: "invalid taints",
+			pools: []NodePool{
+				{
+					Name:   "gpu",
+					Taints: []string{"dedicated=gpu", "dedicated=gpu:NoWay"},
+				},
+			},
+			expectedFields: []string{"nodePools[0].taints[0]", "nodePools[0].taints[1]"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		errs := ValidateNodePools(testCase.pools)
+
+		if len(errs) != len(testCase.expectedFields) {
+			t.Errorf("%s: wrong error count expected %d actual %d: %v",
+				testCase.description, len(testCase.expectedFields), len(errs), errs)
+			continue
+		}
+
+		for i, field := range testCase.expectedFields {
+			if errs[i].Field != field {
+				t.Errorf("%s: wrong field expected %s actual %s",
+					testCase.description, field, errs[i].Field)
+			}
+		}
+	}
+}
+
+func TestProfileWorkers(t *testing.T) {
+	p := Profile{
+		NodesProfiles: []NodeProfile{
+			{"size": "s-2vcpu-4gb"},
+		},
+		NodePools: []NodePool{
+			{
+				Name:    "gpu",
+				Count:   2,
+				Profile: NodeProfile{"size": "s-4vcpu-8gb"},
+			},
+			{
+				Name:    "empty",
+				Profile: NodeProfile{"size": "s-1vcpu-2gb"},
+			},
+		},
+	}
+
+	workers := p.Workers()
+
+	if len(workers) != 3 {
+		t.Fatalf("wrong worker count expected %d actual %d", 3, len(workers))
+	}
+
+	if workers[0].Pool != nil || workers[0].Profile["size"] != "s-2vcpu-4gb" {
+		t.Errorf("wrong first worker %v", workers[0])
+	}
+
+	for _, worker := range workers[1:] {
+		if worker.Pool == nil || worker.Pool.Name != "gpu" {
+			t.Errorf("wrong pool of worker %v", worker)
+			continue
+		}
+
+		if worker.Profile["size"] != "s-4vcpu-8gb" {
+			t.Errorf("wrong profile of worker %v", worker)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package profile
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const schemaVersion = "http://json-schema.org/draft-07/schema#"
+
+var numericRe = regexp.MustCompile("^[0-9]+$")
+
+// DONodeSpec describes a droplet in a DigitalOcean node profile.
+type DONodeSpec struct {
+	Size  string `json:"size" valid:"required" description:"droplet size slug, e.g. s-2vcpu-4gb"`
+	Image string `json:"image" valid:"required" description:"droplet image slug, e.g. ubuntu-16-04-x64"`
+}
+
+// AWSNodeSpec describes an EC2 instance in an AWS node profile.
+type AWSNodeSpec struct {
+	Size             string `json:"size" valid:"required" description:"EC2 instance type, e.g. m4.large"`
+	AvailabilityZone string `json:"availabilityZone" valid:"optional" description:"availability zone of the instance"`
+	Image            string `json:"image" valid:"optional" description:"AMI id, found automatically if empty"`
+	VolumeSize       string `json:"volumeSize" valid:"optional,numeric" description:"root volume size in GiB, 80 if empty"`
+	EbsOptimized     string `json:"ebsOptimized" valid:"optional,in(true|false)" description:"launch EBS optimized instance"`
+	HasPublicAddr    string `json:"hasPublicAddr" valid:"optional,in(true|false)" description:"assign public ip address"`
+}
+
+// GCENodeSpec describes a compute instance in a GCE node profile.
+type GCENodeSpec struct {
+	Size             string `json:"size" valid:"required" description:"machine type, e.g. n1-standard-2"`
+	AvailabilityZone string `json:"availabilityZone" valid:"optional" description:"zone of the instance"`
+	ImageFamily      string `json:"imageFamily" valid:"optional" description:"image family, e.g. ubuntu-1604-lts"`
+}
+
+// BareMetalNodeSpec describes an existing machine that is reachable over SSH.
+type BareMetalNodeSpec struct {
+	PublicIp  string `json:"publicIp" valid:"required" description:"address the machine is reachable at over SSH"`
+	PrivateIp string `json:"privateIp" valid:"optional" description:"address used within the cluster, public address if empty"`
+}
+
+var nodeSpecs = map[clouds.Name]reflect.Type{
+	clouds.DigitalOcean: reflect.TypeOf(DONodeSpec{}),
+	clouds.AWS:          reflect.TypeOf(AWSNodeSpec{}),
+	clouds.GCE:          reflect.TypeOf(GCENodeSpec{}),
+	clouds.BareMetal:    reflect.TypeOf(BareMetalNodeSpec{}),
+}
+
+// Schema is a subset of JSON schema used to describe node specs to UI and CLI.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+// specField is a parsed representation of a node spec struct field.
+type specField struct {
+	index       int
+	name        string
+	description string
+	required    bool
+	numeric     bool
+	enum        []string
+}
+
+// NodeSpecProviders returns providers that have typed node specs.
+func NodeSpecProviders() []clouds.Name {
+	providers := make([]clouds.Name, 0, len(nodeSpecs))
+	for provider := range nodeSpecs {
+		providers = append(providers, provider)
+	}
+
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i] < providers[j]
+	})
+
+	return providers
+}
+
+// NodeSpecSchema returns JSON schema of the provider node spec.
+func NodeSpecSchema(provider clouds.Name) (*Schema, error) {
+	specType, ok := nodeSpecs[provider]
+	if !ok {
+		return nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "node spec %s", provider)
+	}
+
+	additional := false
+	schema := &Schema{
+		Schema:               schemaVersion,
+		Title:                specType.Name(),
+		Description:          fmt.Sprintf("%s node profile", provider),
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		Required:             []string{},
+		AdditionalProperties: &additional,
+	}
+
+	for _, f := range specFields(specType) {
+		prop := &Schema{
+			Type:        "string",
+			Description: f.description,
+			Enum:        f.enum,
+		}
+		if f.numeric {
+			prop.Pattern = numericRe.String()
+		}
+
+		schema.Properties[f.name] = prop
+		if f.required {
+			schema.Required = append(schema.Required, f.name)
+		}
+	}
+
+	return schema, nil
+}
+
+// ParseNodeProfile converts node profile to a typed node spec of the provider,
+// e.g. *AWSNodeSpec. Unknown, missing and malformed keys are reported as
+// ValidationErrors with names prefixed by the field.
+func ParseNodeProfile(provider clouds.Name, field string, nodeProfile NodeProfile) (interface{}, error) {
+	specType, ok := nodeSpecs[provider]
+	if !ok {
+		return nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "node spec %s", provider)
+	}
+
+	errs := ValidationErrors{}
+	spec := reflect.New(specType)
+	known := make(map[string]struct{})
+
+	for _, f := range specFields(specType) {
+		known[f.name] = struct{}{}
+		name := fmt.Sprintf("%s.%s", field, f.name)
+		value, ok := nodeProfile[f.name]
+
+		switch {
+		case !ok || value == "":
+			if f.required {
+				errs.Add(name, "is required")
+			}
+			continue
+		case f.numeric && !numericRe.MatchString(value):
+			errs.Add(name, "must be a number, got %q", value)
+		case len(f.enum) > 0 && !oneOf(f.enum, value):
+			errs.Add(name, "must be one of %s, got %q", strings.Join(f.enum, ", "), value)
+		}
+
+		spec.Elem().Field(f.index).SetString(value)
+	}
+
+	unknown := make([]string, 0)
+	for key := range nodeProfile {
+		if _, ok := known[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, key := range unknown {
+		errs.Add(fmt.Sprintf("%s.%s", field, key), "unknown field for %s provider", provider)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return spec.Interface(), nil
+}
+
+func specFields(specType reflect.Type) []specField {
+	fields := make([]specField, 0, specType.NumField())
+
+	for i := 0; i < specType.NumField(); i++ {
+		structField := specType.Field(i)
+		f := specField{
+			index:       i,
+			name:        strings.Split(structField.Tag.Get("json"), ",")[0],
+			description: structField.Tag.Get("description"),
+		}
+
+		for _, rule := range strings.Split(structField.Tag.Get("valid"), ",") {
+			rule = strings.TrimSpace(rule)
+
+			switch {
+			case rule == "required":
+				f.required = true
+			case rule == "numeric":
+				f.numeric = true
+			case strings.HasPrefix(rule, "in(") && strings.HasSuffix(rule, ")"):
+				f.enum = strings.Split(rule[len("in("):len(rule)-1], "|")
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+func oneOf(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateNodeProfiles checks master and node profiles against the provider
+// node spec, providers without typed node specs are skipped.
+func ValidateNodeProfiles(p *Profile) ValidationErrors {
+	errs := ValidationErrors{}
+
+	if _, ok := nodeSpecs[p.Provider]; !ok {
+		return errs
+	}
+
+	for i, nodeProfile := range p.MasterProfiles {
+		field := fmt.Sprintf("masterProfiles[%d]", i)
+		if _, err := ParseNodeProfile(p.Provider, field, nodeProfile); err != nil {
+			if fieldErrs, ok := err.(ValidationErrors); ok {
+				errs = append(errs, fieldErrs...)
+			}
+		}
+	}
+
+	for i, nodeProfile := range p.NodesProfiles {
+		field := fmt.Sprintf("nodesProfiles[%d]", i)
+		if _, err := ParseNodeProfile(p.Provider, field, nodeProfile); err != nil {
+			if fieldErrs, ok := err.(ValidationErrors); ok {
+				errs = append(errs, fieldErrs...)
+			}
+		}
+	}
+
+	for i, pool := range p.NodePools {
+		field := fmt.Sprintf("nodePools[%d].profile", i)
+		if _, err := ParseNodeProfile(p.Provider, field, pool.Profile); err != nil {
+			if fieldErrs, ok := err.(ValidationErrors); ok {
+				errs = append(errs, fieldErrs...)
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,186 @@
+package profile
+
+import (
+	"testing"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestParseNodeProfile(t *testing.T) {
+	testCases := []struct {
+		description    string
+		provider       clouds.Name
+		nodeProfile    NodeProfile
+		expectedFields []string
+		expectedSpec   interface{}
+	}{
+		{
+			description: "digitalocean",
+			provider:    clouds.DigitalOcean,
+			nodeProfile: NodeProfile{
+				"size":  "s-2vcpu-4gb",
+				"image": "ubuntu-16-04-x64",
+			},
+			expectedSpec: DONodeSpec{
+				Size:  "s-2vcpu-4gb",
+				Image: "ubuntu-16-04-x64",
+			},
+		},
+		{
+			description: "aws",
+			provider:    clouds.AWS,
+			nodeProfile: NodeProfile{
+				"size":          "m4.large",
+				"volumeSize":    "80",
+				"ebsOptimized":  "true",
+				"hasPublicAddr": "false",
+			},
+			expectedSpec: AWSNodeSpec{
+				Size:          "m4.large",
+				VolumeSize:    "80",
+				EbsOptimized:  "true",
+				HasPublicAddr: "false",
+			},
+		},
+		{
+			description: "baremetal",
+			provider:    clouds.BareMetal,
+			nodeProfile: NodeProfile{
+				"publicIp": "10.0.0.10",
+			},
+			expectedSpec: BareMetalNodeSpec{
+				PublicIp: "10.0.0.10",
+			},
+		},
+		{
+			description: "missing and unknown keys",
+			provider:    clouds.DigitalOcean,
+			nodeProfile: NodeProfile{
+				"sise":  "s-2vcpu-4gb",
+				"imgae": "ubuntu-16-04-x64",
+			},
+			expectedFields: []string{"p.size", "p.image", "p.imgae", "p.sise"},
+		},
+		{
+			description: "malformed values",
+			provider:    clouds.AWS,
+			nodeProfile: NodeProfile{
+				"size":          "m4.large",
+				"volumeSize":    "80GB",
+				"hasPublicAddr": "yes",
+			},
+			expectedFields: []string{"p.volumeSize", "p.hasPublicAddr"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		spec, err := ParseNodeProfile(testCase.provider, "p", testCase.nodeProfile)
+
+		if len(testCase.expectedFields) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", testCase.description, err)
+				continue
+			}
+
+			switch s := spec.(type) {
+			case *DONodeSpec:
+				if *s != testCase.expectedSpec {
+					t.Errorf("%s: wrong spec expected %v actual %v",
+						testCase.description, testCase.expectedSpec, *s)
+				}
+			case *AWSNodeSpec:
+				if *s != testCase.expectedSpec {
+					t.Errorf("%s: wrong spec expected %v actual %v",
+						testCase.description, testCase.expectedSpec, *s)
+				}
+			case *BareMetalNodeSpec:
+				if *s != testCase.expectedSpec {
+					t.Errorf("%s: wrong spec expected %v actual %v",
+						testCase.description, testCase.expectedSpec, *s)
+				}
+			default:
+				t.Errorf("%s: unexpected spec type %T", testCase.description, spec)
+			}
+			continue
+		}
+
+		errs, ok := err.(ValidationErrors)
+		if !ok {
+			t.Errorf("%s: expected validation errors actual %v",
+				testCase.description, err)
+			continue
+		}
+
+		if len(errs) != len(testCase.expectedFields) {
+			t.Errorf("%s: wrong error count expected %d actual %d: %v",
+				testCase.description, len(testCase.expectedFields), len(errs), errs)
+			continue
+		}
+
+		for i, field := range testCase.expectedFields {
+			if errs[i].Field != field {
+				t.Errorf("%s: wrong field expected %s actual %s",
+					testCase.description, field, errs[i].Field)
+			}
+		}
+	}
+}
+
+func TestParseNodeProfileUnsupportedProvider(t *testing.T) {
+	_, err := ParseNodeProfile(clouds.Packet, "p", NodeProfile{})
+
+	if !sgerrors.IsUnsupportedProvider(err) {
+		t.Errorf("Wrong error expected %v actual %v",
+			sgerrors.ErrUnsupportedProvider, err)
+	}
+}
+
+func TestNodeSpecSchema(t *testing.T) {
+	schema, err := NodeSpecSchema(clouds.AWS)
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if len(schema.Required) != 1 || schema.Required[0] != "size" {
+		t.Errorf("Wrong required fields %v", schema.Required)
+	}
+
+	if schema.AdditionalProperties == nil || *schema.AdditionalProperties {
+		t.Errorf("Additional properties must be forbidden")
+	}
+
+	if len(schema.Properties["ebsOptimized"].Enum) != 2 {
+		t.Errorf("Wrong enum for ebsOptimized %v",
+			schema.Properties["ebsOptimized"].Enum)
+	}
+
+	if schema.Properties["volumeSize"].Pattern == "" {
+		t.Errorf("Pattern for volumeSize must not be empty")
+	}
+}
+
+func TestValidateNodeProfiles(t *testing.T) {
+	p := &Profile{
+		Provider: clouds.GCE,
+		MasterProfiles: []NodeProfile{
+			{"size": "n1-standard-2"},
+		},
+		NodesProfiles: []NodeProfile{
+			{"size": "n1-standard-2"},
+			{"availabilityZone": "us-east1-b"},
+		},
+	}
+
+	errs := ValidateNodeProfiles(p)
+
+	if len(errs) != 1 || errs[0].Field != "nodesProfiles[1].size" {
+		t.Errorf("Wrong validation errors %v", errs)
+	}
+
+	p.Provider = clouds.OpenStack
+	if errs := ValidateNodeProfiles(p); len(errs) != 0 {
+		t.Errorf("Unexpected errors for provider without node spec %v", errs)
+	}
+}
@@ -5,8 +5,18 @@ import "github.com/supergiant/control/pkg/clouds"
 type Profile struct {
 	ID string `json:"id" valid:"required"`
 
+	// BaseProfileID refers to a profile which settings are inherited,
+	// fields that are set in this profile take precedence.
+	BaseProfileID string `json:"baseProfileId,omitempty" valid:"-"`
+	// Parameters are substituted to string values of the profile written
+	// as templates, e.g. "region": "{{ .region }}".
+	Parameters []Parameter `json:"parameters,omitempty" valid:"-"`
+
 	MasterProfiles []NodeProfile `json:"masterProfiles" valid:"-"`
 	NodesProfiles  []NodeProfile `json:"nodesProfiles" valid:"-"`
+	// NodePools are named groups of workers that are provisioned
+	// in addition to NodesProfiles and can be scaled independently.
+	NodePools []NodePool `json:"nodePools,omitempty" valid:"-"`
 
 	// StaticAuth represents tokens and basic authentication credentials that
 	// would be set to kube-apiserver on start.
@@ -20,7 +30,7 @@ type Profile struct {
 
 	// TODO(stgleb): In future releases arch will probably migrate to node profile
 	// to allow user create heterogeneous cluster of machine with different arch
-	Provider        clouds.Name `json:"provider" valid:"in(aws|digitalocean|packet|gce|openstack)" valid:"-"`
+	Provider        clouds.Name `json:"provider" valid:"optional,in(aws|digitalocean|packet|gce|openstack|baremetal)"`
 	Region          string      `json:"region" valid:"-"`
 	Zone            string      `json:"zone" valid:"-"`
 	Arch            string      `json:"arch" valid:"-"`
@@ -39,6 +49,61 @@ type Profile struct {
 	CloudSpecificSettings  CloudSpecificSettings `json:"cloudSpecificSettings" valid:"-"`
 	PublicKey              string                `json:"publicKey" valid:"-"`
 	LogBootstrapPrivateKey bool                  `json:"logBootstrapPrivateKey" valid:"-"`
+
+	// RetryPolicies override retry policies of steps by step name.
+	RetryPolicies map[string]RetryPolicy `json:"retryPolicies,omitempty" valid:"-"`
+}
+
+// Parameter is a value that is provided when a cluster is provisioned
+// from the profile.
+type Parameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     string `json:"default"`
+	Required    bool   `json:"required"`
+}
+
+// NodePool is a named group of identical workers, nodes of the pool
+// are registered in kubernetes with labels and taints of the pool.
+type NodePool struct {
+	Name string `json:"name"`
+	// Count is a desired number of nodes in the pool.
+	Count   int               `json:"count"`
+	Profile NodeProfile       `json:"profile"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	// Taints are written in kubectl format key=value:Effect.
+	Taints []string `json:"taints,omitempty"`
+}
+
+// WorkerProfile is a node profile of a single worker,
+// Pool is nil for workers that don't belong to any pool.
+type WorkerProfile struct {
+	Pool    *NodePool
+	Profile NodeProfile
+}
+
+// Workers lists profiles of all workers of the cluster, NodesProfiles
+// go first followed by nodes of each pool.
+func (p Profile) Workers() []WorkerProfile {
+	workers := make([]WorkerProfile, 0, len(p.NodesProfiles))
+
+	for _, nodeProfile := range p.NodesProfiles {
+		workers = append(workers, WorkerProfile{
+			Profile: nodeProfile,
+		})
+	}
+
+	for i := range p.NodePools {
+		pool := &p.NodePools[i]
+		for j := 0; j < pool.Count; j++ {
+			workers = append(workers, WorkerProfile{
+				Pool:    pool,
+				Profile: pool.Profile,
+			})
+		}
+	}
+
+	return workers
 }
 
 type NodeProfile map[string]string
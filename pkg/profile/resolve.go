@@ -0,0 +1,356 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// maxInheritanceDepth limits the chain of base profiles.
+const maxInheritanceDepth = 10
+
+var parameterNameRe = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+
+type Getter interface {
+	Get(ctx context.Context, id string) (*Profile, error)
+}
+
+// Resolve merges the profile with all its base profiles and renders parameters
+// with values provided, the result does not depend on other profiles anymore.
+func Resolve(ctx context.Context, getter Getter, p *Profile, values map[string]string) (*Profile, error) {
+	if p == nil {
+		return nil, errors.New("nil profile")
+	}
+
+	resolved := copyProfile(p)
+	visited := map[string]struct{}{
+		p.ID: {},
+	}
+
+	for baseID := p.BaseProfileID; baseID != ""; {
+		errs := ValidationErrors{}
+		if _, ok := visited[baseID]; ok {
+			errs.Add("baseProfileId", "profile %s is inherited twice", baseID)
+			return nil, errs
+		}
+		if len(visited) > maxInheritanceDepth {
+			errs.Add("baseProfileId", "inheritance is deeper than %d profiles", maxInheritanceDepth)
+			return nil, errs
+		}
+		visited[baseID] = struct{}{}
+
+		base, err := getter.Get(ctx, baseID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get base profile %s", baseID)
+		}
+
+		resolved = Merge(base, resolved)
+		baseID = base.BaseProfileID
+	}
+
+	return Render(resolved, values)
+}
+
+// Merge returns a copy of the base profile overridden by the child profile.
+// Empty strings, slices and maps of the child are inherited from the base,
+// map entries are merged one by one.
+func Merge(base, child *Profile) *Profile {
+	merged := copyProfile(base)
+
+	merged.ID = child.ID
+	merged.BaseProfileID = child.BaseProfileID
+
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&merged.User, child.User},
+		{&merged.Password, child.Password},
+		{&merged.Region, child.Region},
+		{&merged.Zone, child.Zone},
+		{&merged.Arch, child.Arch},
+		{&merged.OperatingSystem, child.OperatingSystem},
+		{&merged.UbuntuVersion, child.UbuntuVersion},
+		{&merged.DockerVersion, child.DockerVersion},
+		{&merged.K8SVersion, child.K8SVersion},
+		{&merged.K8SServicesCIDR, child.K8SServicesCIDR},
+		{&merged.FlannelVersion, child.FlannelVersion},
+		{&merged.NetworkType, child.NetworkType},
+		{&merged.CIDR, child.CIDR},
+		{&merged.HelmVersion, child.HelmVersion},
+		{&merged.PublicKey, child.PublicKey},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+
+	if child.Provider != "" {
+		merged.Provider = child.Provider
+	}
+
+	// NOTE: false can't be distinguished from an unset value,
+	// so child profile can only turn these flags on.
+	merged.RBACEnabled = merged.RBACEnabled || child.RBACEnabled
+	merged.LogBootstrapPrivateKey = merged.LogBootstrapPrivateKey || child.LogBootstrapPrivateKey
+
+	if len(child.MasterProfiles) > 0 {
+		merged.MasterProfiles = copyNodeProfiles(child.MasterProfiles)
+	}
+	if len(child.NodesProfiles) > 0 {
+		merged.NodesProfiles = copyNodeProfiles(child.NodesProfiles)
+	}
+	if len(child.NodePools) > 0 {
+		merged.NodePools = copyNodePools(child.NodePools)
+	}
+	if len(child.StaticAuth.BasicAuth) > 0 {
+		merged.StaticAuth.BasicAuth = append([]BasicAuthUser(nil), child.StaticAuth.BasicAuth...)
+	}
+	if len(child.StaticAuth.Tokens) > 0 {
+		merged.StaticAuth.Tokens = append([]TokenAuthUser(nil), child.StaticAuth.Tokens...)
+	}
+
+	merged.Subnets = mergeMaps(merged.Subnets, child.Subnets)
+	merged.CloudSpecificSettings = mergeMaps(merged.CloudSpecificSettings, child.CloudSpecificSettings)
+	merged.RetryPolicies = mergeRetryPolicies(merged.RetryPolicies, child.RetryPolicies)
+
+	for _, param := range child.Parameters {
+		overridden := false
+		for i := range merged.Parameters {
+			if merged.Parameters[i].Name == param.Name {
+				merged.Parameters[i] = param
+				overridden = true
+			}
+		}
+		if !overridden {
+			merged.Parameters = append(merged.Parameters, param)
+		}
+	}
+
+	return merged
+}
+
+// Render substitutes parameters to the profile templates, parameters that are
+// not provided get default values. Rendered profile has no parameters.
+func Render(p *Profile, values map[string]string) (*Profile, error) {
+	data, errs := parameterValues(p.Parameters, values)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if len(p.Parameters) == 0 {
+		rendered := copyProfile(p)
+		rendered.Parameters = nil
+		return rendered, nil
+	}
+
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal profile")
+	}
+
+	tree := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &tree); err != nil {
+		return nil, errors.Wrap(err, "unmarshal profile")
+	}
+	delete(tree, "parameters")
+
+	renderValue("", tree, data, &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if raw, err = json.Marshal(tree); err != nil {
+		return nil, errors.Wrap(err, "marshal rendered profile")
+	}
+
+	rendered := &Profile{}
+	if err := json.Unmarshal(raw, rendered); err != nil {
+		return nil, errors.Wrap(err, "unmarshal rendered profile")
+	}
+
+	return rendered, nil
+}
+
+// ValidateParameters checks that parameter names are unique
+// and can be used in templates.
+func ValidateParameters(params []Parameter) ValidationErrors {
+	errs := ValidationErrors{}
+	names := make(map[string]struct{}, len(params))
+
+	for i, param := range params {
+		field := fmt.Sprintf("parameters[%d].name", i)
+
+		if !parameterNameRe.MatchString(param.Name) {
+			errs.Add(field, "%q is not a valid parameter name", param.Name)
+			continue
+		}
+		if _, ok := names[param.Name]; ok {
+			errs.Add(field, "duplicate parameter %s", param.Name)
+		}
+		names[param.Name] = struct{}{}
+	}
+
+	return errs
+}
+
+func parameterValues(params []Parameter, values map[string]string) (map[string]string, ValidationErrors) {
+	errs := ValidateParameters(params)
+	data := make(map[string]string, len(params))
+
+	for _, param := range params {
+		value, ok := values[param.Name]
+		if !ok {
+			if param.Required {
+				errs.Add("parameters."+param.Name, "is required")
+				continue
+			}
+			value = param.Default
+		}
+		data[param.Name] = value
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := data[name]; !ok {
+			errs.Add("parameters."+name, "unknown parameter")
+		}
+	}
+
+	return data, errs
+}
+
+func renderValue(path string, value interface{}, data map[string]string, errs *ValidationErrors) interface{} {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v
+		}
+
+		tpl, err := template.New(path).Option("missingkey=error").Parse(v)
+		if err != nil {
+			errs.Add(path, "invalid template: %v", err)
+			return v
+		}
+
+		buf := &bytes.Buffer{}
+		if err := tpl.Execute(buf, data); err != nil {
+			errs.Add(path, "render template: %v", err)
+			return v
+		}
+
+		return buf.String()
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			v[key] = renderValue(field, v[key], data, errs)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = renderValue(fmt.Sprintf("%s[%d]", path, i), v[i], data, errs)
+		}
+	}
+
+	return value
+}
+
+func copyProfile(p *Profile) *Profile {
+	c := *p
+
+	c.Parameters = append([]Parameter(nil), p.Parameters...)
+	c.MasterProfiles = copyNodeProfiles(p.MasterProfiles)
+	c.NodesProfiles = copyNodeProfiles(p.NodesProfiles)
+	c.NodePools = copyNodePools(p.NodePools)
+	c.StaticAuth.BasicAuth = append([]BasicAuthUser(nil), p.StaticAuth.BasicAuth...)
+	c.StaticAuth.Tokens = append([]TokenAuthUser(nil), p.StaticAuth.Tokens...)
+	c.Subnets = mergeMaps(nil, p.Subnets)
+	c.CloudSpecificSettings = mergeMaps(nil, p.CloudSpecificSettings)
+	c.RetryPolicies = mergeRetryPolicies(nil, p.RetryPolicies)
+
+	return &c
+}
+
+func copyNodeProfiles(nodeProfiles []NodeProfile) []NodeProfile {
+	if nodeProfiles == nil {
+		return nil
+	}
+
+	c := make([]NodeProfile, 0, len(nodeProfiles))
+	for _, nodeProfile := range nodeProfiles {
+		c = append(c, mergeMaps(nil, nodeProfile))
+	}
+
+	return c
+}
+
+func copyNodePools(pools []NodePool) []NodePool {
+	if pools == nil {
+		return nil
+	}
+
+	c := make([]NodePool, 0, len(pools))
+	for _, pool := range pools {
+		pool.Profile = mergeMaps(nil, pool.Profile)
+		pool.Labels = mergeMaps(nil, pool.Labels)
+		pool.Taints = append([]string(nil), pool.Taints...)
+		c = append(c, pool)
+	}
+
+	return c
+}
+
+// mergeMaps returns a new map with entries of a overridden by entries of b.
+func mergeMaps(a, b map[string]string) map[string]string {
+	if a == nil && b == nil {
+		return nil
+	}
+
+	merged := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// mergeRetryPolicies merges policies step by step, a policy of the child
+// replaces the whole policy of the base.
+func mergeRetryPolicies(base, child map[string]RetryPolicy) map[string]RetryPolicy {
+	if base == nil && child == nil {
+		return nil
+	}
+
+	merged := make(map[string]RetryPolicy, len(base)+len(child))
+	for _, policies := range []map[string]RetryPolicy{base, child} {
+		for name, policy := range policies {
+			policy.RetryOn = append([]string(nil), policy.RetryOn...)
+			merged[name] = policy
+		}
+	}
+
+	return merged
+}
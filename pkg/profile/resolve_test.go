@@ -0,0 +1,249 @@
+package profile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+type mockGetter map[string]*Profile
+
+func (m mockGetter) Get(ctx context.Context, id string) (*Profile, error) {
+	p, ok := m[id]
+	if !ok {
+		return nil, sgerrors.ErrNotFound
+	}
+
+	return p, nil
+}
+
+func TestMerge(t *testing.T) {
+	base := &Profile{
+		ID:          "base",
+		Provider:    clouds.DigitalOcean,
+		Region:      "fra1",
+		K8SVersion:  "1.11.5",
+		RBACEnabled: true,
+		MasterProfiles: []NodeProfile{
+			{"size": "s-2vcpu-4gb"},
+		},
+		NodesProfiles: []NodeProfile{
+			{"size": "s-2vcpu-4gb"},
+		},
+		CloudSpecificSettings: CloudSpecificSettings{
+			"a": "base",
+			"b": "base",
+		},
+		Parameters: []Parameter{
+			{Name: "size", Default: "s-1vcpu-2gb"},
+		},
+		RetryPolicies: map[string]RetryPolicy{
+			"docker": {MaxAttempts: 5},
+			"cni":    {MaxAttempts: 2},
+		},
+	}
+	child := &Profile{
+		ID:            "child",
+		BaseProfileID: "base",
+		Region:        "nyc1",
+		NodesProfiles: []NodeProfile{
+			{"size": "s-4vcpu-8gb"},
+			{"size": "s-4vcpu-8gb"},
+		},
+		CloudSpecificSettings: CloudSpecificSettings{
+			"b": "child",
+		},
+		Parameters: []Parameter{
+			{Name: "size", Default: "s-2vcpu-4gb"},
+			{Name: "count", Default: "1"},
+		},
+		RetryPolicies: map[string]RetryPolicy{
+			"docker": {Backoff: "1m"},
+		},
+	}
+
+	merged := Merge(base, child)
+
+	if merged.ID != child.ID || merged.BaseProfileID != child.BaseProfileID {
+		t.Errorf("wrong ids %s %s", merged.ID, merged.BaseProfileID)
+	}
+
+	if merged.Region != "nyc1" || merged.K8SVersion != "1.11.5" ||
+		merged.Provider != clouds.DigitalOcean || !merged.RBACEnabled {
+		t.Errorf("wrong fields inherited %+v", merged)
+	}
+
+	if len(merged.MasterProfiles) != 1 || len(merged.NodesProfiles) != 2 {
+		t.Errorf("wrong node profiles inherited masters %d nodes %d",
+			len(merged.MasterProfiles), len(merged.NodesProfiles))
+	}
+
+	if merged.CloudSpecificSettings["a"] != "base" || merged.CloudSpecificSettings["b"] != "child" {
+		t.Errorf("wrong cloud specific settings %v", merged.CloudSpecificSettings)
+	}
+
+	if len(merged.Parameters) != 2 || merged.Parameters[0].Default != "s-2vcpu-4gb" {
+		t.Errorf("wrong parameters %v", merged.Parameters)
+	}
+
+	if docker := merged.RetryPolicies["docker"]; docker.Backoff != "1m" || docker.MaxAttempts != 0 ||
+		merged.RetryPolicies["cni"].MaxAttempts != 2 {
+		t.Errorf("wrong retry policies %v", merged.RetryPolicies)
+	}
+
+	merged.MasterProfiles[0]["size"] = "changed"
+	if base.MasterProfiles[0]["size"] == "changed" {
+		t.Errorf("base profile must not be modified")
+	}
+}
+
+func TestRender(t *testing.T) {
+	p := &Profile{
+		ID:     "template",
+		Region: "{{ .region }}",
+		NodesProfiles: []NodeProfile{
+			{"size": "{{ .size }}", "image": "ubuntu-16-04-x64"},
+		},
+		Parameters: []Parameter{
+			{Name: "region", Required: true},
+			{Name: "size", Default: "s-2vcpu-4gb"},
+		},
+	}
+
+	testCases := []struct {
+		description    string
+		profile        *Profile
+		values         map[string]string
+		expectedRegion string
+		expectedSize   string
+		expectedFields []string
+	}{
+		{
+			description:    "defaults",
+			profile:        p,
+			values:         map[string]string{"region": "fra1"},
+			expectedRegion: "fra1",
+			expectedSize:   "s-2vcpu-4gb",
+		},
+		{
+			description:    "override default",
+			profile:        p,
+			values:         map[string]string{"region": "fra1", "size": "s-4vcpu-8gb"},
+			expectedRegion: "fra1",
+			expectedSize:   "s-4vcpu-8gb",
+		},
+		{
+			description:    "missing required and unknown parameters",
+			profile:        p,
+			values:         map[string]string{"zone": "a"},
+			expectedFields: []string{"parameters.region", "parameters.zone"},
+		},
+		{
+			description: "undeclared parameter in template",
+			profile: &Profile{
+				Region:     "{{ .region }}",
+				Zone:       "{{ .zone }}",
+				Parameters: []Parameter{{Name: "region"}},
+			},
+			expectedFields: []string{"zone"},
+		},
+		{
+			description: "invalid parameter name",
+			profile: &Profile{
+				Parameters: []Parameter{{Name: "node-size"}},
+			},
+			expectedFields: []string{"parameters[0].name"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		rendered, err := Render(testCase.profile, testCase.values)
+
+		if len(testCase.expectedFields) > 0 {
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Errorf("%s: expected validation errors actual %v", testCase.description, err)
+				continue
+			}
+
+			if len(errs) != len(testCase.expectedFields) {
+				t.Errorf("%s: wrong error count expected %d actual %v",
+					testCase.description, len(testCase.expectedFields), errs)
+				continue
+			}
+
+			for i, field := range testCase.expectedFields {
+				if errs[i].Field != field {
+					t.Errorf("%s: wrong field expected %s actual %s",
+						testCase.description, field, errs[i].Field)
+				}
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		if rendered.Region != testCase.expectedRegion {
+			t.Errorf("%s: wrong region expected %s actual %s",
+				testCase.description, testCase.expectedRegion, rendered.Region)
+		}
+
+		if size := rendered.NodesProfiles[0]["size"]; size != testCase.expectedSize {
+			t.Errorf("%s: wrong size expected %s actual %s",
+				testCase.description, testCase.expectedSize, size)
+		}
+
+		if len(rendered.Parameters) != 0 {
+			t.Errorf("%s: rendered profile must not have parameters", testCase.description)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	getter := mockGetter{
+		"root": {
+			ID:         "root",
+			Provider:   clouds.DigitalOcean,
+			K8SVersion: "1.11.5",
+			Region:     "{{ .region }}",
+			Parameters: []Parameter{{Name: "region", Required: true}},
+		},
+		"regional": {
+			ID:            "regional",
+			BaseProfileID: "root",
+			Parameters:    []Parameter{{Name: "region", Default: "fra1"}},
+		},
+		"loop-a": {ID: "loop-a", BaseProfileID: "loop-b"},
+		"loop-b": {ID: "loop-b", BaseProfileID: "loop-a"},
+	}
+
+	resolved, err := Resolve(context.Background(), getter, &Profile{
+		BaseProfileID: "regional",
+		K8SVersion:    "1.12.3",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if resolved.Region != "fra1" || resolved.K8SVersion != "1.12.3" ||
+		resolved.Provider != clouds.DigitalOcean || resolved.BaseProfileID != "regional" {
+		t.Errorf("wrong resolved profile %+v", resolved)
+	}
+
+	if _, err := Resolve(context.Background(), getter, &Profile{
+		BaseProfileID: "missing",
+	}, nil); !sgerrors.IsNotFound(err) {
+		t.Errorf("expected not found error actual %v", err)
+	}
+
+	if _, err := Resolve(context.Background(), getter, &Profile{
+		BaseProfileID: "loop-a",
+	}, nil); err == nil {
+		t.Errorf("expected error for inheritance cycle")
+	}
+}
@@ -0,0 +1,59 @@
+package profile
+
+import (
+	"sort"
+	"time"
+)
+
+// RetryPolicy overrides how a failed step is retried, empty fields
+// keep the policy declared by the step.
+type RetryPolicy struct {
+	// MaxAttempts is how many times the step runs at most, 1 disables retries.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// Backoff is a delay before the second attempt, e.g. "10s",
+	// it doubles with every next attempt.
+	Backoff string `json:"backoff,omitempty"`
+	// MaxBackoff limits the delay between attempts.
+	MaxBackoff string `json:"maxBackoff,omitempty"`
+	// RetryOn are parts of messages of errors that are worth retrying,
+	// e.g. "RequestLimitExceeded".
+	RetryOn []string `json:"retryOn,omitempty"`
+}
+
+// ValidateRetryPolicies checks attempts and delays of retry policies,
+// policies are keyed by names of steps.
+func ValidateRetryPolicies(policies map[string]RetryPolicy) ValidationErrors {
+	errs := ValidationErrors{}
+
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		policy := policies[name]
+		prefix := "retryPolicies." + name
+
+		if policy.MaxAttempts < 0 {
+			errs.Add(prefix+".maxAttempts", "must not be negative, got %d", policy.MaxAttempts)
+		}
+
+		for _, f := range []struct {
+			field string
+			value string
+		}{
+			{".backoff", policy.Backoff},
+			{".maxBackoff", policy.MaxBackoff},
+		} {
+			if f.value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(f.value); err != nil || d < 0 {
+				errs.Add(prefix+f.field, "%q is not a valid duration", f.value)
+			}
+		}
+	}
+
+	return errs
+}
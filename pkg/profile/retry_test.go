@@ -0,0 +1,58 @@
+package profile
+
+import (
+	"testing"
+)
+
+func TestValidateRetryPolicies(t *testing.T) {
+	testCases := []struct {
+		description    string
+		policies       map[string]RetryPolicy
+		expectedFields []string
+	}{
+		{
+			description: "valid",
+			policies: map[string]RetryPolicy{
+				"docker": {
+					MaxAttempts: 5,
+					Backoff:     "10s",
+					MaxBackoff:  "2m",
+					RetryOn:     []string{"Could not get lock"},
+				},
+				"cni": {},
+			},
+		},
+		{
+			description: "negative attempts",
+			policies: map[string]RetryPolicy{
+				"docker": {MaxAttempts: -1},
+			},
+			expectedFields: []string{"retryPolicies.docker.maxAttempts"},
+		},
+		{
+			description: "invalid durations",
+			policies: map[string]RetryPolicy{
+				"docker": {Backoff: "ten seconds"},
+				"cni":    {MaxBackoff: "-1m"},
+			},
+			expectedFields: []string{"retryPolicies.cni.maxBackoff", "retryPolicies.docker.backoff"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		errs := ValidateRetryPolicies(testCase.policies)
+
+		if len(errs) != len(testCase.expectedFields) {
+			t.Errorf("%s: wrong error count expected %d actual %d: %v",
+				testCase.description, len(testCase.expectedFields), len(errs), errs)
+			continue
+		}
+
+		for i, field := range testCase.expectedFields {
+			if errs[i].Field != field {
+				t.Errorf("%s: wrong field expected %s actual %s",
+					testCase.description, field, errs[i].Field)
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes a problem with a particular field of a profile.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors holds all problems that have been found in a profile,
+// so user can fix them at once instead of failing one by one.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message))
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Add appends an error for the field with formatted message.
+func (e *ValidationErrors) Add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
@@ -0,0 +1,284 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/asaskevich/govalidator.v8"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// clonedCloudSpec lists cloud specific settings that are chosen by user,
+// the rest of them refer to resources created for the kube.
+var clonedCloudSpec = []string{
+	clouds.AwsAZ,
+	clouds.AwsVpcCIDR,
+}
+
+// CloneRequest either saves profile of the kube as ProfileID or
+// provisions a new kube named ClusterName from it. Kube is provisioned
+// with the account of the cloned kube if CloudAccountName is empty.
+type CloneRequest struct {
+	ProfileID        string `json:"profileId" valid:"-"`
+	ClusterName      string `json:"clusterName" valid:"optional,matches(^[A-Za-z0-9-]+$)"`
+	CloudAccountName string `json:"cloudAccountName" valid:"-"`
+}
+
+// ProfileFromKube reconstructs a profile the kube could be provisioned
+// from again. Sizes of nodes are taken from nodes of the kube, the rest of
+// node settings come from node profiles it has been provisioned with.
+// Credentials and resources created for the kube are not copied.
+func ProfileFromKube(k *model.Kube) *profile.Profile {
+	p := &profile.Profile{
+		Provider:        k.Provider,
+		Region:          k.Region,
+		Zone:            k.Zone,
+		Arch:            k.Arch,
+		OperatingSystem: k.OperatingSystem,
+		UbuntuVersion:   k.OperatingSystemVersion,
+		DockerVersion:   k.DockerVersion,
+		K8SVersion:      k.K8SVersion,
+		K8SServicesCIDR: k.ServicesCIDR,
+		FlannelVersion:  k.Networking.Version,
+		NetworkType:     k.Networking.Type,
+		CIDR:            k.Networking.CIDR,
+		HelmVersion:     k.HelmVersion,
+		RBACEnabled:     k.RBACEnabled,
+		PublicKey:       string(k.SshPublicKey),
+
+		CloudSpecificSettings: profile.CloudSpecificSettings{},
+		MasterProfiles:        []profile.NodeProfile{},
+		NodesProfiles:         []profile.NodeProfile{},
+	}
+
+	var masterTemplates, nodeTemplates []profile.NodeProfile
+	if k.Profile != nil {
+		// Settings of the original profile have been chosen by user
+		for key, value := range k.Profile.CloudSpecificSettings {
+			p.CloudSpecificSettings[key] = value
+		}
+		if len(k.Profile.Subnets) > 0 {
+			p.Subnets = make(map[string]string, len(k.Profile.Subnets))
+			for az, subnet := range k.Profile.Subnets {
+				p.Subnets[az] = subnet
+			}
+		}
+		p.LogBootstrapPrivateKey = k.Profile.LogBootstrapPrivateKey
+
+		masterTemplates = k.Profile.MasterProfiles
+		nodeTemplates = k.Profile.NodesProfiles
+	} else {
+		for _, key := range clonedCloudSpec {
+			if value, ok := k.CloudSpec[key]; ok {
+				p.CloudSpecificSettings[key] = value
+			}
+		}
+	}
+
+	for _, n := range clonedNodes(k.Masters) {
+		p.MasterProfiles = append(p.MasterProfiles, nodeProfileFor(n, masterTemplates))
+	}
+	for _, n := range clonedNodes(k.Nodes) {
+		// Nodes of pools are provisioned due to the pool
+		if n.Pool != "" {
+			continue
+		}
+		p.NodesProfiles = append(p.NodesProfiles, nodeProfileFor(n, nodeTemplates))
+	}
+
+	poolNames := make([]string, 0, len(k.NodePools))
+	for name := range k.NodePools {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+
+	for _, name := range poolNames {
+		pool := *k.NodePools[name]
+		pool.Profile = copyNodeProfile(pool.Profile)
+		pool.Labels = copyMap(pool.Labels)
+		pool.Taints = append([]string(nil), pool.Taints...)
+		p.NodePools = append(p.NodePools, pool)
+	}
+
+	return p
+}
+
+// clonedNodes returns nodes sorted by name, nodes that are being
+// deleted are skipped.
+func clonedNodes(nodes map[string]*node.Node) []*node.Node {
+	cloned := make([]*node.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.State == node.StateDeleting {
+			continue
+		}
+		cloned = append(cloned, n)
+	}
+
+	sort.Slice(cloned, func(i, j int) bool {
+		return cloned[i].Name < cloned[j].Name
+	})
+
+	return cloned
+}
+
+// nodeProfileFor copies the node profile of the same size as the node
+// or the first one, size of the node is set to the copy.
+func nodeProfileFor(n *node.Node, templates []profile.NodeProfile) profile.NodeProfile {
+	var template profile.NodeProfile
+	for _, t := range templates {
+		if t["size"] == n.Size {
+			template = t
+			break
+		}
+	}
+	if template == nil && len(templates) > 0 {
+		template = templates[0]
+	}
+
+	nodeProfile := copyNodeProfile(template)
+	if n.Size != "" {
+		nodeProfile["size"] = n.Size
+	}
+
+	return nodeProfile
+}
+
+func copyNodeProfile(nodeProfile profile.NodeProfile) profile.NodeProfile {
+	return profile.NodeProfile(copyMap(nodeProfile))
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for key, value := range m {
+		c[key] = value
+	}
+
+	return c
+}
+
+// cloneableKube returns the kube if it has been provisioned by supergiant.
+func (h *Handler) cloneableKube(w http.ResponseWriter, r *http.Request) (*model.Kube, bool) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.kubeGetter.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return nil, false
+		}
+		message.SendUnknownError(w, err)
+		return nil, false
+	}
+
+	if k.State == model.StateImported {
+		http.Error(w, "kube "+k.ID+" is imported, it can't be cloned", http.StatusConflict)
+		return nil, false
+	}
+
+	return k, true
+}
+
+// GetKubeProfile returns a profile the kube would be cloned with.
+func (h *Handler) GetKubeProfile(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.cloneableKube(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(ProfileFromKube(k)); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// Clone saves profile of the kube or provisions a new kube from it.
+func (h *Handler) Clone(w http.ResponseWriter, r *http.Request) {
+	req := &CloneRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if ok, err := govalidator.ValidateStruct(req); !ok {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if (req.ProfileID == "") == (req.ClusterName == "") {
+		message.SendValidationFailed(w, errors.New("either profileId or clusterName must be set"))
+		return
+	}
+
+	k, ok := h.cloneableKube(w, r)
+	if !ok {
+		return
+	}
+	p := ProfileFromKube(k)
+
+	if req.ProfileID != "" {
+		// Profile of the same ID would be overwritten
+		_, err := h.profileGetter.Get(r.Context(), req.ProfileID)
+		if err == nil {
+			message.SendAlreadyExists(w, req.ProfileID, sgerrors.ErrAlreadyExists)
+			return
+		}
+		if !sgerrors.IsNotFound(err) {
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		p.ID = req.ProfileID
+		if err := h.profileCreator.Create(r.Context(), p); err != nil {
+			logrus.Error(errors.Wrapf(err, "save profile %s", p.ID))
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			logrus.Error(errors.Wrap(err, "marshal json"))
+		}
+		return
+	}
+
+	// NOTE: machines of bare metal kubes can't be provisioned twice
+	if k.Provider == clouds.BareMetal {
+		http.Error(w, "machines of bare metal kube "+k.ID+" can't be cloned", http.StatusConflict)
+		return
+	}
+
+	if req.CloudAccountName == "" {
+		req.CloudAccountName = k.AccountName
+	}
+
+	acc, err := h.accountGetter.Get(r.Context(), req.CloudAccountName)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, req.CloudAccountName, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if acc.Provider != k.Provider {
+		message.SendValidationFailed(w, errors.Errorf("account %s of provider %s can't provision %s kube",
+			acc.Name, acc.Provider, k.Provider))
+		return
+	}
+
+	h.provision(w, r, &ProvisionRequest{
+		ClusterName:      req.ClusterName,
+		Profile:          *p,
+		CloudAccountName: req.CloudAccountName,
+	})
+}
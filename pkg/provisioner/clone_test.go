@@ -0,0 +1,301 @@
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type mockProfileCreator struct {
+	created *profile.Profile
+}
+
+func (m *mockProfileCreator) Create(ctx context.Context, p *profile.Profile) error {
+	m.created = p
+	return nil
+}
+
+func cloneKube() *model.Kube {
+	original := validProfile()
+	original.ID = "original"
+	original.User = "admin"
+	original.Password = "secret"
+
+	return &model.Kube{
+		ID:           "1234abcd",
+		Name:         "prod-east",
+		State:        model.StateOperational,
+		Provider:     clouds.DigitalOcean,
+		AccountName:  "do",
+		Region:       "fra1",
+		K8SVersion:   "1.12.7",
+		ServicesCIDR: DefaultK8SServicesCIDR,
+
+		Arch:                   "amd64",
+		OperatingSystem:        "linux",
+		OperatingSystemVersion: "xenial",
+		DockerVersion:          "17.06.0",
+		HelmVersion:            "2.11.0",
+		Networking: model.Networking{
+			Version: "0.10.0",
+			Type:    "vxlan",
+			CIDR:    "10.0.0.0/16",
+		},
+		Profile: &original,
+		Masters: map[string]*node.Node{
+			"master-1": {Name: "master-1", Size: "s-2vcpu-4gb"},
+		},
+		Nodes: map[string]*node.Node{
+			"node-2": {Name: "node-2", Size: "s-4vcpu-8gb"},
+			"node-1": {Name: "node-1", Size: "s-2vcpu-4gb"},
+			"node-3": {Name: "node-3", Size: "s-2vcpu-4gb", State: node.StateDeleting},
+			"gpu-1":  {Name: "gpu-1", Size: "g-2vcpu-8gb", Pool: "gpu"},
+		},
+		NodePools: map[string]*profile.NodePool{
+			"gpu": {
+				Name:  "gpu",
+				Count: 1,
+				Profile: profile.NodeProfile{
+					"size":  "g-2vcpu-8gb",
+					"image": "ubuntu-16-04-x64",
+				},
+			},
+		},
+	}
+}
+
+func TestProfileFromKube(t *testing.T) {
+	k := cloneKube()
+	p := ProfileFromKube(k)
+
+	if p.ID != "" || p.User != "" || p.Password != "" {
+		t.Errorf("id and credentials must not be cloned %v", p)
+	}
+	if p.K8SVersion != k.K8SVersion {
+		t.Errorf("expected version %s actual %s", k.K8SVersion, p.K8SVersion)
+	}
+	if len(p.MasterProfiles) != 1 {
+		t.Fatalf("expected 1 master actual %v", p.MasterProfiles)
+	}
+
+	expectedNodes := []profile.NodeProfile{
+		{"size": "s-2vcpu-4gb", "image": "ubuntu-16-04-x64"},
+		{"size": "s-4vcpu-8gb", "image": "ubuntu-16-04-x64"},
+	}
+	if len(p.NodesProfiles) != len(expectedNodes) {
+		t.Fatalf("expected nodes %v actual %v", expectedNodes, p.NodesProfiles)
+	}
+	for i, expected := range expectedNodes {
+		for key, value := range expected {
+			if p.NodesProfiles[i][key] != value {
+				t.Errorf("node %d: expected %s=%s actual %v", i, key, value, p.NodesProfiles[i])
+			}
+		}
+	}
+
+	if len(p.NodePools) != 1 || p.NodePools[0].Name != "gpu" {
+		t.Fatalf("expected pool gpu actual %v", p.NodePools)
+	}
+
+	// Profiles of the clone must not share maps with the kube
+	p.NodesProfiles[1]["size"] = "changed"
+	p.NodePools[0].Profile["size"] = "changed"
+	if k.Profile.NodesProfiles[0]["size"] == "changed" || k.NodePools["gpu"].Profile["size"] == "changed" {
+		t.Errorf("kube has been modified")
+	}
+
+	if err := ValidateProfile(ProfileFromKube(k)); err != nil {
+		t.Errorf("unexpected validation error %v", err)
+	}
+}
+
+func TestProfileFromKubeWithoutProfile(t *testing.T) {
+	k := cloneKube()
+	k.Provider = clouds.AWS
+	k.Profile = nil
+	k.CloudSpec = profile.CloudSpecificSettings{
+		clouds.AwsVpcCIDR: "10.2.0.0/16",
+		clouds.AwsVpcID:   "vpc-1",
+	}
+
+	p := ProfileFromKube(k)
+	if p.CloudSpecificSettings[clouds.AwsVpcCIDR] != "10.2.0.0/16" {
+		t.Errorf("vpc cidr must be cloned %v", p.CloudSpecificSettings)
+	}
+	if _, ok := p.CloudSpecificSettings[clouds.AwsVpcID]; ok {
+		t.Errorf("vpc of the kube must not be cloned %v", p.CloudSpecificSettings)
+	}
+	if p.MasterProfiles[0]["size"] != "s-2vcpu-4gb" {
+		t.Errorf("size of master must be cloned %v", p.MasterProfiles[0])
+	}
+}
+
+func TestClone(t *testing.T) {
+	testCases := []struct {
+		description  string
+		kubeID       string
+		body         string
+		state        model.KubeState
+		account      *model.CloudAccount
+		expectedCode int
+	}{
+		{
+			description:  "invalid json",
+			kubeID:       "1234abcd",
+			body:         "{",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "neither profile nor cluster name",
+			kubeID:       "1234abcd",
+			body:         `{}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "kube not found",
+			kubeID:       "missing",
+			body:         `{"profileId":"staging"}`,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description:  "imported kube",
+			kubeID:       "1234abcd",
+			body:         `{"profileId":"staging"}`,
+			state:        model.StateImported,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description:  "profile exists",
+			kubeID:       "1234abcd",
+			body:         `{"profileId":"production"}`,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			description:  "save profile",
+			kubeID:       "1234abcd",
+			body:         `{"profileId":"staging"}`,
+			expectedCode: http.StatusCreated,
+		},
+		{
+			description:  "account of another provider",
+			kubeID:       "1234abcd",
+			body:         `{"clusterName":"staging","cloudAccountName":"aws"}`,
+			account:      &model.CloudAccount{Name: "aws", Provider: clouds.AWS},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "provision",
+			kubeID:       "1234abcd",
+			body:         `{"clusterName":"staging"}`,
+			account:      &model.CloudAccount{Name: "do", Provider: clouds.DigitalOcean},
+			expectedCode: http.StatusAccepted,
+		},
+	}
+
+	for _, testCase := range testCases {
+		creator := &mockProfileCreator{}
+		var provisioned *steps.Config
+
+		h := &Handler{
+			kubeGetter: &mockKubeGetter{
+				get: func(ctx context.Context, id string) (*model.Kube, error) {
+					if id != "1234abcd" {
+						return nil, sgerrors.ErrNotFound
+					}
+					k := cloneKube()
+					if testCase.state != "" {
+						k.State = testCase.state
+					}
+					return k, nil
+				},
+			},
+			accountGetter: &mockAccountGetter{
+				get: func(context.Context, string) (*model.CloudAccount, error) {
+					return testCase.account, nil
+				},
+			},
+			profileGetter: &mockProfileGetter{
+				get: func(ctx context.Context, id string) (*profile.Profile, error) {
+					if id != "production" {
+						return nil, sgerrors.ErrNotFound
+					}
+					return &profile.Profile{ID: id}, nil
+				},
+			},
+			profileCreator: creator,
+			provisioner: &mockProvisioner{
+				provisionCluster: func(ctx context.Context, p *profile.Profile, config *steps.Config) (map[string][]*workflows.Task, error) {
+					provisioned = config
+					return map[string][]*workflows.Task{}, nil
+				},
+			},
+		}
+
+		router := mux.NewRouter()
+		h.Register(router)
+
+		req, _ := http.NewRequest(http.MethodPost, "/kubes/"+testCase.kubeID+"/clone",
+			bytes.NewBufferString(testCase.body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong status code expected %d actual %d %s",
+				testCase.description, testCase.expectedCode, rec.Code, rec.Body.String())
+			continue
+		}
+
+		switch rec.Code {
+		case http.StatusCreated:
+			if creator.created == nil || creator.created.ID != "staging" {
+				t.Errorf("%s: profile staging has not been saved %v", testCase.description, creator.created)
+			}
+		case http.StatusAccepted:
+			if provisioned == nil || provisioned.ClusterName != "staging" {
+				t.Errorf("%s: kube staging has not been provisioned", testCase.description)
+			}
+		}
+	}
+}
+
+func TestGetKubeProfile(t *testing.T) {
+	h := &Handler{
+		kubeGetter: &mockKubeGetter{
+			get: func(context.Context, string) (*model.Kube, error) {
+				return cloneKube(), nil
+			},
+		},
+	}
+
+	router := mux.NewRouter()
+	h.Register(router)
+
+	req, _ := http.NewRequest(http.MethodGet, "/kubes/1234abcd/clone", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("wrong status code expected %d actual %d", http.StatusOK, rec.Code)
+	}
+
+	p := &profile.Profile{}
+	if err := json.NewDecoder(rec.Body).Decode(p); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(p.NodesProfiles) != 2 {
+		t.Errorf("expected 2 node profiles actual %v", p.NodesProfiles)
+	}
+}
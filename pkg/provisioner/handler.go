@@ -34,16 +34,31 @@ type KubeGetter interface {
 	Get(ctx context.Context, name string) (*model.Kube, error)
 }
 
+// ProfileService gets base profiles and saves profiles of cloned kubes.
+type ProfileService interface {
+	profile.Getter
+	Create(context.Context, *profile.Profile) error
+}
+
+type profileCreator interface {
+	Create(context.Context, *profile.Profile) error
+}
+
 type Handler struct {
-	accountGetter AccountGetter
-	kubeGetter    KubeGetter
-	provisioner   ClusterProvisioner
+	accountGetter  AccountGetter
+	kubeGetter     KubeGetter
+	profileGetter  profile.Getter
+	profileCreator profileCreator
+	provisioner    ClusterProvisioner
 }
 
 type ProvisionRequest struct {
 	ClusterName      string          `json:"clusterName" valid:"matches(^[A-Za-z0-9-]+$)"`
 	Profile          profile.Profile `json:"profile" valid:"-"`
 	CloudAccountName string          `json:"cloudAccountName" valid:"-"`
+	// ProfileID is a stored profile the request profile is based on.
+	ProfileID  string            `json:"profileId" valid:"-"`
+	Parameters map[string]string `json:"parameters" valid:"-"`
 }
 
 type ProvisionResponse struct {
@@ -55,16 +70,68 @@ type ClusterProvisioner interface {
 	ProvisionCluster(context.Context, *profile.Profile, *steps.Config) (map[string][]*workflows.Task, error)
 }
 
-func NewHandler(kubeService KubeGetter, cloudAccountService *account.Service, provisioner ClusterProvisioner) *Handler {
+func NewHandler(kubeService KubeGetter, cloudAccountService *account.Service,
+	profileService ProfileService, provisioner ClusterProvisioner) *Handler {
 	return &Handler{
-		kubeGetter:    kubeService,
-		accountGetter: cloudAccountService,
-		provisioner:   provisioner,
+		kubeGetter:     kubeService,
+		accountGetter:  cloudAccountService,
+		profileGetter:  profileService,
+		profileCreator: profileService,
+		provisioner:    provisioner,
 	}
 }
 
 func (h *Handler) Register(m *mux.Router) {
 	m.HandleFunc("/provision", h.Provision).Methods(http.MethodPost)
+	m.HandleFunc("/kubeprofiles/validate", h.ValidateProfile).Methods(http.MethodPost)
+	m.HandleFunc("/kubes/{kubeID}/clone", h.GetKubeProfile).Methods(http.MethodGet)
+	m.HandleFunc("/kubes/{kubeID}/clone", h.Clone).Methods(http.MethodPost)
+}
+
+// ValidateProfile runs the same checks as Provision does without
+// provisioning anything and reports all problems found in a profile.
+func (h *Handler) ValidateProfile(w http.ResponseWriter, r *http.Request) {
+	p := &profile.Profile{}
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	resp := ValidationResponse{
+		Valid:  true,
+		Errors: []profile.FieldError{},
+	}
+
+	// NOTE: parameters get their default values, templates with
+	// required parameters are reported as invalid.
+	p, err := profile.Resolve(r.Context(), h.profileGetter, p, nil)
+	if err == nil {
+		if p.K8SServicesCIDR == "" {
+			p.K8SServicesCIDR = DefaultK8SServicesCIDR
+		}
+		err = ValidateProfile(p)
+	}
+
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, "base profile", err)
+			return
+		}
+
+		errs, ok := err.(profile.ValidationErrors)
+		if !ok {
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		resp.Valid = false
+		resp.Errors = errs
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Error(errors.Wrap(err, "marshal json"))
+		message.SendUnknownError(w, err)
+	}
 }
 
 // TODO(stgleb): Move this to KubeHandler create kube
@@ -85,6 +152,32 @@ func (h *Handler) Provision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.provision(w, r, req)
+}
+
+// provision provisions a cluster from the request profile resolved
+// with its base profiles.
+func (h *Handler) provision(w http.ResponseWriter, r *http.Request, req *ProvisionRequest) {
+	if req.ProfileID != "" {
+		req.Profile.BaseProfileID = req.ProfileID
+	}
+
+	resolved, err := profile.Resolve(r.Context(), h.profileGetter, &req.Profile, req.Parameters)
+	if err != nil {
+		logrus.Errorf("Resolve profile error %v", err)
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, req.Profile.BaseProfileID, err)
+			return
+		}
+		if _, ok := err.(profile.ValidationErrors); ok {
+			message.SendValidationFailed(w, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+	req.Profile = *resolved
+
 	clusterToken := uuid.New()
 	logrus.Infof("cluster token for ETCD %s", clusterToken)
 
@@ -106,6 +199,12 @@ func (h *Handler) Provision(w http.ResponseWriter, r *http.Request) {
 		req.Profile.K8SServicesCIDR = DefaultK8SServicesCIDR
 	}
 
+	if err := ValidateProfile(&req.Profile); err != nil {
+		logrus.Errorf("Profile validation error %v", err)
+		message.SendValidationFailed(w, err)
+		return
+	}
+
 	config := steps.NewConfig(req.ClusterName, clusterToken, req.CloudAccountName, req.Profile)
 
 	acc, err := h.accountGetter.Get(r.Context(), req.CloudAccountName)
@@ -42,6 +42,14 @@ func (m *mockAccountGetter) Get(ctx context.Context, id string) (*model.CloudAcc
 	return m.get(ctx, id)
 }
 
+type mockProfileGetter struct {
+	get func(context.Context, string) (*profile.Profile, error)
+}
+
+func (m *mockProfileGetter) Get(ctx context.Context, id string) (*profile.Profile, error) {
+	return m.get(ctx, id)
+}
+
 type mockKubeGetter struct {
 	get func(context.Context, string) (*model.Kube, error)
 }
@@ -74,13 +82,50 @@ func TestProvisionBadClusterName(t *testing.T) {
 
 func TestProvisionHandler(t *testing.T) {
 	p := &ProvisionRequest{
-		"test",
-		profile.Profile{},
-		"1234",
+		ClusterName:      "test",
+		Profile:          validProfile(),
+		CloudAccountName: "1234",
 	}
 
 	validBody, _ := json.Marshal(p)
 
+	invalidProfile := validProfile()
+	invalidProfile.K8SServicesCIDR = invalidProfile.CIDR
+	invalidBody, _ := json.Marshal(&ProvisionRequest{
+		ClusterName:      "test",
+		Profile:          invalidProfile,
+		CloudAccountName: "1234",
+	})
+
+	baseProfile := validProfile()
+	baseProfile.ID = "base"
+	baseProfile.Region = "{{ .region }}"
+	baseProfile.Parameters = []profile.Parameter{
+		{
+			Name:     "region",
+			Required: true,
+		},
+	}
+
+	templateBody, _ := json.Marshal(&ProvisionRequest{
+		ClusterName:      "test",
+		CloudAccountName: "1234",
+		ProfileID:        baseProfile.ID,
+		Parameters: map[string]string{
+			"region": "nyc1",
+		},
+	})
+	missingParamBody, _ := json.Marshal(&ProvisionRequest{
+		ClusterName:      "test",
+		CloudAccountName: "1234",
+		ProfileID:        baseProfile.ID,
+	})
+	missingBaseBody, _ := json.Marshal(&ProvisionRequest{
+		ClusterName:      "test",
+		CloudAccountName: "1234",
+		ProfileID:        "missing",
+	})
+
 	testCases := []struct {
 		description string
 
@@ -97,6 +142,21 @@ func TestProvisionHandler(t *testing.T) {
 			body:         []byte(`{`),
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			description:  "invalid profile",
+			body:         invalidBody,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "base profile not found",
+			body:         missingBaseBody,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			description:  "required parameter missing",
+			body:         missingParamBody,
+			expectedCode: http.StatusBadRequest,
+		},
 		{
 			description:  "account not found",
 			body:         validBody,
@@ -135,6 +195,23 @@ func TestProvisionHandler(t *testing.T) {
 				return nil, sgerrors.ErrInvalidCredentials
 			},
 		},
+		{
+			description:  "provision from profile template",
+			body:         templateBody,
+			expectedCode: http.StatusAccepted,
+			getAccount: func(context.Context, string) (*model.CloudAccount, error) {
+				return &model.CloudAccount{
+					Provider: clouds.DigitalOcean,
+				}, nil
+			},
+			provision: func(ctx context.Context, p *profile.Profile, config *steps.Config) (map[string][]*workflows.Task, error) {
+				if p.Region != "nyc1" || p.BaseProfileID != baseProfile.ID {
+					return nil, errors.Errorf("profile has not been resolved %+v", p)
+				}
+				config.ClusterID = uuid.New()
+				return map[string][]*workflows.Task{}, nil
+			},
+		},
 		{
 			body:         validBody,
 			expectedCode: http.StatusAccepted,
@@ -170,6 +247,15 @@ func TestProvisionHandler(t *testing.T) {
 	provisioner := &mockProvisioner{}
 	kubeGetter := &mockKubeGetter{}
 	accGetter := &mockAccountGetter{}
+	profileGetter := &mockProfileGetter{
+		get: func(_ context.Context, id string) (*profile.Profile, error) {
+			if id != baseProfile.ID {
+				return nil, sgerrors.ErrNotFound
+			}
+			p := baseProfile
+			return &p, nil
+		},
+	}
 
 	for _, testCase := range testCases {
 		provisioner.provisionCluster = testCase.provision
@@ -181,6 +267,7 @@ func TestProvisionHandler(t *testing.T) {
 
 		handler := Handler{
 			kubeGetter:    kubeGetter,
+			profileGetter: profileGetter,
 			provisioner:   provisioner,
 			accountGetter: accGetter,
 		}
@@ -188,7 +275,7 @@ func TestProvisionHandler(t *testing.T) {
 		handler.Provision(rec, req)
 
 		if rec.Code != testCase.expectedCode {
-			t.Errorf("Wrong status code expected %d actual %d", testCase.expectedCode, rec.Code)
+			t.Errorf("%s: wrong status code expected %d actual %d", testCase.description, testCase.expectedCode, rec.Code)
 			return
 		}
 
@@ -212,7 +299,7 @@ func TestNewHandler(t *testing.T) {
 	accSvc := &account.Service{}
 	kubeSvc := &mockKubeService{}
 	p := &TaskProvisioner{}
-	h := NewHandler(kubeSvc, accSvc, p)
+	h := NewHandler(kubeSvc, accSvc, &profile.Service{}, p)
 
 	if h.accountGetter == nil {
 		t.Errorf("account getter must not be nil")
@@ -228,7 +315,7 @@ func TestHandler_Register(t *testing.T) {
 	r := mux.NewRouter()
 	h.Register(r)
 
-	expectedRouteCount := 1
+	expectedRouteCount := 4
 	actualRouteCount := 0
 	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
 		if router != r {
@@ -246,3 +333,66 @@ func TestHandler_Register(t *testing.T) {
 		t.Errorf("Wrong route count expected %d actual %d", expectedRouteCount, actualRouteCount)
 	}
 }
+
+func TestHandler_ValidateProfile(t *testing.T) {
+	invalidProfile := validProfile()
+	invalidProfile.MasterProfiles = append(invalidProfile.MasterProfiles,
+		profile.NodeProfile{})
+
+	testCases := []struct {
+		description  string
+		body         interface{}
+		expectedCode int
+		expectValid  bool
+	}{
+		{
+			description:  "malformed request body",
+			body:         "{",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "valid profile",
+			body:         validProfile(),
+			expectedCode: http.StatusOK,
+			expectValid:  true,
+		},
+		{
+			description:  "invalid profile",
+			body:         invalidProfile,
+			expectedCode: http.StatusOK,
+			expectValid:  false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		data, _ := json.Marshal(testCase.body)
+		req, _ := http.NewRequest(http.MethodPost, "/kubeprofiles/validate",
+			bytes.NewBuffer(data))
+		rec := httptest.NewRecorder()
+
+		h := Handler{}
+		h.ValidateProfile(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("%s: wrong status code expected %d actual %d",
+				testCase.description, testCase.expectedCode, rec.Code)
+			continue
+		}
+
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		resp := ValidationResponse{}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Errorf("%s: unexpected error decoding response %v",
+				testCase.description, err)
+			continue
+		}
+
+		if resp.Valid != testCase.expectValid {
+			t.Errorf("%s: wrong validation result expected %v actual %v %v",
+				testCase.description, testCase.expectValid, resp.Valid, resp.Errors)
+		}
+	}
+}
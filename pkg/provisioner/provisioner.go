@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/events"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/node"
 	"github.com/supergiant/control/pkg/pki"
@@ -27,6 +29,7 @@ const keySize = 4096
 type KubeService interface {
 	Create(ctx context.Context, k *model.Kube) error
 	Get(ctx context.Context, name string) (*model.Kube, error)
+	ListAll(ctx context.Context) ([]model.Kube, error)
 }
 
 type TaskProvisioner struct {
@@ -54,6 +57,7 @@ func NewProvisioner(repository storage.Interface, kubeService KubeService,
 			clouds.DigitalOcean: {
 				ProvisionMaster: workflows.DigitalOceanMaster,
 				ProvisionNode:   workflows.DigitalOceanNode,
+				PreProvision:    workflows.DigitalOceanPreProvision,
 			},
 			clouds.AWS: {
 				ProvisionMaster: workflows.AWSMaster,
@@ -63,6 +67,11 @@ func NewProvisioner(repository storage.Interface, kubeService KubeService,
 			clouds.GCE: {
 				ProvisionMaster: workflows.GCEMaster,
 				ProvisionNode:   workflows.GCENode,
+				PreProvision:    workflows.GCEPreProvision,
+			},
+			clouds.BareMetal: {
+				ProvisionMaster: workflows.BareMetalMaster,
+				ProvisionNode:   workflows.BareMetalNode,
 			},
 		},
 		getWriter:   util.GetWriter,
@@ -76,7 +85,7 @@ func NewProvisioner(repository storage.Interface, kubeService KubeService,
 func (tp *TaskProvisioner) ProvisionCluster(parentContext context.Context,
 	profile *profile.Profile, config *steps.Config) (map[string][]*workflows.Task, error) {
 	masterTasks, nodeTasks, preProvisionTask, clusterTask := tp.prepare(config.Provider, len(profile.MasterProfiles),
-		len(profile.NodesProfiles))
+		len(profile.Workers()))
 
 	// Get clusterID from taskID
 	if clusterTask != nil && len(clusterTask.ID) >= 8 {
@@ -93,8 +102,11 @@ func (tp *TaskProvisioner) ProvisionCluster(parentContext context.Context,
 	masters, nodes := nodesFromProfile(config.ClusterName,
 		masterTasks, nodeTasks, profile)
 
-	if err := bootstrapKeys(config); err != nil {
-		return nil, errors.Wrap(err, "bootstrap keys")
+	// Machines of bare metal provider are reachable with the key of cloud account
+	if config.Provider != clouds.BareMetal {
+		if err := bootstrapKeys(config); err != nil {
+			return nil, errors.Wrap(err, "bootstrap keys")
+		}
 	}
 
 	if err := bootstrapCerts(config); err != nil {
@@ -109,6 +121,8 @@ func (tp *TaskProvisioner) ProvisionCluster(parentContext context.Context,
 	if err != nil {
 		return nil, errors.Wrap(err, "build initial cluster")
 	}
+	events.Emitf(config.ClusterID, events.KubeCreated, "kube %s provisioning has started with %d masters and %d nodes",
+		config.ClusterName, len(masters), len(nodes))
 
 	// monitor cluster state in separate goroutine
 	go tp.monitorClusterState(ctx, config.ClusterID, config.NodeChan(),
@@ -277,9 +291,17 @@ func (tp *TaskProvisioner) prepare(name clouds.Name, masterCount, nodeCount int)
 			logrus.Errorf("create pre provision task has finished with %v", err)
 			return nil, nil, nil, nil
 		}
-	case clouds.GCE:
-	case clouds.DigitalOcean:
+	case clouds.GCE, clouds.DigitalOcean:
 		// TODO(stgleb): Create key pairs here
+		// Load balancer in front of masters is needed only for multi master clusters
+		if masterCount > 1 {
+			preProvisionTask, err = workflows.NewTask(tp.provisionMap[name].PreProvision, tp.repository)
+
+			if err != nil {
+				logrus.Errorf("create pre provision task has finished with %v", err)
+				return nil, nil, nil, nil
+			}
+		}
 	}
 
 	for i := 0; i < masterCount; i++ {
@@ -353,14 +375,14 @@ func (tp *TaskProvisioner) provisionMasters(ctx context.Context, profile *profil
 	failLatch := util.NewCountdownLatch(ctx, len(profile.MasterProfiles)/2+1)
 
 	// ProvisionCluster master nodes
-	for index, masterTask := range tasks {
+	for index, t := range tasks {
 		// Take token that allows perform action with Cloud Provider API
 		tp.rateLimiter.Take()
 
-		if masterTask == nil {
+		if t == nil {
 			logrus.Fatal(tasks)
 		}
-		fileName := util.MakeFileName(masterTask.ID)
+		fileName := util.MakeFileName(t.ID)
 		out, err := tp.getWriter(fileName)
 
 		if err != nil {
@@ -372,20 +394,20 @@ func (tp *TaskProvisioner) provisionMasters(ctx context.Context, profile *profil
 		p := profile.MasterProfiles[index]
 		FillNodeCloudSpecificData(profile.Provider, p, config)
 
-		go func(t *workflows.Task) {
-			// Put task id to config so that create instance step can use this id when generate node name
-			config.TaskID = t.ID
-			result := t.Run(ctx, *config, out)
-			err = <-result
+		// Put task id to config so that create instance step can use this id when generate node name,
+		// task copies config before the next master is filled in.
+		config.TaskID = t.ID
+		result := t.Run(ctx, *config, out)
 
-			if err != nil {
+		go func(t *workflows.Task, result chan error) {
+			if err := <-result; err != nil {
 				failLatch.CountDown()
 				logrus.Errorf("master task %s has finished with error %v", t.ID, err)
 			} else {
 				masterLatch.CountDown()
 				logrus.Infof("master-task %s has finished", t.ID)
 			}
-		}(masterTask)
+		}(t, result)
 	}
 
 	go func() {
@@ -402,45 +424,70 @@ func (tp *TaskProvisioner) provisionMasters(ctx context.Context, profile *profil
 }
 
 func (tp *TaskProvisioner) provisionNodes(ctx context.Context, profile *profile.Profile, config *steps.Config, tasks []*workflows.Task) {
-	config.IsMaster = false
-	config.ManifestConfig.IsMaster = false
-	// Do internal communication inside private network
-	if master := config.GetMaster(); master != nil {
-		config.FlannelConfig.EtcdHost = master.PrivateIp
-	} else {
+	if !nodeConfig(config) {
 		return
 	}
 
+	workers := profile.Workers()
+
 	// ProvisionCluster nodes
-	for index, nodeTask := range tasks {
-		// Take token that allows perform action with Cloud Provider API
-		tp.rateLimiter.Take()
+	for index, t := range tasks {
+		if err := tp.provisionNode(ctx, profile.Provider, workers[index], config, t); err != nil {
+			logrus.Errorf("provision node task %s: %v", t.ID, err)
+			return
+		}
+	}
+}
 
-		fileName := util.MakeFileName(nodeTask.ID)
-		out, err := tp.getWriter(fileName)
+// provisionNode runs the task of the worker, failed nodes do not fail
+// the cluster so the result is only logged.
+func (tp *TaskProvisioner) provisionNode(ctx context.Context, provider clouds.Name,
+	worker profile.WorkerProfile, config *steps.Config, t *workflows.Task) error {
+	// Take token that allows perform action with Cloud Provider API
+	tp.rateLimiter.Take()
 
-		if err != nil {
-			logrus.Errorf("Error getting writer for %s", fileName)
-			return
+	fileName := util.MakeFileName(t.ID)
+	out, err := tp.getWriter(fileName)
+
+	if err != nil {
+		return errors.Wrapf(err, "get writer for %s", fileName)
+	}
+
+	// Fulfill task config with data about provider specific node configuration
+	FillNodeCloudSpecificData(provider, worker.Profile, config)
+	config.NodePool = worker.Pool
+
+	// Put task id to config so that create instance step can use this id when generate node name,
+	// task copies config before the next node is filled in.
+	config.TaskID = t.ID
+	result := t.Run(ctx, *config, out)
+
+	go func(t *workflows.Task, result chan error) {
+		if err := <-result; err != nil {
+			logrus.Errorf("node task %s has finished with error %v", t.ID, err)
+		} else {
+			logrus.Infof("node-task %s has finished", t.ID)
 		}
+	}(t, result)
 
-		// Fulfill task config with data about provider specific node configuration
-		p := profile.NodesProfiles[index]
-		FillNodeCloudSpecificData(profile.Provider, p, config)
+	return nil
+}
 
-		go func(t *workflows.Task) {
-			// Put task id to config so that create instance step can use this id when generate node name
-			config.TaskID = t.ID
-			result := t.Run(ctx, *config, out)
-			err = <-result
+// nodeConfig turns config that has been used for masters into config for nodes,
+// false is returned if there is no active master to join.
+func nodeConfig(config *steps.Config) bool {
+	config.IsMaster = false
+	config.ManifestConfig.IsMaster = false
+	// Do internal communication inside private network
+	master := config.GetMaster()
 
-			if err != nil {
-				logrus.Errorf("node task %s has finished with error %v", t.ID, err)
-			} else {
-				logrus.Infof("node-task %s has finished", t.ID)
-			}
-		}(nodeTask)
+	if master == nil {
+		return false
 	}
+
+	config.FlannelConfig.EtcdHost = master.PrivateIp
+
+	return true
 }
 
 func (tp *TaskProvisioner) waitCluster(ctx context.Context, clusterTask *workflows.Task, config *steps.Config) {
@@ -499,6 +546,7 @@ func (tp *TaskProvisioner) buildInitialCluster(ctx context.Context,
 		Region:              profile.Region,
 		Zone:                profile.Zone,
 		SshUser:             config.SshConfig.User,
+		SshPort:             config.SshConfig.Port,
 		SshPublicKey:        []byte(config.SshConfig.PublicKey),
 		BootstrapPublicKey:  []byte(config.SshConfig.BootstrapPublicKey),
 		BootstrapPrivateKey: []byte(config.SshConfig.BootstrapPrivateKey),
@@ -528,8 +576,10 @@ func (tp *TaskProvisioner) buildInitialCluster(ctx context.Context,
 		},
 
 		CloudSpec: profile.CloudSpecificSettings,
+		Profile:   profile,
 		Masters:   masters,
 		Nodes:     nodes,
+		NodePools: nodePools(profile.NodePools),
 		Tasks:     taskIds,
 	}
 
@@ -575,6 +625,8 @@ func (t *TaskProvisioner) updateCloudSpecificData(k *model.Kube, config *steps.C
 			config.AWSConfig.NodesInstanceProfile
 		cloudSpecificSettings[clouds.AwsImageID] =
 			config.AWSConfig.ImageID
+		cloudSpecificSettings[clouds.AwsResourcesTagged] =
+			strconv.FormatBool(config.AWSConfig.ResourcesTagged)
 	case clouds.GCE:
 		// GCE is the most simple :-)
 	case clouds.DigitalOcean:
@@ -583,6 +635,11 @@ func (t *TaskProvisioner) updateCloudSpecificData(k *model.Kube, config *steps.C
 		k.BootstrapPublicKey = []byte(config.SshConfig.BootstrapPublicKey)
 	}
 
+	// API of the cluster is reachable through the load balancer in front of masters
+	if config.LoadBalancerConfig.Host != "" {
+		k.APIEndpoint = fmt.Sprintf("https://%s", config.LoadBalancerConfig.Host)
+	}
+
 	k.CloudSpec = cloudSpecificSettings
 }
 
@@ -643,18 +700,25 @@ func (tp *TaskProvisioner) monitorClusterState(ctx context.Context,
 				continue
 			}
 
+			nodes := k.Nodes
 			if n.Role == node.RoleMaster {
-				k.Masters[n.Name] = &n
-			} else {
-				k.Nodes[n.Name] = &n
+				nodes = k.Masters
 			}
 
+			var previous node.NodeState
+			if old := nodes[n.Name]; old != nil {
+				previous = old.State
+			}
+			nodes[n.Name] = &n
+
 			err = tp.kubeService.Create(ctx, k)
 
 			if err != nil {
 				logrus.Errorf("cluster monitor: update kube state caused %v", err)
 				continue
 			}
+
+			emitNodeState(k.ID, &n, previous)
 		case state := <-kubeStateChan:
 			logrus.Debugf("monitor: get kube %s", clusterID)
 			k, err := tp.kubeService.Get(ctx, clusterID)
@@ -664,6 +728,7 @@ func (tp *TaskProvisioner) monitorClusterState(ctx context.Context,
 				continue
 			}
 
+			previous := k.State
 			k.State = state
 			logrus.Debugf("monitor: update kube %s with state %s",
 				k.ID, state)
@@ -673,6 +738,10 @@ func (tp *TaskProvisioner) monitorClusterState(ctx context.Context,
 				logrus.Errorf("cluster monitor: update kube state caused %v", err)
 				continue
 			}
+
+			if previous != state {
+				events.StateChanged(k.ID, previous, state, "")
+			}
 		case config := <-configChan:
 			logrus.Debugf("monitor: get kube %s", clusterID)
 			k, err := tp.kubeService.Get(ctx, clusterID)
@@ -695,3 +764,34 @@ func (tp *TaskProvisioner) monitorClusterState(ctx context.Context,
 		}
 	}
 }
+
+// emitNodeState emits an event when the node becomes active or fails.
+func emitNodeState(kubeID string, n *node.Node, previous node.NodeState) {
+	if n.State == previous {
+		return
+	}
+
+	e := events.Event{
+		KubeID:   kubeID,
+		NodeName: n.Name,
+		TaskID:   n.TaskID,
+		Details: map[string]string{
+			"role":   string(n.Role),
+			"region": n.Region,
+			"size":   n.Size,
+		},
+	}
+
+	switch n.State {
+	case node.StateActive:
+		e.Type = events.NodeAdded
+		e.Message = fmt.Sprintf("%s %s has been added", n.Role, n.Name)
+	case node.StateError:
+		e.Type = events.NodeFailed
+		e.Message = fmt.Sprintf("%s %s has failed to provision", n.Role, n.Name)
+	default:
+		return
+	}
+
+	events.Emit(e)
+}
@@ -50,6 +50,18 @@ func (m *mockKubeService) Get(ctx context.Context, kname string) (*model.Kube, e
 	return m.data[kname], m.getError
 }
 
+func (m *mockKubeService) ListAll(ctx context.Context) ([]model.Kube, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	kubes := make([]model.Kube, 0, len(m.data))
+	for _, k := range m.data {
+		kubes = append(kubes, *k)
+	}
+
+	return kubes, m.getError
+}
+
 func TestNewProvisioner(t *testing.T) {
 	storage := &testutils.MockStorage{}
 	service := &mockKubeService{}
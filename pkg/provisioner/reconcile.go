@@ -0,0 +1,404 @@
+package provisioner
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/events"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/etcd"
+)
+
+// interruptedReason is saved to steps of tasks that can not be resumed
+const interruptedReason = "interrupted by restart of the controlplane"
+
+// Reconcile looks for clusters and tasks that have been interrupted by restart
+// of the controlplane. Provisioning of clusters is resumed from the last
+// successful step of each task, clusters and tasks that can not be resumed
+// are marked as failed.
+func (tp *TaskProvisioner) Reconcile(ctx context.Context) error {
+	kubes, err := tp.kubeService.ListAll(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "list kubes")
+	}
+
+	// resumed tasks are running again, tasks of abandoned
+	// clusters are failed even if they have never been started
+	resumed := make(map[string]bool)
+	abandoned := make(map[string]bool)
+
+	for i := range kubes {
+		k := &kubes[i]
+		state := k.State
+
+		switch k.State {
+		case model.StateProvisioning:
+			ids, err := tp.resumeCluster(ctx, k)
+
+			if err == nil {
+				logrus.Infof("reconcile: provisioning of cluster %s has been resumed", k.ID)
+				for _, id := range ids {
+					resumed[id] = true
+				}
+				continue
+			}
+
+			logrus.Errorf("reconcile: provisioning of cluster %s can not be resumed: %v", k.ID, err)
+			k.State = model.StateFailed
+		case model.StateUpgrading:
+			// Upgrade leaves cluster operational regardless of the result
+			logrus.Errorf("reconcile: upgrade of cluster %s has been interrupted", k.ID)
+			k.State = model.StateOperational
+		case model.StateRotating:
+			// Rotated and old certificates are signed by the same CA
+			logrus.Errorf("reconcile: certificate rotation of cluster %s has been interrupted", k.ID)
+			k.State = model.StateOperational
+		case model.StateDeleting:
+			// Failed cluster can be deleted again
+			logrus.Errorf("reconcile: deletion of cluster %s has been interrupted", k.ID)
+			k.State = model.StateFailed
+		case model.StateRestoring:
+			// Etcd members may be left stopped, restore must be repeated
+			logrus.Errorf("reconcile: etcd restore of cluster %s has been interrupted", k.ID)
+			k.State = model.StateFailed
+		default:
+			continue
+		}
+
+		for _, id := range k.Tasks {
+			abandoned[id] = true
+		}
+		events.StateChanged(k.ID, state, k.State, "controlplane has been restarted")
+
+		if err := tp.kubeService.Create(ctx, k); err != nil {
+			logrus.Errorf("reconcile: update cluster %s caused %v", k.ID, err)
+		}
+	}
+
+	return tp.interruptTasks(ctx, resumed, abandoned)
+}
+
+// resumeCluster continues provisioning of the cluster with tasks that have
+// been saved to storage, ids of resumed tasks are returned.
+func (tp *TaskProvisioner) resumeCluster(parentContext context.Context, k *model.Kube) ([]string, error) {
+	workflowSet, ok := tp.provisionMap[k.Provider]
+
+	if !ok {
+		return nil, errors.Wrapf(sgerrors.ErrNotFound, "provider %s workflow", k.Provider)
+	}
+
+	var preProvisionTask, clusterTask *workflows.Task
+	masterTasks := make([]*workflows.Task, 0)
+	nodeTasks := make([]*workflows.Task, 0)
+
+	for _, id := range k.Tasks {
+		t, err := tp.loadTask(parentContext, id)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "load task %s", id)
+		}
+
+		switch t.Type {
+		case workflowSet.PreProvision:
+			preProvisionTask = t
+		case workflowSet.ProvisionMaster:
+			masterTasks = append(masterTasks, t)
+		case workflowSet.ProvisionNode:
+			nodeTasks = append(nodeTasks, t)
+		case workflows.Cluster:
+			clusterTask = t
+		}
+	}
+
+	if clusterTask == nil {
+		return nil, errors.Wrap(sgerrors.ErrNotFound, "cluster task")
+	}
+
+	// Pre provisioning creates cloud resources that would be created twice
+	if preProvisionTask != nil && preProvisionTask.Pending() {
+		return nil, errors.New("pre provisioning has not finished")
+	}
+
+	if len(masterTasks) == 0 {
+		return nil, errors.Wrap(sgerrors.ErrNotFound, "master tasks")
+	}
+
+	for _, t := range masterTasks {
+		if t.Config == nil {
+			return nil, errors.Errorf("master task %s has not been started", t.ID)
+		}
+	}
+
+	var workers []profile.WorkerProfile
+	if k.Profile != nil {
+		workers = k.Profile.Workers()
+	}
+
+	for _, t := range nodeTasks {
+		if t.Config == nil && len(workers) != len(nodeTasks) {
+			return nil, errors.Errorf("node task %s has not been started and profile "+
+				"of the cluster does not match", t.ID)
+		}
+	}
+
+	// Config of the cluster is taken from the master, separate copy is
+	// loaded so that masters keep their own configs.
+	master, err := tp.loadTask(parentContext, masterTasks[0].ID)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "load task %s", masterTasks[0].ID)
+	}
+
+	config := master.Config
+	config.ClusterID = k.ID
+
+	// Writers are taken before anything is run, so the cluster can still be failed
+	writers := make(map[string]io.WriteCloser)
+	for _, t := range append(masterTasks, nodeTasks...) {
+		if !resumable(t) {
+			continue
+		}
+
+		fileName := util.MakeFileName(t.ID)
+		if writers[t.ID], err = tp.getWriter(fileName); err != nil {
+			return nil, errors.Wrapf(err, "get writer for %s", fileName)
+		}
+	}
+
+	restoreConfigs(k, config, masterTasks, nodeTasks)
+
+	// Save cancel that cancels cluster provisioning to cancelMap
+	ctx, cancel := context.WithCancel(parentContext)
+	tp.cancelMap[k.ID] = cancel
+
+	// monitor cluster state in separate goroutine
+	go tp.monitorClusterState(ctx, k.ID, config.NodeChan(),
+		config.KubeStateChan(), config.ConfigChan())
+
+	ids := []string{clusterTask.ID}
+	pendingMasters := make([]*workflows.Task, 0, len(masterTasks))
+	// Masters that have not reached etcd yet wait each other for bootstrapping
+	latch := &sync.WaitGroup{}
+
+	for _, t := range masterTasks {
+		if !resumable(t) {
+			continue
+		}
+
+		t.Config.ReadyForBootstrapLatch = latch
+		if stepPending(t, etcd.StepName) {
+			latch.Add(1)
+		}
+
+		pendingMasters = append(pendingMasters, t)
+		ids = append(ids, t.ID)
+	}
+
+	for _, t := range nodeTasks {
+		if t.Config == nil || resumable(t) {
+			ids = append(ids, t.ID)
+		}
+	}
+
+	go func() {
+		wg := sync.WaitGroup{}
+
+		for _, t := range pendingMasters {
+			wg.Add(1)
+			result := t.Resume(ctx, writers[t.ID])
+
+			go func(t *workflows.Task, result chan error) {
+				defer wg.Done()
+
+				if err := <-result; err != nil {
+					logrus.Errorf("master task %s has finished with error %v", t.ID, err)
+				} else {
+					logrus.Infof("master-task %s has finished", t.ID)
+				}
+			}(t, result)
+		}
+
+		wg.Wait()
+
+		if ctx.Err() != nil {
+			logrus.Errorf("Master cluster has not been created %v", ctx.Err())
+			return
+		}
+
+		// If we fail n /2 of master deploy jobs - all cluster deployment is failed
+		active := 0
+		for _, t := range masterTasks {
+			if !t.Pending() {
+				active++
+			}
+		}
+
+		if active < len(masterTasks)/2+1 || !nodeConfig(config) {
+			config.KubeStateChan() <- model.StateFailed
+			logrus.Errorf("master cluster deployment has been failed")
+			return
+		}
+
+		logrus.Infof("master provisioning for cluster %s has finished successfully", k.ID)
+
+		for index, t := range nodeTasks {
+			if t.Config == nil {
+				err := tp.provisionNode(ctx, k.Provider, workers[index], config, t)
+
+				if err != nil {
+					logrus.Errorf("provision node task %s: %v", t.ID, err)
+				}
+
+				continue
+			}
+
+			if !resumable(t) {
+				continue
+			}
+
+			tp.rateLimiter.Take()
+			result := t.Resume(ctx, writers[t.ID])
+
+			go func(t *workflows.Task, result chan error) {
+				if err := <-result; err != nil {
+					logrus.Errorf("node task %s has finished with error %v", t.ID, err)
+				} else {
+					logrus.Infof("node-task %s has finished", t.ID)
+				}
+			}(t, result)
+		}
+
+		if clusterTask.Pending() {
+			// Wait for cluster checks are finished
+			tp.waitCluster(ctx, clusterTask, config)
+		} else {
+			config.KubeStateChan() <- model.StateOperational
+		}
+
+		logrus.Infof("cluster %s deployment has finished", k.ID)
+	}()
+
+	return ids, nil
+}
+
+// interruptTasks marks tasks that have been left in progress as failed,
+// tasks that have never been started are failed only for abandoned clusters.
+func (tp *TaskProvisioner) interruptTasks(ctx context.Context, resumed, abandoned map[string]bool) error {
+	data, err := tp.repository.GetAll(ctx, workflows.Prefix)
+
+	if err != nil {
+		return errors.Wrap(err, "list tasks")
+	}
+
+	for _, raw := range data {
+		t, err := workflows.DeserializeTask(raw, tp.repository)
+
+		if err != nil {
+			logrus.Errorf("reconcile: deserialize task caused %v", err)
+			continue
+		}
+
+		if resumed[t.ID] || !t.Pending() || t.Status == statuses.Error ||
+			t.Status == statuses.Cancelled {
+			continue
+		}
+
+		if t.Config == nil && !abandoned[t.ID] {
+			continue
+		}
+
+		logrus.Infof("reconcile: task %s of type %s has been interrupted", t.ID, t.Type)
+		if err := t.Interrupt(ctx, interruptedReason); err != nil {
+			logrus.Errorf("reconcile: update task %s caused %v", t.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (tp *TaskProvisioner) loadTask(ctx context.Context, id string) (*workflows.Task, error) {
+	data, err := tp.repository.Get(ctx, workflows.Prefix, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return workflows.DeserializeTask(data, tp.repository)
+}
+
+// restoreConfigs shares channels and machines of the cluster among configs
+// loaded from storage the same way as configs copied from one cluster config.
+func restoreConfigs(k *model.Kube, config *steps.Config, masterTasks, nodeTasks []*workflows.Task) {
+	nodeChan := make(chan node.Node, len(masterTasks)+len(nodeTasks))
+	kubeStateChan := make(chan model.KubeState, 2)
+	configChan := make(chan *steps.Config)
+
+	// Configs of tasks point to machines they provision, so that
+	// the machines become active for other tasks when tasks finish.
+	masters := make(map[string]*node.Node)
+	for _, n := range k.Masters {
+		if n != nil {
+			masters[n.ID] = n
+		}
+	}
+
+	nodes := make(map[string]*node.Node)
+	for _, n := range k.Nodes {
+		if n != nil {
+			nodes[n.ID] = n
+		}
+	}
+
+	for _, t := range masterTasks {
+		if t.Config != nil && t.Config.Node.ID != "" {
+			masters[t.Config.Node.ID] = &t.Config.Node
+		}
+	}
+
+	for _, t := range nodeTasks {
+		if t.Config != nil && t.Config.Node.ID != "" {
+			nodes[t.Config.Node.ID] = &t.Config.Node
+		}
+	}
+
+	configs := []*steps.Config{config}
+	for _, t := range append(masterTasks, nodeTasks...) {
+		if t.Config != nil {
+			configs = append(configs, t.Config)
+		}
+	}
+
+	for _, cfg := range configs {
+		cfg.RestoreChannels(nodeChan, kubeStateChan, configChan)
+		cfg.Masters = steps.NewMap(masters)
+		cfg.Nodes = steps.NewMap(nodes)
+	}
+}
+
+// resumable returns true if the task has been stopped in the middle
+func resumable(t *workflows.Task) bool {
+	return t.Config != nil && t.Pending() &&
+		t.Status != statuses.Error && t.Status != statuses.Cancelled
+}
+
+func stepPending(t *workflows.Task, stepName string) bool {
+	for _, stepStatus := range t.StepStatuses {
+		if stepStatus.StepName == stepName && stepStatus.Status != statuses.Success {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,334 @@
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type mapStorage struct {
+	lock sync.Mutex
+	data map[string][]byte
+}
+
+func (m *mapStorage) Get(ctx context.Context, prefix string, key string) ([]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.data[prefix+key], nil
+}
+
+func (m *mapStorage) Put(ctx context.Context, prefix string, key string, value []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.data[prefix+key] = value
+	return nil
+}
+
+func (m *mapStorage) Delete(ctx context.Context, prefix string, key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.data, prefix+key)
+	return nil
+}
+
+func (m *mapStorage) GetAll(ctx context.Context, prefix string) ([][]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	result := make([][]byte, 0)
+	for key, value := range m.data {
+		if strings.HasPrefix(key, prefix) {
+			result = append(result, value)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *mapStorage) task(t *testing.T, id string) *workflows.Task {
+	data, _ := m.Get(context.Background(), workflows.Prefix, id)
+	task, err := workflows.DeserializeTask(data, m)
+
+	if err != nil {
+		t.Fatalf("deserialize task %s: %v", id, err)
+	}
+
+	return task
+}
+
+type countStep struct {
+	name    string
+	lock    sync.Mutex
+	counter int
+}
+
+func (s *countStep) Run(context.Context, io.Writer, *steps.Config) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.counter++
+	return nil
+}
+
+func (s *countStep) count() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.counter
+}
+
+func (s *countStep) Name() string                                             { return s.name }
+func (s *countStep) Description() string                                      { return "" }
+func (s *countStep) Depends() []string                                        { return nil }
+func (s *countStep) Rollback(context.Context, io.Writer, *steps.Config) error { return nil }
+
+func putTask(t *testing.T, repository *mapStorage, task *workflows.Task) {
+	data, err := json.Marshal(task)
+
+	if err != nil {
+		t.Fatalf("marshal task %v", err)
+	}
+
+	repository.Put(context.Background(), workflows.Prefix, task.ID, data)
+}
+
+// copyingKubeService returns copies of kubes, so that the test reads
+// the kube only as it has been saved by the cluster monitor.
+type copyingKubeService struct {
+	*mockKubeService
+}
+
+func (m *copyingKubeService) Get(ctx context.Context, kname string) (*model.Kube, error) {
+	k, err := m.mockKubeService.Get(ctx, kname)
+	if k == nil {
+		return nil, err
+	}
+
+	c := *k
+	return &c, err
+}
+
+func reconcileProvisioner(repository *mapStorage, svc KubeService) *TaskProvisioner {
+	return &TaskProvisioner{
+		kubeService: svc,
+		repository:  repository,
+		getWriter: func(string) (io.WriteCloser, error) {
+			return &bufferCloser{}, nil
+		},
+		provisionMap: map[clouds.Name]workflows.WorkflowSet{
+			clouds.DigitalOcean: {
+				ProvisionMaster: "reconcile_master",
+				ProvisionNode:   "reconcile_node",
+			},
+			clouds.AWS: {
+				ProvisionMaster: "reconcile_master",
+				ProvisionNode:   "reconcile_node",
+				PreProvision:    "reconcile_preprovision",
+			},
+		},
+		rateLimiter: NewRateLimiter(time.Nanosecond),
+		cancelMap:   make(map[string]func()),
+	}
+}
+
+func TestReconcileResume(t *testing.T) {
+	created := &countStep{name: "created"}
+	bootstrap := &countStep{name: "bootstrap"}
+	nodeStep := &countStep{name: "node"}
+
+	workflows.Init()
+	workflows.RegisterWorkFlow("reconcile_master", []steps.Step{created, bootstrap})
+	workflows.RegisterWorkFlow("reconcile_node", []steps.Step{nodeStep})
+	workflows.RegisterWorkFlow(workflows.Cluster, []steps.Step{})
+
+	repository := &mapStorage{data: make(map[string][]byte)}
+	master := node.Node{
+		ID:        "m1",
+		Name:      "master-1",
+		PrivateIp: "10.0.0.1",
+		State:     node.StateActive,
+	}
+
+	putTask(t, repository, &workflows.Task{
+		ID:     "master",
+		Type:   "reconcile_master",
+		Status: statuses.Executing,
+		Config: &steps.Config{
+			IsMaster: true,
+			Node:     master,
+		},
+		StepStatuses: []workflows.StepStatus{
+			{StepName: "created", Status: statuses.Success},
+			{StepName: "bootstrap", Status: statuses.Executing},
+		},
+	})
+	putTask(t, repository, &workflows.Task{
+		ID:     "node",
+		Type:   "reconcile_node",
+		Status: statuses.Todo,
+	})
+	putTask(t, repository, &workflows.Task{
+		ID:     "cluster",
+		Type:   workflows.Cluster,
+		Status: statuses.Todo,
+	})
+
+	svc := &copyingKubeService{&mockKubeService{
+		data: map[string]*model.Kube{
+			"kube": {
+				ID:       "kube",
+				State:    model.StateProvisioning,
+				Provider: clouds.DigitalOcean,
+				Profile: &profile.Profile{
+					Provider:      clouds.DigitalOcean,
+					NodesProfiles: []profile.NodeProfile{{}},
+				},
+				Masters: map[string]*node.Node{master.Name: &master},
+				Nodes:   map[string]*node.Node{},
+				Tasks:   []string{"cluster", "master", "node"},
+			},
+		},
+	}}
+
+	tp := reconcileProvisioner(repository, svc)
+
+	if err := tp.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		k, _ := svc.Get(context.Background(), "kube")
+		if k.State == model.StateOperational {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("cluster has not become operational, state %s", k.State)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if created.count() != 0 || bootstrap.count() != 1 {
+		t.Errorf("master must be resumed from the interrupted step, runs %d %d",
+			created.count(), bootstrap.count())
+	}
+
+	if tp.cancelMap["kube"] == nil {
+		t.Errorf("cancel func of resumed cluster not found")
+	}
+
+	if task := repository.task(t, "master"); task.Pending() {
+		t.Errorf("master task must be finished %v", task.StepStatuses)
+	}
+
+	deadline = time.Now().Add(time.Second * 5)
+	for nodeStep.count() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("node task that has not been started must be run")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestReconcileFail(t *testing.T) {
+	workflows.Init()
+	workflows.RegisterWorkFlow("reconcile_master", []steps.Step{&countStep{name: "created"}})
+	workflows.RegisterWorkFlow("reconcile_preprovision", []steps.Step{&countStep{name: "vpc"}})
+
+	repository := &mapStorage{data: make(map[string][]byte)}
+
+	putTask(t, repository, &workflows.Task{
+		ID:     "preprovision",
+		Type:   "reconcile_preprovision",
+		Status: statuses.Executing,
+		Config: &steps.Config{},
+		StepStatuses: []workflows.StepStatus{
+			{StepName: "vpc", Status: statuses.Executing},
+		},
+	})
+	putTask(t, repository, &workflows.Task{
+		ID:     "master",
+		Type:   "reconcile_master",
+		Status: statuses.Todo,
+	})
+	putTask(t, repository, &workflows.Task{
+		ID:     "cluster",
+		Type:   workflows.Cluster,
+		Status: statuses.Todo,
+	})
+	// task that does not belong to any cluster
+	putTask(t, repository, &workflows.Task{
+		ID:     "upgrade",
+		Type:   "reconcile_master",
+		Status: statuses.Success,
+		Config: &steps.Config{},
+		StepStatuses: []workflows.StepStatus{
+			{StepName: "created", Status: statuses.Todo},
+		},
+	})
+
+	svc := &mockKubeService{
+		data: map[string]*model.Kube{
+			"aws": {
+				ID:       "aws",
+				State:    model.StateProvisioning,
+				Provider: clouds.AWS,
+				Tasks:    []string{"cluster", "preprovision", "master"},
+			},
+			"upgrading": {
+				ID:    "upgrading",
+				State: model.StateUpgrading,
+			},
+			"restoring": {
+				ID:    "restoring",
+				State: model.StateRestoring,
+			},
+			"rotating": {
+				ID:    "rotating",
+				State: model.StateRotating,
+			},
+			"operational": {
+				ID:    "operational",
+				State: model.StateOperational,
+			},
+		},
+	}
+
+	if err := reconcileProvisioner(repository, svc).Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for id, expected := range map[string]model.KubeState{
+		"aws":         model.StateFailed,
+		"upgrading":   model.StateOperational,
+		"restoring":   model.StateFailed,
+		"rotating":    model.StateOperational,
+		"operational": model.StateOperational,
+	} {
+		if k, _ := svc.Get(context.Background(), id); k.State != expected {
+			t.Errorf("kube %s expected state %s actual %s", id, expected, k.State)
+		}
+	}
+
+	for _, id := range []string{"preprovision", "master", "cluster", "upgrade"} {
+		if task := repository.task(t, id); task.Status != statuses.Error {
+			t.Errorf("task %s expected status %s actual %s", id, statuses.Error, task.Status)
+		}
+	}
+
+	task := repository.task(t, "preprovision")
+	if task.StepStatuses[0].ErrMsg != interruptedReason {
+		t.Errorf("wrong reason of interrupted step %v", task.StepStatuses[0])
+	}
+}
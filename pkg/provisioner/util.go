@@ -38,18 +38,60 @@ func (r *RateLimiter) Take() {
 // Fill cloud account specific data gets data from the map and puts to particular cloud provider config
 func FillNodeCloudSpecificData(provider clouds.Name, nodeProfile profile.NodeProfile, config *steps.Config) error {
 	switch provider {
-	case clouds.AWS:
-		return util.BindParams(nodeProfile, &config.AWSConfig)
-	case clouds.GCE:
-		return util.BindParams(nodeProfile, &config.GCEConfig)
-	case clouds.DigitalOcean:
-		return util.BindParams(nodeProfile, &config.DigitalOceanConfig)
 	case clouds.Packet:
 		return util.BindParams(nodeProfile, &config.PacketConfig)
 	case clouds.OpenStack:
 		return util.BindParams(nodeProfile, &config.OSConfig)
-	default:
-		return sgerrors.ErrUnknownProvider
+	}
+
+	spec, err := profile.ParseNodeProfile(provider, "nodeProfile", nodeProfile)
+	if err != nil {
+		if sgerrors.IsUnsupportedProvider(err) {
+			return sgerrors.ErrUnknownProvider
+		}
+		return err
+	}
+
+	switch s := spec.(type) {
+	case *profile.AWSNodeSpec:
+		config.AWSConfig.InstanceType = s.Size
+		if s.VolumeSize != "" {
+			config.AWSConfig.VolumeSize = s.VolumeSize
+		}
+		if s.EbsOptimized != "" {
+			config.AWSConfig.EbsOptimized = s.EbsOptimized
+		}
+		if s.Image != "" {
+			config.AWSConfig.ImageID = s.Image
+		}
+		if s.AvailabilityZone != "" {
+			config.AWSConfig.AvailabilityZone = s.AvailabilityZone
+		}
+		if s.HasPublicAddr != "" {
+			config.AWSConfig.HasPublicAddr = s.HasPublicAddr == "true"
+		}
+	case *profile.GCENodeSpec:
+		config.GCEConfig.Size = s.Size
+		if s.AvailabilityZone != "" {
+			config.GCEConfig.AvailabilityZone = s.AvailabilityZone
+		}
+		if s.ImageFamily != "" {
+			config.GCEConfig.ImageFamily = s.ImageFamily
+		}
+	case *profile.DONodeSpec:
+		config.DigitalOceanConfig.Size = s.Size
+		config.DigitalOceanConfig.Image = s.Image
+	case *profile.BareMetalNodeSpec:
+		// Machine already exists, ssh step adds it to the cluster
+		config.Node = node.Node{
+			Provider:  clouds.BareMetal,
+			PublicIp:  s.PublicIp,
+			PrivateIp: s.PrivateIp,
+			State:     node.StatePlanned,
+		}
+		if config.Node.PrivateIp == "" {
+			config.Node.PrivateIp = s.PublicIp
+		}
 	}
 
 	return nil
@@ -79,7 +121,7 @@ func nodesFromProfile(clusterName string, masterTasks, nodeTasks []*workflows.Ta
 		masters[n.Name] = n
 	}
 
-	for index, p := range profile.NodesProfiles {
+	for index, worker := range profile.Workers() {
 		taskId := nodeTasks[index].ID
 		name := util.MakeNodeName(clusterName, taskId[:4], false)
 
@@ -95,13 +137,33 @@ func nodesFromProfile(clusterName string, masterTasks, nodeTasks []*workflows.Ta
 			State:    node.StatePlanned,
 		}
 
-		util.BindParams(p, n)
+		if worker.Pool != nil {
+			n.Pool = worker.Pool.Name
+		}
+
+		util.BindParams(worker.Profile, n)
 		nodes[n.Name] = n
 	}
 
 	return masters, nodes
 }
 
+// nodePools maps pools of the profile by name, kube keeps its own copy
+// of each pool because pools are scaled after cluster is provisioned.
+func nodePools(pools []profile.NodePool) map[string]*profile.NodePool {
+	if len(pools) == 0 {
+		return nil
+	}
+
+	m := make(map[string]*profile.NodePool, len(pools))
+	for _, pool := range pools {
+		pool := pool
+		m[pool.Name] = &pool
+	}
+
+	return m
+}
+
 func generateKeyPair(size int) (string, string, error) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, size)
 
@@ -9,6 +9,7 @@ import (
 	"golang.org/x/crypto/ssh"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/node"
 	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/workflows"
 	"github.com/supergiant/control/pkg/workflows/steps"
@@ -87,6 +88,117 @@ func TestNodesFromProfile(t *testing.T) {
 	}
 }
 
+func TestNodesFromProfileNodePools(t *testing.T) {
+	p := &profile.Profile{
+		Provider: clouds.DigitalOcean,
+		Region:   "fra1",
+		NodesProfiles: []profile.NodeProfile{
+			{
+				"image": "ubuntu-16-04-x64",
+				"size":  "s-2vcpu-4gb",
+			},
+		},
+		NodePools: []profile.NodePool{
+			{
+				Name:  "gpu",
+				Count: 2,
+				Profile: profile.NodeProfile{
+					"image": "ubuntu-16-04-x64",
+					"size":  "s-4vcpu-8gb",
+				},
+			},
+		},
+	}
+
+	nodeTasks := []*workflows.Task{{ID: "5678"}, {ID: "4321"}, {ID: "8765"}}
+	_, nodes := nodesFromProfile("test", nil, nodeTasks, p)
+
+	if len(nodes) != len(nodeTasks) {
+		t.Fatalf("Wrong node count expected %d actual %d",
+			len(nodeTasks), len(nodes))
+	}
+
+	poolNodes := 0
+	for _, n := range nodes {
+		if n.Pool == "" {
+			continue
+		}
+
+		poolNodes++
+		if n.Pool != "gpu" || n.Size != "s-4vcpu-8gb" {
+			t.Errorf("Wrong pool node %v pool %s", n, n.Pool)
+		}
+	}
+
+	if poolNodes != 2 {
+		t.Errorf("Wrong pool node count expected %d actual %d", 2, poolNodes)
+	}
+}
+
+func TestFillNodeCloudSpecificDataAWS(t *testing.T) {
+	config := &steps.Config{
+		AWSConfig: steps.AWSConfig{
+			VolumeSize:   "100",
+			EbsOptimized: "true",
+		},
+	}
+
+	err := FillNodeCloudSpecificData(clouds.AWS, profile.NodeProfile{
+		"size": "m4.large",
+	}, config)
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if config.AWSConfig.InstanceType != "m4.large" {
+		t.Errorf("Wrong instance type %s", config.AWSConfig.InstanceType)
+	}
+
+	// Fields that are not set in node profile are kept
+	if config.AWSConfig.VolumeSize != "100" || config.AWSConfig.EbsOptimized != "true" {
+		t.Errorf("Wrong aws config %+v", config.AWSConfig)
+	}
+
+	err = FillNodeCloudSpecificData(clouds.AWS, profile.NodeProfile{
+		"size":       "m4.large",
+		"volumeSize": "40",
+	}, config)
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if config.AWSConfig.VolumeSize != "40" {
+		t.Errorf("Wrong volume size expected 40 actual %s", config.AWSConfig.VolumeSize)
+	}
+}
+
+func TestFillNodeCloudSpecificDataBareMetal(t *testing.T) {
+	config := &steps.Config{}
+
+	err := FillNodeCloudSpecificData(clouds.BareMetal, profile.NodeProfile{
+		"publicIp": "10.20.30.40",
+	}, config)
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if config.Node.PublicIp != "10.20.30.40" || config.Node.PrivateIp != "10.20.30.40" ||
+		config.Node.State != node.StatePlanned {
+		t.Errorf("Wrong node %v", config.Node)
+	}
+
+	err = FillNodeCloudSpecificData(clouds.BareMetal, profile.NodeProfile{
+		"size": "m4.large",
+	}, config)
+
+	if err == nil {
+		t.Errorf("Error must not be nil")
+	}
+}
+
 func TestGeneratePublicKey(t *testing.T) {
 	pk, _ := ssh.ParseRawPrivateKey([]byte(privateKeyBytes))
 
@@ -0,0 +1,253 @@
+package provisioner
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// ValidationResponse is a result of a profile dry run.
+type ValidationResponse struct {
+	Valid  bool                 `json:"valid"`
+	Errors []profile.FieldError `json:"errors"`
+}
+
+// versionSet contains versions of components that are known to work
+// with a particular kubernetes minor version using provisioning templates.
+type versionSet struct {
+	docker  []string
+	helm    []string
+	flannel []string
+}
+
+// NOTE: docker template builds package url in 17.x format,
+// helm and flannel versions are compared by major.minor.
+var supportedVersions = map[string]versionSet{
+	"1.11": {
+		docker:  []string{"17.06.0", "17.09.0", "17.12.0"},
+		helm:    []string{"2.8", "2.9", "2.10", "2.11"},
+		flannel: []string{"0.9", "0.10"},
+	},
+	"1.12": {
+		docker:  []string{"17.06.0", "17.09.0", "17.12.0"},
+		helm:    []string{"2.9", "2.10", "2.11", "2.12"},
+		flannel: []string{"0.10"},
+	},
+	"1.13": {
+		docker:  []string{"17.06.0", "17.09.0", "17.12.0"},
+		helm:    []string{"2.11", "2.12"},
+		flannel: []string{"0.10"},
+	},
+}
+
+// ValidateProfile checks that cluster can be built from the profile, it returns
+// profile.ValidationErrors with all problems found or nil.
+func ValidateProfile(p *profile.Profile) error {
+	errs := profile.ValidationErrors{}
+
+	if p == nil {
+		errs.Add("profile", "must not be empty")
+		return errs
+	}
+
+	validateMasters(p, &errs)
+	errs = append(errs, profile.ValidateNodeProfiles(p)...)
+	errs = append(errs, profile.ValidateNodePools(p.NodePools)...)
+	validateNetworking(p, &errs)
+	validateVersions(p, &errs)
+	validateCloudSpecificSettings(p, &errs)
+	validateRetryPolicies(p, &errs)
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+func validateMasters(p *profile.Profile, errs *profile.ValidationErrors) {
+	count := len(p.MasterProfiles)
+
+	if count == 0 {
+		errs.Add("masterProfiles", "at least one master is required")
+		return
+	}
+
+	// etcd runs on each master, even count of members gives no
+	// extra failure tolerance but increases quorum size.
+	if count%2 == 0 {
+		errs.Add("masterProfiles", "master count must be odd to keep etcd quorum, got %d", count)
+	}
+}
+
+func validateNetworking(p *profile.Profile, errs *profile.ValidationErrors) {
+	podSubnet := parseSubnet("cidr", p.CIDR, errs)
+	svcSubnet := parseSubnet("k8sServicesCIDR", p.K8SServicesCIDR, errs)
+
+	if util.SubnetsOverlap(podSubnet, svcSubnet) {
+		errs.Add("k8sServicesCIDR", "services subnet %s overlaps with pod subnet %s",
+			svcSubnet, podSubnet)
+	}
+
+	if svcSubnet != nil {
+		if _, err := util.GetDNSIP(svcSubnet.String()); err != nil {
+			errs.Add("k8sServicesCIDR", "no room for cluster DNS address in %s", svcSubnet)
+		}
+	}
+
+	if p.Provider == clouds.AWS && p.CloudSpecificSettings[clouds.AwsVpcCIDR] != "" {
+		field := "cloudSpecificSettings." + clouds.AwsVpcCIDR
+		vpcSubnet := parseSubnet(field, p.CloudSpecificSettings[clouds.AwsVpcCIDR], errs)
+
+		if util.SubnetsOverlap(vpcSubnet, podSubnet) {
+			errs.Add(field, "vpc subnet %s overlaps with pod subnet %s", vpcSubnet, podSubnet)
+		}
+
+		if util.SubnetsOverlap(vpcSubnet, svcSubnet) {
+			errs.Add(field, "vpc subnet %s overlaps with services subnet %s", vpcSubnet, svcSubnet)
+		}
+	}
+}
+
+func parseSubnet(field, cidr string, errs *profile.ValidationErrors) *net.IPNet {
+	if cidr == "" {
+		errs.Add(field, "must not be empty")
+		return nil
+	}
+
+	subnet, err := util.ParseSubnet(cidr)
+	if err != nil {
+		errs.Add(field, "invalid CIDR %s", cidr)
+		return nil
+	}
+
+	return subnet
+}
+
+func validateVersions(p *profile.Profile, errs *profile.ValidationErrors) {
+	if p.K8SVersion == "" {
+		errs.Add("K8SVersion", "must not be empty")
+		return
+	}
+
+	versions, ok := supportedVersions[minorVersion(p.K8SVersion)]
+	if !ok {
+		errs.Add("K8SVersion", "kubernetes version %s is not supported", p.K8SVersion)
+		return
+	}
+
+	if !contains(versions.docker, p.DockerVersion) {
+		errs.Add("dockerVersion", "docker version %q is not supported with kubernetes %s, use one of %s",
+			p.DockerVersion, p.K8SVersion, strings.Join(versions.docker, ", "))
+	}
+
+	if !contains(versions.helm, minorVersion(p.HelmVersion)) {
+		errs.Add("helmVersion", "helm version %q is not supported with kubernetes %s, use one of %s",
+			p.HelmVersion, p.K8SVersion, strings.Join(versions.helm, ", "))
+	}
+
+	if !contains(versions.flannel, minorVersion(p.FlannelVersion)) {
+		errs.Add("flannelVersion", "flannel version %q is not supported with kubernetes %s, use one of %s",
+			p.FlannelVersion, p.K8SVersion, strings.Join(versions.flannel, ", "))
+	}
+}
+
+func validateCloudSpecificSettings(p *profile.Profile, errs *profile.ValidationErrors) {
+	switch p.Provider {
+	case clouds.AWS:
+		// NOTE: empty vpc id means that new vpc will be created
+		// with the provided CIDR.
+		if p.CloudSpecificSettings[clouds.AwsVpcID] == "" &&
+			p.CloudSpecificSettings[clouds.AwsVpcCIDR] == "" {
+			errs.Add("cloudSpecificSettings."+clouds.AwsVpcCIDR,
+				"either %s or %s must be specified", clouds.AwsVpcID, clouds.AwsVpcCIDR)
+		}
+		if p.Region == "" {
+			errs.Add("region", "must not be empty")
+		}
+	case clouds.GCE:
+		if p.Zone == "" {
+			errs.Add("zone", "must not be empty")
+		}
+	case clouds.DigitalOcean:
+		if p.Region == "" {
+			errs.Add("region", "must not be empty")
+		}
+	case clouds.BareMetal:
+		validateHosts(p, errs)
+	default:
+		errs.Add("provider", "unsupported provider %q", p.Provider)
+	}
+}
+
+// validateHosts checks that each machine of bare metal provider
+// is used once, pool nodes share the profile, so pools are not supported.
+func validateHosts(p *profile.Profile, errs *profile.ValidationErrors) {
+	if len(p.NodePools) > 0 {
+		errs.Add("nodePools", "node pools are not supported by %s provider", p.Provider)
+	}
+
+	hosts := make(map[string]struct{})
+	check := func(field string, nodeProfile profile.NodeProfile) {
+		host := nodeProfile["publicIp"]
+		if host == "" {
+			return
+		}
+
+		if _, ok := hosts[host]; ok {
+			errs.Add(field+".publicIp", "host %s is used more than once", host)
+		}
+		hosts[host] = struct{}{}
+	}
+
+	for i, nodeProfile := range p.MasterProfiles {
+		check(fmt.Sprintf("masterProfiles[%d]", i), nodeProfile)
+	}
+
+	for i, nodeProfile := range p.NodesProfiles {
+		check(fmt.Sprintf("nodesProfiles[%d]", i), nodeProfile)
+	}
+}
+
+// validateRetryPolicies checks that policies override steps that exist.
+func validateRetryPolicies(p *profile.Profile, errs *profile.ValidationErrors) {
+	*errs = append(*errs, profile.ValidateRetryPolicies(p.RetryPolicies)...)
+
+	names := make([]string, 0, len(p.RetryPolicies))
+	for name := range p.RetryPolicies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if steps.GetStep(name) == nil {
+			errs.Add("retryPolicies."+name, "unknown step %s", name)
+		}
+	}
+}
+
+// minorVersion trims patch part of the version, 1.11.5 becomes 1.11
+func minorVersion(version string) string {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) < 2 {
+		return version
+	}
+
+	return strings.Join(parts[:2], ".")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,217 @@
+package provisioner
+
+import (
+	"testing"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/profile"
+)
+
+func validProfile() profile.Profile {
+	return profile.Profile{
+		Provider:        clouds.DigitalOcean,
+		Region:          "fra1",
+		Arch:            "amd64",
+		OperatingSystem: "linux",
+		UbuntuVersion:   "xenial",
+		K8SVersion:      "1.11.5",
+		DockerVersion:   "17.06.0",
+		HelmVersion:     "2.11.0",
+		FlannelVersion:  "0.10.0",
+		NetworkType:     "vxlan",
+		CIDR:            "10.0.0.0/16",
+		K8SServicesCIDR: DefaultK8SServicesCIDR,
+		MasterProfiles: []profile.NodeProfile{
+			{
+				"size":  "s-2vcpu-4gb",
+				"image": "ubuntu-16-04-x64",
+			},
+		},
+		NodesProfiles: []profile.NodeProfile{
+			{
+				"size":  "s-2vcpu-4gb",
+				"image": "ubuntu-16-04-x64",
+			},
+		},
+		CloudSpecificSettings: profile.CloudSpecificSettings{},
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	testCases := []struct {
+		description    string
+		modify         func(*profile.Profile)
+		expectedFields []string
+	}{
+		{
+			description: "valid",
+			modify:      func(*profile.Profile) {},
+		},
+		{
+			description: "malformed pod cidr",
+			modify: func(p *profile.Profile) {
+				p.CIDR = "10.0.0.0/35"
+			},
+			expectedFields: []string{"cidr"},
+		},
+		{
+			description: "services cidr overlaps pod cidr",
+			modify: func(p *profile.Profile) {
+				p.K8SServicesCIDR = "10.0.3.0/24"
+			},
+			expectedFields: []string{"k8sServicesCIDR"},
+		},
+		{
+			description: "services cidr too small",
+			modify: func(p *profile.Profile) {
+				p.K8SServicesCIDR = "10.3.0.0/30"
+			},
+			expectedFields: []string{"k8sServicesCIDR"},
+		},
+		{
+			description: "even master count",
+			modify: func(p *profile.Profile) {
+				p.MasterProfiles = append(p.MasterProfiles, p.MasterProfiles[0])
+			},
+			expectedFields: []string{"masterProfiles"},
+		},
+		{
+			description: "node profile typo",
+			modify: func(p *profile.Profile) {
+				p.NodesProfiles[0] = profile.NodeProfile{
+					"sise":  "s-2vcpu-4gb",
+					"image": "ubuntu-16-04-x64",
+				}
+			},
+			expectedFields: []string{"nodesProfiles[0].size", "nodesProfiles[0].sise"},
+		},
+		{
+			description: "no masters",
+			modify: func(p *profile.Profile) {
+				p.MasterProfiles = nil
+			},
+			expectedFields: []string{"masterProfiles"},
+		},
+		{
+			description: "unsupported k8s version",
+			modify: func(p *profile.Profile) {
+				p.K8SVersion = "1.8.0"
+			},
+			expectedFields: []string{"K8SVersion"},
+		},
+		{
+			description: "incompatible component versions",
+			modify: func(p *profile.Profile) {
+				p.K8SVersion = "1.13.0"
+				p.DockerVersion = "1.18.1"
+				p.HelmVersion = "2.8.0"
+				p.FlannelVersion = "0.9.1"
+			},
+			expectedFields: []string{"dockerVersion", "helmVersion", "flannelVersion"},
+		},
+		{
+			description: "aws without vpc",
+			modify: func(p *profile.Profile) {
+				p.Provider = clouds.AWS
+				p.MasterProfiles[0] = profile.NodeProfile{"size": "m4.large"}
+				p.NodesProfiles[0] = profile.NodeProfile{"size": "m4.large"}
+			},
+			expectedFields: []string{"cloudSpecificSettings." + clouds.AwsVpcCIDR},
+		},
+		{
+			description: "aws vpc overlaps pod cidr",
+			modify: func(p *profile.Profile) {
+				p.Provider = clouds.AWS
+				p.MasterProfiles[0] = profile.NodeProfile{"size": "m4.large"}
+				p.NodesProfiles[0] = profile.NodeProfile{"size": "m4.large"}
+				p.CloudSpecificSettings[clouds.AwsVpcCIDR] = "10.0.0.0/8"
+			},
+			expectedFields: []string{
+				"cloudSpecificSettings." + clouds.AwsVpcCIDR,
+				"cloudSpecificSettings." + clouds.AwsVpcCIDR,
+			},
+		},
+		{
+			description: "gce without zone",
+			modify: func(p *profile.Profile) {
+				p.Provider = clouds.GCE
+				p.MasterProfiles[0] = profile.NodeProfile{"size": "n1-standard-2"}
+				p.NodesProfiles[0] = profile.NodeProfile{"size": "n1-standard-2"}
+			},
+			expectedFields: []string{"zone"},
+		},
+		{
+			description: "baremetal",
+			modify: func(p *profile.Profile) {
+				p.Provider = clouds.BareMetal
+				p.MasterProfiles[0] = profile.NodeProfile{"publicIp": "10.0.0.1"}
+				p.NodesProfiles[0] = profile.NodeProfile{"publicIp": "10.0.0.2"}
+			},
+		},
+		{
+			description: "baremetal host used twice",
+			modify: func(p *profile.Profile) {
+				p.Provider = clouds.BareMetal
+				p.MasterProfiles[0] = profile.NodeProfile{"publicIp": "10.0.0.1"}
+				p.NodesProfiles[0] = profile.NodeProfile{"publicIp": "10.0.0.1"}
+			},
+			expectedFields: []string{"nodesProfiles[0].publicIp"},
+		},
+		{
+			description: "unknown provider",
+			modify: func(p *profile.Profile) {
+				p.Provider = clouds.Unknown
+			},
+			expectedFields: []string{"provider"},
+		},
+		{
+			description: "retry policy of unknown step",
+			modify: func(p *profile.Profile) {
+				p.RetryPolicies = map[string]profile.RetryPolicy{
+					"unknown": {MaxAttempts: -1},
+				}
+			},
+			expectedFields: []string{"retryPolicies.unknown.maxAttempts", "retryPolicies.unknown"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		p := validProfile()
+		testCase.modify(&p)
+
+		err := ValidateProfile(&p)
+
+		if len(testCase.expectedFields) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", testCase.description, err)
+			}
+			continue
+		}
+
+		errs, ok := err.(profile.ValidationErrors)
+		if !ok {
+			t.Errorf("%s: expected validation errors actual %v",
+				testCase.description, err)
+			continue
+		}
+
+		if len(errs) != len(testCase.expectedFields) {
+			t.Errorf("%s: wrong error count expected %d actual %d: %v",
+				testCase.description, len(testCase.expectedFields), len(errs), errs)
+			continue
+		}
+
+		for i, field := range testCase.expectedFields {
+			if errs[i].Field != field {
+				t.Errorf("%s: wrong field expected %s actual %s",
+					testCase.description, field, errs[i].Field)
+			}
+		}
+	}
+}
+
+func TestValidateProfileNil(t *testing.T) {
+	if err := ValidateProfile(nil); err == nil {
+		t.Errorf("error expected for nil profile")
+	}
+}
@@ -30,7 +30,12 @@ type PortRange struct {
 	From, To int32
 }
 
-type Target struct{ ProxyID, TargetURL, SelfLink, User, Password string }
+type Target struct {
+	ProxyID, TargetURL, SelfLink, User, Password string
+	// BearerToken or ClientCert and ClientKey authenticate at the API
+	// of kubes that have no basic auth credentials, e.g. imported ones.
+	BearerToken, ClientCert, ClientKey string
+}
 
 type Container interface {
 	RegisterProxies(targets []*Target) error
@@ -54,22 +59,32 @@ func NewReverseProxyContainer(proxiesPortRange PortRange, logger logrus.FieldLog
 	}
 }
 
-func NewServiceProxy(port int32, targetURL, selfLink, user, password string, logger logrus.FieldLogger) (*ServiceReverseProxy, error) {
+func NewServiceProxy(port int32, target *Target, logger logrus.FieldLogger) (*ServiceReverseProxy, error) {
 	var httpServer = &http.Server{}
 	var mux = http.NewServeMux()
 	httpServer.Handler = mux
 
-	url, err := url.Parse(targetURL)
+	url, err := url.Parse(target.TargetURL)
 	if err != nil {
 		return nil, err
 	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	if target.ClientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(target.ClientCert), []byte(target.ClientKey))
+		if err != nil {
+			return nil, errors.Wrap(err, "load client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
 	// create the reverse proxy
 	proxy := httputil.NewSingleHostReverseProxy(url)
 	proxy.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		TLSClientConfig: tlsConfig,
 	}
 
-	mux.HandleFunc("/", newHandler(selfLink, user, password, proxy, logger))
+	mux.HandleFunc("/", newHandler(target, proxy, logger))
 
 	listener, err := net.Listen("tcp", ":"+strconv.Itoa(int(port)))
 	if err != nil {
@@ -90,7 +105,7 @@ func NewServiceProxy(port int32, targetURL, selfLink, user, password string, log
 		}
 	}()
 
-	logger.Infof("proxy server started on: %s, for targetURL: %+v", addr, targetURL)
+	logger.Infof("proxy server started on: %s, for targetURL: %+v", addr, target.TargetURL)
 
 	return &ServiceReverseProxy{
 		ServingBase: addr,
@@ -119,7 +134,7 @@ func (p *ReverseProxyContainer) RegisterProxies(targets []*Target) error {
 
 		p.logger.Infof("returned port: %d", port)
 
-		proxy, err := NewServiceProxy(port, targetSvc.TargetURL, targetSvc.SelfLink, targetSvc.User, targetSvc.Password, p.logger)
+		proxy, err := NewServiceProxy(port, targetSvc, p.logger)
 		if err != nil {
 			p.logger.Errorf("can't create proxy for serviceID: %v, err: %v", err, targetSvc.ProxyID)
 			continue
@@ -146,7 +161,8 @@ func (p *ReverseProxyContainer) GetProxies(prefix string) map[string]*ServiceRev
 	return result
 }
 
-func newHandler(selfLink, user, password string, reverseProxy *httputil.ReverseProxy, logger logrus.FieldLogger) func(http.ResponseWriter, *http.Request) {
+func newHandler(target *Target, reverseProxy *httputil.ReverseProxy, logger logrus.FieldLogger) func(http.ResponseWriter, *http.Request) {
+	selfLink := target.SelfLink
 
 	return func(res http.ResponseWriter, req *http.Request) {
 		var inputURL = req.URL.Path
@@ -169,7 +185,11 @@ func newHandler(selfLink, user, password string, reverseProxy *httputil.ReverseP
 			req.URL.String(),
 		)
 
-		req.SetBasicAuth(user, password)
+		if target.BearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+target.BearerToken)
+		} else if target.User != "" {
+			req.SetBasicAuth(target.User, target.Password)
+		}
 
 		// Update the headers to allow for SSL redirection
 		req.Header.Set("X-Forwarded-Host", req.Header.Get("Host"))
@@ -111,8 +111,11 @@ func TestNewHandler(t *testing.T) {
 	u := &url.URL{}
 	reverseproxy := httputil.NewSingleHostReverseProxy(u)
 	logger := logrus.New()
-	h := newHandler("selflink",
-		"user", "password", reverseproxy, logger)
+	h := newHandler(&Target{
+		SelfLink: "selflink",
+		User:     "user",
+		Password: "password",
+	}, reverseproxy, logger)
 
 	if h == nil {
 		t.Errorf("Handler value must not be nil")
@@ -125,6 +128,48 @@ func TestNewHandler(t *testing.T) {
 	h(rec, req)
 }
 
+func TestNewHandlerAuth(t *testing.T) {
+	testCases := []struct {
+		description string
+		target      *Target
+		expected    string
+	}{
+		{
+			description: "basic auth",
+			target:      &Target{User: "user", Password: "password"},
+			expected:    "Basic dXNlcjpwYXNzd29yZA==",
+		},
+		{
+			description: "bearer token",
+			target:      &Target{User: "user", Password: "password", BearerToken: "token"},
+			expected:    "Bearer token",
+		},
+		{
+			description: "no credentials",
+			target:      &Target{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		var actual string
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actual = r.Header.Get("Authorization")
+		}))
+
+		u, _ := url.Parse(backend.URL)
+		h := newHandler(testCase.target, httputil.NewSingleHostReverseProxy(u), logrus.New())
+
+		req, _ := http.NewRequest(http.MethodGet, "http://hostname.com/", nil)
+		h(httptest.NewRecorder(), req)
+		backend.Close()
+
+		if actual != testCase.expected {
+			t.Errorf("%s: wrong authorization expected %q actual %q",
+				testCase.description, testCase.expected, actual)
+		}
+	}
+}
+
 func TestCheckPort(t *testing.T) {
 	isOccupied := checkPort(-1)
 
@@ -172,7 +217,12 @@ func TestNewServiceProxy(t *testing.T) {
 	}
 
 	logger := logrus.New()
-	proxy, err := NewServiceProxy(port, "/url", "selflink", "user", "password", logger)
+	proxy, err := NewServiceProxy(port, &Target{
+		TargetURL: "/url",
+		SelfLink:  "selflink",
+		User:      "user",
+		Password:  "password",
+	}, logger)
 	if err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
@@ -18,6 +18,8 @@ type Command struct {
 
 	Script string
 
+	// In is an optional input of the script, it is used to upload files.
+	In  io.Reader
 	Out io.Writer
 	Err io.Writer
 }
@@ -29,9 +31,9 @@ func NewCommand(ctx context.Context, script string, out, err io.Writer) (*Comman
 	}
 
 	return &Command{
-		ctx,
-		script,
-		out,
-		err,
+		Ctx:    ctx,
+		Script: script,
+		Out:    out,
+		Err:    err,
 	}, nil
 }
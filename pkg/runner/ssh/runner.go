@@ -77,6 +77,9 @@ func (r *Runner) Run(cmd *runner.Command) (err error) {
 
 	session.Stdout = cmd.Out
 	session.Stderr = cmd.Err
+	if cmd.In != nil {
+		session.Stdin = cmd.In
+	}
 
 	waitCh := make(chan error)
 	go func() {
@@ -18,6 +18,17 @@ type Interface interface {
 	Delete(ctx context.Context, prefix string, key string) error
 }
 
+// ErrValueChanged is returned when a value has been changed by someone else.
+var ErrValueChanged = errors.New("value has been changed")
+
+// Swapper updates values atomically, it lets one of controlplanes
+// that share the storage claim a job.
+type Swapper interface {
+	// CompareAndSwap puts the value if the key still holds the old one,
+	// nil old value means that the key must not exist.
+	CompareAndSwap(ctx context.Context, prefix string, key string, old, value []byte) error
+}
+
 type ETCDRepository struct {
 	cfg clientv3.Config
 }
@@ -52,6 +63,28 @@ func (e *ETCDRepository) Put(ctx context.Context, prefix string, key string, val
 	return errors.Wrap(err, "failed to write to the etcd")
 }
 
+func (e *ETCDRepository) CompareAndSwap(ctx context.Context, prefix string, key string, old, value []byte) error {
+	cl, err := e.GetClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the etcd")
+	}
+	defer cl.Close()
+
+	cmp := clientv3.Compare(clientv3.Value(prefix+key), "=", string(old))
+	if old == nil {
+		cmp = clientv3.Compare(clientv3.CreateRevision(prefix+key), "=", 0)
+	}
+
+	res, err := cl.Txn(ctx).If(cmp).Then(clientv3.OpPut(prefix+key, string(value))).Commit()
+	if err != nil {
+		return errors.Wrap(err, "failed to write to the etcd")
+	}
+	if !res.Succeeded {
+		return ErrValueChanged
+	}
+	return nil
+}
+
 func (e *ETCDRepository) Delete(ctx context.Context, prefix string, key string) error {
 	cl, err := e.GetClient()
 	if err != nil {
@@ -2,13 +2,16 @@ package util
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/digitalocean/godo"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/jwt"
 	compute "google.golang.org/api/compute/v1"
@@ -29,6 +32,7 @@ type CloudAccountValidatorImpl struct {
 	digitalOcean func(map[string]string) error
 	aws          func(map[string]string) error
 	gce          func(map[string]string) error
+	bareMetal    func(map[string]string) error
 }
 
 func NewCloudAccountValidator() *CloudAccountValidatorImpl {
@@ -36,6 +40,7 @@ func NewCloudAccountValidator() *CloudAccountValidatorImpl {
 		digitalOcean: validateDigitalOceanCredentials,
 		aws:          validateAWSCredentials,
 		gce:          validateGCECredentials,
+		bareMetal:    validateBareMetalCredentials,
 	}
 }
 
@@ -47,6 +52,8 @@ func (validator *CloudAccountValidatorImpl) ValidateCredentials(cloudAccount *mo
 		return validator.aws(cloudAccount.Credentials)
 	case clouds.GCE:
 		return validator.gce(cloudAccount.Credentials)
+	case clouds.BareMetal:
+		return validator.bareMetal(cloudAccount.Credentials)
 	}
 
 	return sgerrors.ErrUnsupportedProvider
@@ -131,3 +138,24 @@ func validateGCECredentials(creds map[string]string) error {
 
 	return nil
 }
+
+// validateBareMetalCredentials checks that the private key can be parsed
+// and the port is valid if set, machines are not known until the kube
+// is provisioned.
+func validateBareMetalCredentials(creds map[string]string) error {
+	if creds[clouds.BareMetalUser] == "" {
+		return errors.New("ssh user must not be empty")
+	}
+
+	if _, err := ssh.ParsePrivateKey([]byte(creds[clouds.BareMetalPrivateKey])); err != nil {
+		return errors.Wrap(err, "parse ssh private key")
+	}
+
+	if port := creds[clouds.BareMetalPort]; port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return errors.Errorf("%q is not a valid ssh port", port)
+		}
+	}
+
+	return nil
+}
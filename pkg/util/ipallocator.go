@@ -58,3 +58,22 @@ func bigForIP(ip net.IP) *big.Int {
 func addIPOffset(base *big.Int, offset int) net.IP {
 	return net.IP(big.NewInt(0).Add(base, big.NewInt(int64(offset))).Bytes())
 }
+
+// ParseSubnet returns a network of the provided CIDR notation string.
+func ParseSubnet(cidr string) (*net.IPNet, error) {
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s subnet", cidr)
+	}
+
+	return subnet, nil
+}
+
+// SubnetsOverlap reports whether two networks have at least one address in common.
+func SubnetsOverlap(a, b *net.IPNet) bool {
+	if a == nil || b == nil {
+		return false
+	}
+
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
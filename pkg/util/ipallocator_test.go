@@ -63,3 +63,65 @@ func TestGetKubernetesDefaultSvcIP(t *testing.T) {
 		}
 	}
 }
+
+func TestSubnetsOverlap(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		a        string
+		b        string
+		expected bool
+	}{
+		{
+			name:     "same",
+			a:        "10.0.0.0/16",
+			b:        "10.0.0.0/16",
+			expected: true,
+		},
+		{
+			name:     "nested",
+			a:        "10.0.0.0/8",
+			b:        "10.3.0.0/16",
+			expected: true,
+		},
+		{
+			name:     "nested_reversed",
+			a:        "10.3.0.0/16",
+			b:        "10.0.0.0/8",
+			expected: true,
+		},
+		{
+			name:     "disjoint",
+			a:        "10.0.0.0/16",
+			b:        "10.3.0.0/16",
+			expected: false,
+		},
+	} {
+		a, err := ParseSubnet(tc.a)
+		if err != nil {
+			t.Fatalf("TC: %s: unexpected error %v", tc.name, err)
+		}
+		b, err := ParseSubnet(tc.b)
+		if err != nil {
+			t.Fatalf("TC: %s: unexpected error %v", tc.name, err)
+		}
+
+		if actual := SubnetsOverlap(a, b); actual != tc.expected {
+			t.Fatalf("TC: %s: overlap %v, expected %v", tc.name, actual, tc.expected)
+		}
+	}
+}
+
+func TestParseSubnet(t *testing.T) {
+	if _, err := ParseSubnet("10.0.0.0/33"); err == nil {
+		t.Fatal("error expected for invalid subnet")
+	}
+
+	subnet, err := ParseSubnet("10.0.0.1/24")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if subnet.String() != "10.0.0.0/24" {
+		t.Fatalf("wrong subnet %s, expected 10.0.0.0/24", subnet)
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"net/url"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -97,6 +100,29 @@ func MakeKeyName(name string, isUser bool) string {
 	return fmt.Sprintf("%s-provision", name)
 }
 
+// MakeLoadBalancerName returns name of the load balancer in front of masters
+// of the cluster, the name fits naming restrictions of all cloud providers.
+func MakeLoadBalancerName(clusterID string) string {
+	return fmt.Sprintf("sg-%s-api", strings.ToLower(clusterID))
+}
+
+// LoadBalancerHost returns address of the load balancer in front of masters
+// of provisioned kube or empty string if masters are reached directly.
+func LoadBalancerHost(k *model.Kube) (string, error) {
+	// API of provisioned kube has an endpoint only if it is behind load balancer
+	if k.APIEndpoint == "" || k.State == model.StateImported {
+		return "", nil
+	}
+
+	u, err := url.Parse(k.APIEndpoint)
+
+	if err != nil {
+		return "", errors.Wrapf(err, "parse api endpoint %s", k.APIEndpoint)
+	}
+
+	return u.Hostname(), nil
+}
+
 // TODO(stgleb): move getting cloud account outside of this function
 // Gets cloud account from storage and fills config object with those credentials
 func FillCloudAccountCredentials(ctx context.Context, cloudAccount *model.CloudAccount, config *steps.Config) error {
@@ -117,6 +143,15 @@ func FillCloudAccountCredentials(ctx context.Context, cloudAccount *model.CloudA
 		return BindParams(cloudAccount.Credentials, &config.DigitalOceanConfig)
 	case clouds.GCE:
 		return BindParams(cloudAccount.Credentials, &config.GCEConfig)
+	case clouds.BareMetal:
+		// Machines are reachable with the key of the account,
+		// there is no cloud API to upload a bootstrap key to.
+		config.SshConfig.User = cloudAccount.Credentials[clouds.BareMetalUser]
+		config.SshConfig.BootstrapPrivateKey = cloudAccount.Credentials[clouds.BareMetalPrivateKey]
+		if port := cloudAccount.Credentials[clouds.BareMetalPort]; port != "" {
+			config.SshConfig.Port = port
+		}
+		return nil
 	default:
 		return sgerrors.ErrUnknownProvider
 	}
@@ -143,6 +178,17 @@ func LoadCloudSpecificDataFromKube(k *model.Kube, config *steps.Config) error {
 	config.SshConfig.BootstrapPrivateKey = string(k.BootstrapPrivateKey)
 	config.SshConfig.PublicKey = string(k.SshPublicKey)
 
+	lbHost, err := LoadBalancerHost(k)
+
+	if err != nil {
+		return err
+	}
+
+	if lbHost != "" {
+		config.LoadBalancerConfig.Enabled = true
+		config.LoadBalancerConfig.Host = lbHost
+	}
+
 	switch config.Provider {
 	case clouds.AWS:
 		// Load AZ -> subnet mapping for cluster
@@ -161,12 +207,20 @@ func LoadCloudSpecificDataFromKube(k *model.Kube, config *steps.Config) error {
 		config.AWSConfig.MastersInstanceProfile = k.CloudSpec[clouds.AwsMasterInstanceProfile]
 		config.AWSConfig.NodesInstanceProfile = k.CloudSpec[clouds.AwsNodeInstanceProfile]
 		config.AWSConfig.ImageID = k.CloudSpec[clouds.AwsImageID]
+		// NOTE: kubes created before resources were tagged have no such key.
+		config.AWSConfig.ResourcesTagged, _ = strconv.ParseBool(k.CloudSpec[clouds.AwsResourcesTagged])
 		return nil
 	case clouds.GCE:
 		config.GCEConfig.Region = k.Region
 		return nil
 	case clouds.DigitalOcean:
 		return nil
+	case clouds.BareMetal:
+		config.SshConfig.User = k.SshUser
+		if k.SshPort != "" {
+			config.SshConfig.Port = k.SshPort
+		}
+		return nil
 	}
 
 	return errors.Wrapf(sgerrors.ErrUnsupportedProvider,
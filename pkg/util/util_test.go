@@ -120,6 +120,19 @@ func TestFillCloudAccountCredentials(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			testName: "baremetal",
+			cloudAccount: &model.CloudAccount{
+				Name:     "testName",
+				Provider: clouds.BareMetal,
+				Credentials: map[string]string{
+					clouds.BareMetalUser:       "ubuntu",
+					clouds.BareMetalPrivateKey: "private-key",
+					clouds.BareMetalPort:       "2222",
+				},
+			},
+			err: nil,
+		},
 		{
 			testName: "unknown provider",
 			cloudAccount: &model.CloudAccount{
@@ -162,6 +175,14 @@ func TestFillCloudAccountCredentials(t *testing.T) {
 			}
 		}
 
+		if testCase.cloudAccount.Provider == clouds.BareMetal {
+			if config.SshConfig.User != "ubuntu" ||
+				config.SshConfig.BootstrapPrivateKey != "private-key" ||
+				config.SshConfig.Port != "2222" {
+				t.Errorf("Wrong ssh config %v", config.SshConfig)
+			}
+		}
+
 		if config.SshConfig.PublicKey != testCase.cloudAccount.Credentials["publicKey"] {
 			t.Errorf("PublicKey %s not found in credentials %v",
 				testCase.cloudAccount.Credentials["publicKey"], config.SshConfig.PublicKey)
@@ -352,6 +373,7 @@ func TestLoadCloudSpecificDataFromKube(t *testing.T) {
 		description string
 		kube        *model.Kube
 		provider    clouds.Name
+		host        string
 		hasErr      bool
 	}{
 		{
@@ -397,6 +419,30 @@ func TestLoadCloudSpecificDataFromKube(t *testing.T) {
 			},
 			provider: clouds.AWS,
 		},
+		{
+			description: "load balancer",
+			kube: &model.Kube{
+				APIEndpoint: "https://sg-1234abcd-api.example.com",
+			},
+			provider: clouds.DigitalOcean,
+			host:     "sg-1234abcd-api.example.com",
+		},
+		{
+			description: "imported",
+			kube: &model.Kube{
+				State:       model.StateImported,
+				APIEndpoint: "https://10.20.30.40:6443",
+			},
+			provider: clouds.DigitalOcean,
+		},
+		{
+			description: "baremetal",
+			kube: &model.Kube{
+				BootstrapPrivateKey: []byte(`private-key`),
+				SshUser:             "ubuntu",
+			},
+			provider: clouds.BareMetal,
+		},
 		{
 			description: "unsupported",
 			kube: &model.Kube{
@@ -429,3 +475,27 @@ func TestLoadCloudSpecificDataFromKube(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadCloudSpecificDataFromKubeTagged(t *testing.T) {
+	for _, tagged := range []bool{false, true} {
+		k := &model.Kube{
+			CloudSpec: map[string]string{},
+		}
+		// Kubes created before resources were tagged have no key
+		if tagged {
+			k.CloudSpec[clouds.AwsResourcesTagged] = "true"
+		}
+
+		config := &steps.Config{
+			Provider: clouds.AWS,
+		}
+		if err := LoadCloudSpecificDataFromKube(k, config); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if config.AWSConfig.ResourcesTagged != tagged {
+			t.Errorf("expected resources tagged %v actual %v",
+				tagged, config.AWSConfig.ResourcesTagged)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package workflows
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/authorizedKeys"
+	"github.com/supergiant/control/pkg/workflows/steps/certificates"
+	"github.com/supergiant/control/pkg/workflows/steps/cni"
+	"github.com/supergiant/control/pkg/workflows/steps/docker"
+	"github.com/supergiant/control/pkg/workflows/steps/downloadk8sbinary"
+	"github.com/supergiant/control/pkg/workflows/steps/flannel"
+	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
+	"github.com/supergiant/control/pkg/workflows/steps/manifest"
+	"github.com/supergiant/control/pkg/workflows/steps/restart"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
+	"github.com/supergiant/control/pkg/workflows/steps/upgradecheck"
+)
+
+// Custom is a type of tasks that run steps chosen by user,
+// names of the steps are kept in the task.
+const Custom = "Custom"
+
+// nodeSteps change a single machine that already exists, they neither
+// create or delete machines nor change the cluster as a whole.
+var nodeSteps = map[string]bool{
+	ssh.StepName:               true,
+	authorizedKeys.StepName:    true,
+	certificates.StepName:      true,
+	cni.StepName:               true,
+	docker.StepName:            true,
+	downloadk8sbinary.StepName: true,
+	flannel.StepName:           true,
+	kubelet.StepName:           true,
+	manifest.StepName:          true,
+	restart.StepName:           true,
+	upgradecheck.StepName:      true,
+}
+
+// StepInfo describes a registered step.
+type StepInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Depends     []string `json:"depends,omitempty"`
+}
+
+// WorkflowInfo describes a registered workflow, steps are listed
+// in the order they are run unless they declare dependencies.
+type WorkflowInfo struct {
+	Name  string     `json:"name"`
+	Steps []StepInfo `json:"steps"`
+}
+
+// ListSteps returns registered steps sorted by name.
+func ListSteps() []StepInfo {
+	list := make([]StepInfo, 0)
+	for _, step := range steps.ListSteps() {
+		list = append(list, stepInfo(step))
+	}
+
+	return list
+}
+
+// ListWorkflows returns registered workflows sorted by name.
+func ListWorkflows() []WorkflowInfo {
+	m.RLock()
+	defer m.RUnlock()
+
+	names := make([]string, 0, len(workflowMap))
+	for name := range workflowMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]WorkflowInfo, 0, len(names))
+	for _, name := range names {
+		info := WorkflowInfo{
+			Name:  name,
+			Steps: make([]StepInfo, 0, len(workflowMap[name])),
+		}
+		for _, step := range workflowMap[name] {
+			info.Steps = append(info.Steps, stepInfo(step))
+		}
+		list = append(list, info)
+	}
+
+	return list
+}
+
+// BuildWorkflow makes a workflow of registered steps, steps run in the
+// given order unless they declare dependencies. An error is returned if
+// a step is not registered or the steps depend on missing steps.
+func BuildWorkflow(stepNames []string) (Workflow, error) {
+	if len(stepNames) == 0 {
+		return nil, errors.New("no steps")
+	}
+
+	w := make(Workflow, 0, len(stepNames))
+	for _, name := range stepNames {
+		step := steps.GetStep(name)
+		if step == nil {
+			return nil, errors.Errorf("step %s is not registered", name)
+		}
+		w = append(w, step)
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// NodeScoped returns an error if some step of the workflow is not
+// safe to run on a single node of a running kube, e.g. creates
+// machines or restores etcd.
+func NodeScoped(w Workflow) error {
+	for _, step := range w {
+		if !nodeSteps[step.Name()] {
+			return errors.Errorf("step %s can't be run on a node", step.Name())
+		}
+	}
+
+	return nil
+}
+
+// NewCustomTask creates a task that runs the steps in the given order.
+func NewCustomTask(stepNames []string, repository storage.Interface) (*Task, error) {
+	w, err := BuildWorkflow(stepNames)
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTask(Custom, w, repository)
+	t.Steps = append([]string{}, stepNames...)
+
+	return t, t.sync(context.Background())
+}
+
+// restoreWorkflow returns the workflow of the task loaded from storage,
+// it is nil if the workflow or some step is no longer registered.
+func (w *Task) restoreWorkflow() Workflow {
+	if w.Type != Custom {
+		return GetWorkflow(w.Type)
+	}
+
+	workflow, err := BuildWorkflow(w.Steps)
+	if err != nil {
+		return nil
+	}
+
+	return workflow
+}
+
+func stepInfo(step steps.Step) StepInfo {
+	return StepInfo{
+		Name:        step.Name(),
+		Description: step.Description(),
+		Depends:     step.Depends(),
+	}
+}
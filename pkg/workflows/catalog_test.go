@@ -0,0 +1,128 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestBuildWorkflow(t *testing.T) {
+	steps.RegisterStep("catalog_a", &MockStep{name: "catalog_a"})
+	steps.RegisterStep("catalog_b", &MockStep{name: "catalog_b", depends: []string{"catalog_a"}})
+
+	testCases := []struct {
+		description string
+		stepNames   []string
+		errMsg      string
+	}{
+		{
+			description: "no steps",
+			errMsg:      "no steps",
+		},
+		{
+			description: "not registered",
+			stepNames:   []string{"catalog_a", "unknown"},
+			errMsg:      "step unknown is not registered",
+		},
+		{
+			description: "missing dependency",
+			stepNames:   []string{"catalog_b"},
+			errMsg:      "step catalog_b depends on missing step catalog_a",
+		},
+		{
+			description: "duplicated",
+			stepNames:   []string{"catalog_a", "catalog_a"},
+			errMsg:      "step catalog_a is duplicated",
+		},
+		{
+			description: "success",
+			stepNames:   []string{"catalog_a", "catalog_b"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		w, err := BuildWorkflow(testCase.stepNames)
+		if testCase.errMsg != "" {
+			require.NotNil(t, err, testCase.description)
+			require.Contains(t, err.Error(), testCase.errMsg, testCase.description)
+			continue
+		}
+
+		require.Nil(t, err, testCase.description)
+		require.Len(t, w, len(testCase.stepNames), testCase.description)
+	}
+}
+
+func TestCustomTask(t *testing.T) {
+	repository := &MockRepository{
+		storage: map[string][]byte{},
+	}
+	steps.RegisterStep("catalog_a", &MockStep{name: "catalog_a"})
+
+	task, err := NewCustomTask([]string{"catalog_a"}, repository)
+	require.Nil(t, err)
+	require.Equal(t, Custom, task.Type)
+
+	errChan := task.Run(context.Background(), steps.Config{}, &bufferCloser{})
+	require.Nil(t, <-errChan)
+	require.Equal(t, statuses.Success, task.Status)
+
+	// Custom workflow is rebuilt of the steps saved with the task
+	data, err := repository.Get(context.Background(), Prefix, task.ID)
+	require.Nil(t, err)
+
+	restored, err := DeserializeTask(data, repository)
+	require.Nil(t, err)
+	require.Equal(t, []string{"catalog_a"}, restored.Steps)
+	require.Len(t, restored.workflow, 1)
+	require.Equal(t, "catalog_a", restored.workflow[0].Name())
+}
+
+func TestTaskHandlerListCatalog(t *testing.T) {
+	workflowMap = make(map[string]Workflow)
+	steps.RegisterStep("catalog_a", &MockStep{name: "catalog_a", description: "first step"})
+	require.Nil(t, RegisterWorkFlow("CatalogWorkflow", Workflow{steps.GetStep("catalog_a")}))
+
+	h := NewTaskHandler(&MockRepository{storage: map[string][]byte{}}, nil, nil)
+	router := mux.NewRouter()
+	h.Register(router)
+
+	req, _ := http.NewRequest(http.MethodGet, "/steps", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	stepList := make([]StepInfo, 0)
+	require.Nil(t, json.NewDecoder(rec.Body).Decode(&stepList))
+	require.Contains(t, stepList, StepInfo{Name: "catalog_a", Description: "first step"})
+
+	req, _ = http.NewRequest(http.MethodGet, "/workflows", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	workflowList := make([]WorkflowInfo, 0)
+	require.Nil(t, json.NewDecoder(rec.Body).Decode(&workflowList))
+	require.Equal(t, []WorkflowInfo{
+		{
+			Name:  "CatalogWorkflow",
+			Steps: []StepInfo{{Name: "catalog_a", Description: "first step"}},
+		},
+	}, workflowList)
+}
+
+func TestNodeScoped(t *testing.T) {
+	require.Nil(t, NodeScoped(Workflow{&MockStep{name: "restart"}, &MockStep{name: "upgradecheck"}}))
+
+	err := NodeScoped(Workflow{&MockStep{name: "restart"}, &MockStep{name: "etcd_restore"}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "etcd_restore")
+}
@@ -0,0 +1,177 @@
+package workflows
+
+import (
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// DefaultParallelism is how many steps of a task may run at once
+const DefaultParallelism = 4
+
+var (
+	pm          sync.RWMutex
+	parallelism = DefaultParallelism
+)
+
+// SetParallelism limits the number of steps of a task that run at once,
+// steps of the task run one by one when n is less than 2.
+func SetParallelism(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	pm.Lock()
+	defer pm.Unlock()
+	parallelism = n
+}
+
+func getParallelism() int {
+	pm.RLock()
+	defer pm.RUnlock()
+	return parallelism
+}
+
+// Validate checks that steps of the workflow are registered and
+// their dependencies are present in the workflow and have no cycles.
+func (w Workflow) Validate() error {
+	_, err := w.dependencies()
+	return err
+}
+
+// dependencies returns indexes of steps each step of the workflow waits for,
+// a step that doesn't declare dependencies waits for all steps before it.
+func (w Workflow) dependencies() ([][]int, error) {
+	index := make(map[string]int, len(w))
+	for i, step := range w {
+		if step == nil {
+			return nil, errors.Errorf("step #%d is not registered", i)
+		}
+
+		if _, ok := index[step.Name()]; ok {
+			return nil, errors.Errorf("step %s is duplicated", step.Name())
+		}
+		index[step.Name()] = i
+	}
+
+	deps := make([][]int, len(w))
+	for i, step := range w {
+		names := step.Depends()
+		if len(names) == 0 {
+			for j := 0; j < i; j++ {
+				deps[i] = append(deps[i], j)
+			}
+			continue
+		}
+
+		for _, name := range names {
+			j, ok := index[name]
+			if !ok {
+				return nil, errors.Errorf("step %s depends on missing step %s", step.Name(), name)
+			}
+			deps[i] = append(deps[i], j)
+		}
+	}
+
+	if cycle := findCycle(deps); cycle != nil {
+		names := make([]string, 0, len(cycle))
+		for _, i := range cycle {
+			names = append(names, w[i].Name())
+		}
+		return nil, errors.Errorf("steps depend on each other %s", strings.Join(names, " -> "))
+	}
+
+	return deps, nil
+}
+
+// after makes the step wait for the given steps as well as for the ones
+// it depends on. NOTE: the order holds only in the workflow the returned
+// step is part of, e.g. flannel of masters waits for etcd network config.
+func after(step steps.Step, names ...string) steps.Step {
+	if step == nil {
+		return nil
+	}
+
+	return &orderedStep{
+		Step:  step,
+		after: names,
+	}
+}
+
+type orderedStep struct {
+	steps.Step
+	after []string
+}
+
+func (s *orderedStep) Depends() []string {
+	return append(append([]string{}, s.Step.Depends()...), s.after...)
+}
+
+// RetryPolicy keeps the policy declared by the step.
+func (s *orderedStep) RetryPolicy() steps.RetryPolicy {
+	return steps.GetRetryPolicy(s.Step, nil)
+}
+
+// findCycle returns indexes of steps that make a cycle, the first
+// step is repeated at the end, nil is returned if there is no cycle.
+func findCycle(deps [][]int) []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(deps))
+	path := make([]int, 0, len(deps))
+
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		path = append(path, i)
+
+		for _, j := range deps[i] {
+			switch state[j] {
+			case visiting:
+				for k := range path {
+					if path[k] == j {
+						return append(append([]int{}, path[k:]...), j)
+					}
+				}
+			case unvisited:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range deps {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
+
+// syncWriter serializes writes of steps that run at once.
+type syncWriter struct {
+	m sync.Mutex
+	w io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.w.Write(p)
+}
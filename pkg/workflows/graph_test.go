@@ -0,0 +1,333 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/certificates"
+	"github.com/supergiant/control/pkg/workflows/steps/cni"
+	"github.com/supergiant/control/pkg/workflows/steps/docker"
+	"github.com/supergiant/control/pkg/workflows/steps/downloadk8sbinary"
+	"github.com/supergiant/control/pkg/workflows/steps/etcd"
+	"github.com/supergiant/control/pkg/workflows/steps/flannel"
+	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
+	"github.com/supergiant/control/pkg/workflows/steps/manifest"
+	"github.com/supergiant/control/pkg/workflows/steps/network"
+	"github.com/supergiant/control/pkg/workflows/steps/poststart"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
+	"github.com/supergiant/control/pkg/workflows/steps/upgradecheck"
+)
+
+// concurrentStep records how many steps run at once.
+type concurrentStep struct {
+	MockStep
+	state *concurrency
+}
+
+type concurrency struct {
+	m       sync.Mutex
+	running int
+	max     int
+	order   []string
+}
+
+func (s *concurrentStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	s.state.m.Lock()
+	s.state.running++
+	if s.state.running > s.state.max {
+		s.state.max = s.state.running
+	}
+	s.state.m.Unlock()
+
+	time.Sleep(time.Millisecond * 20)
+
+	s.state.m.Lock()
+	s.state.running--
+	s.state.order = append(s.state.order, s.name)
+	s.state.m.Unlock()
+
+	return nil
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	testCases := []struct {
+		description string
+		workflow    Workflow
+		errMsg      string
+	}{
+		{
+			description: "sequential",
+			workflow: Workflow{
+				&MockStep{name: "a"},
+				&MockStep{name: "b"},
+			},
+		},
+		{
+			description: "graph",
+			workflow: Workflow{
+				&MockStep{name: "a"},
+				&MockStep{name: "b", depends: []string{"a"}},
+				&MockStep{name: "c", depends: []string{"a"}},
+				&MockStep{name: "d", depends: []string{"b", "c"}},
+			},
+		},
+		{
+			description: "not registered",
+			workflow: Workflow{
+				&MockStep{name: "a"},
+				nil,
+			},
+			errMsg: "step #1 is not registered",
+		},
+		{
+			description: "duplicated",
+			workflow: Workflow{
+				&MockStep{name: "a"},
+				&MockStep{name: "a"},
+			},
+			errMsg: "step a is duplicated",
+		},
+		{
+			description: "missing",
+			workflow: Workflow{
+				&MockStep{name: "a", depends: []string{"b"}},
+			},
+			errMsg: "step a depends on missing step b",
+		},
+		{
+			description: "cycle",
+			workflow: Workflow{
+				&MockStep{name: "a"},
+				&MockStep{name: "b", depends: []string{"c"}},
+				&MockStep{name: "c", depends: []string{"b"}},
+			},
+			errMsg: "steps depend on each other b -> c -> b",
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.workflow.Validate()
+		if testCase.errMsg == "" {
+			require.Nil(t, err, testCase.description)
+			continue
+		}
+
+		require.NotNil(t, err, testCase.description)
+		require.Contains(t, err.Error(), testCase.errMsg, testCase.description)
+	}
+}
+
+func TestRegisterWorkflowInvalid(t *testing.T) {
+	workflowMap = make(map[string]Workflow)
+
+	err := RegisterWorkFlow("invalid", Workflow{
+		&MockStep{name: "a", depends: []string{"a"}},
+	})
+	require.NotNil(t, err)
+	require.Nil(t, GetWorkflow("invalid"))
+}
+
+func TestTaskRunParallel(t *testing.T) {
+	defer SetParallelism(DefaultParallelism)
+	SetParallelism(2)
+
+	state := &concurrency{}
+	step := func(name string, depends ...string) steps.Step {
+		return &concurrentStep{MockStep{name: name, depends: depends}, state}
+	}
+
+	s := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+	task := Task{
+		ID:         "abcd",
+		repository: s,
+		workflow: []steps.Step{
+			step("a"),
+			step("b", "a"),
+			step("c", "a"),
+			step("d", "a"),
+			step("e", "b", "c", "d"),
+		},
+	}
+
+	err := <-task.Run(context.Background(), steps.Config{ClusterID: "kube"}, &bufferCloser{})
+	require.Nil(t, err)
+
+	require.Equal(t, "kube", task.KubeID)
+	require.False(t, task.StartedAt.IsZero())
+	require.False(t, task.FinishedAt.Before(task.StartedAt))
+	require.Equal(t, 2, state.max, "parallelism limit")
+	require.Equal(t, "a", state.order[0])
+	require.Equal(t, "e", state.order[4])
+	require.Equal(t, statuses.Success, task.Status)
+	for _, status := range task.StepStatuses {
+		require.Equal(t, statuses.Success, status.Status, status.StepName)
+	}
+}
+
+func TestTaskRunParallelError(t *testing.T) {
+	defer SetParallelism(DefaultParallelism)
+	SetParallelism(1)
+
+	s := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+	failed := &MockStep{name: "b", depends: []string{"a"}, errs: []error{errors.New("error")}}
+	dependent := &MockStep{name: "d", depends: []string{"b"}}
+	task := Task{
+		ID:         "abcd",
+		repository: s,
+		workflow: []steps.Step{
+			&MockStep{name: "a"},
+			failed,
+			&MockStep{name: "c", depends: []string{"a"}},
+			dependent,
+		},
+	}
+
+	err := <-task.Run(context.Background(), steps.Config{}, &bufferCloser{})
+	require.NotNil(t, err)
+
+	require.True(t, failed.rollback)
+	require.Equal(t, 0, dependent.counter)
+	require.Equal(t, statuses.Error, task.Status)
+	require.Equal(t, statuses.Success, task.StepStatuses[0].Status)
+	require.Equal(t, statuses.Error, task.StepStatuses[1].Status)
+	require.Equal(t, "error", task.StepStatuses[1].ErrMsg)
+	require.Equal(t, statuses.Todo, task.StepStatuses[3].Status)
+}
+
+// configStep writes to the config while its siblings run.
+type configStep struct {
+	MockStep
+	write func(*steps.Config, int)
+}
+
+func (s *configStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	for i := 0; i < 20; i++ {
+		config.Update(func() {
+			s.write(config, i)
+		})
+		time.Sleep(time.Millisecond)
+	}
+
+	return nil
+}
+
+func TestTaskRunParallelConfig(t *testing.T) {
+	s := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+	task := Task{
+		ID:         "abcd",
+		repository: s,
+		workflow: []steps.Step{
+			&MockStep{name: "a"},
+			&configStep{
+				MockStep: MockStep{name: "certificates", depends: []string{"a"}},
+				write: func(config *steps.Config, i int) {
+					config.CertificatesConfig.PublicIP = fmt.Sprintf("10.0.0.%d", i)
+				},
+			},
+			&configStep{
+				MockStep: MockStep{name: "etcd", depends: []string{"a"}},
+				write: func(config *steps.Config, i int) {
+					config.EtcdConfig.Host = fmt.Sprintf("10.0.1.%d", i)
+				},
+			},
+			// Task is saved when the step finishes and the others still run
+			&MockStep{name: "d", depends: []string{"a"}},
+		},
+	}
+
+	err := <-task.Run(context.Background(), steps.Config{}, &bufferCloser{})
+	require.Nil(t, err)
+
+	require.Equal(t, statuses.Success, task.Status)
+	require.Equal(t, "10.0.0.19", task.Config.CertificatesConfig.PublicIP)
+	require.Equal(t, "10.0.1.19", task.Config.EtcdConfig.Host)
+}
+
+// waitsFor returns true if step i waits for step j directly or through others.
+func waitsFor(deps [][]int, i, j int) bool {
+	for _, dep := range deps[i] {
+		if dep == j || waitsFor(deps, dep, j) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeWorkflow returns dependencies of a workflow built of registered
+// steps, indexes of steps are looked up by name.
+func nodeWorkflow(t *testing.T, name string) ([][]int, map[string]int) {
+	for _, step := range []steps.Step{
+		&ssh.Step{},
+		&downloadk8sbinary.Step{},
+		&cni.Step{},
+		&etcd.Step{},
+		&network.Step{},
+		&flannel.Step{},
+		&docker.Step{},
+		&certificates.Step{},
+		&manifest.Step{},
+		&kubelet.Step{},
+		&poststart.Step{},
+		&upgradecheck.Step{},
+	} {
+		steps.RegisterStep(step.Name(), step)
+	}
+	// NOTE: other workflows miss cloud steps, they are not checked
+	Init()
+
+	w := GetWorkflow(name)
+	require.NotNil(t, w, name)
+
+	deps, err := w.dependencies()
+	require.Nil(t, err, name)
+
+	index := make(map[string]int, len(w))
+	for i, step := range w {
+		index[step.Name()] = i
+	}
+
+	return deps, index
+}
+
+func TestProvisionStepsRunAtOnce(t *testing.T) {
+	for _, name := range []string{BareMetalMaster, BareMetalNode} {
+		deps, index := nodeWorkflow(t, name)
+		waits := func(a, b string) bool {
+			return waitsFor(deps, index[a], index[b])
+		}
+
+		// Downloads don't wait for each other
+		require.False(t, waits(certificates.StepName, downloadk8sbinary.StepName), name)
+		require.False(t, waits(docker.StepName, downloadk8sbinary.StepName), name)
+		require.False(t, waits(cni.StepName, downloadk8sbinary.StepName), name)
+
+		require.True(t, waits(docker.StepName, flannel.StepName), name)
+		for _, dep := range []string{docker.StepName, certificates.StepName, manifest.StepName} {
+			require.True(t, waits(kubelet.StepName, dep), "%s: kubelet must wait for %s", name, dep)
+		}
+		for _, step := range []string{kubelet.StepName, cni.StepName, downloadk8sbinary.StepName} {
+			require.True(t, waits(poststart.StepName, step), "%s: poststart must wait for %s", name, step)
+		}
+	}
+
+	deps, index := nodeWorkflow(t, BareMetalMaster)
+	for _, dep := range []string{etcd.StepName, network.StepName} {
+		require.True(t, waitsFor(deps, index[flannel.StepName], index[dep]),
+			"flannel of master must wait for %s", dep)
+	}
+}
@@ -2,18 +2,21 @@ package workflows
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/hpcloud/tail"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/model"
@@ -21,7 +24,7 @@ import (
 	"github.com/supergiant/control/pkg/runner/ssh"
 	"github.com/supergiant/control/pkg/storage"
 	"github.com/supergiant/control/pkg/util"
-	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/statuses"
 )
 
 type cloudAccountGetter interface {
@@ -37,17 +40,6 @@ type TaskHandler struct {
 	getWriter      func(string) (io.WriteCloser, error)
 }
 
-type RunTaskRequest struct {
-	WorkflowName string       `json:"workflowName"`
-	Cfg          steps.Config `json:"config"`
-}
-
-type BuildTaskRequest struct {
-	StepNames []string     `json:"stepNames"`
-	Cfg       steps.Config `json:"config"`
-	SshConfig ssh.Config   `json:"sshConfig"`
-}
-
 type TaskResponse struct {
 	ID string `json:"id"`
 }
@@ -84,11 +76,15 @@ func NewTaskHandler(repository storage.Interface, runnerFactory func(config ssh.
 }
 
 func (h *TaskHandler) Register(m *mux.Router) {
+	m.HandleFunc("/tasks", h.ListTasks).Methods(http.MethodGet)
 	m.HandleFunc("/tasks/{id}", h.GetTask).Methods(http.MethodGet)
 	m.HandleFunc("/tasks/{id}/restart",
 		h.RestartTask).Methods(http.MethodPost)
+	m.HandleFunc("/tasks/{id}/cancel", h.CancelTask).Methods(http.MethodPost)
 	m.HandleFunc("/tasks/{id}/logs", h.StreamLogs).Methods(http.MethodGet)
 	m.HandleFunc("/tasks/{id}/logs/ws", h.GetLogs).Methods(http.MethodGet)
+	m.HandleFunc("/steps", h.ListSteps).Methods(http.MethodGet)
+	m.HandleFunc("/workflows", h.ListWorkflows).Methods(http.MethodGet)
 }
 
 func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
@@ -110,6 +106,27 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// ListTasks returns a page of tasks that match filters of the query, tasks
+// are listed without configs since they contain credentials.
+func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	f, offset, limit, err := tasksQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := ListTasks(r.Context(), h.repository, f, offset, limit)
+	if err != nil {
+		logrus.Errorf("list tasks: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (h *TaskHandler) RestartTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
@@ -148,6 +165,40 @@ func (h *TaskHandler) RestartTask(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 }
 
+// CancelTask cancels the task that runs in this controlplane, steps in
+// progress are rolled back and the task is saved as cancelled.
+func (h *TaskHandler) CancelTask(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	data, err := h.repository.Get(r.Context(), Prefix, id)
+	if err != nil || data == nil {
+		logrus.Debugf("task %s not found", id)
+		http.NotFound(w, r)
+		return
+	}
+
+	if !Cancel(id) {
+		http.Error(w, fmt.Sprintf("task %s is not running", id), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ListSteps returns registered steps, custom tasks are composed of them.
+func (h *TaskHandler) ListSteps(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(ListSteps()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ListWorkflows returns registered workflows with their steps.
+func (h *TaskHandler) ListWorkflows(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(ListWorkflows()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // NOTE(stgleb): This is made for testing purposes and example, remove when UI is done.
 func (h *TaskHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	authHeader := r.Header.Get("Authorization")
@@ -261,3 +312,50 @@ func (h *TaskHandler) StreamLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 }
+
+// tasksQuery parses filters and pagination of the query, status may be
+// a comma separated list, since and until are RFC3339 times.
+func tasksQuery(r *http.Request) (TaskFilter, int, int, error) {
+	q := r.URL.Query()
+	f := TaskFilter{
+		KubeID:   q.Get("kube"),
+		Type:     q.Get("type"),
+		NodeName: q.Get("node"),
+	}
+
+	for _, value := range q["status"] {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				f.Statuses = append(f.Statuses, statuses.Status(status))
+			}
+		}
+	}
+
+	var err error
+	if since := q.Get("since"); since != "" {
+		if f.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return f, 0, 0, errors.Wrap(err, "since")
+		}
+	}
+
+	if until := q.Get("until"); until != "" {
+		if f.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return f, 0, 0, errors.Wrap(err, "until")
+		}
+	}
+
+	offset, limit := 0, DefaultTasksLimit
+	if value := q.Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return f, 0, 0, errors.Errorf("offset %q must be a non negative number", value)
+		}
+	}
+
+	if value := q.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			return f, 0, 0, errors.Errorf("limit %q must be a positive number", value)
+		}
+	}
+
+	return f, offset, limit, nil
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 
 	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
 
 	"github.com/hpcloud/tail"
 	"github.com/pkg/errors"
@@ -20,6 +21,7 @@ import (
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/runner/ssh"
 	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/statuses"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -259,4 +261,52 @@ func TestNewTaskHandler(t *testing.T) {
 	if h == nil {
 		t.Errorf("Handler must not be nil")
 	}
-}
\ No newline at end of file
+}
+// blockingStep runs until the task is cancelled.
+type blockingStep struct {
+	MockStep
+	started chan struct{}
+}
+
+func (s *blockingStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	close(s.started)
+	<-ctx.Done()
+	return errors.Wrap(ctx.Err(), "blocking step")
+}
+
+func TestTaskHandlerCancelTask(t *testing.T) {
+	repository := &MockRepository{
+		make(map[string][]byte),
+	}
+	h := NewTaskHandler(repository, nil, nil)
+
+	router := mux.NewRouter()
+	h.Register(router)
+
+	step := &blockingStep{
+		MockStep: MockStep{name: "blocking"},
+		started:  make(chan struct{}),
+	}
+	task := newTask("cancel", Workflow{step}, repository)
+	errChan := task.Run(context.Background(), steps.Config{}, &bufferCloser{})
+	<-step.started
+
+	cancel := func(id string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/tasks/%s/cancel", id), nil)
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusNotFound, cancel("unknown"))
+	require.Equal(t, http.StatusAccepted, cancel(task.ID))
+	require.Equal(t, context.Canceled, <-errChan)
+
+	require.True(t, step.rollback)
+	require.Equal(t, http.StatusConflict, cancel(task.ID), "task is not running anymore")
+
+	saved := &Task{}
+	require.Nil(t, json.Unmarshal(repository.storage[Prefix+task.ID], saved))
+	require.Equal(t, statuses.Cancelled, saved.Status)
+	require.Equal(t, statuses.Cancelled, saved.StepStatuses[0].Status)
+}
@@ -0,0 +1,53 @@
+package workflows
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// Plan lists cloud resources that a workflow would delete.
+type Plan struct {
+	Workflow  string           `json:"workflow"`
+	Resources []steps.Resource `json:"resources"`
+	// Unplanned holds names of steps that can't tell which
+	// resources they would delete.
+	Unplanned []string `json:"unplanned,omitempty"`
+}
+
+// MakePlan asks steps of the workflow what they would delete,
+// nothing is changed in the cloud.
+func MakePlan(ctx context.Context, workflowName string, config *steps.Config) (*Plan, error) {
+	w := GetWorkflow(workflowName)
+	if w == nil {
+		return nil, sgerrors.ErrNotFound
+	}
+
+	plan := &Plan{
+		Workflow:  workflowName,
+		Resources: make([]steps.Resource, 0),
+	}
+
+	for _, step := range w {
+		planner, ok := step.(steps.Planner)
+		if !ok {
+			plan.Unplanned = append(plan.Unplanned, step.Name())
+			continue
+		}
+
+		resources, err := planner.Plan(ctx, config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "plan step %s", step.Name())
+		}
+
+		for i := range resources {
+			resources[i].Step = step.Name()
+		}
+		plan.Resources = append(plan.Resources, resources...)
+	}
+
+	return plan, nil
+}
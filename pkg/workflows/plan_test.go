@@ -0,0 +1,70 @@
+package workflows
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type planStep struct {
+	MockStep
+	resources []steps.Resource
+	err       error
+}
+
+func (p *planStep) Plan(context.Context, *steps.Config) ([]steps.Resource, error) {
+	return p.resources, p.err
+}
+
+func TestMakePlan(t *testing.T) {
+	workflowMap = make(map[string]Workflow)
+
+	if _, err := MakePlan(context.Background(), "missing", &steps.Config{}); !sgerrors.IsNotFound(err) {
+		t.Errorf("unknown workflow: expected not found actual %v", err)
+	}
+
+	RegisterWorkFlow("delete", Workflow{
+		&planStep{
+			MockStep: MockStep{name: "delete_vpc"},
+			resources: []steps.Resource{
+				{Type: "vpc", ID: "vpc-1"},
+				{Type: "vpc", ID: "vpc-2", Foreign: true},
+			},
+		},
+		&MockStep{name: "cleanup"},
+	})
+
+	plan, err := MakePlan(context.Background(), "delete", &steps.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(plan.Resources) != 2 {
+		t.Fatalf("expected 2 resources actual %v", plan.Resources)
+	}
+	for _, r := range plan.Resources {
+		if r.Step != "delete_vpc" {
+			t.Errorf("expected step delete_vpc actual %s", r.Step)
+		}
+	}
+	if !plan.Resources[1].Foreign {
+		t.Errorf("resource %s must be foreign", plan.Resources[1].ID)
+	}
+	if len(plan.Unplanned) != 1 || plan.Unplanned[0] != "cleanup" {
+		t.Errorf("expected unplanned step cleanup actual %v", plan.Unplanned)
+	}
+
+	RegisterWorkFlow("broken", Workflow{
+		&planStep{
+			MockStep: MockStep{name: "delete_vpc"},
+			err:      errors.New("describe vpcs"),
+		},
+	})
+	if _, err := MakePlan(context.Background(), "broken", &steps.Config{}); err == nil {
+		t.Errorf("step error expected")
+	}
+}
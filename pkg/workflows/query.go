@@ -0,0 +1,206 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+// DefaultTasksLimit is a number of tasks listed at once unless limit is set.
+const DefaultTasksLimit = 50
+
+// TaskFilter selects tasks, empty fields match all tasks.
+type TaskFilter struct {
+	// IDs limits tasks to the given ones, e.g. tasks of a kube,
+	// only they are read from storage when set.
+	IDs      []string
+	KubeID   string
+	Type     string
+	Statuses []statuses.Status
+	// NodeName matches name or id of the node the task runs on
+	NodeName string
+	// Since and Until limit the time the task has been created at
+	Since time.Time
+	Until time.Time
+}
+
+// Match returns true if the task passes the filter.
+func (f TaskFilter) Match(t *Task) bool {
+	if len(f.IDs) > 0 {
+		found := false
+		for _, id := range f.IDs {
+			if t.ID == id {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if f.KubeID != "" && t.kubeID() != f.KubeID {
+		return false
+	}
+
+	if f.Type != "" && t.Type != f.Type {
+		return false
+	}
+
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, status := range f.Statuses {
+			if t.Status == status {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if f.NodeName != "" {
+		if t.Config == nil || (t.Config.Node.Name != f.NodeName && t.Config.Node.ID != f.NodeName) {
+			return false
+		}
+	}
+
+	if !f.Since.IsZero() && t.CreatedAt.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && t.CreatedAt.After(f.Until) {
+		return false
+	}
+
+	return true
+}
+
+// TaskSummary is a task without config, since config holds credentials.
+type TaskSummary struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	KubeID       string          `json:"kubeId,omitempty"`
+	NodeName     string          `json:"nodeName,omitempty"`
+	Status       statuses.Status `json:"status"`
+	StepStatuses []StepStatus    `json:"stepsStatuses"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	StartedAt    time.Time       `json:"startedAt"`
+	FinishedAt   time.Time       `json:"finishedAt"`
+}
+
+// TaskList is a page of tasks, total is the number of
+// tasks that match the filter on all pages.
+type TaskList struct {
+	Items  []TaskSummary `json:"items"`
+	Total  int           `json:"total"`
+	Offset int           `json:"offset"`
+	Limit  int           `json:"limit"`
+}
+
+// ListTasks returns a page of tasks that match the filter, newest tasks
+// come first. Tasks that can't be decoded are skipped. NOTE: tasks are not
+// indexed, all of them are read and matched unless the filter has IDs.
+func ListTasks(ctx context.Context, repository storage.Interface, f TaskFilter, offset, limit int) (*TaskList, error) {
+	data, err := getTasks(ctx, repository, f.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*Task, 0, len(data))
+	for _, raw := range data {
+		t := &Task{}
+		if err := json.Unmarshal(raw, t); err != nil {
+			logrus.Warnf("list tasks: decode task: %v", err)
+			continue
+		}
+
+		if f.Match(t) {
+			tasks = append(tasks, t)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	list := &TaskList{
+		Items:  make([]TaskSummary, 0),
+		Total:  len(tasks),
+		Offset: offset,
+		Limit:  limit,
+	}
+
+	for i := offset; i < len(tasks) && len(list.Items) < limit; i++ {
+		list.Items = append(list.Items, tasks[i].summary())
+	}
+
+	return list, nil
+}
+
+// getTasks reads tasks of the given ids or all tasks if there are none,
+// tasks that are not found might have been deleted and are skipped.
+func getTasks(ctx context.Context, repository storage.Interface, ids []string) ([][]byte, error) {
+	if len(ids) == 0 {
+		data, err := repository.GetAll(ctx, Prefix)
+		return data, errors.Wrap(err, "get tasks")
+	}
+
+	data := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		raw, err := repository.Get(ctx, Prefix, id)
+		if sgerrors.IsNotFound(err) {
+			logrus.Debugf("list tasks: task %s not found", id)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "get task %s", id)
+		}
+		data = append(data, raw)
+	}
+
+	return data, nil
+}
+
+func (w *Task) summary() TaskSummary {
+	s := TaskSummary{
+		ID:           w.ID,
+		Type:         w.Type,
+		KubeID:       w.kubeID(),
+		Status:       w.Status,
+		StepStatuses: w.StepStatuses,
+		CreatedAt:    w.CreatedAt,
+		StartedAt:    w.StartedAt,
+		FinishedAt:   w.FinishedAt,
+	}
+
+	if w.Config != nil {
+		s.NodeName = w.Config.Node.Name
+	}
+
+	return s
+}
+
+// kubeID returns the kube of the task, tasks created before
+// kube id has been recorded have it in config only.
+func (w *Task) kubeID() string {
+	if w.KubeID == "" && w.Config != nil {
+		return w.Config.ClusterID
+	}
+
+	return w.KubeID
+}
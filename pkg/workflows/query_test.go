@@ -0,0 +1,193 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func newTestTasks(t *testing.T) *MockRepository {
+	repository := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+
+	created := time.Date(2019, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i, task := range []*Task{
+		{Type: DigitalOceanMaster, KubeID: "kube-1", Status: statuses.Success},
+		{Type: DigitalOceanNode, KubeID: "kube-1", Status: statuses.Error},
+		{Type: DigitalOceanNode, KubeID: "kube-1", Status: statuses.Executing},
+		{Type: DigitalOceanNode, Status: statuses.Cancelled, Config: &steps.Config{
+			// Kube id of tasks created before it has been recorded is taken from config
+			ClusterID: "kube-2",
+			Node:      node.Node{Name: "node-1"},
+		}},
+		{Type: UpgradeNode, Status: statuses.Success},
+	} {
+		task.ID = fmt.Sprintf("task-%d", i)
+		task.CreatedAt = created.Add(time.Duration(i) * time.Hour)
+
+		data, err := json.Marshal(task)
+		require.Nil(t, err)
+		repository.storage[Prefix+task.ID] = data
+	}
+
+	return repository
+}
+
+func TestListTasks(t *testing.T) {
+	repository := newTestTasks(t)
+
+	testCases := []struct {
+		description string
+		filter      TaskFilter
+		offset      int
+		limit       int
+		expected    []string
+		total       int
+	}{
+		{
+			description: "newest first",
+			limit:       DefaultTasksLimit,
+			expected:    []string{"task-4", "task-3", "task-2", "task-1", "task-0"},
+			total:       5,
+		},
+		{
+			description: "page",
+			offset:      1,
+			limit:       2,
+			expected:    []string{"task-3", "task-2"},
+			total:       5,
+		},
+		{
+			description: "ids",
+			filter:      TaskFilter{IDs: []string{"task-0", "task-3", "missing"}},
+			limit:       DefaultTasksLimit,
+			expected:    []string{"task-3", "task-0"},
+			total:       2,
+		},
+		{
+			description: "kube",
+			filter:      TaskFilter{KubeID: "kube-2"},
+			limit:       DefaultTasksLimit,
+			expected:    []string{"task-3"},
+			total:       1,
+		},
+		{
+			description: "type and status",
+			filter: TaskFilter{
+				Type:     DigitalOceanNode,
+				Statuses: []statuses.Status{statuses.Error, statuses.Executing},
+			},
+			limit:    DefaultTasksLimit,
+			expected: []string{"task-2", "task-1"},
+			total:    2,
+		},
+		{
+			description: "node",
+			filter:      TaskFilter{NodeName: "node-1"},
+			limit:       DefaultTasksLimit,
+			expected:    []string{"task-3"},
+			total:       1,
+		},
+		{
+			description: "time range",
+			filter: TaskFilter{
+				Since: time.Date(2019, 1, 1, 11, 0, 0, 0, time.UTC),
+				Until: time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC),
+			},
+			limit:    DefaultTasksLimit,
+			expected: []string{"task-2", "task-1"},
+			total:    2,
+		},
+		{
+			description: "offset after the last task",
+			offset:      10,
+			limit:       DefaultTasksLimit,
+			expected:    []string{},
+			total:       5,
+		},
+	}
+
+	for _, testCase := range testCases {
+		list, err := ListTasks(context.Background(), repository,
+			testCase.filter, testCase.offset, testCase.limit)
+		require.Nil(t, err, testCase.description)
+
+		ids := make([]string, 0)
+		for _, task := range list.Items {
+			ids = append(ids, task.ID)
+		}
+		require.Equal(t, testCase.expected, ids, testCase.description)
+		require.Equal(t, testCase.total, list.Total, testCase.description)
+	}
+}
+
+func TestTaskHandlerListTasks(t *testing.T) {
+	h := NewTaskHandler(newTestTasks(t), nil, nil)
+	router := mux.NewRouter()
+	h.Register(router)
+
+	testCases := []struct {
+		query        string
+		expectedCode int
+		expected     int
+	}{
+		{
+			expectedCode: http.StatusOK,
+			expected:     5,
+		},
+		{
+			query:        "kube=kube-1&status=success,error",
+			expectedCode: http.StatusOK,
+			expected:     2,
+		},
+		{
+			query:        "type=UpgradeNode&limit=1&offset=0",
+			expectedCode: http.StatusOK,
+			expected:     1,
+		},
+		{
+			query:        "node=node-1&since=2019-01-01T12:00:00Z",
+			expectedCode: http.StatusOK,
+			expected:     1,
+		},
+		{
+			query:        "until=tomorrow",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			query:        "limit=0",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			query:        "offset=-1",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/tasks?"+testCase.query, nil)
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, testCase.expectedCode, rec.Code, testCase.query)
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		list := &TaskList{}
+		require.Nil(t, json.NewDecoder(rec.Body).Decode(list))
+		require.Len(t, list.Items, testCase.expected, testCase.query)
+	}
+}
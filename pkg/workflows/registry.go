@@ -0,0 +1,60 @@
+package workflows
+
+import (
+	"context"
+	"sync"
+)
+
+// registry keeps cancel functions of tasks that run in this controlplane,
+// so that a particular task can be cancelled by its id.
+type registry struct {
+	m     sync.Mutex
+	tasks map[string]*runningTask
+}
+
+type runningTask struct {
+	cancel context.CancelFunc
+}
+
+var running = &registry{
+	tasks: make(map[string]*runningTask),
+}
+
+// track returns a context of the task that is cancelled by Cancel,
+// done must be called when the task stops.
+func (r *registry) track(ctx context.Context, id string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &runningTask{cancel: cancel}
+
+	r.m.Lock()
+	r.tasks[id] = t
+	r.m.Unlock()
+
+	return ctx, func() {
+		r.m.Lock()
+		// Task may have been restarted meanwhile
+		if r.tasks[id] == t {
+			delete(r.tasks, id)
+		}
+		r.m.Unlock()
+		cancel()
+	}
+}
+
+func (r *registry) cancel(id string) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	t, ok := r.tasks[id]
+	if ok {
+		t.cancel()
+	}
+
+	return ok
+}
+
+// Cancel cancels the task that runs in this controlplane, false is
+// returned if there is no such task.
+func Cancel(id string) bool {
+	return running.cancel(id)
+}
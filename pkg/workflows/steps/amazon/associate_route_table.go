@@ -99,5 +99,5 @@ func (*AssociateRouteTableStep) Description() string {
 }
 
 func (*AssociateRouteTableStep) Depends() []string {
-	return []string{StepCreateSubnets}
+	return []string{StepCreateSubnets, StepCreateRouteTable}
 }
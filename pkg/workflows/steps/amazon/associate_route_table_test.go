@@ -64,9 +64,9 @@ func TestNewAssociateRouteTableStepError(t *testing.T) {
 func TestAssociateRouteTableStep_Depends(t *testing.T) {
 	step := &AssociateRouteTableStep{}
 
-	if deps := step.Depends(); len(deps) != 1 || deps[0] != StepCreateSubnets {
+	if deps := step.Depends(); len(deps) != 2 || deps[0] != StepCreateSubnets || deps[1] != StepCreateRouteTable {
 		t.Errorf("Wron dependencies expected %v actual %v",
-			[]string{StepCreateSubnets}, deps)
+			[]string{StepCreateSubnets, StepCreateRouteTable}, deps)
 	}
 }
  func TestAssociateRouteTableStep_Name(t *testing.T) {
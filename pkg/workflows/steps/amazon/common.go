@@ -6,6 +6,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 
@@ -43,3 +45,19 @@ func GetIAM(cfg steps.AWSConfig) (iamiface.IAMAPI, error) {
 	}
 	return iam.New(sess), nil
 }
+
+type GetELBFn func(steps.AWSConfig) (elbiface.ELBAPI, error)
+
+func GetELB(cfg steps.AWSConfig) (elbiface.ELBAPI, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Region:      aws.String(cfg.Region),
+			Credentials: credentials.NewStaticCredentials(cfg.KeyID, cfg.Secret, ""),
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return elb.New(sess), nil
+}
@@ -28,3 +28,15 @@ func TestGetIAM(t *testing.T) {
 		t.Errorf("Api must not be nil")
 	}
 }
+
+func TestGetELB(t *testing.T) {
+	api, err := GetELB(steps.AWSConfig{})
+
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	if api == nil {
+		t.Errorf("Api must not be nil")
+	}
+}
@@ -0,0 +1,174 @@
+package amazon
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const CreateLoadBalancerStepName = "aws_create_load_balancer"
+
+type createLoadBalancerService interface {
+	CreateLoadBalancerWithContext(aws.Context, *elb.CreateLoadBalancerInput, ...request.Option) (*elb.CreateLoadBalancerOutput, error)
+	ConfigureHealthCheckWithContext(aws.Context, *elb.ConfigureHealthCheckInput, ...request.Option) (*elb.ConfigureHealthCheckOutput, error)
+}
+
+type ingressService interface {
+	AuthorizeSecurityGroupIngressWithContext(aws.Context, *ec2.AuthorizeSecurityGroupIngressInput, ...request.Option) (*ec2.AuthorizeSecurityGroupIngressOutput, error)
+}
+
+// CreateLoadBalancerStep creates classic load balancer in front of
+// API of masters, masters are registered in the balancer when they are up.
+type CreateLoadBalancerStep struct {
+	getSvc        func(steps.AWSConfig) (createLoadBalancerService, error)
+	getIngressSvc func(steps.AWSConfig) (ingressService, error)
+}
+
+func InitCreateLoadBalancer(elbFn GetELBFn, ec2Fn GetEC2Fn) {
+	steps.RegisterStep(CreateLoadBalancerStepName, NewCreateLoadBalancerStep(elbFn, ec2Fn))
+}
+
+func NewCreateLoadBalancerStep(elbFn GetELBFn, ec2Fn GetEC2Fn) *CreateLoadBalancerStep {
+	return &CreateLoadBalancerStep{
+		getSvc: func(cfg steps.AWSConfig) (createLoadBalancerService, error) {
+			ELB, err := elbFn(cfg)
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return ELB, nil
+		},
+		getIngressSvc: func(cfg steps.AWSConfig) (ingressService, error) {
+			EC2, err := ec2Fn(cfg)
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return EC2, nil
+		},
+	}
+}
+
+func (s *CreateLoadBalancerStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	if !cfg.LoadBalancerConfig.Enabled {
+		logrus.Debugf("Skip creating load balancer for single master cluster %s",
+			cfg.ClusterID)
+		return nil
+	}
+
+	if cfg.LoadBalancerConfig.Host != "" {
+		logrus.Debugf("use load balancer %s", cfg.LoadBalancerConfig.Host)
+		return nil
+	}
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+
+	if err != nil {
+		logrus.Errorf("Getting service caused %v", err)
+		return errors.Wrapf(err, "%s get service", CreateLoadBalancerStepName)
+	}
+
+	// Load balancer needs a subnet in each availability zone of masters
+	subnets := make([]string, 0, len(cfg.AWSConfig.Subnets))
+	for _, subnetID := range cfg.AWSConfig.Subnets {
+		subnets = append(subnets, subnetID)
+	}
+	sort.Strings(subnets)
+
+	name := util.MakeLoadBalancerName(cfg.ClusterID)
+	out, err := svc.CreateLoadBalancerWithContext(ctx, &elb.CreateLoadBalancerInput{
+		LoadBalancerName: aws.String(name),
+		Listeners: []*elb.Listener{
+			{
+				Protocol:         aws.String("TCP"),
+				LoadBalancerPort: aws.Int64(443),
+				InstanceProtocol: aws.String("TCP"),
+				InstancePort:     aws.Int64(443),
+			},
+		},
+		Subnets:        aws.StringSlice(subnets),
+		SecurityGroups: aws.StringSlice([]string{cfg.AWSConfig.MastersSecurityGroupID}),
+		Tags: []*elb.Tag{
+			{
+				Key:   aws.String("KubernetesCluster"),
+				Value: aws.String(cfg.ClusterName),
+			},
+			{
+				Key:   aws.String(clouds.ClusterIDTag),
+				Value: aws.String(cfg.ClusterID),
+			},
+		},
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "create load balancer %s", name)
+	}
+
+	_, err = svc.ConfigureHealthCheckWithContext(ctx, &elb.ConfigureHealthCheckInput{
+		LoadBalancerName: aws.String(name),
+		HealthCheck: &elb.HealthCheck{
+			Target:             aws.String("TCP:443"),
+			Interval:           aws.Int64(10),
+			Timeout:            aws.Int64(5),
+			HealthyThreshold:   aws.Int64(2),
+			UnhealthyThreshold: aws.Int64(2),
+		},
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "configure health check of load balancer %s", name)
+	}
+
+	// Workers reach the balancer from their public addresses
+	ingressSvc, err := s.getIngressSvc(cfg.AWSConfig)
+
+	if err != nil {
+		return errors.Wrapf(err, "%s get service", CreateLoadBalancerStepName)
+	}
+
+	_, err = ingressSvc.AuthorizeSecurityGroupIngressWithContext(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId:    aws.String(cfg.AWSConfig.MastersSecurityGroupID),
+		FromPort:   aws.Int64(443),
+		ToPort:     aws.Int64(443),
+		CidrIp:     aws.String("0.0.0.0/0"),
+		IpProtocol: aws.String("tcp"),
+	})
+
+	if err != nil && !strings.Contains(err.Error(), "InvalidPermission.Duplicate") {
+		return errors.Wrapf(err, "authorize api port for load balancer %s", name)
+	}
+
+	cfg.LoadBalancerConfig.Host = aws.StringValue(out.DNSName)
+	logrus.Debugf("Load balancer %s has been created",
+		cfg.LoadBalancerConfig.Host)
+
+	return nil
+}
+
+func (s *CreateLoadBalancerStep) Rollback(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	return nil
+}
+
+func (*CreateLoadBalancerStep) Name() string {
+	return CreateLoadBalancerStepName
+}
+
+func (*CreateLoadBalancerStep) Description() string {
+	return "Create load balancer in front of masters"
+}
+
+func (*CreateLoadBalancerStep) Depends() []string {
+	return nil
+}
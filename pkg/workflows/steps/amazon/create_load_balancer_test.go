@@ -0,0 +1,169 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type mockCreateLoadBalancerSvc struct {
+	mock.Mock
+}
+
+func (m *mockCreateLoadBalancerSvc) CreateLoadBalancerWithContext(ctx aws.Context,
+	input *elb.CreateLoadBalancerInput, opts ...request.Option) (*elb.CreateLoadBalancerOutput, error) {
+	args := m.Called(ctx, input, opts)
+	val, ok := args.Get(0).(*elb.CreateLoadBalancerOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func (m *mockCreateLoadBalancerSvc) ConfigureHealthCheckWithContext(ctx aws.Context,
+	input *elb.ConfigureHealthCheckInput, opts ...request.Option) (*elb.ConfigureHealthCheckOutput, error) {
+	args := m.Called(ctx, input, opts)
+	val, ok := args.Get(0).(*elb.ConfigureHealthCheckOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+type mockIngressSvc struct {
+	mock.Mock
+}
+
+func (m *mockIngressSvc) AuthorizeSecurityGroupIngressWithContext(ctx aws.Context,
+	input *ec2.AuthorizeSecurityGroupIngressInput, opts ...request.Option) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	args := m.Called(ctx, input, opts)
+	val, ok := args.Get(0).(*ec2.AuthorizeSecurityGroupIngressOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func TestCreateLoadBalancerStep_Run(t *testing.T) {
+	dnsName := "sg-1234abcd-api-1.us-east-1.elb.amazonaws.com"
+
+	testCases := []struct {
+		description string
+		enabled     bool
+		getSvcErr   error
+		createErr   error
+		ingressErr  error
+		host        string
+		errMsg      string
+	}{
+		{
+			description: "single master",
+		},
+		{
+			description: "get service error",
+			enabled:     true,
+			getSvcErr:   errors.New("message1"),
+			errMsg:      "message1",
+		},
+		{
+			description: "create error",
+			enabled:     true,
+			createErr:   errors.New("message2"),
+			errMsg:      "message2",
+		},
+		{
+			description: "duplicate permission",
+			enabled:     true,
+			ingressErr:  errors.New("InvalidPermission.Duplicate: rule already exists"),
+			host:        dnsName,
+		},
+		{
+			description: "success",
+			enabled:     true,
+			host:        dnsName,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+
+		svc := &mockCreateLoadBalancerSvc{}
+		svc.On("CreateLoadBalancerWithContext", mock.Anything, mock.Anything, mock.Anything).
+			Return(&elb.CreateLoadBalancerOutput{DNSName: aws.String(dnsName)}, testCase.createErr)
+		svc.On("ConfigureHealthCheckWithContext", mock.Anything, mock.Anything, mock.Anything).
+			Return(&elb.ConfigureHealthCheckOutput{}, nil)
+
+		ingressSvc := &mockIngressSvc{}
+		ingressSvc.On("AuthorizeSecurityGroupIngressWithContext", mock.Anything, mock.Anything, mock.Anything).
+			Return(&ec2.AuthorizeSecurityGroupIngressOutput{}, testCase.ingressErr)
+
+		step := &CreateLoadBalancerStep{
+			getSvc: func(steps.AWSConfig) (createLoadBalancerService, error) {
+				return svc, testCase.getSvcErr
+			},
+			getIngressSvc: func(steps.AWSConfig) (ingressService, error) {
+				return ingressSvc, nil
+			},
+		}
+
+		cfg := &steps.Config{
+			ClusterID: "1234abcd",
+			AWSConfig: steps.AWSConfig{
+				MastersSecurityGroupID: "sg-1",
+				Subnets: map[string]string{
+					"us-east-1a": "subnet-1",
+					"us-east-1b": "subnet-2",
+				},
+			},
+			LoadBalancerConfig: steps.LoadBalancerConfig{
+				Enabled: testCase.enabled,
+			},
+		}
+
+		err := step.Run(context.Background(), &bytes.Buffer{}, cfg)
+
+		if testCase.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), testCase.errMsg) {
+				t.Errorf("Err message must contain %s actual %v",
+					testCase.errMsg, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		if cfg.LoadBalancerConfig.Host != testCase.host {
+			t.Errorf("Wrong load balancer host expected %s actual %s",
+				testCase.host, cfg.LoadBalancerConfig.Host)
+		}
+	}
+}
+
+func TestInitCreateLoadBalancer(t *testing.T) {
+	InitCreateLoadBalancer(GetELB, GetEC2)
+
+	if s := steps.GetStep(CreateLoadBalancerStepName); s == nil {
+		t.Error("step must not be nil")
+	}
+}
+
+func TestCreateLoadBalancerStep_Name(t *testing.T) {
+	s := &CreateLoadBalancerStep{}
+
+	if s.Name() != CreateLoadBalancerStepName {
+		t.Errorf("Wrong step name expected %s actual %s",
+			CreateLoadBalancerStepName, s.Name())
+	}
+}
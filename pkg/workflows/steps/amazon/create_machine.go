@@ -20,6 +20,10 @@ import (
 
 const (
 	StepNameCreateEC2Instance = "aws_create_instance"
+
+	// DefaultVolumeSize is a size of root volume in GiB
+	// for node profiles that don't set it.
+	DefaultVolumeSize = 80
 )
 
 type instanceService interface {
@@ -77,6 +81,7 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 		Size:     cfg.AWSConfig.InstanceType,
 		Provider: clouds.AWS,
 		State:    node.StatePlanned,
+		Pool:     cfg.PoolName(),
 	}
 
 	// Update node state in cluster
@@ -95,7 +100,13 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 	}
 
 	isEbs := false
-	volumeSize, err := strconv.Atoi(cfg.AWSConfig.VolumeSize)
+	volumeSize := DefaultVolumeSize
+	if cfg.AWSConfig.VolumeSize != "" {
+		volumeSize, err = strconv.Atoi(cfg.AWSConfig.VolumeSize)
+		if err != nil || volumeSize <= 0 {
+			return errors.Errorf("invalid volume size %q", cfg.AWSConfig.VolumeSize)
+		}
+	}
 
 	runInstanceInput := &ec2.RunInstancesInput{
 		BlockDeviceMappings: []*ec2.BlockDeviceMapping{
@@ -175,6 +186,7 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 		Provider: clouds.AWS,
 		Size:     cfg.AWSConfig.InstanceType,
 		State:    node.StateBuilding,
+		Pool:     cfg.PoolName(),
 	}
 
 	// Update node state in cluster
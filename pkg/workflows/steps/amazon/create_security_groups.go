@@ -20,6 +20,7 @@ const StepCreateSecurityGroups = "create_security_groups_step"
 type secGroupService interface {
 	CreateSecurityGroupWithContext(aws.Context, *ec2.CreateSecurityGroupInput, ...request.Option) (*ec2.CreateSecurityGroupOutput, error)
 	AuthorizeSecurityGroupIngressWithContext(aws.Context, *ec2.AuthorizeSecurityGroupIngressInput, ...request.Option) (*ec2.AuthorizeSecurityGroupIngressOutput, error)
+	tagCreator
 }
 
 type CreateSecurityGroupsStep struct {
@@ -73,6 +74,10 @@ func (s *CreateSecurityGroupsStep) Run(ctx context.Context, w io.Writer, cfg *st
 		} else {
 			cfg.AWSConfig.MastersSecurityGroupID = *out.GroupId
 		}
+
+		if err := tagResources(ctx, svc, cfg, "masters-secgroup", cfg.AWSConfig.MastersSecurityGroupID); err != nil {
+			return errors.Wrapf(err, "create master security group")
+		}
 	}
 	//If there is no security group, create it
 	if cfg.AWSConfig.NodesSecurityGroupID == "" {
@@ -89,6 +94,10 @@ func (s *CreateSecurityGroupsStep) Run(ctx context.Context, w io.Writer, cfg *st
 		} else {
 			cfg.AWSConfig.NodesSecurityGroupID = *out.GroupId
 		}
+
+		if err := tagResources(ctx, svc, cfg, "nodes-secgroup", cfg.AWSConfig.NodesSecurityGroupID); err != nil {
+			return errors.Wrapf(err, "create node security group")
+		}
 	}
 
 	logrus.Debugf("Security groups %s %s has been created",
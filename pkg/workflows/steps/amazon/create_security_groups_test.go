@@ -18,6 +18,7 @@ import (
 
 type mockSecurityGroupSvc struct {
 	mock.Mock
+	fakeTags
 }
 
 func (m *mockSecurityGroupSvc) CreateSecurityGroupWithContext(ctx aws.Context,
@@ -27,6 +27,7 @@ type accountGetter interface {
 type subnetSvc interface {
 	CreateSubnetWithContext(aws.Context, *ec2.CreateSubnetInput,
 		...request.Option) (*ec2.CreateSubnetOutput, error)
+	tagCreator
 }
 
 type CreateSubnetsStep struct {
@@ -137,6 +138,10 @@ func (s *CreateSubnetsStep) Run(ctx context.Context, w io.Writer, cfg *steps.Con
 
 		// Store subnet in subnets map
 		cfg.AWSConfig.Subnets[zone] = *out.Subnet.SubnetId
+
+		if err := tagResources(ctx, svc, cfg, "subnet-"+zone, *out.Subnet.SubnetId); err != nil {
+			return errors.Wrap(ErrCreateSubnet, err.Error())
+		}
 	}
 
 	return nil
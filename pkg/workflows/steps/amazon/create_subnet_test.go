@@ -22,6 +22,7 @@ import (
 
 type mockSubnetSvc struct {
 	mock.Mock
+	fakeTags
 }
 
 func (m *mockSubnetSvc) CreateSubnetWithContext(ctx aws.Context,
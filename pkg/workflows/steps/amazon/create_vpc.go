@@ -62,6 +62,11 @@ func (c *CreateVPCStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 					cfg.AWSConfig.VPCID, err.Error())
 			return errors.Wrapf(err, "create vpc error wait")
 		}
+
+		if err := tagResources(ctx, EC2, cfg, "vpc", cfg.AWSConfig.VPCID); err != nil {
+			return errors.Wrap(ErrCreateVPC, err.Error())
+		}
+
 		log.Infof("[%s] - created a VPC with ID %s and CIDR %s",
 			c.Name(), cfg.AWSConfig.VPCID, cfg.AWSConfig.VPCCIDR)
 	} else {
@@ -33,6 +33,10 @@ func (f *fakeEC2VPC) DescribeVpcsWithContext(aws.Context, *ec2.DescribeVpcsInput
 	return f.describeVPCOutput, f.err
 }
 
+func (f *fakeEC2VPC) CreateTagsWithContext(aws.Context, *ec2.CreateTagsInput, ...request.Option) (*ec2.CreateTagsOutput, error) {
+	return &ec2.CreateTagsOutput{}, f.err
+}
+
 func (f *fakeEC2VPC) WaitUntilVpcExistsWithContext(aws.Context,
 	*ec2.DescribeVpcsInput, ...request.WaiterOption) error {
 	return nil
@@ -54,25 +54,12 @@ func (s *DeleteClusterMachines) Run(ctx context.Context, w io.Writer, cfg *steps
 	}
 
 	logrus.Debug(cfg.AWSConfig)
-	describeInstanceOutput, err := svc.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
-		Filters: []*ec2.Filter{
-			{
-				Name:   aws.String(fmt.Sprintf("tag:%s", clouds.ClusterIDTag)),
-				Values: aws.StringSlice([]string{cfg.ClusterID}),
-			},
-		},
-	})
+	instanceIDS, err := clusterInstances(ctx, svc, cfg.ClusterID)
 
 	if err != nil {
 		return errors.Wrap(ErrDeleteCluster, err.Error())
 	}
 
-	instanceIDS := make([]string, 0)
-	for _, res := range describeInstanceOutput.Reservations {
-		for _, instance := range res.Instances {
-			instanceIDS = append(instanceIDS, *instance.InstanceId)
-		}
-	}
 	if len(instanceIDS) == 0 {
 		logrus.Infof("[%s] - no nodes in k8s cluster %s", s.Name(), cfg.ClusterName)
 		return nil
@@ -94,6 +81,57 @@ func (s *DeleteClusterMachines) Run(ctx context.Context, w io.Writer, cfg *steps
 	return nil
 }
 
+func (s *DeleteClusterMachines) Plan(ctx context.Context, cfg *steps.Config) ([]steps.Resource, error) {
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		return nil, errors.Wrap(ErrAuthorization, err.Error())
+	}
+
+	instanceIDS, err := clusterInstances(ctx, svc, cfg.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]steps.Resource, 0, len(instanceIDS))
+	for _, id := range instanceIDS {
+		resources = append(resources, steps.Resource{
+			Type: "instance",
+			ID:   id,
+		})
+	}
+
+	return resources, nil
+}
+
+// clusterInstances finds instances tagged with ID of the cluster.
+func clusterInstances(ctx context.Context, svc instanceDeleter, clusterID string) ([]string, error) {
+	if clusterID == "" {
+		return nil, nil
+	}
+
+	out, err := svc.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", clouds.ClusterIDTag)),
+				Values: aws.StringSlice([]string{clusterID}),
+			},
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIDS := make([]string, 0)
+	for _, res := range out.Reservations {
+		for _, instance := range res.Instances {
+			instanceIDS = append(instanceIDS, *instance.InstanceId)
+		}
+	}
+
+	return instanceIDS, nil
+}
+
 func (*DeleteClusterMachines) Name() string {
 	return DeleteClusterMachinesStepName
 }
@@ -98,7 +98,9 @@ func TestDeleteClusterMachibesStep_Run(t *testing.T) {
 			mock.Anything, mock.Anything, mock.Anything).
 			Return(mock.Anything, testCase.terminateErr)
 
-		config := &steps.Config{}
+		config := &steps.Config{
+			ClusterID: testClusterID,
+		}
 		step := DeleteClusterMachines{
 			getSvc: func(steps.AWSConfig) (instanceDeleter, error) {
 				return svc, testCase.getSvcErr
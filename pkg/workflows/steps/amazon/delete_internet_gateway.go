@@ -17,6 +17,7 @@ const DeleteInternetGatewayStepName = "aws_delete_internet_gateway"
 type IGWDeleter interface {
 	DetachInternetGateway(*ec2.DetachInternetGatewayInput) (*ec2.DetachInternetGatewayOutput, error)
 	DeleteInternetGateway(*ec2.DeleteInternetGatewayInput) (*ec2.DeleteInternetGatewayOutput, error)
+	tagDescriber
 }
 
 type DeleteInternetGateway struct {
@@ -53,6 +54,17 @@ func (s *DeleteInternetGateway) Run(ctx context.Context, w io.Writer, cfg *steps
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
+	owned, _, err := resourceOwnership(ctx, svc, cfg.AWSConfig.InternetGatewayID, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "%s check internet gateway", DeleteInternetGatewayStepName)
+	}
+
+	if !owned {
+		logrus.Infof("Skip deleting internet gateway %s, it has not been created by cluster %s",
+			cfg.AWSConfig.InternetGatewayID, cfg.ClusterID)
+		return nil
+	}
+
 	logrus.Debugf("Detach internet gateway %s from vpc %s",
 		cfg.AWSConfig.InternetGatewayID, cfg.AWSConfig.VPCID)
 	_, err = svc.DetachInternetGateway(&ec2.DetachInternetGatewayInput{
@@ -83,6 +95,31 @@ func (s *DeleteInternetGateway) Run(ctx context.Context, w io.Writer, cfg *steps
 	return nil
 }
 
+func (s *DeleteInternetGateway) Plan(ctx context.Context, cfg *steps.Config) ([]steps.Resource, error) {
+	if cfg.AWSConfig.InternetGatewayID == "" {
+		return nil, nil
+	}
+
+	svc, err := s.getIGWService(cfg.AWSConfig)
+	if err != nil {
+		return nil, errors.Wrap(ErrAuthorization, err.Error())
+	}
+
+	owned, untagged, err := resourceOwnership(ctx, svc, cfg.AWSConfig.InternetGatewayID, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return []steps.Resource{
+		{
+			Type:     "internet gateway",
+			ID:       cfg.AWSConfig.InternetGatewayID,
+			Foreign:  !owned,
+			Untagged: untagged,
+		},
+	}, nil
+}
+
 func (*DeleteInternetGateway) Name() string {
 	return DeleteInternetGatewayStepName
 }
@@ -91,6 +128,10 @@ func (*DeleteInternetGateway) Depends() []string {
 	return []string{DeleteSecurityGroupsStepName}
 }
 
+func (*DeleteInternetGateway) RetryPolicy() steps.RetryPolicy {
+	return deleteRetryPolicy
+}
+
 func (*DeleteInternetGateway) Description() string {
 	return "Delete internet gateway from VPC"
 }
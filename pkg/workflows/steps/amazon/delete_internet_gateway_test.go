@@ -15,6 +15,7 @@ import (
 
 type mockDeleter struct {
 	mock.Mock
+	fakeTags
 }
 
 func (m *mockDeleter) DetachInternetGateway(input *ec2.DetachInternetGatewayInput) (*ec2.DetachInternetGatewayOutput, error) {
@@ -72,7 +73,9 @@ func TestDeleteInternetGateway_Run(t *testing.T) {
 	}
 
 	for _, testCase := range testCases {
-		svc := &mockDeleter{}
+		svc := &mockDeleter{
+			fakeTags: fakeTags{owner: testClusterID},
+		}
 		svc.On("DetachInternetGateway",
 			mock.Anything).Return(mock.Anything, testCase.detachErr)
 		svc.On("DeleteInternetGateway",
@@ -84,6 +87,7 @@ func TestDeleteInternetGateway_Run(t *testing.T) {
 		}
 
 		config := &steps.Config{
+			ClusterID: testClusterID,
 			AWSConfig: steps.AWSConfig{
 				InternetGatewayID: testCase.existingID,
 			},
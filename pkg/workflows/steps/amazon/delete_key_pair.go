@@ -52,6 +52,14 @@ func (s *DeleteKeyPair) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 		return nil
 	}
 
+	// NOTE: EC2 key pairs can't be tagged, key pair of the cluster
+	// is told apart by its name.
+	if cfg.AWSConfig.KeyPairName != bootstrapKeyPairName(cfg) {
+		logrus.Infof("Skip deleting key pair %s, it has not been created by cluster %s",
+			cfg.AWSConfig.KeyPairName, cfg.ClusterID)
+		return nil
+	}
+
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
@@ -84,6 +92,20 @@ func (s *DeleteKeyPair) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 	return nil
 }
 
+func (s *DeleteKeyPair) Plan(ctx context.Context, cfg *steps.Config) ([]steps.Resource, error) {
+	if cfg.AWSConfig.KeyPairName == "" {
+		return nil, nil
+	}
+
+	return []steps.Resource{
+		{
+			Type:    "key pair",
+			ID:      cfg.AWSConfig.KeyPairName,
+			Foreign: cfg.AWSConfig.KeyPairName != bootstrapKeyPairName(cfg),
+		},
+	}, nil
+}
+
 func (*DeleteKeyPair) Name() string {
 	return DeleteKeyPairStepName
 }
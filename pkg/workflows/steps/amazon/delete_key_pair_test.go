@@ -75,11 +75,13 @@ func TestDeleteKeyPair_Run(t *testing.T) {
 		}
 
 		config := &steps.Config{
+			ClusterID:   testClusterID,
+			ClusterName: "test",
 			AWSConfig: steps.AWSConfig{
 				KeyID: testCase.existingKeyID,
-				KeyPairName: "test",
 			},
 		}
+		config.AWSConfig.KeyPairName = bootstrapKeyPairName(config)
 
 		err := step.Run(context.Background(), &bytes.Buffer{}, config)
 
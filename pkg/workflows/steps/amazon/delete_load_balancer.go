@@ -0,0 +1,147 @@
+package amazon
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const DeleteLoadBalancerStepName = "aws_delete_load_balancer"
+
+type deleteLoadBalancerService interface {
+	DeleteLoadBalancerWithContext(aws.Context, *elb.DeleteLoadBalancerInput, ...request.Option) (*elb.DeleteLoadBalancerOutput, error)
+	elbTagDescriber
+}
+
+type DeleteLoadBalancerStep struct {
+	getSvc func(steps.AWSConfig) (deleteLoadBalancerService, error)
+}
+
+func InitDeleteLoadBalancer(fn GetELBFn) {
+	steps.RegisterStep(DeleteLoadBalancerStepName, NewDeleteLoadBalancerStep(fn))
+}
+
+func NewDeleteLoadBalancerStep(fn GetELBFn) *DeleteLoadBalancerStep {
+	return &DeleteLoadBalancerStep{
+		getSvc: func(cfg steps.AWSConfig) (deleteLoadBalancerService, error) {
+			ELB, err := fn(cfg)
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return ELB, nil
+		},
+	}
+}
+
+func (s *DeleteLoadBalancerStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	if cfg.LoadBalancerConfig.Host == "" {
+		logrus.Debug("Skip deleting empty load balancer")
+		return nil
+	}
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+
+	if err != nil {
+		logrus.Errorf("Getting service caused %v", err)
+		return errors.Wrapf(err, "%s get service", DeleteLoadBalancerStepName)
+	}
+
+	// Load balancer is released before security groups it belongs to
+	name := util.MakeLoadBalancerName(cfg.ClusterID)
+	owned, err := loadBalancerOwnedByCluster(ctx, svc, name, cfg.ClusterID)
+
+	if isLoadBalancerNotFound(err) {
+		logrus.Debugf("Load balancer %s has already been deleted", name)
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "%s check load balancer", DeleteLoadBalancerStepName)
+	}
+
+	if !owned {
+		logrus.Infof("Skip deleting load balancer %s, it has not been created by cluster %s",
+			name, cfg.ClusterID)
+		return nil
+	}
+
+	_, err = svc.DeleteLoadBalancerWithContext(ctx, &elb.DeleteLoadBalancerInput{
+		LoadBalancerName: aws.String(name),
+	})
+
+	if isLoadBalancerNotFound(err) {
+		logrus.Debugf("Load balancer %s has already been deleted", name)
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "delete load balancer %s", name)
+	}
+
+	return nil
+}
+
+func (s *DeleteLoadBalancerStep) Plan(ctx context.Context, cfg *steps.Config) ([]steps.Resource, error) {
+	if cfg.LoadBalancerConfig.Host == "" {
+		return nil, nil
+	}
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		return nil, errors.Wrap(ErrAuthorization, err.Error())
+	}
+
+	name := util.MakeLoadBalancerName(cfg.ClusterID)
+	owned, err := loadBalancerOwnedByCluster(ctx, svc, name, cfg.ClusterID)
+
+	if isLoadBalancerNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []steps.Resource{
+		{
+			Type:    "load balancer",
+			ID:      name,
+			Foreign: !owned,
+		},
+	}, nil
+}
+
+func isLoadBalancerNotFound(err error) bool {
+	err = errors.Cause(err)
+	if err, ok := err.(awserr.Error); ok && err.Code() == elb.ErrCodeAccessPointNotFoundException {
+		return true
+	}
+
+	return false
+}
+
+func (s *DeleteLoadBalancerStep) Rollback(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	return nil
+}
+
+func (*DeleteLoadBalancerStep) Name() string {
+	return DeleteLoadBalancerStepName
+}
+
+func (*DeleteLoadBalancerStep) Description() string {
+	return "Delete load balancer in front of masters"
+}
+
+func (*DeleteLoadBalancerStep) Depends() []string {
+	return nil
+}
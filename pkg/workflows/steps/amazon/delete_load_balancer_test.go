@@ -0,0 +1,113 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type mockDeleteLoadBalancerSvc struct {
+	mock.Mock
+	fakeELBTags
+}
+
+func (m *mockDeleteLoadBalancerSvc) DeleteLoadBalancerWithContext(ctx aws.Context,
+	input *elb.DeleteLoadBalancerInput, opts ...request.Option) (*elb.DeleteLoadBalancerOutput, error) {
+	args := m.Called(ctx, input, opts)
+	val, ok := args.Get(0).(*elb.DeleteLoadBalancerOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func TestDeleteLoadBalancerStep_Run(t *testing.T) {
+	testCases := []struct {
+		description string
+		host        string
+		getSvcErr   error
+		deleteErr   error
+		errMsg      string
+	}{
+		{
+			description: "no load balancer",
+		},
+		{
+			description: "get service error",
+			host:        "elb.amazonaws.com",
+			getSvcErr:   errors.New("message1"),
+			errMsg:      "message1",
+		},
+		{
+			description: "delete error",
+			host:        "elb.amazonaws.com",
+			deleteErr:   errors.New("message2"),
+			errMsg:      "message2",
+		},
+		{
+			description: "already deleted",
+			host:        "elb.amazonaws.com",
+			deleteErr: awserr.New(elb.ErrCodeAccessPointNotFoundException,
+				"not found", nil),
+		},
+		{
+			description: "success",
+			host:        "elb.amazonaws.com",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+
+		svc := &mockDeleteLoadBalancerSvc{
+			fakeELBTags: fakeELBTags{owner: testClusterID},
+		}
+		svc.On("DeleteLoadBalancerWithContext", mock.Anything, mock.Anything, mock.Anything).
+			Return(&elb.DeleteLoadBalancerOutput{}, testCase.deleteErr)
+
+		step := &DeleteLoadBalancerStep{
+			getSvc: func(steps.AWSConfig) (deleteLoadBalancerService, error) {
+				return svc, testCase.getSvcErr
+			},
+		}
+
+		cfg := &steps.Config{
+			ClusterID: testClusterID,
+			LoadBalancerConfig: steps.LoadBalancerConfig{
+				Host: testCase.host,
+			},
+		}
+
+		err := step.Run(context.Background(), &bytes.Buffer{}, cfg)
+
+		if testCase.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), testCase.errMsg) {
+				t.Errorf("Err message must contain %s actual %v",
+					testCase.errMsg, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+	}
+}
+
+func TestInitDeleteLoadBalancer(t *testing.T) {
+	InitDeleteLoadBalancer(GetELB)
+
+	if s := steps.GetStep(DeleteLoadBalancerStepName); s == nil {
+		t.Error("step must not be nil")
+	}
+}
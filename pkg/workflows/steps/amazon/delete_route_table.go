@@ -22,6 +22,7 @@ var (
 
 type deleteRouteTableSvc interface{
 	DeleteRouteTable(*ec2.DeleteRouteTableInput) (*ec2.DeleteRouteTableOutput, error)
+	tagDescriber
 }
 
 type DeleteRouteTable struct {
@@ -57,6 +58,17 @@ func (s *DeleteRouteTable) Run(ctx context.Context, w io.Writer, cfg *steps.Conf
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
+	owned, _, err := resourceOwnership(ctx, svc, cfg.AWSConfig.RouteTableID, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "%s check route table", DeleteRouteTableStepName)
+	}
+
+	if !owned {
+		logrus.Infof("Skip deleting route table %s, it has not been created by cluster %s",
+			cfg.AWSConfig.RouteTableID, cfg.ClusterID)
+		return nil
+	}
+
 	var (
 		deleteErr error
 		timeout   = deleteRouteTimeout
@@ -83,12 +95,37 @@ func (s *DeleteRouteTable) Run(ctx context.Context, w io.Writer, cfg *steps.Conf
 	return nil
 }
 
+func (s *DeleteRouteTable) Plan(ctx context.Context, cfg *steps.Config) ([]steps.Resource, error) {
+	if cfg.AWSConfig.RouteTableID == "" {
+		return nil, nil
+	}
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		return nil, errors.Wrap(ErrAuthorization, err.Error())
+	}
+
+	owned, untagged, err := resourceOwnership(ctx, svc, cfg.AWSConfig.RouteTableID, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return []steps.Resource{
+		{
+			Type:     "route table",
+			ID:       cfg.AWSConfig.RouteTableID,
+			Foreign:  !owned,
+			Untagged: untagged,
+		},
+	}, nil
+}
+
 func (*DeleteRouteTable) Name() string {
 	return DeleteRouteTableStepName
 }
 
 func (*DeleteRouteTable) Depends() []string {
-	return []string{DeleteSubnetsStepName}
+	return []string{DeleteSubnetsStepName, DisassociateRouteTableStepName}
 }
 
 func (*DeleteRouteTable) Description() string {
@@ -15,6 +15,7 @@ import (
 
 type mockDeleteRouteTableService struct{
 	mock.Mock
+	fakeTags
 }
 
 func (m *mockDeleteRouteTableService) DeleteRouteTable(input *ec2.DeleteRouteTableInput) (*ec2.DeleteRouteTableOutput, error) {
@@ -58,7 +59,9 @@ func TestDeleteRouteTable_Run(t *testing.T) {
 	deleteRouteAttemptCount = 1
 
 	for _, testCase := range testCases {
-		svc := &mockDeleteRouteTableService{}
+		svc := &mockDeleteRouteTableService{
+			fakeTags: fakeTags{owner: testClusterID},
+		}
 		svc.On("DeleteRouteTable", mock.Anything).
 			Return(mock.Anything, testCase.deleteErr)
 
@@ -69,6 +72,7 @@ func TestDeleteRouteTable_Run(t *testing.T) {
 		}
 
 		config := &steps.Config{
+			ClusterID: testClusterID,
 			AWSConfig: steps.AWSConfig{
 				RouteTableID: testCase.existingID,
 			},
@@ -135,8 +139,8 @@ func TestNewDeleteRouteTableStepError(t *testing.T) {
 func TestDeleteRouteTable_Depends(t *testing.T) {
 	step := &DeleteRouteTable{}
 
-	if deps := step.Depends(); deps == nil || len(deps) != 1 || deps[0] != DeleteSubnetsStepName {
-		t.Errorf("Wrong deps %v expected %v", deps, []string{DeleteSubnetsStepName})
+	if deps := step.Depends(); len(deps) != 2 || deps[0] != DeleteSubnetsStepName || deps[1] != DisassociateRouteTableStepName {
+		t.Errorf("Wrong deps %v expected %v", deps, []string{DeleteSubnetsStepName, DisassociateRouteTableStepName})
 	}
 }
 
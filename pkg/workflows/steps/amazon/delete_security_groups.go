@@ -3,9 +3,9 @@ package amazon
 import (
 	"context"
 	"io"
-	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -17,15 +17,14 @@ import (
 
 const DeleteSecurityGroupsStepName = "aws_delete_security_groups"
 
-var (
-	deleteSecGroupTimeout      = time.Second * 10
-	deleteSecGroupAttemptCount = 10
-)
+// errCodeGroupNotFound is returned by EC2 API for security groups that don't exist.
+const errCodeGroupNotFound = "InvalidGroup.NotFound"
 
 type deleteSecurityGroupService interface {
 	DescribeSecurityGroups(*ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error)
 	RevokeSecurityGroupIngressWithContext(aws.Context, *ec2.RevokeSecurityGroupIngressInput, ...request.Option) (*ec2.RevokeSecurityGroupIngressOutput, error)
 	DeleteSecurityGroupWithContext(aws.Context, *ec2.DeleteSecurityGroupInput, ...request.Option) (*ec2.DeleteSecurityGroupOutput, error)
+	tagDescriber
 }
 
 type DeleteSecurityGroup struct {
@@ -66,164 +65,152 @@ func (s *DeleteSecurityGroup) Run(ctx context.Context, w io.Writer, cfg *steps.C
 			DeleteSecurityGroupsStepName)
 	}
 
+	// NOTE: groups deleted by a previous attempt of the step are skipped.
+	groupIDs := make([]string, 0, 2)
+
 	masterGroupName, err := s.getSecurityGroupNameByID(
 		cfg.AWSConfig.MastersSecurityGroupID, svc)
 
-	if err != nil {
+	switch {
+	case isGroupNotFound(err):
+		logrus.Debugf("Master group %s has been deleted", cfg.AWSConfig.MastersSecurityGroupID)
+	case err != nil:
 		logrus.Debugf("get master security group ID %v", err)
 		return errors.Wrapf(err, "get master security group ID")
+	default:
+		logrus.Debugf("Master group name %s", masterGroupName)
+		groupIDs = append(groupIDs, cfg.AWSConfig.MastersSecurityGroupID)
 	}
 
-	logrus.Debugf("Master group name %s", masterGroupName)
 	nodeGroupName, err := s.getSecurityGroupNameByID(
 		cfg.AWSConfig.NodesSecurityGroupID, svc)
 
-	if err != nil {
+	switch {
+	case isGroupNotFound(err):
+		logrus.Debugf("Node group %s has been deleted", cfg.AWSConfig.NodesSecurityGroupID)
+	case err != nil:
 		logrus.Debugf("get node security group ID %v", err)
 		return errors.Wrapf(err, "get node security group ID")
+	default:
+		logrus.Debugf("Node group name %s", nodeGroupName)
+		groupIDs = append(groupIDs, cfg.AWSConfig.NodesSecurityGroupID)
 	}
 
-	logrus.Debugf("Revoking dependent Node Security Group ingress rules %s", nodeGroupName)
-
-	// Decouple security groups from each other
-	revokeInput := &ec2.RevokeSecurityGroupIngressInput{
-		GroupId: aws.String(cfg.AWSConfig.MastersSecurityGroupID),
-		IpPermissions: []*ec2.IpPermission{
-			{
-				FromPort:   aws.Int64(0),
-				ToPort:     aws.Int64(0),
-				IpProtocol: aws.String("-1"),
-				UserIdGroupPairs: []*ec2.UserIdGroupPair{
-					{
-						GroupId: aws.String(cfg.AWSConfig.NodesSecurityGroupID),
-					},
-				},
-			},
-			{
-				FromPort:   aws.Int64(0),
-				ToPort:     aws.Int64(0),
-				IpProtocol: aws.String("-1"),
-				UserIdGroupPairs: []*ec2.UserIdGroupPair{
-					{
-						GroupId: aws.String(cfg.AWSConfig.MastersSecurityGroupID),
-					},
-				},
-			},
-		},
-	}
-
-	logrus.Debugf("Revoking dependent Master Security Group  %s ingress rules",
-		cfg.AWSConfig.MastersSecurityGroupID)
-	_, err = svc.RevokeSecurityGroupIngressWithContext(ctx, revokeInput)
+	owned := make([]string, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		ok, _, err := resourceOwnership(ctx, svc, groupID, cfg)
+		if err != nil {
+			return errors.Wrapf(err, "%s check security group",
+				DeleteSecurityGroupsStepName)
+		}
 
-	if err != nil {
-		return errors.Wrapf(err, "revoke relation between master "+
-			"and node security group %s caused %s",
-			cfg.AWSConfig.NodesSecurityGroupID, err.Error())
+		if !ok {
+			logrus.Infof("Skip deleting security group %s, it has not been created by cluster %s",
+				groupID, cfg.ClusterID)
+			continue
+		}
+		owned = append(owned, groupID)
 	}
 
-	revokeInput = &ec2.RevokeSecurityGroupIngressInput{
-		GroupId: aws.String(cfg.AWSConfig.NodesSecurityGroupID),
-		IpPermissions: []*ec2.IpPermission{
-			{
-				FromPort:   aws.Int64(0),
-				ToPort:     aws.Int64(0),
-				IpProtocol: aws.String("-1"),
-				UserIdGroupPairs: []*ec2.UserIdGroupPair{
-					{
-						GroupId: aws.String(cfg.AWSConfig.NodesSecurityGroupID),
-					},
-				},
-			},
-			{
-				FromPort:   aws.Int64(0),
-				ToPort:     aws.Int64(0),
-				IpProtocol: aws.String("-1"),
-				UserIdGroupPairs: []*ec2.UserIdGroupPair{
-					{
-						GroupId: aws.String(cfg.AWSConfig.MastersSecurityGroupID),
-					},
-				},
-			},
-		},
+	if len(owned) == 0 {
+		return nil
 	}
 
-	_, err = svc.RevokeSecurityGroupIngressWithContext(ctx, revokeInput)
-
-	if err != nil {
-		logrus.Debugf("revoke relation between node and master "+
-			"security group caused %s caused %s",
-			cfg.AWSConfig.MastersSecurityGroupID, err.Error())
-		return errors.Wrapf(err, "find security group %s",
-			cfg.AWSConfig.NodesSecurityGroupID)
+	// Decouple security groups from each other, only rules that
+	// refer to groups of the cluster are revoked.
+	for _, groupID := range groupIDs {
+		logrus.Debugf("Revoking dependent security group %s ingress rules", groupID)
+		if err := s.revokeIngress(ctx, svc, groupID, owned); err != nil {
+			return errors.Wrapf(err, "revoke relation between security "+
+				"group %s and groups of cluster", groupID)
+		}
 	}
 
 	logrus.Debugf("Dependencies between security groups has been revoked")
-	var deleteErr error
-	var timeout = deleteSecGroupTimeout
 
 	// Delete master security group first
-	for i := 0; i < deleteSecGroupAttemptCount; i++ {
-		logrus.Debugf("Delete master security group %s", cfg.AWSConfig.MastersSecurityGroupID)
-		reqMaster := &ec2.DeleteSecurityGroupInput{
-			GroupId: aws.String(cfg.AWSConfig.MastersSecurityGroupID),
-		}
-		_, deleteErr = svc.DeleteSecurityGroupWithContext(ctx, reqMaster)
-
-		if deleteErr != nil {
-			logrus.Debugf("delete master security group %s caused %s",
-				cfg.AWSConfig.MastersSecurityGroupID, deleteErr.Error())
-		} else {
-			logrus.Debugf("master security group %s has been deleted",
-				cfg.AWSConfig.MastersSecurityGroupID)
-			break
+	for _, groupID := range owned {
+		if err := s.deleteGroup(ctx, svc, groupID); err != nil {
+			logrus.Errorf("Delete security group %s %s", groupID, DeleteSecurityGroupsStepName)
+			return errors.Wrapf(err, "%s delete security group %s",
+				DeleteSecurityGroupsStepName, groupID)
 		}
+	}
 
-		logrus.Debugf("Sleep for %v", timeout)
-		time.Sleep(timeout)
-		timeout = timeout * 2
+	logrus.Debugf("Deleting security group finished")
+	return nil
+}
+
+func (s *DeleteSecurityGroup) Plan(ctx context.Context, cfg *steps.Config) ([]steps.Resource, error) {
+	if cfg.AWSConfig.MastersSecurityGroupID == "" ||
+		cfg.AWSConfig.NodesSecurityGroupID == "" {
+		return nil, nil
 	}
 
-	if deleteErr != nil {
-		logrus.Errorf("Delete master security group %s", DeleteSecurityGroupsStepName)
-		return errors.Wrapf(deleteErr, "%s delete master security group",
-			DeleteSecurityGroupsStepName)
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		return nil, errors.Wrap(ErrAuthorization, err.Error())
 	}
 
-	timeout = deleteSecGroupTimeout
-	// Delete node security group
-	for i := 0; i < deleteSecGroupAttemptCount; i++ {
-		reqNode := &ec2.DeleteSecurityGroupInput{
-			GroupId: aws.String(cfg.AWSConfig.NodesSecurityGroupID),
+	resources := make([]steps.Resource, 0, 2)
+	for _, groupID := range []string{
+		cfg.AWSConfig.MastersSecurityGroupID,
+		cfg.AWSConfig.NodesSecurityGroupID,
+	} {
+		owned, untagged, err := resourceOwnership(ctx, svc, groupID, cfg)
+		if err != nil {
+			return nil, err
 		}
 
-		logrus.Debugf("Delete node security group %s",
-			cfg.AWSConfig.NodesSecurityGroupID)
-		_, deleteErr = svc.DeleteSecurityGroupWithContext(ctx, reqNode)
-
-		if deleteErr != nil {
-			logrus.Debugf("delete node security group %s %s",
-				cfg.AWSConfig.NodesSecurityGroupID, deleteErr.Error())
-		} else {
-			logrus.Debugf("node security group %s has been deleted",
-				cfg.AWSConfig.NodesSecurityGroupID)
-			break
-		}
+		resources = append(resources, steps.Resource{
+			Type:     "security group",
+			ID:       groupID,
+			Foreign:  !owned,
+			Untagged: untagged,
+		})
+	}
 
-		logrus.Debugf("Sleep for %v", timeout)
-		time.Sleep(timeout)
-		timeout = timeout * 2
+	return resources, nil
+}
+
+// revokeIngress revokes rules of the group that allow traffic from refs.
+func (*DeleteSecurityGroup) revokeIngress(ctx context.Context, svc deleteSecurityGroupService,
+	groupID string, refs []string) error {
+	permissions := make([]*ec2.IpPermission, 0, len(refs))
+	for _, ref := range refs {
+		permissions = append(permissions, &ec2.IpPermission{
+			FromPort:   aws.Int64(0),
+			ToPort:     aws.Int64(0),
+			IpProtocol: aws.String("-1"),
+			UserIdGroupPairs: []*ec2.UserIdGroupPair{
+				{
+					GroupId: aws.String(ref),
+				},
+			},
+		})
 	}
 
+	_, err := svc.RevokeSecurityGroupIngressWithContext(ctx, &ec2.RevokeSecurityGroupIngressInput{
+		GroupId:       aws.String(groupID),
+		IpPermissions: permissions,
+	})
 
-	if deleteErr != nil {
-		logrus.Errorf("Delete node security group %s", DeleteSecurityGroupsStepName)
-		return errors.Wrapf(deleteErr, "%s delete node security group",
-			DeleteSecurityGroupsStepName)
+	return err
+}
+
+// deleteGroup deletes the group, groups that belong to instances
+// which are still being terminated are deleted by retries of the step.
+func (*DeleteSecurityGroup) deleteGroup(ctx context.Context, svc deleteSecurityGroupService, groupID string) error {
+	logrus.Debugf("Delete security group %s", groupID)
+	_, err := svc.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{
+		GroupId: aws.String(groupID),
+	})
+
+	if err != nil && !isGroupNotFound(err) {
+		return err
 	}
 
-	// Don't fail even if something not get deleted
-	logrus.Debugf("Deleting security group finished")
+	logrus.Debugf("security group %s has been deleted", groupID)
 	return nil
 }
 
@@ -235,6 +222,10 @@ func (*DeleteSecurityGroup) Depends() []string {
 	return []string{DeleteClusterMachinesStepName}
 }
 
+func (*DeleteSecurityGroup) RetryPolicy() steps.RetryPolicy {
+	return deleteRetryPolicy
+}
+
 func (*DeleteSecurityGroup) Description() string {
 	return "Deletes security groups"
 }
@@ -261,3 +252,11 @@ func (*DeleteSecurityGroup) getSecurityGroupNameByID(name string, svc deleteSecu
 
 	return *resp.SecurityGroups[0].GroupName, nil
 }
+
+func isGroupNotFound(err error) bool {
+	if err, ok := errors.Cause(err).(awserr.Error); ok && err.Code() == errCodeGroupNotFound {
+		return true
+	}
+
+	return false
+}
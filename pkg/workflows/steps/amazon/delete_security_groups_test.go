@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
@@ -12,12 +13,12 @@ import (
 	"github.com/supergiant/control/pkg/workflows/steps"
 	"strings"
 	"testing"
-	"time"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 )
 
 type mockDeleteSecGroupSvc struct {
 	mock.Mock
+	fakeTags
 }
 
 func (m *mockDeleteSecGroupSvc) DescribeSecurityGroups(
@@ -240,6 +241,22 @@ func TestDeleteSecurityGroup_Run(t *testing.T) {
 			deleteNodeErr: errors.New("message7"),
 			errMsg: "message7",
 		},
+		{
+			description: "master has been deleted",
+
+			masterSecGroupId: "1234",
+			nodeSecGroupId:   "5678",
+
+			describeMasterErr: awserr.New(errCodeGroupNotFound, "not found", nil),
+
+			describeNodeOutput: &ec2.DescribeSecurityGroupsOutput{
+				SecurityGroups: []*ec2.SecurityGroup{
+					{
+						GroupName: aws.String("node"),
+					},
+				},
+			},
+		},
 		{
 			description: "success",
 
@@ -266,7 +283,9 @@ func TestDeleteSecurityGroup_Run(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Log(testCase.description)
-		svc := &mockDeleteSecGroupSvc{}
+		svc := &mockDeleteSecGroupSvc{
+			fakeTags: fakeTags{owner: testClusterID},
+		}
 		svc.On("DescribeSecurityGroups",
 			mock.Anything).Return(testCase.describeMasterOutput,
 			testCase.describeMasterErr).Once()
@@ -295,15 +314,13 @@ func TestDeleteSecurityGroup_Run(t *testing.T) {
 		}
 
 		config := &steps.Config{
+			ClusterID: testClusterID,
 			AWSConfig: steps.AWSConfig{
 				MastersSecurityGroupID: testCase.masterSecGroupId,
 				NodesSecurityGroupID:   testCase.nodeSecGroupId,
 			},
 		}
 
-		deleteSecGroupAttemptCount = 1
-		deleteSecGroupTimeout = time.Nanosecond
-
 		err := step.Run(context.Background(), &bytes.Buffer{}, config)
 
 		if err == nil && testCase.errMsg != "" {
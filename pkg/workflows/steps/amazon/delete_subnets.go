@@ -16,6 +16,7 @@ const DeleteSubnetsStepName = "aws_delete_subnets"
 
 type deleteSubnetesSvc interface {
 	DeleteSubnet(*ec2.DeleteSubnetInput) (*ec2.DeleteSubnetOutput, error)
+	tagDescriber
 }
 
 type DeleteSubnets struct {
@@ -56,6 +57,17 @@ func (s *DeleteSubnets) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 	}
 
 	for az, subnet := range cfg.AWSConfig.Subnets {
+		owned, _, err := resourceOwnership(ctx, svc, subnet, cfg)
+		if err != nil {
+			return errors.Wrapf(err, "%s check subnet", DeleteSubnetsStepName)
+		}
+
+		if !owned {
+			logrus.Infof("Skip deleting subnet %s, it has not been created by cluster %s",
+				subnet, cfg.ClusterID)
+			continue
+		}
+
 		logrus.Debugf("Delete subnet %s in az %s", subnet, az)
 		descReq := &ec2.DeleteSubnetInput{
 			SubnetId: aws.String(subnet),
@@ -71,12 +83,44 @@ func (s *DeleteSubnets) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 	return nil
 }
 
+func (s *DeleteSubnets) Plan(ctx context.Context, cfg *steps.Config) ([]steps.Resource, error) {
+	if len(cfg.AWSConfig.Subnets) == 0 {
+		return nil, nil
+	}
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		return nil, errors.Wrap(ErrAuthorization, err.Error())
+	}
+
+	resources := make([]steps.Resource, 0, len(cfg.AWSConfig.Subnets))
+	for _, subnet := range cfg.AWSConfig.Subnets {
+		owned, untagged, err := resourceOwnership(ctx, svc, subnet, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, steps.Resource{
+			Type:     "subnet",
+			ID:       subnet,
+			Foreign:  !owned,
+			Untagged: untagged,
+		})
+	}
+
+	return resources, nil
+}
+
 func (*DeleteSubnets) Name() string {
 	return DeleteSubnetsStepName
 }
 
 func (*DeleteSubnets) Depends() []string {
-	return []string{DeleteSecurityGroupsStepName}
+	return []string{DisassociateRouteTableStepName}
+}
+
+func (*DeleteSubnets) RetryPolicy() steps.RetryPolicy {
+	return deleteRetryPolicy
 }
 
 func (*DeleteSubnets) Description() string {
@@ -14,6 +14,7 @@ import (
 
 type mockDeleteSubnetService struct {
 	mock.Mock
+	fakeTags
 }
 
 func (m *mockDeleteSubnetService) DeleteSubnet(
@@ -69,7 +70,9 @@ func TestDeleteSubnets_Run(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Log(testCase.description)
-		svc := &mockDeleteSubnetService{}
+		svc := &mockDeleteSubnetService{
+			fakeTags: fakeTags{owner: testClusterID},
+		}
 		svc.On("DeleteSubnet", mock.Anything).
 			Return(mock.Anything, testCase.deleteErr)
 
@@ -80,6 +83,7 @@ func TestDeleteSubnets_Run(t *testing.T) {
 		}
 
 		config := &steps.Config{
+			ClusterID: testClusterID,
 			AWSConfig: steps.AWSConfig{
 				Subnets: testCase.subnets,
 			},
@@ -149,9 +153,9 @@ func TestDeleteSubnets_Depends(t *testing.T) {
 	s := &DeleteSubnets{}
 
 	if deps := s.Depends(); deps == nil ||
-		len(deps) != 1 || deps[0] != DeleteSecurityGroupsStepName {
+		len(deps) != 1 || deps[0] != DisassociateRouteTableStepName {
 		t.Errorf("Wrong dependencies expected %v actual %v",
-			[]string{DeleteSecurityGroupsStepName},deps)
+			[]string{DisassociateRouteTableStepName},deps)
 	}
 }
 
@@ -5,23 +5,23 @@ import (
 	"io"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"time"
 )
 
 const DeleteVPCStepName = "aws_delete_vpc"
 
-var (
-	deleteVPCTimeout      = time.Second * 30
-	deleteVPCAttemptCount = 3
-)
+// errCodeVPCNotFound is returned by EC2 API for VPCs that don't exist.
+const errCodeVPCNotFound = "InvalidVpcID.NotFound"
 
 type vpcSvc interface {
 	DeleteVpcWithContext(aws.Context, *ec2.DeleteVpcInput, ...request.Option) (*ec2.DeleteVpcOutput, error)
+	DescribeVpcsWithContext(aws.Context, *ec2.DescribeVpcsInput, ...request.Option) (*ec2.DescribeVpcsOutput, error)
+	tagDescriber
 }
 
 type DeleteVPC struct {
@@ -60,30 +60,78 @@ func (s *DeleteVPC) Run(ctx context.Context, w io.Writer, cfg *steps.Config) err
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
-	var (
-		deleteErr error
-		timeout   = deleteVPCTimeout
-	)
+	owned, _, err := s.ownership(ctx, svc, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "%s check VPC", DeleteVPCStepName)
+	}
 
-	for i := 0; i < deleteVPCAttemptCount; i++ {
-		req := &ec2.DeleteVpcInput{
-			VpcId: aws.String(cfg.AWSConfig.VPCID),
-		}
+	if !owned {
+		logrus.Infof("Skip deleting VPC %s, it has not been created by cluster %s",
+			cfg.AWSConfig.VPCID, cfg.ClusterID)
+		return nil
+	}
+
+	logrus.Debugf("Delete VPC ID: %s", cfg.AWSConfig.VPCID)
+	_, err = svc.DeleteVpcWithContext(ctx, &ec2.DeleteVpcInput{
+		VpcId: aws.String(cfg.AWSConfig.VPCID),
+	})
+
+	// NOTE: VPC could have been deleted by a previous attempt of the step.
+	if err, ok := err.(awserr.Error); ok && err.Code() == errCodeVPCNotFound {
+		return nil
+	}
+
+	return errors.Wrapf(err, "%s delete VPC %s", DeleteVPCStepName, cfg.AWSConfig.VPCID)
+}
+
+func (s *DeleteVPC) Plan(ctx context.Context, cfg *steps.Config) ([]steps.Resource, error) {
+	if cfg.AWSConfig.VPCID == "" {
+		return nil, nil
+	}
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		return nil, errors.Wrap(ErrAuthorization, err.Error())
+	}
+
+	owned, untagged, err := s.ownership(ctx, svc, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return []steps.Resource{
+		{
+			Type:     "vpc",
+			ID:       cfg.AWSConfig.VPCID,
+			Foreign:  !owned,
+			Untagged: untagged,
+		},
+	}, nil
+}
+
+// ownership is resourceOwnership of the VPC, clusters created before
+// resources were tagged used default VPC when VPC was supplied by user,
+// so untagged default VPC is never owned.
+func (s *DeleteVPC) ownership(ctx context.Context, svc vpcSvc, cfg *steps.Config) (bool, bool, error) {
+	owned, untagged, err := resourceOwnership(ctx, svc, cfg.AWSConfig.VPCID, cfg)
+	if err != nil || !untagged {
+		return owned, untagged, err
+	}
 
-		logrus.Debugf("Delete VPC ID: %s", cfg.AWSConfig.VPCID)
-		_, deleteErr = svc.DeleteVpcWithContext(ctx, req)
+	out, err := svc.DescribeVpcsWithContext(ctx, &ec2.DescribeVpcsInput{
+		VpcIds: aws.StringSlice([]string{cfg.AWSConfig.VPCID}),
+	})
+	if err != nil {
+		return false, false, errors.Wrapf(err, "describe vpc %s", cfg.AWSConfig.VPCID)
+	}
 
-		if deleteErr != nil {
-			logrus.Debugf("Delete VPC %s caused %s retry in %v ",
-				cfg.AWSConfig.VPCID, deleteErr.Error(), timeout)
-			time.Sleep(timeout)
-			timeout = timeout * 2
-		} else {
-			break
+	for _, vpc := range out.Vpcs {
+		if aws.BoolValue(vpc.IsDefault) {
+			return false, false, nil
 		}
 	}
 
-	return deleteErr
+	return cfg.AWSConfig.DeleteUntagged, true, nil
 }
 
 func (*DeleteVPC) Name() string {
@@ -91,7 +139,11 @@ func (*DeleteVPC) Name() string {
 }
 
 func (*DeleteVPC) Depends() []string {
-	return []string{DeleteSecurityGroupsStepName}
+	return nil
+}
+
+func (*DeleteVPC) RetryPolicy() steps.RetryPolicy {
+	return deleteRetryPolicy
 }
 
 func (*DeleteVPC) Description() string {
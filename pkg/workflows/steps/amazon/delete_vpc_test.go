@@ -4,13 +4,13 @@ import (
 	"context"
 	"bytes"
 	"strings"
-	"time"
 	"testing"
 
 	"github.com/pkg/errors"
 
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/stretchr/testify/mock"
@@ -21,6 +21,9 @@ import (
 
 type mockDeleteVpcSvc struct{
 	mock.Mock
+	fakeTags
+
+	isDefault bool
 }
 
 func (m *mockDeleteVpcSvc) DeleteVpcWithContext(ctx aws.Context, input *ec2.DeleteVpcInput, opts ...request.Option) (*ec2.DeleteVpcOutput, error) {
@@ -32,6 +35,19 @@ func (m *mockDeleteVpcSvc) DeleteVpcWithContext(ctx aws.Context, input *ec2.Dele
 	return val, args.Error(1)
 }
 
+// DescribeVpcsWithContext describes VPC as default one when isDefault is set.
+func (m *mockDeleteVpcSvc) DescribeVpcsWithContext(ctx aws.Context, input *ec2.DescribeVpcsInput,
+	opts ...request.Option) (*ec2.DescribeVpcsOutput, error) {
+	return &ec2.DescribeVpcsOutput{
+		Vpcs: []*ec2.Vpc{
+			{
+				VpcId:     input.VpcIds[0],
+				IsDefault: aws.Bool(m.isDefault),
+			},
+		},
+	}, nil
+}
+
 
 func TestDeleteVPC_Run(t *testing.T) {
 	testCases := []struct{
@@ -58,6 +74,11 @@ func TestDeleteVPC_Run(t *testing.T) {
 			deleteErr: errors.New("message2"),
 			errMsg: "message2",
 		},
+		{
+			description: "already deleted",
+			existingID: "1234",
+			deleteErr: awserr.New(errCodeVPCNotFound, "not found", nil),
+		},
 		{
 			description: "success",
 			existingID: "1234",
@@ -66,7 +87,9 @@ func TestDeleteVPC_Run(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Log(testCase.description)
-		svc := &mockDeleteVpcSvc{}
+		svc := &mockDeleteVpcSvc{
+			fakeTags: fakeTags{owner: testClusterID},
+		}
 		svc.On("DeleteVpcWithContext", mock.Anything,
 			mock.Anything, mock.Anything).Return(mock.Anything,
 				testCase.deleteErr)
@@ -77,14 +100,12 @@ func TestDeleteVPC_Run(t *testing.T) {
 		}
 
 		config := &steps.Config{
+			ClusterID: testClusterID,
 			AWSConfig: steps.AWSConfig{
 				VPCID: testCase.existingID,
 			},
 		}
 
-		deleteVPCAttemptCount  =1
-		deleteVPCTimeout = time.Nanosecond
-
 		err := step.Run(context.Background(), &bytes.Buffer{}, config)
 
 		if err == nil && testCase.errMsg != "" {
@@ -157,10 +178,9 @@ func TestDeleteVPC_Name(t *testing.T) {
 func TestDeleteVPC_Depends(t *testing.T) {
 	s := DeleteVPC{}
 
-	if deps := s.Depends(); deps == nil ||
-		len(deps) != 1 || deps[0] != DeleteSecurityGroupsStepName {
+	if deps := s.Depends(); deps != nil {
 		t.Errorf("Wrong dependencies expected %v actual %v",
-			deps, []string{DeleteSecurityGroupsStepName})
+			deps, nil)
 	}
 }
 
@@ -16,6 +16,7 @@ const DisassociateRouteTableStepName = "aws_disassociate_route_table"
 
 type DisassociateService interface {
 	DisassociateRouteTable(*ec2.DisassociateRouteTableInput) (*ec2.DisassociateRouteTableOutput, error)
+	tagDescriber
 }
 
 type DisassociateRouteTable struct {
@@ -51,6 +52,19 @@ func (s *DisassociateRouteTable) Run(ctx context.Context, w io.Writer, cfg *step
 			DisassociateRouteTableStepName)
 	}
 
+	// Associations can't be tagged, they belong to the route table
+	owned, err := s.ownedRouteTable(ctx, svc, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "%s check route table",
+			DisassociateRouteTableStepName)
+	}
+
+	if !owned {
+		logrus.Infof("Skip disassociating route table %s, it has not been created by cluster %s",
+			cfg.AWSConfig.RouteTableID, cfg.ClusterID)
+		return nil
+	}
+
 	for _, associationID := range cfg.AWSConfig.RouteTableAssociationIDs {
 		if associationID == "" {
 			continue
@@ -70,6 +84,45 @@ func (s *DisassociateRouteTable) Run(ctx context.Context, w io.Writer, cfg *step
 	return nil
 }
 
+func (s *DisassociateRouteTable) Plan(ctx context.Context, cfg *steps.Config) ([]steps.Resource, error) {
+	if len(cfg.AWSConfig.RouteTableAssociationIDs) == 0 {
+		return nil, nil
+	}
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		return nil, errors.Wrap(ErrAuthorization, err.Error())
+	}
+
+	owned, err := s.ownedRouteTable(ctx, svc, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]steps.Resource, 0, len(cfg.AWSConfig.RouteTableAssociationIDs))
+	for _, associationID := range cfg.AWSConfig.RouteTableAssociationIDs {
+		if associationID == "" {
+			continue
+		}
+
+		resources = append(resources, steps.Resource{
+			Type:    "route table association",
+			ID:      associationID,
+			Foreign: !owned,
+		})
+	}
+
+	return resources, nil
+}
+
+func (*DisassociateRouteTable) ownedRouteTable(ctx context.Context, svc DisassociateService, cfg *steps.Config) (bool, error) {
+	if cfg.AWSConfig.RouteTableID == "" {
+		return false, nil
+	}
+
+	return ownedByCluster(ctx, svc, cfg.AWSConfig.RouteTableID, cfg.ClusterID)
+}
+
 func (*DisassociateRouteTable) Name() string {
 	return DisassociateRouteTableStepName
 }
@@ -14,6 +14,7 @@ import (
 
 type mockDisassociateService struct{
 	mock.Mock
+	fakeTags
 }
 
 func (m *mockDisassociateService) DisassociateRouteTable(
@@ -55,12 +56,16 @@ func TestDisassociateRouteTable_Run(t *testing.T) {
 	}
 
 	for _, testCase := range testCases {
-		svc := &mockDisassociateService{}
+		svc := &mockDisassociateService{
+			fakeTags: fakeTags{owner: testClusterID},
+		}
 		svc.On("DisassociateRouteTable",
 			mock.Anything).Return(mock.Anything, testCase.disassociateErr)
 
 		config := &steps.Config{
+			ClusterID: testClusterID,
 			AWSConfig: steps.AWSConfig{
+				RouteTableID: "routeTableId",
 				RouteTableAssociationIDs: map[string]string{
 					"subnetId": "routeTableId",
 				},
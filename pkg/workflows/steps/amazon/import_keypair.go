@@ -64,16 +64,11 @@ func (s *KeyPairStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) e
 		return errors.New("Cluster ID is too short")
 	}
 
-	// NOTE(stgleb): Add unique part to key pair name that allows to
-	// create cluster with the same name and avoid name collision of key pairs.
-	bootstrapKeyPairName := util.MakeKeyName(fmt.Sprintf("%s-%s",
-		cfg.ClusterName,
-		cfg.ClusterID[:4]),
-		false)
+	keyPairName := bootstrapKeyPairName(cfg)
 	log.Infof("[%s] - importing cluster bootstrap key as keypair %s",
-		s.Name(), bootstrapKeyPairName)
+		s.Name(), keyPairName)
 	req := &ec2.ImportKeyPairInput{
-		KeyName:           &bootstrapKeyPairName,
+		KeyName:           &keyPairName,
 		PublicKeyMaterial: []byte(cfg.SshConfig.BootstrapPublicKey),
 	}
 
@@ -98,12 +93,25 @@ func (s *KeyPairStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) e
 	if err != nil {
 		logrus.Debugf("WaitUntilKeyPairExists caused %s", err.Error())
 		return errors.Wrap(err, fmt.Sprintf("wait until key pair found %s",
-			bootstrapKeyPairName))
+			keyPairName))
 	}
 
 	return nil
 }
 
+// NOTE(stgleb): Add unique part to key pair name that allows to
+// create cluster with the same name and avoid name collision of key pairs.
+func bootstrapKeyPairName(cfg *steps.Config) string {
+	if len(cfg.ClusterID) < 4 {
+		return ""
+	}
+
+	return util.MakeKeyName(fmt.Sprintf("%s-%s",
+		cfg.ClusterName,
+		cfg.ClusterID[:4]),
+		false)
+}
+
 func (s *KeyPairStep) Rollback(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	return nil
 }
@@ -0,0 +1,152 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type tagCreator interface {
+	CreateTagsWithContext(aws.Context, *ec2.CreateTagsInput, ...request.Option) (*ec2.CreateTagsOutput, error)
+}
+
+type tagDescriber interface {
+	DescribeTagsWithContext(aws.Context, *ec2.DescribeTagsInput, ...request.Option) (*ec2.DescribeTagsOutput, error)
+}
+
+type elbTagDescriber interface {
+	DescribeTagsWithContext(aws.Context, *elb.DescribeTagsInput, ...request.Option) (*elb.DescribeTagsOutput, error)
+}
+
+// clusterTags are tags of resources created by the cluster, delete
+// steps don't touch resources that have no cluster ID tag.
+func clusterTags(cfg *steps.Config, name string) []*ec2.Tag {
+	return []*ec2.Tag{
+		{
+			Key:   aws.String("KubernetesCluster"),
+			Value: aws.String(cfg.ClusterName),
+		},
+		{
+			Key:   aws.String(clouds.ClusterIDTag),
+			Value: aws.String(cfg.ClusterID),
+		},
+		{
+			Key:   aws.String("Name"),
+			Value: aws.String(fmt.Sprintf("%s-%s", name, cfg.ClusterID)),
+		},
+	}
+}
+
+// tagResources marks resources as created by the cluster.
+func tagResources(ctx context.Context, svc tagCreator, cfg *steps.Config, name string, ids ...string) error {
+	_, err := svc.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: aws.StringSlice(ids),
+		Tags:      clusterTags(cfg, name),
+	})
+
+	return errors.Wrapf(err, "tag %s %v", name, ids)
+}
+
+// ownedByCluster checks that EC2 resource is tagged with ID of the cluster,
+// resources without the tag have been supplied by user.
+func ownedByCluster(ctx context.Context, svc tagDescriber, resourceID, clusterID string) (bool, error) {
+	if clusterID == "" {
+		return false, nil
+	}
+
+	out, err := svc.DescribeTagsWithContext(ctx, &ec2.DescribeTagsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("resource-id"),
+				Values: aws.StringSlice([]string{resourceID}),
+			},
+			{
+				Name:   aws.String("key"),
+				Values: aws.StringSlice([]string{clouds.ClusterIDTag}),
+			},
+		},
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "describe tags of %s", resourceID)
+	}
+
+	for _, tag := range out.Tags {
+		if aws.StringValue(tag.Value) == clusterID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// resourceOwnership is ownedByCluster that takes clusters created before
+// resources have been tagged into account. Untagged resources of such
+// clusters may have been supplied by user, they are owned only when
+// deletion of untagged resources has been requested.
+func resourceOwnership(ctx context.Context, svc tagDescriber, resourceID string,
+	cfg *steps.Config) (owned bool, untagged bool, err error) {
+	if cfg.ClusterID == "" {
+		return false, false, nil
+	}
+
+	out, err := svc.DescribeTagsWithContext(ctx, &ec2.DescribeTagsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("resource-id"),
+				Values: aws.StringSlice([]string{resourceID}),
+			},
+			{
+				Name:   aws.String("key"),
+				Values: aws.StringSlice([]string{clouds.ClusterIDTag}),
+			},
+		},
+	})
+	if err != nil {
+		return false, false, errors.Wrapf(err, "describe tags of %s", resourceID)
+	}
+
+	for _, tag := range out.Tags {
+		if aws.StringValue(tag.Value) == cfg.ClusterID {
+			return true, false, nil
+		}
+	}
+
+	if len(out.Tags) == 0 && !cfg.AWSConfig.ResourcesTagged {
+		return cfg.AWSConfig.DeleteUntagged, true, nil
+	}
+
+	return false, false, nil
+}
+
+// loadBalancerOwnedByCluster is ownedByCluster for classic load balancers.
+func loadBalancerOwnedByCluster(ctx context.Context, svc elbTagDescriber, name, clusterID string) (bool, error) {
+	if clusterID == "" {
+		return false, nil
+	}
+
+	out, err := svc.DescribeTagsWithContext(ctx, &elb.DescribeTagsInput{
+		LoadBalancerNames: aws.StringSlice([]string{name}),
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "describe tags of load balancer %s", name)
+	}
+
+	for _, desc := range out.TagDescriptions {
+		for _, tag := range desc.Tags {
+			if aws.StringValue(tag.Key) == clouds.ClusterIDTag &&
+				aws.StringValue(tag.Value) == clusterID {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,386 @@
+package amazon
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const testClusterID = "1234abcd"
+
+// fakeTags is embedded into mocks of EC2 services, resources
+// are tagged with owner when it is set.
+type fakeTags struct {
+	owner string
+	err   error
+
+	created []*ec2.CreateTagsInput
+}
+
+func (f *fakeTags) CreateTagsWithContext(ctx aws.Context, input *ec2.CreateTagsInput,
+	opts ...request.Option) (*ec2.CreateTagsOutput, error) {
+	f.created = append(f.created, input)
+	return &ec2.CreateTagsOutput{}, f.err
+}
+
+func (f *fakeTags) DescribeTagsWithContext(ctx aws.Context, input *ec2.DescribeTagsInput,
+	opts ...request.Option) (*ec2.DescribeTagsOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	out := &ec2.DescribeTagsOutput{}
+	if f.owner != "" {
+		out.Tags = []*ec2.TagDescription{
+			{
+				Key:   aws.String(clouds.ClusterIDTag),
+				Value: aws.String(f.owner),
+			},
+		}
+	}
+
+	return out, nil
+}
+
+// fakeELBTags is fakeTags for classic load balancers.
+type fakeELBTags struct {
+	owner string
+	err   error
+}
+
+func (f *fakeELBTags) DescribeTagsWithContext(ctx aws.Context, input *elb.DescribeTagsInput,
+	opts ...request.Option) (*elb.DescribeTagsOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	desc := &elb.TagDescription{
+		LoadBalancerName: input.LoadBalancerNames[0],
+	}
+	if f.owner != "" {
+		desc.Tags = []*elb.Tag{
+			{
+				Key:   aws.String(clouds.ClusterIDTag),
+				Value: aws.String(f.owner),
+			},
+		}
+	}
+
+	return &elb.DescribeTagsOutput{
+		TagDescriptions: []*elb.TagDescription{desc},
+	}, nil
+}
+
+func TestOwnedByCluster(t *testing.T) {
+	testCases := []struct {
+		description string
+		owner       string
+		clusterID   string
+		err         error
+
+		expected  bool
+		expectErr bool
+	}{
+		{
+			description: "owned",
+			owner:       testClusterID,
+			clusterID:   testClusterID,
+			expected:    true,
+		},
+		{
+			description: "no tag",
+			clusterID:   testClusterID,
+		},
+		{
+			description: "other cluster",
+			owner:       "other",
+			clusterID:   testClusterID,
+		},
+		{
+			description: "empty cluster id",
+		},
+		{
+			description: "describe error",
+			clusterID:   testClusterID,
+			err:         errors.New("error"),
+			expectErr:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		owned, err := ownedByCluster(context.Background(), &fakeTags{
+			owner: testCase.owner,
+			err:   testCase.err,
+		}, "resource", testCase.clusterID)
+
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+		}
+
+		if owned != testCase.expected {
+			t.Errorf("%s: expected owned %v actual %v",
+				testCase.description, testCase.expected, owned)
+		}
+	}
+}
+
+func TestResourceOwnership(t *testing.T) {
+	testCases := []struct {
+		description    string
+		owner          string
+		tagged         bool
+		deleteUntagged bool
+
+		expectedOwned    bool
+		expectedUntagged bool
+	}{
+		{
+			description:   "owned",
+			owner:         testClusterID,
+			tagged:        true,
+			expectedOwned: true,
+		},
+		{
+			description: "no tag",
+			tagged:      true,
+		},
+		{
+			description:   "owned by cluster created before tagging",
+			owner:         testClusterID,
+			expectedOwned: true,
+		},
+		{
+			description:      "no tag of cluster created before tagging",
+			expectedUntagged: true,
+		},
+		{
+			description:      "deletion of untagged resources requested",
+			deleteUntagged:   true,
+			expectedOwned:    true,
+			expectedUntagged: true,
+		},
+		{
+			description:    "deletion of untagged resources requested for tagged cluster",
+			tagged:         true,
+			deleteUntagged: true,
+		},
+		{
+			description: "other cluster",
+			owner:       "other",
+		},
+	}
+
+	for _, testCase := range testCases {
+		cfg := &steps.Config{
+			ClusterID: testClusterID,
+			AWSConfig: steps.AWSConfig{
+				ResourcesTagged: testCase.tagged,
+				DeleteUntagged:  testCase.deleteUntagged,
+			},
+		}
+
+		owned, untagged, err := resourceOwnership(context.Background(), &fakeTags{
+			owner: testCase.owner,
+		}, "resource", cfg)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+			continue
+		}
+
+		if owned != testCase.expectedOwned || untagged != testCase.expectedUntagged {
+			t.Errorf("%s: expected owned %v untagged %v actual %v %v", testCase.description,
+				testCase.expectedOwned, testCase.expectedUntagged, owned, untagged)
+		}
+	}
+}
+
+func TestDeleteVPCPlanUntagged(t *testing.T) {
+	cfg := &steps.Config{
+		ClusterID: testClusterID,
+		AWSConfig: steps.AWSConfig{
+			VPCID: "vpc-1",
+		},
+	}
+
+	for _, isDefault := range []bool{false, true} {
+		step := &DeleteVPC{
+			getSvc: func(steps.AWSConfig) (vpcSvc, error) {
+				return &mockDeleteVpcSvc{isDefault: isDefault}, nil
+			},
+		}
+
+		// Untagged VPC is kept unless its deletion has been requested
+		for _, deleteUntagged := range []bool{false, true} {
+			cfg.AWSConfig.DeleteUntagged = deleteUntagged
+
+			resources, err := step.Plan(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			// Default VPC has been used when VPC was supplied by user
+			foreign := isDefault || !deleteUntagged
+			if len(resources) != 1 || resources[0].Foreign != foreign ||
+				resources[0].Untagged == isDefault {
+				t.Errorf("default %v delete untagged %v: wrong plan of untagged vpc %v",
+					isDefault, deleteUntagged, resources)
+			}
+		}
+	}
+}
+
+func TestTagResources(t *testing.T) {
+	svc := &fakeTags{}
+	cfg := &steps.Config{
+		ClusterID:   testClusterID,
+		ClusterName: "test",
+	}
+
+	if err := tagResources(context.Background(), svc, cfg, "vpc", "vpc-1"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(svc.created) != 1 || aws.StringValue(svc.created[0].Resources[0]) != "vpc-1" {
+		t.Fatalf("vpc has not been tagged %v", svc.created)
+	}
+
+	owned, _ := ownedByCluster(context.Background(), &fakeTags{
+		owner: tagValue(svc.created[0].Tags, clouds.ClusterIDTag),
+	}, "vpc-1", testClusterID)
+	if !owned {
+		t.Errorf("tagged resource is not owned by cluster %v", svc.created[0].Tags)
+	}
+
+	svc.err = errors.New("error")
+	if err := tagResources(context.Background(), svc, cfg, "vpc", "vpc-1"); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+func TestDeleteStepsPlan(t *testing.T) {
+	cfg := &steps.Config{
+		ClusterID:   testClusterID,
+		ClusterName: "test",
+		AWSConfig: steps.AWSConfig{
+			VPCID:                  "vpc-1",
+			KeyPairName:            "user-key",
+			MastersSecurityGroupID: "sg-1",
+			NodesSecurityGroupID:   "sg-2",
+			ResourcesTagged:        true,
+		},
+	}
+
+	vpcStep := &DeleteVPC{
+		getSvc: func(steps.AWSConfig) (vpcSvc, error) {
+			return &mockDeleteVpcSvc{}, nil
+		},
+	}
+
+	resources, err := vpcStep.Plan(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(resources) != 1 || resources[0].ID != "vpc-1" || !resources[0].Foreign {
+		t.Errorf("untagged vpc must be foreign %v", resources)
+	}
+
+	secGroupStep := &DeleteSecurityGroup{
+		getSvc: func(steps.AWSConfig) (deleteSecurityGroupService, error) {
+			return &mockDeleteSecGroupSvc{
+				fakeTags: fakeTags{owner: testClusterID},
+			}, nil
+		},
+	}
+
+	resources, err = secGroupStep.Plan(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(resources) != 2 || resources[0].Foreign || resources[1].Foreign {
+		t.Errorf("tagged security groups must be owned %v", resources)
+	}
+
+	keyStep := &DeleteKeyPair{}
+	resources, _ = keyStep.Plan(context.Background(), cfg)
+	if len(resources) != 1 || !resources[0].Foreign {
+		t.Errorf("key pair of user must be foreign %v", resources)
+	}
+
+	cfg.AWSConfig.KeyPairName = bootstrapKeyPairName(cfg)
+	resources, _ = keyStep.Plan(context.Background(), cfg)
+	if len(resources) != 1 || resources[0].Foreign {
+		t.Errorf("bootstrap key pair must be owned %v", resources)
+	}
+
+	lbStep := &DeleteLoadBalancerStep{
+		getSvc: func(steps.AWSConfig) (deleteLoadBalancerService, error) {
+			return &mockDeleteLoadBalancerSvc{
+				fakeELBTags: fakeELBTags{
+					err: awserr.New(elb.ErrCodeAccessPointNotFoundException, "", nil),
+				},
+			}, nil
+		},
+	}
+
+	cfg.LoadBalancerConfig.Host = "lb"
+	resources, err = lbStep.Plan(context.Background(), cfg)
+	if err != nil || len(resources) != 0 {
+		t.Errorf("deleted load balancer must be skipped %v %v", resources, err)
+	}
+}
+
+func TestDeleteStepsSkipForeign(t *testing.T) {
+	cfg := &steps.Config{
+		ClusterID: testClusterID,
+		AWSConfig: steps.AWSConfig{
+			VPCID:           "vpc-1",
+			Subnets:         map[string]string{"us-east-1a": "subnet-1"},
+			ResourcesTagged: true,
+		},
+	}
+
+	// Mocks panic when delete methods are called
+	vpcStep := &DeleteVPC{
+		getSvc: func(steps.AWSConfig) (vpcSvc, error) {
+			return &mockDeleteVpcSvc{}, nil
+		},
+	}
+
+	if err := vpcStep.Run(context.Background(), ioutil.Discard, cfg); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	subnetsStep := &DeleteSubnets{
+		getSvc: func(steps.AWSConfig) (deleteSubnetesSvc, error) {
+			return &mockDeleteSubnetService{
+				fakeTags: fakeTags{owner: "other"},
+			}, nil
+		},
+	}
+
+	if err := subnetsStep.Run(context.Background(), ioutil.Discard, cfg); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func tagValue(tags []*ec2.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == key {
+			return aws.StringValue(tag.Value)
+		}
+	}
+
+	return ""
+}
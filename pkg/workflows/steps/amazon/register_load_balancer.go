@@ -0,0 +1,91 @@
+package amazon
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const RegisterLoadBalancerStepName = "aws_register_load_balancer"
+
+type registerLoadBalancerService interface {
+	RegisterInstancesWithLoadBalancerWithContext(aws.Context, *elb.RegisterInstancesWithLoadBalancerInput, ...request.Option) (*elb.RegisterInstancesWithLoadBalancerOutput, error)
+}
+
+// RegisterLoadBalancerStep adds master to the load balancer in front of API
+type RegisterLoadBalancerStep struct {
+	getSvc func(steps.AWSConfig) (registerLoadBalancerService, error)
+}
+
+func InitRegisterLoadBalancer(fn GetELBFn) {
+	steps.RegisterStep(RegisterLoadBalancerStepName, NewRegisterLoadBalancerStep(fn))
+}
+
+func NewRegisterLoadBalancerStep(fn GetELBFn) *RegisterLoadBalancerStep {
+	return &RegisterLoadBalancerStep{
+		getSvc: func(cfg steps.AWSConfig) (registerLoadBalancerService, error) {
+			ELB, err := fn(cfg)
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return ELB, nil
+		},
+	}
+}
+
+func (s *RegisterLoadBalancerStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	if cfg.LoadBalancerConfig.Host == "" {
+		logrus.Debugf("Skip registering master %s without load balancer",
+			cfg.Node.Name)
+		return nil
+	}
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+
+	if err != nil {
+		logrus.Errorf("Getting service caused %v", err)
+		return errors.Wrapf(err, "%s get service", RegisterLoadBalancerStepName)
+	}
+
+	name := util.MakeLoadBalancerName(cfg.ClusterID)
+	_, err = svc.RegisterInstancesWithLoadBalancerWithContext(ctx, &elb.RegisterInstancesWithLoadBalancerInput{
+		LoadBalancerName: aws.String(name),
+		Instances: []*elb.Instance{
+			{
+				InstanceId: aws.String(cfg.Node.ID),
+			},
+		},
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "register instance %s in load balancer %s",
+			cfg.Node.ID, name)
+	}
+
+	return nil
+}
+
+func (s *RegisterLoadBalancerStep) Rollback(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	return nil
+}
+
+func (*RegisterLoadBalancerStep) Name() string {
+	return RegisterLoadBalancerStepName
+}
+
+func (*RegisterLoadBalancerStep) Description() string {
+	return "Register master in the load balancer"
+}
+
+func (*RegisterLoadBalancerStep) Depends() []string {
+	return nil
+}
@@ -0,0 +1,111 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type mockRegisterLoadBalancerSvc struct {
+	mock.Mock
+}
+
+func (m *mockRegisterLoadBalancerSvc) RegisterInstancesWithLoadBalancerWithContext(ctx aws.Context,
+	input *elb.RegisterInstancesWithLoadBalancerInput, opts ...request.Option) (*elb.RegisterInstancesWithLoadBalancerOutput, error) {
+	args := m.Called(ctx, input, opts)
+	val, ok := args.Get(0).(*elb.RegisterInstancesWithLoadBalancerOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func TestRegisterLoadBalancerStep_Run(t *testing.T) {
+	testCases := []struct {
+		description string
+		host        string
+		getSvcErr   error
+		registerErr error
+		errMsg      string
+	}{
+		{
+			description: "no load balancer",
+		},
+		{
+			description: "get service error",
+			host:        "elb.amazonaws.com",
+			getSvcErr:   errors.New("message1"),
+			errMsg:      "message1",
+		},
+		{
+			description: "register error",
+			host:        "elb.amazonaws.com",
+			registerErr: errors.New("message2"),
+			errMsg:      "message2",
+		},
+		{
+			description: "success",
+			host:        "elb.amazonaws.com",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+
+		svc := &mockRegisterLoadBalancerSvc{}
+		svc.On("RegisterInstancesWithLoadBalancerWithContext", mock.Anything,
+			mock.MatchedBy(func(input *elb.RegisterInstancesWithLoadBalancerInput) bool {
+				return *input.LoadBalancerName == "sg-1234abcd-api" &&
+					*input.Instances[0].InstanceId == "i-1"
+			}), mock.Anything).
+			Return(&elb.RegisterInstancesWithLoadBalancerOutput{}, testCase.registerErr)
+
+		step := &RegisterLoadBalancerStep{
+			getSvc: func(steps.AWSConfig) (registerLoadBalancerService, error) {
+				return svc, testCase.getSvcErr
+			},
+		}
+
+		cfg := &steps.Config{
+			ClusterID: "1234abcd",
+			Node: node.Node{
+				ID: "i-1",
+			},
+			LoadBalancerConfig: steps.LoadBalancerConfig{
+				Host: testCase.host,
+			},
+		}
+
+		err := step.Run(context.Background(), &bytes.Buffer{}, cfg)
+
+		if testCase.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), testCase.errMsg) {
+				t.Errorf("Err message must contain %s actual %v",
+					testCase.errMsg, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+	}
+}
+
+func TestInitRegisterLoadBalancer(t *testing.T) {
+	InitRegisterLoadBalancer(GetELB)
+
+	if s := steps.GetStep(RegisterLoadBalancerStepName); s == nil {
+		t.Error("step must not be nil")
+	}
+}
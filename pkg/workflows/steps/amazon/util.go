@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
 var (
@@ -82,3 +84,12 @@ func findOutBoundIP() (string, error) {
 
 	return publicIP, err
 }
+
+// deleteRetryPolicy retries requests throttled by AWS API and deletion of
+// resources that are still in use by resources which are being deleted.
+var deleteRetryPolicy = steps.RetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     time.Second * 10,
+	MaxBackoff:  time.Minute,
+	Retryable:   steps.RetryOn("RequestLimitExceeded", "Throttling", "DependencyViolation"),
+}
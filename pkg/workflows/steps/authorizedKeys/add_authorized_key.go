@@ -11,6 +11,7 @@ import (
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 type Step struct {
@@ -59,7 +60,7 @@ func (*Step) Description() string {
 }
 
 func (*Step) Depends() []string {
-	return nil
+	return []string{ssh.StepName}
 }
 
 func (*Step) Rollback(context.Context, io.Writer, *steps.Config) error {
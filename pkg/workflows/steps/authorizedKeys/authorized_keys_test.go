@@ -5,6 +5,7 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
 	"text/template"
@@ -16,6 +17,7 @@ import (
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 type fakeRunner struct {
@@ -113,8 +115,8 @@ func TestStepName(t *testing.T) {
 func TestDepends(t *testing.T) {
 	s := Step{}
 
-	if len(s.Depends()) != 0 {
-		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{})
+	if deps := s.Depends(); !reflect.DeepEqual(deps, []string{ssh.StepName}) {
+		t.Errorf("Wrong dependency list %v expected %v", deps, []string{ssh.StepName})
 	}
 }
 
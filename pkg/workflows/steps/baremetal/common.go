@@ -0,0 +1,29 @@
+package baremetal
+
+import (
+	"fmt"
+
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	TeardownNodeStepName    = "teardownNodeBareMetal"
+	TeardownClusterStepName = "teardownClusterBareMetal"
+
+	// templateName is a script that removes kubernetes components from a machine.
+	templateName = "teardown"
+)
+
+// Init registers steps of machines that are not created by supergiant,
+// only kubernetes components are removed from them on deletion.
+func Init() {
+	tpl, err := tm.GetTemplate(templateName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", templateName))
+	}
+
+	steps.RegisterStep(TeardownNodeStepName, NewTeardownNodeStep(tpl))
+	steps.RegisterStep(TeardownClusterStepName, NewTeardownClusterStep(tpl))
+}
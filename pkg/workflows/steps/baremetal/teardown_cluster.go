@@ -0,0 +1,94 @@
+package baremetal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/runner"
+	sshRunner "github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// TeardownClusterStep removes kubernetes components from all machines
+// of the cluster, machines that have failed are reported together.
+type TeardownClusterStep struct {
+	script    *template.Template
+	getRunner func(sshRunner.Config) (runner.Runner, error)
+}
+
+func NewTeardownClusterStep(script *template.Template) *TeardownClusterStep {
+	return &TeardownClusterStep{
+		script:    script,
+		getRunner: sshRunner.NewRunner,
+	}
+}
+
+func (s *TeardownClusterStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	machines := make([]*node.Node, 0)
+	for _, n := range config.GetMasters() {
+		machines = append(machines, n)
+	}
+	for _, n := range config.GetNodes() {
+		machines = append(machines, n)
+	}
+
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Name < machines[j].Name
+	})
+
+	failed := make([]string, 0)
+	for _, n := range machines {
+		if err := s.teardown(ctx, out, config, n); err != nil {
+			logrus.Errorf("teardown machine %s of cluster %s caused %v",
+				n.Name, config.ClusterID, err)
+			failed = append(failed, n.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("teardown machines %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func (s *TeardownClusterStep) teardown(ctx context.Context, out io.Writer, config *steps.Config, n *node.Node) error {
+	r, err := s.getRunner(sshRunner.Config{
+		Host:    n.PublicIp,
+		Port:    config.SshConfig.Port,
+		User:    config.SshConfig.User,
+		Timeout: config.SshConfig.Timeout,
+		Key:     []byte(config.SshConfig.BootstrapPrivateKey),
+	})
+	if err != nil {
+		return errors.Wrap(err, "ssh runner")
+	}
+
+	fmt.Fprintf(out, "teardown machine %s %s\n", n.Name, n.PublicIp)
+
+	return steps.RunTemplate(ctx, s.script, r, out, config)
+}
+
+func (s *TeardownClusterStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *TeardownClusterStep) Name() string {
+	return TeardownClusterStepName
+}
+
+func (s *TeardownClusterStep) Description() string {
+	return "Remove kubernetes components from machines of the cluster"
+}
+
+func (s *TeardownClusterStep) Depends() []string {
+	return nil
+}
@@ -0,0 +1,49 @@
+package baremetal
+
+import (
+	"context"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
+)
+
+// TeardownNodeStep removes kubernetes components from the machine
+// of config.Node, the machine is left running.
+type TeardownNodeStep struct {
+	script *template.Template
+}
+
+func NewTeardownNodeStep(script *template.Template) *TeardownNodeStep {
+	return &TeardownNodeStep{
+		script: script,
+	}
+}
+
+func (s *TeardownNodeStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, config)
+	if err != nil {
+		return errors.Wrapf(err, "teardown node %s", config.Node.Name)
+	}
+
+	return nil
+}
+
+func (s *TeardownNodeStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *TeardownNodeStep) Name() string {
+	return TeardownNodeStepName
+}
+
+func (s *TeardownNodeStep) Description() string {
+	return "Remove kubernetes components from the machine"
+}
+
+func (s *TeardownNodeStep) Depends() []string {
+	return []string{ssh.StepName}
+}
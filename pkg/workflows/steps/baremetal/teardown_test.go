@@ -0,0 +1,108 @@
+package baremetal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/runner"
+	sshRunner "github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeRunner struct {
+	errMsg string
+}
+
+func (f *fakeRunner) Run(command *runner.Command) error {
+	if len(f.errMsg) > 0 {
+		return errors.New(f.errMsg)
+	}
+
+	_, err := io.Copy(command.Out, strings.NewReader(command.Script))
+	return err
+}
+
+func TestTeardownNode(t *testing.T) {
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(templateName)
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	cfg := &steps.Config{
+		Runner: &fakeRunner{},
+	}
+
+	output := new(bytes.Buffer)
+	if err := NewTeardownNodeStep(tpl).Run(context.Background(), output, cfg); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{"systemctl stop kubelet.service", "rm -rf /etc/kubernetes"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in %s", expected, output.String())
+		}
+	}
+
+	cfg.Runner = &fakeRunner{errMsg: "connection refused"}
+	if err := NewTeardownNodeStep(tpl).Run(context.Background(), output, cfg); err == nil {
+		t.Errorf("error must not be nil")
+	}
+}
+
+func TestTeardownCluster(t *testing.T) {
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(templateName)
+
+	cfg := &steps.Config{
+		SshConfig: steps.SshConfig{
+			User: "root",
+			Port: "2222",
+		},
+		Masters: steps.NewMap(map[string]*node.Node{
+			"master": {Name: "master", PublicIp: "10.0.0.1"},
+		}),
+		Nodes: steps.NewMap(map[string]*node.Node{
+			"node-1": {Name: "node-1", PublicIp: "10.0.0.2"},
+			"node-2": {Name: "node-2", PublicIp: "10.0.0.3"},
+		}),
+	}
+
+	hosts := make([]string, 0)
+	step := NewTeardownClusterStep(tpl)
+	step.getRunner = func(conf sshRunner.Config) (runner.Runner, error) {
+		if conf.User != "root" || conf.Port != "2222" {
+			t.Errorf("wrong ssh config %v", conf)
+		}
+
+		hosts = append(hosts, conf.Host)
+		if conf.Host == "10.0.0.2" {
+			return &fakeRunner{errMsg: "connection refused"}, nil
+		}
+
+		return &fakeRunner{}, nil
+	}
+
+	err := step.Run(context.Background(), new(bytes.Buffer), cfg)
+
+	if err == nil || !strings.Contains(err.Error(), "node-1") {
+		t.Errorf("expected error with node-1 actual %v", err)
+	}
+
+	if len(hosts) != 3 {
+		t.Errorf("all machines must be torn down, actual %v", hosts)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"text/template"
 
 	"github.com/pkg/errors"
@@ -11,6 +12,7 @@ import (
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 const StepName = "certificates"
@@ -39,18 +41,31 @@ func New(tpl *template.Template) *Step {
 }
 
 func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	// TODO: why does these is set here, not on the building config step?
-	config.CertificatesConfig.PrivateIP = config.Node.PrivateIp
-	config.CertificatesConfig.PublicIP = config.Node.PublicIp
-	config.CertificatesConfig.IsMaster = config.IsMaster
-
 	kubeDefaultSvcIp, err := util.GetKubernetesDefaultSvcIP(config.ManifestConfig.ServicesCIDR)
 	if err != nil {
 		return errors.Wrapf(err, "get cluster dns ip from the %s subnet", config.ManifestConfig.ServicesCIDR)
 	}
-	config.CertificatesConfig.KubernetesSvcIP = kubeDefaultSvcIp.String()
 
-	err = steps.RunTemplate(ctx, s.template, config.Runner, out, config.CertificatesConfig)
+	var certs steps.CertificatesConfig
+	config.Update(func() {
+		// TODO: why does these is set here, not on the building config step?
+		config.CertificatesConfig.PrivateIP = config.Node.PrivateIp
+		config.CertificatesConfig.PublicIP = config.Node.PublicIp
+		config.CertificatesConfig.IsMaster = config.IsMaster
+
+		// Load balancer in front of masters is a part of SANs of api server
+		if host := config.LoadBalancerConfig.Host; host != "" {
+			if net.ParseIP(host) != nil {
+				config.CertificatesConfig.LoadBalancerIP = host
+			} else {
+				config.CertificatesConfig.LoadBalancerDNS = host
+			}
+		}
+		config.CertificatesConfig.KubernetesSvcIP = kubeDefaultSvcIp.String()
+		certs = config.CertificatesConfig
+	})
+
+	err = steps.RunTemplate(ctx, s.template, config.Runner, out, certs)
 	if err != nil {
 		return errors.Wrap(err, "write certificates step")
 	}
@@ -67,5 +82,5 @@ func (s *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return nil
+	return []string{ssh.StepName}
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
 	"text/template"
@@ -17,6 +18,7 @@ import (
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 type fakeRunner struct {
@@ -186,6 +188,35 @@ func TestWriteCertificates(t *testing.T) {
 	}
 }
 
+func TestWriteCertificatesLoadBalancer(t *testing.T) {
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	for host, san := range map[string]string{
+		"34.56.78.90":          "IP.4 = 34.56.78.90",
+		"sg-kube-api.elb.test": "DNS.6 = sg-kube-api.elb.test",
+	} {
+		cfg := steps.NewConfig("", "", "", profile.Profile{
+			K8SServicesCIDR: "10.3.0.0/16",
+		})
+		cfg.Runner = &fakeRunner{}
+		cfg.IsMaster = true
+		cfg.LoadBalancerConfig.Host = host
+
+		output := new(bytes.Buffer)
+		if err := New(tpl).Run(context.Background(), output, cfg); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if !strings.Contains(output.String(), san) {
+			t.Errorf("%s not found in %s", san, output.String())
+		}
+	}
+}
+
 func TestWriteCertificatesError(t *testing.T) {
 	errMsg := "error has occurred"
 
@@ -231,8 +262,8 @@ func TestStepName(t *testing.T) {
 func TestDepends(t *testing.T) {
 	s := Step{}
 
-	if len(s.Depends()) != 0 {
-		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{})
+	if deps := s.Depends(); !reflect.DeepEqual(deps, []string{ssh.StepName}) {
+		t.Errorf("Wrong dependency list %v expected %v", deps, []string{ssh.StepName})
 	}
 }
 
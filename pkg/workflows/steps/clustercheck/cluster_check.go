@@ -10,7 +10,6 @@ import (
 
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
 )
 
 const StepName = "clustercheck"
@@ -60,5 +59,5 @@ func (s *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return []string{kubelet.StepName}
+	return nil
 }
@@ -17,7 +17,6 @@ import (
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
 )
 
 type fakeRunner struct {
@@ -120,8 +119,8 @@ func TestStepName(t *testing.T) {
 func TestDepends(t *testing.T) {
 	s := Step{}
 
-	if len(s.Depends()) != 1 && s.Depends()[0] != kubelet.StepName {
-		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{kubelet.StepName})
+	if len(s.Depends()) != 0 {
+		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{})
 	}
 }
 
@@ -10,6 +10,7 @@ import (
 
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 const StepName = "cni"
@@ -59,5 +60,10 @@ func (s *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return nil
+	return []string{ssh.StepName}
+}
+
+// RetryPolicy retries downloads that fail for network hiccups.
+func (s *Step) RetryPolicy() steps.RetryPolicy {
+	return steps.DownloadRetryPolicy
 }
@@ -21,6 +21,10 @@ type CertificatesConfig struct {
 	// TODO: this shouldn't be a part of SANs
 	// https://kubernetes.io/docs/setup/certificates/#all-certificates
 	KubernetesSvcIP string `json:"kubernetesSvcIp"`
+	// Address of the load balancer in front of masters is either
+	// an ip or a dns name depending on the cloud provider.
+	LoadBalancerIP  string `json:"loadBalancerIp"`
+	LoadBalancerDNS string `json:"loadBalancerDns"`
 
 	StaticAuth profile.StaticAuth `json:"staticAuth"`
 
@@ -93,6 +97,12 @@ type AWSConfig struct {
 	Subnets map[string]string `json:"subnets"`
 	// Map az to route table association
 	RouteTableAssociationIDs map[string]string `json:"routeTableAssociationIds"`
+	// ResourcesTagged is set for clusters that tag resources they create,
+	// resources of older clusters have no cluster ID tag.
+	ResourcesTagged bool `json:"resourcesTagged"`
+	// DeleteUntagged is set by the delete request, untagged resources
+	// of older clusters may have been supplied by user and are kept otherwise.
+	DeleteUntagged bool `json:"deleteUntagged"`
 }
 
 type FlannelConfig struct {
@@ -117,6 +127,7 @@ type NetworkConfig struct {
 type KubeletConfig struct {
 	IsMaster       bool   `json:"isMaster"`
 	NodeLabels     string `json:"nodeLabels"`
+	NodeTaints     string `json:"nodeTaints"`
 	ProxyPort      string `json:"proxyPort"`
 	K8SVersion     string `json:"k8sVersion"`
 	ProviderString string `json:"ProviderString"`
@@ -133,6 +144,18 @@ type ManifestConfig struct {
 	MasterHost          string `json:"masterHost"`
 	MasterPort          string `json:"masterPort"`
 	Password            string `json:"password"`
+	// LoadBalancerHost is used by workers instead of MasterHost when set
+	LoadBalancerHost string `json:"loadBalancerHost"`
+}
+
+// LoadBalancerConfig describes a load balancer of the cloud provider
+// in front of API of masters.
+type LoadBalancerConfig struct {
+	// Enabled is set for clusters with more than one master
+	Enabled bool `json:"enabled"`
+	// Host is an address of the load balancer, it is known
+	// after the load balancer has been created.
+	Host string `json:"host"`
 }
 
 type PostStartConfig struct {
@@ -144,6 +167,16 @@ type PostStartConfig struct {
 	Timeout     time.Duration `json:"timeout"`
 }
 
+// UpgradeConfig holds settings of steps that upgrade kubernetes
+// components on a running node.
+type UpgradeConfig struct {
+	IsMaster     bool          `json:"isMaster"`
+	K8SVersion   string        `json:"k8sVersion"`
+	MasterHost   string        `json:"masterHost"`
+	MasterPort   string        `json:"masterPort"`
+	ReadyTimeout time.Duration `json:"readyTimeout"`
+}
+
 type TillerConfig struct {
 	HelmVersion     string `json:"helmVersion"`
 	RBACEnabled     bool   `json:"rbacEnabled"`
@@ -175,6 +208,8 @@ type EtcdConfig struct {
 	StartTimeout   string        `json:"startTimeout"`
 	RestartTimeout string        `json:"restartTimeout"`
 	ClusterToken   string        `json:"clusterToken"`
+	// Snapshot is a key of the backup the etcd member is restored from.
+	Snapshot string `json:"snapshot,omitempty"`
 }
 
 type SshConfig struct {
@@ -234,12 +269,21 @@ type Config struct {
 	KubeletConfig      KubeletConfig      `json:"kubeletConfig"`
 	ManifestConfig     ManifestConfig     `json:"manifestConfig"`
 	PostStartConfig    PostStartConfig    `json:"postStartConfig"`
+	UpgradeConfig      UpgradeConfig      `json:"upgradeConfig"`
 	TillerConfig       TillerConfig       `json:"tillerConfig"`
 	EtcdConfig         EtcdConfig         `json:"etcdConfig"`
 	SshConfig          SshConfig          `json:"sshConfig"`
 	PrometheusConfig   PrometheusConfig   `json:"prometheusConfig"`
 
 	ClusterCheckConfig ClusterCheckConfig `json:"clusterCheckConfig"`
+	LoadBalancerConfig LoadBalancerConfig `json:"loadBalancerConfig"`
+
+	// NodePool is a pool of the worker being provisioned,
+	// it is nil for workers that don't belong to any pool.
+	NodePool *profile.NodePool `json:"nodePool,omitempty"`
+
+	// RetryPolicies override retry policies of steps by step name.
+	RetryPolicies map[string]profile.RetryPolicy `json:"retryPolicies,omitempty"`
 
 	Node             node.Node     `json:"node"`
 	CloudAccountID   string        `json:"cloudAccountId" valid:"required, length(1|32)"`
@@ -249,11 +293,12 @@ type Config struct {
 
 	repository storage.Interface `json:"-"`
 
-	m1      sync.RWMutex
-	Masters Map `json:"masters"`
+	// locks are behind a pointer, copies of the config share them
+	// the same way they share maps of masters and nodes.
+	locks *configLocks
 
-	m2    sync.RWMutex
-	Nodes Map `json:"nodes"`
+	Masters Map `json:"masters"`
+	Nodes   Map `json:"nodes"`
 
 	nodeChan      chan node.Node
 	kubeStateChan chan model.KubeState
@@ -262,6 +307,18 @@ type Config struct {
 	ReadyForBootstrapLatch *sync.WaitGroup
 }
 
+type configLocks struct {
+	// config guards fields that steps write to while other steps of the
+	// task run and the task is saved, see Update.
+	config  sync.RWMutex
+	masters sync.RWMutex
+	nodes   sync.RWMutex
+}
+
+// newLocks guards lazy creation of locks of configs
+// that haven't been built by NewConfig.
+var newLocks sync.Mutex
+
 // NewConfig builds instance of config for provisioning
 func NewConfig(clusterName, clusterToken, cloudAccountName string, profile profile.Profile) *Config {
 	cfg := &Config{
@@ -280,8 +337,10 @@ func NewConfig(clusterName, clusterToken, cloudAccountName string, profile profi
 			MastersSecurityGroupID: profile.CloudSpecificSettings[clouds.AwsMastersSecGroupID],
 			NodesSecurityGroupID:   profile.CloudSpecificSettings[clouds.AwsNodesSecgroupID],
 			HasPublicAddr:          true,
+			ResourcesTagged:        true,
 		},
 		GCEConfig: GCEConfig{
+			Region:           profile.Region,
 			AvailabilityZone: profile.Zone,
 			ImageFamily:      "ubuntu-1604-lts",
 		},
@@ -344,6 +403,12 @@ func NewConfig(clusterName, clusterToken, cloudAccountName string, profile profi
 			RBACEnabled: profile.RBACEnabled,
 			Timeout:     time.Minute * 20,
 		},
+		UpgradeConfig: UpgradeConfig{
+			K8SVersion:   profile.K8SVersion,
+			MasterHost:   "localhost",
+			MasterPort:   "8080",
+			ReadyTimeout: time.Minute * 10,
+		},
 		TillerConfig: TillerConfig{
 			HelmVersion:     profile.HelmVersion,
 			OperatingSystem: profile.OperatingSystem,
@@ -370,7 +435,10 @@ func NewConfig(clusterName, clusterToken, cloudAccountName string, profile profi
 			ClusterToken:   clusterToken,
 		},
 		ClusterCheckConfig: ClusterCheckConfig{
-			MachineCount: len(profile.NodesProfiles) + len(profile.MasterProfiles),
+			MachineCount: len(profile.Workers()) + len(profile.MasterProfiles),
+		},
+		LoadBalancerConfig: LoadBalancerConfig{
+			Enabled: len(profile.MasterProfiles) > 1,
 		},
 		PrometheusConfig: PrometheusConfig{
 			Port:        "30900",
@@ -381,31 +449,73 @@ func NewConfig(clusterName, clusterToken, cloudAccountName string, profile profi
 			internal: make(map[string]*node.Node, len(profile.MasterProfiles)),
 		},
 		Nodes: Map{
-			internal: make(map[string]*node.Node, len(profile.NodesProfiles)),
+			internal: make(map[string]*node.Node, len(profile.Workers())),
 		},
+		RetryPolicies:    profile.RetryPolicies,
 		Timeout:          time.Minute * 30,
 		CloudAccountName: cloudAccountName,
 
-		nodeChan:      make(chan node.Node, len(profile.MasterProfiles)+len(profile.NodesProfiles)),
+		nodeChan:      make(chan node.Node, len(profile.MasterProfiles)+len(profile.Workers())),
 		kubeStateChan: make(chan model.KubeState, 2),
 		configChan:    make(chan *Config),
+
+		locks: &configLocks{},
 	}
 
 	return cfg
 }
 
+// PoolName returns name of the node pool or empty string
+// if the node doesn't belong to any pool.
+func (c *Config) PoolName() string {
+	if c.NodePool == nil {
+		return ""
+	}
+
+	return c.NodePool.Name
+}
+
+func (c *Config) getLocks() *configLocks {
+	newLocks.Lock()
+	defer newLocks.Unlock()
+
+	if c.locks == nil {
+		c.locks = &configLocks{}
+	}
+
+	return c.locks
+}
+
+// Update must wrap writes of a step to the config, steps of a task
+// may run at once and the task is saved with its config meanwhile.
+func (c *Config) Update(fn func()) {
+	l := c.getLocks()
+	l.config.Lock()
+	defer l.config.Unlock()
+	fn()
+}
+
+// View wraps reads of the config that must not interleave with
+// an update, e.g. serialization of the config.
+func (c *Config) View(fn func()) {
+	l := c.getLocks()
+	l.config.RLock()
+	defer l.config.RUnlock()
+	fn()
+}
+
 // AddMaster to map of master, map is used because it is reference and can be shared among
 // goroutines that run multiple tasks of cluster deployment
 func (c *Config) AddMaster(n *node.Node) {
-	c.m1.Lock()
-	defer c.m1.Unlock()
+	c.getLocks().masters.Lock()
+	defer c.getLocks().masters.Unlock()
 	c.Masters.internal[n.ID] = n
 }
 
 // AddNode to map of nodes in cluster
 func (c *Config) AddNode(n *node.Node) {
-	c.m2.Lock()
-	defer c.m2.Unlock()
+	c.getLocks().nodes.Lock()
+	defer c.getLocks().nodes.Unlock()
 	c.Nodes.internal[n.ID] = n
 }
 
@@ -416,8 +526,8 @@ func (c *Config) GetMaster() *node.Node {
 		return &c.Node
 	}
 
-	c.m1.RLock()
-	defer c.m1.RUnlock()
+	c.getLocks().masters.RLock()
+	defer c.getLocks().masters.RUnlock()
 
 	if len(c.Masters.internal) == 0 {
 		return nil
@@ -434,8 +544,8 @@ func (c *Config) GetMaster() *node.Node {
 }
 
 func (c *Config) GetMasters() map[string]*node.Node {
-	c.m1.RLock()
-	defer c.m1.RUnlock()
+	c.getLocks().masters.RLock()
+	defer c.getLocks().masters.RUnlock()
 
 	m := make(map[string]*node.Node, len(c.Masters.internal))
 
@@ -447,8 +557,8 @@ func (c *Config) GetMasters() map[string]*node.Node {
 }
 
 func (c *Config) GetNodes() map[string]*node.Node {
-	c.m2.RLock()
-	defer c.m2.RUnlock()
+	c.getLocks().nodes.RLock()
+	defer c.getLocks().nodes.RUnlock()
 
 	m := make(map[string]*node.Node, len(c.Nodes.internal))
 
@@ -461,8 +571,8 @@ func (c *Config) GetNodes() map[string]*node.Node {
 
 // GetMaster returns first master in master map or nil
 func (c *Config) GetNode() *node.Node {
-	c.m2.RLock()
-	defer c.m2.RUnlock()
+	c.getLocks().nodes.RLock()
+	defer c.getLocks().nodes.RUnlock()
 
 	if len(c.Nodes.internal) == 0 {
 		return nil
@@ -490,6 +600,14 @@ func (c *Config) ConfigChan() chan *Config {
 	return c.configChan
 }
 
+// RestoreChannels sets channels of config that has been loaded from storage,
+// channels are not persisted and steps would block on sending to nil channel.
+func (c *Config) RestoreChannels(nodeChan chan node.Node, kubeStateChan chan model.KubeState, configChan chan *Config) {
+	c.nodeChan = nodeChan
+	c.kubeStateChan = kubeStateChan
+	c.configChan = configChan
+}
+
 // TODO: cloud profiles is deprecated by kubernetes, use controller-managers
 func toCloudProviderOpt(cloudName clouds.Name) string {
 	switch cloudName {
@@ -14,6 +14,10 @@ const (
 	DeleteMachineStepName    = "deleteMachineDigitalOcean"
 	DeleteClusterMachines    = "deleteClusterMachineDigitalOcean"
 	DeleteDeleteKeysStepName = "deleteKeysDigitalOcean"
+
+	CreateLoadBalancerStepName   = "createLoadBalancerDigitalOcean"
+	RegisterLoadBalancerStepName = "registerLoadBalancerDigitalOcean"
+	DeleteLoadBalancerStepName   = "deleteLoadBalancerDigitalOcean"
 )
 
 type DropletService interface {
@@ -33,9 +37,24 @@ type DeleteService interface {
 	DeleteByTag(context.Context, string) (*godo.Response, error)
 }
 
+type ListService interface {
+	ListByTag(context.Context, string, *godo.ListOptions) ([]godo.Droplet, *godo.Response, error)
+}
+
+type LoadBalancerService interface {
+	Get(context.Context, string) (*godo.LoadBalancer, *godo.Response, error)
+	List(context.Context, *godo.ListOptions) ([]godo.LoadBalancer, *godo.Response, error)
+	Create(context.Context, *godo.LoadBalancerRequest) (*godo.LoadBalancer, *godo.Response, error)
+	Delete(context.Context, string) (*godo.Response, error)
+	AddDroplets(context.Context, string, ...int) (*godo.Response, error)
+}
+
 func Init() {
 	steps.RegisterStep(CreateMachineStepName, NewCreateInstanceStep(time.Minute*5, time.Second*5))
 	steps.RegisterStep(DeleteMachineStepName, NewDeleteMachineStep(time.Minute*1))
 	steps.RegisterStep(DeleteClusterMachines, NewDeletemachinesStep(time.Minute*1))
 	steps.RegisterStep(DeleteDeleteKeysStepName, NewDeleteKeysStep())
+	steps.RegisterStep(CreateLoadBalancerStepName, NewCreateLoadBalancerStep(time.Minute*5, time.Second*5))
+	steps.RegisterStep(RegisterLoadBalancerStepName, NewRegisterLoadBalancerStep())
+	steps.RegisterStep(DeleteLoadBalancerStepName, NewDeleteLoadBalancerStep())
 }
@@ -83,6 +83,7 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer, config *
 		Region:   config.DigitalOceanConfig.Region,
 		State:    node.StateBuilding,
 		Name:     config.DigitalOceanConfig.Name,
+		Pool:     config.PoolName(),
 	}
 
 	// Update node state in cluster
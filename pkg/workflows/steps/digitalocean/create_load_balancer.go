@@ -0,0 +1,110 @@
+package digitalocean
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// CreateLoadBalancerStep creates load balancer in front of API of masters,
+// masters are added to the balancer when they are up.
+type CreateLoadBalancerStep struct {
+	Timeout     time.Duration
+	CheckPeriod time.Duration
+
+	getService func(string) LoadBalancerService
+}
+
+func NewCreateLoadBalancerStep(timeout, checkPeriod time.Duration) *CreateLoadBalancerStep {
+	return &CreateLoadBalancerStep{
+		Timeout:     timeout,
+		CheckPeriod: checkPeriod,
+		getService: func(accessToken string) LoadBalancerService {
+			return digitaloceansdk.New(accessToken).GetClient().LoadBalancers
+		},
+	}
+}
+
+func (s *CreateLoadBalancerStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if !config.LoadBalancerConfig.Enabled || config.LoadBalancerConfig.Host != "" {
+		return nil
+	}
+
+	svc := s.getService(config.DigitalOceanConfig.AccessToken)
+
+	lb, _, err := svc.Create(ctx, &godo.LoadBalancerRequest{
+		Name:      util.MakeLoadBalancerName(config.ClusterID),
+		Algorithm: "round_robin",
+		Region:    config.DigitalOceanConfig.Region,
+		ForwardingRules: []godo.ForwardingRule{
+			{
+				EntryProtocol:  "tcp",
+				EntryPort:      443,
+				TargetProtocol: "tcp",
+				TargetPort:     443,
+			},
+		},
+		HealthCheck: &godo.HealthCheck{
+			Protocol:               "tcp",
+			Port:                   443,
+			CheckIntervalSeconds:   10,
+			ResponseTimeoutSeconds: 5,
+			HealthyThreshold:       2,
+			UnhealthyThreshold:     2,
+		},
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "create load balancer")
+	}
+
+	after := time.After(s.Timeout)
+	ticker := time.NewTicker(s.CheckPeriod)
+	defer ticker.Stop()
+
+	// IP address is assigned to the balancer after a while
+	for lbID := lb.ID; lb.IP == ""; {
+		select {
+		case <-ticker.C:
+			lb, _, err = svc.Get(ctx, lbID)
+
+			if err != nil {
+				return errors.Wrapf(err, "get load balancer %s", lbID)
+			}
+		case <-after:
+			return sgerrors.ErrTimeoutExceeded
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	config.LoadBalancerConfig.Host = lb.IP
+	logrus.Infof("Load balancer %s has been created", lb.IP)
+
+	return nil
+}
+
+func (s *CreateLoadBalancerStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *CreateLoadBalancerStep) Name() string {
+	return CreateLoadBalancerStepName
+}
+
+func (s *CreateLoadBalancerStep) Depends() []string {
+	return nil
+}
+
+func (s *CreateLoadBalancerStep) Description() string {
+	return "create load balancer in front of masters"
+}
@@ -0,0 +1,134 @@
+package digitalocean
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeLoadBalancerService struct {
+	lbs       []godo.LoadBalancer
+	createErr error
+	// number of Get calls before ip is assigned
+	pending int
+
+	added   []int
+	deleted []string
+}
+
+func (f *fakeLoadBalancerService) Get(ctx context.Context, id string) (*godo.LoadBalancer, *godo.Response, error) {
+	for i := range f.lbs {
+		if f.lbs[i].ID != id {
+			continue
+		}
+
+		lb := f.lbs[i]
+		if f.pending > 0 {
+			f.pending--
+			lb.IP = ""
+		}
+
+		return &lb, nil, nil
+	}
+
+	return nil, nil, sgerrors.ErrNotFound
+}
+
+func (f *fakeLoadBalancerService) List(context.Context, *godo.ListOptions) ([]godo.LoadBalancer, *godo.Response, error) {
+	return f.lbs, nil, nil
+}
+
+func (f *fakeLoadBalancerService) Create(ctx context.Context, req *godo.LoadBalancerRequest) (*godo.LoadBalancer, *godo.Response, error) {
+	if f.createErr != nil {
+		return nil, nil, f.createErr
+	}
+
+	f.lbs = append(f.lbs, godo.LoadBalancer{
+		ID:   "lb-1",
+		Name: req.Name,
+		IP:   "10.20.30.40",
+	})
+
+	return &godo.LoadBalancer{ID: "lb-1", Name: req.Name}, nil, nil
+}
+
+func (f *fakeLoadBalancerService) Delete(ctx context.Context, id string) (*godo.Response, error) {
+	f.deleted = append(f.deleted, id)
+	return nil, nil
+}
+
+func (f *fakeLoadBalancerService) AddDroplets(ctx context.Context, id string, dropletIDs ...int) (*godo.Response, error) {
+	f.added = append(f.added, dropletIDs...)
+	return nil, nil
+}
+
+func TestCreateLoadBalancerStep_Run(t *testing.T) {
+	testCases := []struct {
+		description string
+		enabled     bool
+		createErr   error
+		pending     int
+		host        string
+		hasErr      bool
+	}{
+		{
+			description: "single master",
+		},
+		{
+			description: "create error",
+			enabled:     true,
+			createErr:   errors.New("unauthorized"),
+			hasErr:      true,
+		},
+		{
+			description: "ip is not assigned",
+			enabled:     true,
+			pending:     1000,
+			hasErr:      true,
+		},
+		{
+			description: "success",
+			enabled:     true,
+			pending:     2,
+			host:        "10.20.30.40",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+
+		svc := &fakeLoadBalancerService{
+			createErr: testCase.createErr,
+			pending:   testCase.pending,
+		}
+		step := NewCreateLoadBalancerStep(time.Millisecond*100, time.Millisecond)
+		step.getService = func(string) LoadBalancerService {
+			return svc
+		}
+
+		config := &steps.Config{
+			ClusterID: "1234abcd",
+			LoadBalancerConfig: steps.LoadBalancerConfig{
+				Enabled: testCase.enabled,
+			},
+		}
+
+		err := step.Run(context.Background(), ioutil.Discard, config)
+
+		if testCase.hasErr != (err != nil) {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		if config.LoadBalancerConfig.Host != testCase.host {
+			t.Errorf("wrong load balancer host expected %s actual %s",
+				testCase.host, config.LoadBalancerConfig.Host)
+		}
+	}
+}
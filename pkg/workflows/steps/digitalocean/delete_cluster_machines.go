@@ -4,9 +4,11 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/digitalocean/godo"
+	"github.com/pkg/errors"
 
 	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
 	"github.com/supergiant/control/pkg/workflows/steps"
@@ -14,6 +16,7 @@ import (
 
 type DeleteMachinesStep struct {
 	getDeleteService func(string) DeleteService
+	getListService   func(string) ListService
 	timeout          time.Duration
 }
 
@@ -23,6 +26,9 @@ func NewDeletemachinesStep(timeout time.Duration) *DeleteMachinesStep {
 		getDeleteService: func(accessToken string) DeleteService {
 			return digitaloceansdk.New(accessToken).GetClient().Droplets
 		},
+		getListService: func(accessToken string) ListService {
+			return digitaloceansdk.New(accessToken).GetClient().Droplets
+		},
 	}
 }
 
@@ -49,6 +55,40 @@ func (s *DeleteMachinesStep) Run(ctx context.Context, output io.Writer, config *
 	return err
 }
 
+// Plan lists droplets tagged with ID of the cluster, they are deleted by tag.
+func (s *DeleteMachinesStep) Plan(ctx context.Context, config *steps.Config) ([]steps.Resource, error) {
+	if config.ClusterID == "" {
+		return nil, nil
+	}
+
+	svc := s.getListService(config.DigitalOceanConfig.AccessToken)
+	opts := &godo.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+
+	resources := make([]steps.Resource, 0)
+	for {
+		droplets, resp, err := svc.ListByTag(ctx, config.ClusterID, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "list droplets")
+		}
+
+		for _, droplet := range droplets {
+			resources = append(resources, steps.Resource{
+				Type: "droplet",
+				ID:   strconv.Itoa(droplet.ID),
+			})
+		}
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return resources, nil
+		}
+
+		opts.Page++
+	}
+}
+
 func (s *DeleteMachinesStep) Rollback(context.Context, io.Writer, *steps.Config) error {
 	return nil
 }
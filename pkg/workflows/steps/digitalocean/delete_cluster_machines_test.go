@@ -164,4 +164,37 @@ func TestNewDeletemachinesStep(t *testing.T) {
 	if svc := step.getDeleteService("token"); svc == nil {
 		t.Errorf("Service must be nil")
 	}
-}
\ No newline at end of file
+}
+type fakeListService struct {
+	droplets []godo.Droplet
+	tag      string
+}
+
+func (f *fakeListService) ListByTag(ctx context.Context, tag string,
+	opts *godo.ListOptions) ([]godo.Droplet, *godo.Response, error) {
+	f.tag = tag
+	return f.droplets, nil, nil
+}
+
+func TestDeleteMachinesStep_Plan(t *testing.T) {
+	svc := &fakeListService{
+		droplets: []godo.Droplet{{ID: 1}, {ID: 2}},
+	}
+	step := &DeleteMachinesStep{
+		getListService: func(string) ListService {
+			return svc
+		},
+	}
+
+	resources, err := step.Plan(context.Background(), &steps.Config{
+		ClusterID: "1234abcd",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if svc.tag != "1234abcd" || len(resources) != 2 ||
+		resources[0].ID != "1" || resources[1].ID != "2" {
+		t.Errorf("wrong droplets of tag %s %v", svc.tag, resources)
+	}
+}
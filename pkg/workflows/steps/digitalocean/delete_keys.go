@@ -65,6 +65,29 @@ func (s *DeleteKeysStep) Run(ctx context.Context, output io.Writer, config *step
 	return nil
 }
 
+// Plan lists keys that are deleted by fingerprint, user key is deleted
+// as well, it has been uploaded to the account when cluster was created.
+func (s *DeleteKeysStep) Plan(ctx context.Context, config *steps.Config) ([]steps.Resource, error) {
+	resources := make([]steps.Resource, 0, 2)
+	for _, key := range []string{config.SshConfig.BootstrapPublicKey, config.SshConfig.PublicKey} {
+		if key == "" {
+			continue
+		}
+
+		fg, err := fingerprint(key)
+		if err != nil {
+			continue
+		}
+
+		resources = append(resources, steps.Resource{
+			Type: "ssh key",
+			ID:   fg,
+		})
+	}
+
+	return resources, nil
+}
+
 func (s *DeleteKeysStep) Rollback(context.Context, io.Writer, *steps.Config) error {
 	return nil
 }
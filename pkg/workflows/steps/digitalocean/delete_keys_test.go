@@ -165,3 +165,22 @@ func TestDeleteKeysStep_Description(t *testing.T) {
 		t.Errorf("Description must not be empty")
 	}
 }
+
+func TestDeleteKeysStep_Plan(t *testing.T) {
+	step := NewDeleteKeysStep()
+	config := &steps.Config{
+		SshConfig: steps.SshConfig{
+			BootstrapPublicKey: testKey,
+		},
+	}
+
+	resources, err := step.Plan(context.Background(), config)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	fg, _ := fingerprint(testKey)
+	if len(resources) != 1 || resources[0].ID != fg {
+		t.Errorf("wrong plan %v", resources)
+	}
+}
@@ -0,0 +1,85 @@
+package digitalocean
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type DeleteLoadBalancerStep struct {
+	getService func(string) LoadBalancerService
+}
+
+func NewDeleteLoadBalancerStep() *DeleteLoadBalancerStep {
+	return &DeleteLoadBalancerStep{
+		getService: func(accessToken string) LoadBalancerService {
+			return digitaloceansdk.New(accessToken).GetClient().LoadBalancers
+		},
+	}
+}
+
+func (s *DeleteLoadBalancerStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if config.LoadBalancerConfig.Host == "" {
+		return nil
+	}
+
+	svc := s.getService(config.DigitalOceanConfig.AccessToken)
+	name := util.MakeLoadBalancerName(config.ClusterID)
+	lb, err := findLoadBalancer(ctx, svc, name)
+
+	if err != nil {
+		return err
+	}
+
+	if lb == nil {
+		logrus.Debugf("load balancer %s has already been deleted", name)
+		return nil
+	}
+
+	if _, err := svc.Delete(ctx, lb.ID); err != nil {
+		return errors.Wrapf(err, "delete load balancer %s", name)
+	}
+
+	return nil
+}
+
+func (s *DeleteLoadBalancerStep) Plan(ctx context.Context, config *steps.Config) ([]steps.Resource, error) {
+	if config.LoadBalancerConfig.Host == "" {
+		return nil, nil
+	}
+
+	svc := s.getService(config.DigitalOceanConfig.AccessToken)
+	lb, err := findLoadBalancer(ctx, svc, util.MakeLoadBalancerName(config.ClusterID))
+	if err != nil || lb == nil {
+		return nil, err
+	}
+
+	return []steps.Resource{
+		{
+			Type: "load balancer",
+			ID:   lb.ID,
+		},
+	}, nil
+}
+
+func (s *DeleteLoadBalancerStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *DeleteLoadBalancerStep) Name() string {
+	return DeleteLoadBalancerStepName
+}
+
+func (s *DeleteLoadBalancerStep) Depends() []string {
+	return nil
+}
+
+func (s *DeleteLoadBalancerStep) Description() string {
+	return "delete load balancer in front of masters"
+}
@@ -0,0 +1,70 @@
+package digitalocean
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/digitalocean/godo"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestDeleteLoadBalancerStep_Run(t *testing.T) {
+	svc := &fakeLoadBalancerService{
+		lbs: []godo.LoadBalancer{{ID: "lb-1", Name: "sg-1234abcd-api"}},
+	}
+	step := NewDeleteLoadBalancerStep()
+	step.getService = func(string) LoadBalancerService {
+		return svc
+	}
+
+	config := &steps.Config{
+		ClusterID: "1234abcd",
+		LoadBalancerConfig: steps.LoadBalancerConfig{
+			Host: "10.20.30.40",
+		},
+	}
+
+	if err := step.Run(context.Background(), ioutil.Discard, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(svc.deleted) != 1 || svc.deleted[0] != "lb-1" {
+		t.Errorf("load balancer has not been deleted %v", svc.deleted)
+	}
+
+	// Missing load balancer has already been deleted
+	config.ClusterID = "unknown"
+	if err := step.Run(context.Background(), ioutil.Discard, config); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestDeleteLoadBalancerStep_Plan(t *testing.T) {
+	step := NewDeleteLoadBalancerStep()
+	step.getService = func(string) LoadBalancerService {
+		return &fakeLoadBalancerService{
+			lbs: []godo.LoadBalancer{{ID: "lb-1", Name: "sg-1234abcd-api"}},
+		}
+	}
+
+	config := &steps.Config{
+		ClusterID: "1234abcd",
+		LoadBalancerConfig: steps.LoadBalancerConfig{
+			Host: "10.20.30.40",
+		},
+	}
+
+	resources, err := step.Plan(context.Background(), config)
+	if err != nil || len(resources) != 1 || resources[0].ID != "lb-1" {
+		t.Errorf("wrong plan %v %v", resources, err)
+	}
+
+	// Missing load balancer has already been deleted
+	config.ClusterID = "unknown"
+	resources, err = step.Plan(context.Background(), config)
+	if err != nil || len(resources) != 0 {
+		t.Errorf("wrong plan %v %v", resources, err)
+	}
+}
@@ -0,0 +1,73 @@
+package digitalocean
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// RegisterLoadBalancerStep adds droplet of the master to the load balancer
+type RegisterLoadBalancerStep struct {
+	getService func(string) LoadBalancerService
+}
+
+func NewRegisterLoadBalancerStep() *RegisterLoadBalancerStep {
+	return &RegisterLoadBalancerStep{
+		getService: func(accessToken string) LoadBalancerService {
+			return digitaloceansdk.New(accessToken).GetClient().LoadBalancers
+		},
+	}
+}
+
+func (s *RegisterLoadBalancerStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if config.LoadBalancerConfig.Host == "" {
+		return nil
+	}
+
+	dropletID, err := strconv.Atoi(config.Node.ID)
+
+	if err != nil {
+		return errors.Wrapf(err, "parse droplet id %s", config.Node.ID)
+	}
+
+	svc := s.getService(config.DigitalOceanConfig.AccessToken)
+	name := util.MakeLoadBalancerName(config.ClusterID)
+	lb, err := findLoadBalancer(ctx, svc, name)
+
+	if err != nil {
+		return err
+	}
+
+	if lb == nil {
+		return errors.Wrapf(sgerrors.ErrNotFound, "load balancer %s", name)
+	}
+
+	if _, err := svc.AddDroplets(ctx, lb.ID, dropletID); err != nil {
+		return errors.Wrapf(err, "add droplet %d to load balancer %s", dropletID, name)
+	}
+
+	return nil
+}
+
+func (s *RegisterLoadBalancerStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *RegisterLoadBalancerStep) Name() string {
+	return RegisterLoadBalancerStepName
+}
+
+func (s *RegisterLoadBalancerStep) Depends() []string {
+	return nil
+}
+
+func (s *RegisterLoadBalancerStep) Description() string {
+	return "add master to the load balancer"
+}
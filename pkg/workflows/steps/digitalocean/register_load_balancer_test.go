@@ -0,0 +1,48 @@
+package digitalocean
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/digitalocean/godo"
+
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestRegisterLoadBalancerStep_Run(t *testing.T) {
+	svc := &fakeLoadBalancerService{
+		lbs: []godo.LoadBalancer{{ID: "lb-1", Name: "sg-1234abcd-api"}},
+	}
+	step := NewRegisterLoadBalancerStep()
+	step.getService = func(string) LoadBalancerService {
+		return svc
+	}
+
+	config := &steps.Config{
+		ClusterID: "1234abcd",
+		Node: node.Node{
+			ID: "42",
+		},
+	}
+
+	// Masters are not registered without load balancer
+	if err := step.Run(context.Background(), ioutil.Discard, config); err != nil || len(svc.added) != 0 {
+		t.Errorf("unexpected error %v or droplets %v", err, svc.added)
+	}
+
+	config.LoadBalancerConfig.Host = "10.20.30.40"
+	if err := step.Run(context.Background(), ioutil.Discard, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(svc.added) != 1 || svc.added[0] != 42 {
+		t.Errorf("droplet has not been added %v", svc.added)
+	}
+
+	config.ClusterID = "unknown"
+	if err := step.Run(context.Background(), ioutil.Discard, config); err == nil {
+		t.Errorf("error must not be nil for unknown load balancer")
+	}
+}
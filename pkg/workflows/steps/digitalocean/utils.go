@@ -93,3 +93,32 @@ func createKey(ctx context.Context, keyService KeyService, name, publicKey strin
 
 	return key, err
 }
+
+// findLoadBalancer looks for the load balancer by name, nil is
+// returned if there is no such load balancer.
+func findLoadBalancer(ctx context.Context, svc LoadBalancerService, name string) (*godo.LoadBalancer, error) {
+	opts := &godo.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+
+	for {
+		lbs, resp, err := svc.List(ctx, opts)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "list load balancers")
+		}
+
+		for i := range lbs {
+			if lbs[i].Name == name {
+				return &lbs[i], nil
+			}
+		}
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return nil, nil
+		}
+
+		opts.Page++
+	}
+}
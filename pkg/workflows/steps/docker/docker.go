@@ -10,6 +10,7 @@ import (
 
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/flannel"
 )
 
 const StepName = "docker"
@@ -35,7 +36,7 @@ func New(tpl *template.Template) *Step {
 }
 
 func (t *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	err := steps.RunTemplate(context.Background(), t.script,
+	err := steps.RunTemplate(ctx, t.script,
 		config.Runner, out, config.DockerConfig)
 	if err != nil {
 		return errors.Wrap(err, "install docker step")
@@ -57,5 +58,11 @@ func (t *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return nil
+	return []string{flannel.StepName}
+}
+
+// RetryPolicy retries installation, apt lock may be held by
+// unattended upgrades right after the machine has booted.
+func (s *Step) RetryPolicy() steps.RetryPolicy {
+	return steps.DownloadRetryPolicy
 }
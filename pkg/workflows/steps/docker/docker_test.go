@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
 	"text/template"
@@ -15,6 +16,7 @@ import (
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/testutils"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/flannel"
 )
 
 type fakeRunner struct {
@@ -112,8 +114,8 @@ func TestStepName(t *testing.T) {
 func TestDepends(t *testing.T) {
 	s := Step{}
 
-	if len(s.Depends()) != 0 {
-		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{})
+	if deps := s.Depends(); !reflect.DeepEqual(deps, []string{flannel.StepName}) {
+		t.Errorf("Wrong dependency list %v expected %v", deps, []string{flannel.StepName})
 	}
 }
 
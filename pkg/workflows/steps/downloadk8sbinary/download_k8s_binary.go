@@ -10,6 +10,7 @@ import (
 
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 const StepName = "download_kubernetes_binary"
@@ -35,7 +36,7 @@ func New(tpl *template.Template) *Step {
 }
 
 func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	err := steps.RunTemplate(context.Background(), s.script,
+	err := steps.RunTemplate(ctx, s.script,
 		config.Runner, out, config.DownloadK8sBinary)
 	if err != nil {
 		return errors.Wrap(err, "download k8s binary step")
@@ -57,5 +58,10 @@ func (s *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return nil
+	return []string{ssh.StepName}
+}
+
+// RetryPolicy retries downloads that fail for network hiccups.
+func (s *Step) RetryPolicy() steps.RetryPolicy {
+	return steps.DownloadRetryPolicy
 }
@@ -11,7 +11,7 @@ import (
 
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/docker"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 const StepName = "etcd"
@@ -37,9 +37,11 @@ func New(tpl *template.Template) *Step {
 }
 
 func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	config.EtcdConfig.Name = config.Node.ID
-	config.EtcdConfig.AdvertiseHost = config.Node.PrivateIp
-	config.EtcdConfig.Host = config.Node.PrivateIp
+	config.Update(func() {
+		config.EtcdConfig.Name = config.Node.ID
+		config.EtcdConfig.AdvertiseHost = config.Node.PrivateIp
+		config.EtcdConfig.Host = config.Node.PrivateIp
+	})
 	ctx2, _ := context.WithTimeout(ctx, config.EtcdConfig.Timeout)
 
 	vars := struct {
@@ -53,22 +55,8 @@ func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) err
 		NodePrivateIP: config.Node.PrivateIp,
 	}
 
-	masters := config.GetMasters()
-	//one master configuration
-	if len(masters) == 1 {
-		vars.InitialClusterIPs = fmt.Sprintf("%s=http://%s:%s", config.Node.ID, config.Node.PrivateIp, config.EtcdConfig.ManagementPort)
-		vars.InitialAdvertisePeerURLs = fmt.Sprintf("http://%s:%s", config.Node.PrivateIp, config.EtcdConfig.ManagementPort)
-	} else {
-		initialClusterIPs := make([]string, 0)
-		advertisePeers := make([]string, 0)
-
-		for _, master := range masters {
-			initialClusterIPs = append(initialClusterIPs, fmt.Sprintf("%s=http://%s:%s", master.ID, master.PrivateIp, config.EtcdConfig.ManagementPort))
-			advertisePeers = append(advertisePeers, fmt.Sprintf("http://%s:%s", master.PrivateIp, config.EtcdConfig.ManagementPort))
-		}
-
-		vars.InitialClusterIPs = strings.Join(initialClusterIPs, ",")
-		vars.InitialAdvertisePeerURLs = strings.Join(advertisePeers, ",")
+	vars.InitialClusterIPs, vars.InitialAdvertisePeerURLs = initialCluster(config)
+	if len(config.GetMasters()) > 1 {
 		vars.AdvertiseURLs = strings.Replace(vars.InitialAdvertisePeerURLs, ":2380", ":2379", -1)
 	}
 
@@ -94,5 +82,26 @@ func (s *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return []string{docker.StepName}
+	return []string{ssh.StepName}
+}
+
+// initialCluster returns members and their peer urls, the node itself is
+// the only member when there is a single master.
+func initialCluster(config *steps.Config) (string, string) {
+	masters := config.GetMasters()
+	//one master configuration
+	if len(masters) == 1 {
+		return fmt.Sprintf("%s=http://%s:%s", config.Node.ID, config.Node.PrivateIp, config.EtcdConfig.ManagementPort),
+			fmt.Sprintf("http://%s:%s", config.Node.PrivateIp, config.EtcdConfig.ManagementPort)
+	}
+
+	initialClusterIPs := make([]string, 0)
+	advertisePeers := make([]string, 0)
+
+	for _, master := range masters {
+		initialClusterIPs = append(initialClusterIPs, fmt.Sprintf("%s=http://%s:%s", master.ID, master.PrivateIp, config.EtcdConfig.ManagementPort))
+		advertisePeers = append(advertisePeers, fmt.Sprintf("http://%s:%s", master.PrivateIp, config.EtcdConfig.ManagementPort))
+	}
+
+	return strings.Join(initialClusterIPs, ","), strings.Join(advertisePeers, ",")
 }
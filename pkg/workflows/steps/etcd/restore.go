@@ -0,0 +1,118 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/backup"
+	"github.com/supergiant/control/pkg/runner"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	RestoreStepName = "etcd_restore"
+
+	snapshotFile = "/tmp/etcd-restore.db"
+)
+
+// RestoreStep replaces data of the etcd member with a snapshot, all masters
+// must be restored at the same time from the same snapshot.
+type RestoreStep struct {
+	script *template.Template
+	store  backup.Store
+}
+
+func InitRestore(store backup.Store) {
+	tpl, err := tm.GetTemplate(RestoreStepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", RestoreStepName))
+	}
+
+	steps.RegisterStep(RestoreStepName, NewRestore(tpl, store))
+}
+
+func NewRestore(tpl *template.Template, store backup.Store) *RestoreStep {
+	return &RestoreStep{
+		script: tpl,
+		store:  store,
+	}
+}
+
+func (s *RestoreStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if config.EtcdConfig.Snapshot == "" {
+		return errors.New("snapshot is not set")
+	}
+
+	config.Update(func() {
+		config.EtcdConfig.Name = config.Node.ID
+	})
+	ctx2, cancel := context.WithTimeout(ctx, config.EtcdConfig.Timeout)
+	defer cancel()
+
+	if err := s.upload(ctx2, out, config); err != nil {
+		return errors.Wrap(err, "restore etcd step")
+	}
+
+	vars := struct {
+		ETCDConfig        steps.EtcdConfig
+		InitialClusterIPs string
+		NodePrivateIP     string
+		SnapshotFile      string
+	}{
+		ETCDConfig:    config.EtcdConfig,
+		NodePrivateIP: config.Node.PrivateIp,
+		SnapshotFile:  snapshotFile,
+	}
+	vars.InitialClusterIPs, _ = initialCluster(config)
+
+	if err := steps.RunTemplate(ctx2, s.script, config.Runner, out, vars); err != nil {
+		return errors.Wrap(err, "restore etcd step")
+	}
+
+	return nil
+}
+
+// upload copies the snapshot from the store to the node.
+func (s *RestoreStep) upload(ctx context.Context, out io.Writer, config *steps.Config) error {
+	r, err := s.store.Get(ctx, config.EtcdConfig.Snapshot)
+	if err != nil {
+		return errors.Wrapf(err, "get snapshot %s", config.EtcdConfig.Snapshot)
+	}
+	defer r.Close()
+
+	cmd := &runner.Command{
+		Ctx:    ctx,
+		Script: "cat > " + snapshotFile,
+		In:     r,
+		Out:    out,
+		Err:    out,
+	}
+
+	if err := config.Runner.Run(cmd); err != nil {
+		return errors.Wrap(err, "upload snapshot")
+	}
+
+	return nil
+}
+
+func (s *RestoreStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *RestoreStep) Name() string {
+	return RestoreStepName
+}
+
+func (s *RestoreStep) Description() string {
+	return "Restore EtcD from snapshot"
+}
+
+func (s *RestoreStep) Depends() []string {
+	return nil
+}
@@ -0,0 +1,139 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeStore struct {
+	snapshots map[string]string
+}
+
+func (f *fakeStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	data, err := ioutil.ReadAll(r)
+	f.snapshots[key] = string(data)
+	return int64(len(data)), err
+}
+
+func (f *fakeStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := f.snapshots[key]
+	if !ok {
+		return nil, sgerrors.ErrNotFound
+	}
+	return ioutil.NopCloser(strings.NewReader(data)), nil
+}
+
+func (f *fakeStore) Delete(ctx context.Context, key string) error {
+	delete(f.snapshots, key)
+	return nil
+}
+
+// uploadRunner records input of commands that upload files.
+type uploadRunner struct {
+	uploaded string
+}
+
+func (u *uploadRunner) Run(command *runner.Command) error {
+	if command.In != nil {
+		data, err := ioutil.ReadAll(command.In)
+		u.uploaded = string(data)
+		return err
+	}
+
+	_, err := io.Copy(command.Out, strings.NewReader(command.Script))
+	return err
+}
+
+func TestRestoreEtcd(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(RestoreStepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	r := &uploadRunner{}
+	store := &fakeStore{
+		snapshots: map[string]string{
+			"kube/snapshot.db": "snapshot",
+		},
+	}
+
+	output := &bytes.Buffer{}
+	config := steps.NewConfig("", "", "", profile.Profile{})
+	config.EtcdConfig.Timeout = time.Second * 10
+	config.EtcdConfig.ClusterToken = "tkn"
+	config.EtcdConfig.Snapshot = "kube/snapshot.db"
+	config.Runner = r
+	config.Node = node.Node{
+		ID:        "master-1-id",
+		Name:      "master-1",
+		PrivateIp: "10.20.30.40",
+	}
+
+	config.AddMaster(&config.Node)
+	config.AddMaster(&node.Node{
+		ID:        "master-2-id",
+		Name:      "master-2",
+		PrivateIp: "10.20.30.41",
+	})
+
+	task := NewRestore(tpl, store)
+	if err := task.Run(context.Background(), output, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if r.uploaded != "snapshot" {
+		t.Errorf("snapshot has not been uploaded, actual %q", r.uploaded)
+	}
+
+	for _, expected := range []string{
+		"--name master-1-id",
+		"master-1-id=http://10.20.30.40:2380",
+		"master-2-id=http://10.20.30.41:2380",
+		"--initial-cluster-token tkn",
+		"--initial-advertise-peer-urls http://10.20.30.40:2380",
+		snapshotFile,
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in %s", expected, output.String())
+		}
+	}
+}
+
+func TestRestoreEtcdErrors(t *testing.T) {
+	tpl, _ := templatemanager.GetTemplate(RestoreStepName)
+	task := NewRestore(tpl, &fakeStore{
+		snapshots: map[string]string{},
+	})
+
+	config := steps.NewConfig("", "", "", profile.Profile{})
+	config.Runner = &uploadRunner{}
+
+	if err := task.Run(context.Background(), &bytes.Buffer{}, config); err == nil {
+		t.Errorf("snapshot is not set: error expected")
+	}
+
+	config.EtcdConfig.Snapshot = "kube/missing.db"
+	err := task.Run(context.Background(), &bytes.Buffer{}, config)
+	if !sgerrors.IsNotFound(err) {
+		t.Errorf("missing snapshot: expected not found actual %v", err)
+	}
+}
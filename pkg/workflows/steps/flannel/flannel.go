@@ -10,8 +10,7 @@ import (
 
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/etcd"
-	"github.com/supergiant/control/pkg/workflows/steps/network"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 const StepName = "flannel"
@@ -37,16 +36,20 @@ func New(tpl *template.Template) *Step {
 }
 
 func (t *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	config.FlannelConfig.IsMaster = config.IsMaster
-
+	etcdHost := "127.0.0.1"
 	if !config.IsMaster {
-		config.FlannelConfig.EtcdHost = config.GetMaster().PrivateIp
-	} else {
-		config.FlannelConfig.EtcdHost = "127.0.0.1"
+		etcdHost = config.GetMaster().PrivateIp
 	}
 
-	err := steps.RunTemplate(context.Background(), t.script,
-		config.Runner, out, config.FlannelConfig)
+	var flannel steps.FlannelConfig
+	config.Update(func() {
+		config.FlannelConfig.IsMaster = config.IsMaster
+		config.FlannelConfig.EtcdHost = etcdHost
+		flannel = config.FlannelConfig
+	})
+
+	err := steps.RunTemplate(ctx, t.script,
+		config.Runner, out, flannel)
 	if err != nil {
 		return errors.Wrap(err, "install flannel step")
 	}
@@ -67,5 +70,10 @@ func (t *Step) Description() string {
 }
 
 func (t *Step) Depends() []string {
-	return []string{etcd.StepName, network.StepName}
+	return []string{ssh.StepName}
+}
+
+// RetryPolicy retries downloads that fail for network hiccups.
+func (t *Step) RetryPolicy() steps.RetryPolicy {
+	return steps.DownloadRetryPolicy
 }
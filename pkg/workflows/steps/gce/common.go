@@ -2,13 +2,17 @@ package gce
 
 import (
 	"context"
+	"net/http"
+	"time"
 
+	"github.com/pkg/errors"
 	"golang.org/x/oauth2/jwt"
 	compute "google.golang.org/api/compute/v1"
 	"google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
 
+	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"time"
 )
 
 type computeService struct {
@@ -18,16 +22,30 @@ type computeService struct {
 	getInstance         func(context.Context, steps.GCEConfig, string) (*compute.Instance, error)
 	setInstanceMetadata func(context.Context, steps.GCEConfig, string, *compute.Metadata) (*compute.Operation, error)
 	deleteInstance      func(string, string, string) (*compute.Operation, error)
+
+	insertTargetPool     func(context.Context, steps.GCEConfig, *compute.TargetPool) (*compute.Operation, error)
+	addInstanceToPool    func(context.Context, steps.GCEConfig, string, string) (*compute.Operation, error)
+	deleteTargetPool     func(context.Context, steps.GCEConfig, string) (*compute.Operation, error)
+	insertForwardingRule func(context.Context, steps.GCEConfig, *compute.ForwardingRule) (*compute.Operation, error)
+	getForwardingRule    func(context.Context, steps.GCEConfig, string) (*compute.ForwardingRule, error)
+	deleteForwardingRule func(context.Context, steps.GCEConfig, string) (*compute.Operation, error)
+	getRegionOperation   func(context.Context, steps.GCEConfig, string) (*compute.Operation, error)
 }
 
 func Init() {
 	createInstance, _ := NewCreateInstanceStep(time.Second*10, time.Minute*1)
 	deleteCluster, _ := NewDeleteClusterStep()
 	deleteNode, _ := NewDeleteNodeStep()
+	createLoadBalancer := NewCreateLoadBalancerStep(time.Second*5, time.Minute*5)
+	registerLoadBalancer := NewRegisterLoadBalancerStep()
+	deleteLoadBalancer := NewDeleteLoadBalancerStep(time.Second*5, time.Minute*5)
 
 	steps.RegisterStep(CreateInstanceStepName, createInstance)
 	steps.RegisterStep(DeleteClusterStepName, deleteCluster)
 	steps.RegisterStep(DeleteNodeStepName, deleteNode)
+	steps.RegisterStep(CreateLoadBalancerStepName, createLoadBalancer)
+	steps.RegisterStep(RegisterLoadBalancerStepName, registerLoadBalancer)
+	steps.RegisterStep(DeleteLoadBalancerStepName, deleteLoadBalancer)
 }
 
 func GetClient(ctx context.Context, email, privateKey, tokenUri string) (*compute.Service, error) {
@@ -52,3 +70,97 @@ func GetClient(ctx context.Context, email, privateKey, tokenUri string) (*comput
 	}
 	return computeService, nil
 }
+
+// getLoadBalancerSvc returns compute service for managing target pool and
+// forwarding rule of the load balancer in front of masters.
+func getLoadBalancerSvc(ctx context.Context, config steps.GCEConfig) (*computeService, error) {
+	client, err := GetClient(ctx, config.ClientEmail,
+		config.PrivateKey, config.TokenURI)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &computeService{
+		insertTargetPool: func(ctx context.Context, config steps.GCEConfig,
+			pool *compute.TargetPool) (*compute.Operation, error) {
+			return client.TargetPools.Insert(config.ProjectID,
+				config.Region, pool).Do()
+		},
+		addInstanceToPool: func(ctx context.Context, config steps.GCEConfig,
+			pool, instance string) (*compute.Operation, error) {
+			return client.TargetPools.AddInstance(config.ProjectID,
+				config.Region, pool, &compute.TargetPoolsAddInstanceRequest{
+					Instances: []*compute.InstanceReference{
+						{
+							Instance: instance,
+						},
+					},
+				}).Do()
+		},
+		deleteTargetPool: func(ctx context.Context, config steps.GCEConfig,
+			name string) (*compute.Operation, error) {
+			return client.TargetPools.Delete(config.ProjectID,
+				config.Region, name).Do()
+		},
+		insertForwardingRule: func(ctx context.Context, config steps.GCEConfig,
+			rule *compute.ForwardingRule) (*compute.Operation, error) {
+			return client.ForwardingRules.Insert(config.ProjectID,
+				config.Region, rule).Do()
+		},
+		getForwardingRule: func(ctx context.Context, config steps.GCEConfig,
+			name string) (*compute.ForwardingRule, error) {
+			return client.ForwardingRules.Get(config.ProjectID,
+				config.Region, name).Do()
+		},
+		deleteForwardingRule: func(ctx context.Context, config steps.GCEConfig,
+			name string) (*compute.Operation, error) {
+			return client.ForwardingRules.Delete(config.ProjectID,
+				config.Region, name).Do()
+		},
+		getRegionOperation: func(ctx context.Context, config steps.GCEConfig,
+			name string) (*compute.Operation, error) {
+			return client.RegionOperations.Get(config.ProjectID,
+				config.Region, name).Do()
+		},
+	}, nil
+}
+
+// waitRegionOperation waits until operation on regional resource is done
+func waitRegionOperation(ctx context.Context, svc *computeService, config steps.GCEConfig,
+	op *compute.Operation, period, timeout time.Duration) error {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	after := time.After(timeout)
+
+	for op.Status != "DONE" {
+		select {
+		case <-ticker.C:
+			var err error
+			op, err = svc.getRegionOperation(ctx, config, op.Name)
+
+			if err != nil {
+				return errors.Wrap(err, "get operation")
+			}
+		case <-after:
+			return sgerrors.ErrTimeoutExceeded
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if op.Error != nil && len(op.Error.Errors) > 0 {
+		return errors.Errorf("operation %s: %s", op.Name, op.Error.Errors[0].Message)
+	}
+
+	return nil
+}
+
+// isNotFound returns true for errors of missing resources
+func isNotFound(err error) bool {
+	if apiErr, ok := err.(*googleapi.Error); ok {
+		return apiErr.Code == http.StatusNotFound
+	}
+
+	return false
+}
@@ -220,6 +220,7 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer,
 		// cluster wide and we need az to delete instance.
 		// TODO(stgleb): consider adding AZ to node struct
 		Region: config.GCEConfig.AvailabilityZone,
+		Pool:   config.PoolName(),
 	}
 
 	// Update node state in cluster
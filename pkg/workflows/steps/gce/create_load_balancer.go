@@ -0,0 +1,103 @@
+package gce
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	compute "google.golang.org/api/compute/v1"
+
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const CreateLoadBalancerStepName = "gce_create_load_balancer"
+
+// CreateLoadBalancerStep creates target pool for masters and regional
+// forwarding rule that sends API traffic to the pool.
+type CreateLoadBalancerStep struct {
+	checkPeriod time.Duration
+	timeout     time.Duration
+
+	getComputeSvc func(context.Context, steps.GCEConfig) (*computeService, error)
+}
+
+func NewCreateLoadBalancerStep(period, timeout time.Duration) *CreateLoadBalancerStep {
+	return &CreateLoadBalancerStep{
+		checkPeriod:   period,
+		timeout:       timeout,
+		getComputeSvc: getLoadBalancerSvc,
+	}
+}
+
+func (s *CreateLoadBalancerStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if !config.LoadBalancerConfig.Enabled || config.LoadBalancerConfig.Host != "" {
+		return nil
+	}
+
+	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
+
+	if err != nil {
+		return errors.Wrapf(err, "%s get service", CreateLoadBalancerStepName)
+	}
+
+	name := util.MakeLoadBalancerName(config.ClusterID)
+	op, err := svc.insertTargetPool(ctx, config.GCEConfig, &compute.TargetPool{
+		Name:        name,
+		Description: "Kubernetes masters of cluster " + config.ClusterID,
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "insert target pool %s", name)
+	}
+
+	if err := waitRegionOperation(ctx, svc, config.GCEConfig, op,
+		s.checkPeriod, s.timeout); err != nil {
+		return errors.Wrapf(err, "wait target pool %s", name)
+	}
+
+	op, err = svc.insertForwardingRule(ctx, config.GCEConfig, &compute.ForwardingRule{
+		Name:       name,
+		IPProtocol: "TCP",
+		PortRange:  "443",
+		Target:     op.TargetLink,
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "insert forwarding rule %s", name)
+	}
+
+	if err := waitRegionOperation(ctx, svc, config.GCEConfig, op,
+		s.checkPeriod, s.timeout); err != nil {
+		return errors.Wrapf(err, "wait forwarding rule %s", name)
+	}
+
+	rule, err := svc.getForwardingRule(ctx, config.GCEConfig, name)
+
+	if err != nil {
+		return errors.Wrapf(err, "get forwarding rule %s", name)
+	}
+
+	config.LoadBalancerConfig.Host = rule.IPAddress
+	logrus.Infof("Load balancer %s has been created", rule.IPAddress)
+
+	return nil
+}
+
+func (s *CreateLoadBalancerStep) Name() string {
+	return CreateLoadBalancerStepName
+}
+
+func (s *CreateLoadBalancerStep) Depends() []string {
+	return nil
+}
+
+func (s *CreateLoadBalancerStep) Description() string {
+	return "Google compute engine create load balancer in front of masters"
+}
+
+func (s *CreateLoadBalancerStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
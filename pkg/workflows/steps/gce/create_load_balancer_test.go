@@ -0,0 +1,122 @@
+package gce
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/compute/v1"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestCreateLoadBalancerStep_Run(t *testing.T) {
+	testCases := []struct {
+		description string
+		enabled     bool
+		getSvcErr   error
+		poolErr     error
+		opErr       *compute.OperationError
+		host        string
+		errMsg      string
+	}{
+		{
+			description: "single master",
+		},
+		{
+			description: "get service",
+			enabled:     true,
+			getSvcErr:   errors.New("error1"),
+			errMsg:      "error1",
+		},
+		{
+			description: "insert target pool",
+			enabled:     true,
+			poolErr:     errors.New("error2"),
+			errMsg:      "error2",
+		},
+		{
+			description: "operation error",
+			enabled:     true,
+			opErr: &compute.OperationError{
+				Errors: []*compute.OperationErrorErrors{{Message: "error3"}},
+			},
+			errMsg: "error3",
+		},
+		{
+			description: "success",
+			enabled:     true,
+			host:        "10.20.30.40",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+
+		var target string
+		step := NewCreateLoadBalancerStep(time.Millisecond, time.Second)
+		step.getComputeSvc = func(context.Context, steps.GCEConfig) (*computeService, error) {
+			return &computeService{
+				insertTargetPool: func(context.Context, steps.GCEConfig, *compute.TargetPool) (*compute.Operation, error) {
+					return &compute.Operation{Name: "op1", TargetLink: "pool"}, testCase.poolErr
+				},
+				insertForwardingRule: func(ctx context.Context, cfg steps.GCEConfig, rule *compute.ForwardingRule) (*compute.Operation, error) {
+					target = rule.Target
+					return &compute.Operation{Name: "op2", Status: "DONE"}, nil
+				},
+				getForwardingRule: func(context.Context, steps.GCEConfig, string) (*compute.ForwardingRule, error) {
+					return &compute.ForwardingRule{IPAddress: "10.20.30.40"}, nil
+				},
+				getRegionOperation: func(ctx context.Context, cfg steps.GCEConfig, name string) (*compute.Operation, error) {
+					return &compute.Operation{
+						Name:       name,
+						Status:     "DONE",
+						TargetLink: "pool",
+						Error:      testCase.opErr,
+					}, nil
+				},
+			}, testCase.getSvcErr
+		}
+
+		config := &steps.Config{
+			ClusterID: "1234abcd",
+			LoadBalancerConfig: steps.LoadBalancerConfig{
+				Enabled: testCase.enabled,
+			},
+		}
+
+		err := step.Run(context.Background(), &bytes.Buffer{}, config)
+
+		if testCase.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), testCase.errMsg) {
+				t.Errorf("Error %v does not contain %s", err, testCase.errMsg)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		if config.LoadBalancerConfig.Host != testCase.host {
+			t.Errorf("Wrong load balancer host expected %s actual %s",
+				testCase.host, config.LoadBalancerConfig.Host)
+		}
+
+		if testCase.host != "" && target != "pool" {
+			t.Errorf("Forwarding rule must target the pool, actual %s", target)
+		}
+	}
+}
+
+func TestCreateLoadBalancerStep_Name(t *testing.T) {
+	s := &CreateLoadBalancerStep{}
+
+	if name := s.Name(); name != CreateLoadBalancerStepName {
+		t.Errorf("Wrong step name expected %s actual %s",
+			CreateLoadBalancerStepName, name)
+	}
+}
@@ -3,11 +3,13 @@ package gce
 import (
 	"context"
 	"io"
+	"sort"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	compute "google.golang.org/api/compute/v1"
 
+	"github.com/supergiant/control/pkg/node"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -70,6 +72,25 @@ func (s *DeleteClusterStep) Run(ctx context.Context, output io.Writer, config *s
 	return nil
 }
 
+// Plan lists instances of masters and nodes of the cluster.
+func (s *DeleteClusterStep) Plan(ctx context.Context, config *steps.Config) ([]steps.Resource, error) {
+	resources := make([]steps.Resource, 0)
+	for _, machines := range []map[string]*node.Node{config.GetMasters(), config.GetNodes()} {
+		for _, n := range machines {
+			resources = append(resources, steps.Resource{
+				Type: "instance",
+				ID:   n.Name,
+			})
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].ID < resources[j].ID
+	})
+
+	return resources, nil
+}
+
 func (s *DeleteClusterStep) Name() string {
 	return DeleteClusterStepName
 }
@@ -139,3 +139,23 @@ func TestDeleteClusterStep_Description(t *testing.T) {
 			"Google compute engine delete cluster step actual %s", desc)
 	}
 }
+
+func TestDeleteClusterStep_Plan(t *testing.T) {
+	config := &steps.Config{
+		Masters: steps.NewMap(map[string]*node.Node{
+			"master-1": {Name: "master-1"},
+		}),
+		Nodes: steps.NewMap(map[string]*node.Node{
+			"node-1": {Name: "node-1"},
+		}),
+	}
+
+	resources, err := (&DeleteClusterStep{}).Plan(context.Background(), config)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(resources) != 2 || resources[0].ID != "master-1" || resources[1].ID != "node-1" {
+		t.Errorf("wrong plan %v", resources)
+	}
+}
@@ -0,0 +1,100 @@
+package gce
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const DeleteLoadBalancerStepName = "gce_delete_load_balancer"
+
+type DeleteLoadBalancerStep struct {
+	checkPeriod time.Duration
+	timeout     time.Duration
+
+	getComputeSvc func(context.Context, steps.GCEConfig) (*computeService, error)
+}
+
+func NewDeleteLoadBalancerStep(period, timeout time.Duration) *DeleteLoadBalancerStep {
+	return &DeleteLoadBalancerStep{
+		checkPeriod:   period,
+		timeout:       timeout,
+		getComputeSvc: getLoadBalancerSvc,
+	}
+}
+
+func (s *DeleteLoadBalancerStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if config.LoadBalancerConfig.Host == "" {
+		return nil
+	}
+
+	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
+
+	if err != nil {
+		return errors.Wrapf(err, "%s get service", DeleteLoadBalancerStepName)
+	}
+
+	name := util.MakeLoadBalancerName(config.ClusterID)
+
+	// Target pool can be deleted only when forwarding rule is gone
+	op, err := svc.deleteForwardingRule(ctx, config.GCEConfig, name)
+
+	if err != nil && !isNotFound(err) {
+		return errors.Wrapf(err, "delete forwarding rule %s", name)
+	}
+
+	if err == nil {
+		if err := waitRegionOperation(ctx, svc, config.GCEConfig, op,
+			s.checkPeriod, s.timeout); err != nil {
+			return errors.Wrapf(err, "wait forwarding rule %s", name)
+		}
+	}
+
+	if _, err := svc.deleteTargetPool(ctx, config.GCEConfig, name); err != nil && !isNotFound(err) {
+		return errors.Wrapf(err, "delete target pool %s", name)
+	}
+
+	return nil
+}
+
+// Plan lists forwarding rule and target pool of the load balancer,
+// both of them are named after the cluster.
+func (s *DeleteLoadBalancerStep) Plan(ctx context.Context, config *steps.Config) ([]steps.Resource, error) {
+	if config.LoadBalancerConfig.Host == "" {
+		return nil, nil
+	}
+
+	name := util.MakeLoadBalancerName(config.ClusterID)
+
+	return []steps.Resource{
+		{
+			Type: "forwarding rule",
+			ID:   name,
+		},
+		{
+			Type: "target pool",
+			ID:   name,
+		},
+	}, nil
+}
+
+func (s *DeleteLoadBalancerStep) Name() string {
+	return DeleteLoadBalancerStepName
+}
+
+func (s *DeleteLoadBalancerStep) Depends() []string {
+	return nil
+}
+
+func (s *DeleteLoadBalancerStep) Description() string {
+	return "Google compute engine delete load balancer in front of masters"
+}
+
+func (s *DeleteLoadBalancerStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package gce
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestDeleteLoadBalancerStep_Run(t *testing.T) {
+	testCases := []struct {
+		description string
+		host        string
+		ruleErr     error
+		poolErr     error
+		hasErr      bool
+		deleted     int
+	}{
+		{
+			description: "no load balancer",
+		},
+		{
+			description: "already deleted",
+			host:        "10.20.30.40",
+			ruleErr:     &googleapi.Error{Code: http.StatusNotFound},
+			poolErr:     &googleapi.Error{Code: http.StatusNotFound},
+			deleted:     2,
+		},
+		{
+			description: "delete rule error",
+			host:        "10.20.30.40",
+			ruleErr:     errors.New("error1"),
+			hasErr:      true,
+			deleted:     1,
+		},
+		{
+			description: "success",
+			host:        "10.20.30.40",
+			deleted:     2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+
+		deleted := 0
+		step := NewDeleteLoadBalancerStep(time.Millisecond, time.Second)
+		step.getComputeSvc = func(context.Context, steps.GCEConfig) (*computeService, error) {
+			return &computeService{
+				deleteForwardingRule: func(context.Context, steps.GCEConfig, string) (*compute.Operation, error) {
+					deleted++
+					return &compute.Operation{Status: "DONE"}, testCase.ruleErr
+				},
+				deleteTargetPool: func(context.Context, steps.GCEConfig, string) (*compute.Operation, error) {
+					deleted++
+					return &compute.Operation{}, testCase.poolErr
+				},
+			}, nil
+		}
+
+		config := &steps.Config{
+			ClusterID: "1234abcd",
+			LoadBalancerConfig: steps.LoadBalancerConfig{
+				Host: testCase.host,
+			},
+		}
+
+		err := step.Run(context.Background(), &bytes.Buffer{}, config)
+
+		if testCase.hasErr != (err != nil) {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		if deleted != testCase.deleted {
+			t.Errorf("Expected %d deletions actual %d", testCase.deleted, deleted)
+		}
+	}
+}
+
+func TestDeleteLoadBalancerStep_Plan(t *testing.T) {
+	step := &DeleteLoadBalancerStep{}
+	config := &steps.Config{
+		ClusterID: "1234abcd",
+	}
+
+	if resources, _ := step.Plan(context.Background(), config); len(resources) != 0 {
+		t.Errorf("kube without load balancer must have empty plan %v", resources)
+	}
+
+	config.LoadBalancerConfig.Host = "10.20.30.40"
+	resources, err := step.Plan(context.Background(), config)
+	if err != nil || len(resources) != 2 || resources[0].ID != "sg-1234abcd-api" {
+		t.Errorf("wrong plan %v %v", resources, err)
+	}
+}
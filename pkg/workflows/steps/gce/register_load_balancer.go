@@ -0,0 +1,65 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const RegisterLoadBalancerStepName = "gce_register_load_balancer"
+
+// RegisterLoadBalancerStep adds master instance to the target pool
+type RegisterLoadBalancerStep struct {
+	getComputeSvc func(context.Context, steps.GCEConfig) (*computeService, error)
+}
+
+func NewRegisterLoadBalancerStep() *RegisterLoadBalancerStep {
+	return &RegisterLoadBalancerStep{
+		getComputeSvc: getLoadBalancerSvc,
+	}
+}
+
+func (s *RegisterLoadBalancerStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if config.LoadBalancerConfig.Host == "" {
+		return nil
+	}
+
+	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
+
+	if err != nil {
+		return errors.Wrapf(err, "%s get service", RegisterLoadBalancerStepName)
+	}
+
+	// NOTE: region of the node is its availability zone
+	instance := fmt.Sprintf("projects/%s/zones/%s/instances/%s",
+		config.GCEConfig.ProjectID, config.Node.Region, config.Node.Name)
+	name := util.MakeLoadBalancerName(config.ClusterID)
+
+	if _, err := svc.addInstanceToPool(ctx, config.GCEConfig, name, instance); err != nil {
+		return errors.Wrapf(err, "add instance %s to target pool %s",
+			config.Node.Name, name)
+	}
+
+	return nil
+}
+
+func (s *RegisterLoadBalancerStep) Name() string {
+	return RegisterLoadBalancerStepName
+}
+
+func (s *RegisterLoadBalancerStep) Depends() []string {
+	return nil
+}
+
+func (s *RegisterLoadBalancerStep) Description() string {
+	return "Google compute engine add master to the load balancer"
+}
+
+func (s *RegisterLoadBalancerStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
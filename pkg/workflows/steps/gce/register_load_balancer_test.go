@@ -0,0 +1,59 @@
+package gce
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/compute/v1"
+
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestRegisterLoadBalancerStep_Run(t *testing.T) {
+	var pool, instance string
+	var addErr error
+
+	step := NewRegisterLoadBalancerStep()
+	step.getComputeSvc = func(context.Context, steps.GCEConfig) (*computeService, error) {
+		return &computeService{
+			addInstanceToPool: func(ctx context.Context, cfg steps.GCEConfig, p, i string) (*compute.Operation, error) {
+				pool, instance = p, i
+				return &compute.Operation{}, addErr
+			},
+		}, nil
+	}
+
+	config := &steps.Config{
+		ClusterID: "1234abcd",
+		GCEConfig: steps.GCEConfig{
+			ProjectID: "project",
+		},
+		Node: node.Node{
+			Name:   "master-1",
+			Region: "us-east1-b",
+		},
+	}
+
+	// Nothing to register without load balancer
+	if err := step.Run(context.Background(), &bytes.Buffer{}, config); err != nil || pool != "" {
+		t.Errorf("Unexpected error %v or pool %s", err, pool)
+	}
+
+	config.LoadBalancerConfig.Host = "10.20.30.40"
+	if err := step.Run(context.Background(), &bytes.Buffer{}, config); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if pool != "sg-1234abcd-api" ||
+		instance != "projects/project/zones/us-east1-b/instances/master-1" {
+		t.Errorf("Wrong pool %s or instance %s", pool, instance)
+	}
+
+	addErr = errors.New("error1")
+	if err := step.Run(context.Background(), &bytes.Buffer{}, config); err == nil {
+		t.Errorf("Error must not be nil")
+	}
+}
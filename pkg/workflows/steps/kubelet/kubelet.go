@@ -4,15 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"text/template"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/docker"
 	"github.com/supergiant/control/pkg/workflows/steps/manifest"
 )
 
@@ -21,6 +22,8 @@ const (
 
 	// nodeLabelRole specifies the role of a node
 	nodeLabelRole = "kubernetes.io/role"
+	// nodeLabelPool specifies the node pool a node belongs to
+	nodeLabelPool = "supergiant.io/pool"
 )
 
 type Step struct {
@@ -46,9 +49,15 @@ func New(script *template.Template) *Step {
 }
 
 func (t *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	config.KubeletConfig.IsMaster = config.IsMaster
-	config.KubeletConfig.NodeLabels = getNodeLables(toRole(config.IsMaster))
-	err := steps.RunTemplate(ctx, t.script, config.Runner, out, config.KubeletConfig)
+	var kubelet steps.KubeletConfig
+	config.Update(func() {
+		config.KubeletConfig.IsMaster = config.IsMaster
+		config.KubeletConfig.NodeLabels = getNodeLables(toRole(config.IsMaster), config.NodePool)
+		config.KubeletConfig.NodeTaints = getNodeTaints(config.NodePool)
+		kubelet = config.KubeletConfig
+	})
+
+	err := steps.RunTemplate(ctx, t.script, config.Runner, out, kubelet)
 
 	if err != nil {
 		return errors.Wrap(err, "install kubelet step")
@@ -70,13 +79,30 @@ func (t *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return []string{docker.StepName, manifest.StepName}
+	return []string{manifest.StepName}
 }
 
-func getNodeLables(role string) string {
-	return labels.Set(map[string]string{
+func getNodeLables(role string, pool *profile.NodePool) string {
+	nodeLabels := labels.Set{
 		nodeLabelRole: role,
-	}).String()
+	}
+
+	if pool != nil {
+		for key, value := range pool.Labels {
+			nodeLabels[key] = value
+		}
+		nodeLabels[nodeLabelPool] = pool.Name
+	}
+
+	return nodeLabels.String()
+}
+
+func getNodeTaints(pool *profile.NodePool) string {
+	if pool == nil {
+		return ""
+	}
+
+	return strings.Join(pool.Taints, ",")
 }
 
 // TODO: role should be a port of config, it's used by a few tasks
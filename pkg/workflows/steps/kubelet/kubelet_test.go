@@ -5,16 +5,17 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
 	"text/template"
 
 	"github.com/pkg/errors"
 
+	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/docker"
 	"github.com/supergiant/control/pkg/workflows/steps/manifest"
 )
 
@@ -69,6 +70,57 @@ func TestStartKubelet(t *testing.T) {
 	}
 }
 
+func TestStartKubeletNodePool(t *testing.T) {
+	r := &fakeRunner{}
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := new(bytes.Buffer)
+
+	cfg := &steps.Config{
+		KubeletConfig: steps.KubeletConfig{
+			K8SVersion: "1.11.5",
+			ProxyPort:  "8080",
+		},
+		NodePool: &profile.NodePool{
+			Name: "gpu",
+			Labels: map[string]string{
+				"accelerator": "nvidia",
+			},
+			Taints: []string{"dedicated=gpu:NoSchedule", "nvidia.com/gpu:NoExecute"},
+		},
+		Runner: r,
+	}
+
+	task := &Step{
+		tpl,
+	}
+
+	err = task.Run(context.Background(), output, cfg)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"--node-labels=accelerator=nvidia,kubernetes.io/role=node,supergiant.io/pool=gpu",
+		"--register-with-taints=dedicated=gpu:NoSchedule,nvidia.com/gpu:NoExecute",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in %s", expected, output.String())
+		}
+	}
+}
+
 func TestStartKubeletError(t *testing.T) {
 	errMsg := "error has occurred"
 
@@ -111,8 +163,8 @@ func TestStepName(t *testing.T) {
 func TestDepends(t *testing.T) {
 	s := Step{}
 
-	if len(s.Depends()) != 1 && s.Depends()[0] != docker.StepName && s.Depends()[1] != manifest.StepName {
-		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{docker.StepName, manifest.StepName})
+	if deps := s.Depends(); !reflect.DeepEqual(deps, []string{manifest.StepName}) {
+		t.Errorf("Wrong dependency list %v expected %v", deps, []string{manifest.StepName})
 	}
 }
 
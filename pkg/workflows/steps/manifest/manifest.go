@@ -11,7 +11,7 @@ import (
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/certificates"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 const StepName = "manifest"
@@ -39,24 +39,32 @@ func New(script *template.Template) *Step {
 }
 
 func (j *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	// NOTE(stgleb): This is needed for master node to put advertise address for kube api server.
-	config.ManifestConfig.IsMaster = config.IsMaster
-
-	if config.IsMaster {
-		config.ManifestConfig.MasterHost = config.Node.PrivateIp
-	} else {
-		if master := config.GetMaster(); master != nil {
-			config.ManifestConfig.MasterHost = config.GetMaster().PrivateIp
-		}
-	}
-
 	clusterDNSIP, err := util.GetDNSIP(config.ManifestConfig.ServicesCIDR)
 	if err != nil {
 		return errors.Wrapf(err, "get cluster dns ip from the %s subnet", config.ManifestConfig.ServicesCIDR)
 	}
-	config.ManifestConfig.ClusterDNSIP = clusterDNSIP.String()
 
-	err = steps.RunTemplate(ctx, j.script, config.Runner, out, config.ManifestConfig)
+	// NOTE: master is looked up before config is locked, masters have a lock of their own.
+	master := config.GetMaster()
+
+	var manifest steps.ManifestConfig
+	config.Update(func() {
+		// NOTE(stgleb): This is needed for master node to put advertise address for kube api server.
+		config.ManifestConfig.IsMaster = config.IsMaster
+
+		if config.IsMaster {
+			config.ManifestConfig.MasterHost = config.Node.PrivateIp
+		} else if master != nil {
+			config.ManifestConfig.MasterHost = master.PrivateIp
+		}
+
+		// Workers reach api servers through the load balancer if there is any
+		config.ManifestConfig.LoadBalancerHost = config.LoadBalancerConfig.Host
+		config.ManifestConfig.ClusterDNSIP = clusterDNSIP.String()
+		manifest = config.ManifestConfig
+	})
+
+	err = steps.RunTemplate(ctx, j.script, config.Runner, out, manifest)
 	if err != nil {
 		return errors.Wrap(err, "write manifest step")
 	}
@@ -77,5 +85,5 @@ func (t *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return []string{certificates.StepName}
+	return []string{ssh.StepName}
 }
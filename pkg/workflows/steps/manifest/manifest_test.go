@@ -5,6 +5,7 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
 	"text/template"
@@ -16,7 +17,7 @@ import (
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/certificates"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 type fakeRunner struct {
@@ -175,6 +176,49 @@ func TestWriteManifestNode(t *testing.T) {
 	}
 }
 
+func TestWriteManifestLoadBalancer(t *testing.T) {
+	lbHost := "sg-kube-api.example.com"
+
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	cfg := steps.NewConfig("", "", "", profile.Profile{
+		K8SVersion:      "1.11.1",
+		K8SServicesCIDR: "10.3.0.0/16",
+	})
+	cfg.AddMaster(&node.Node{
+		State:     node.StateActive,
+		PrivateIp: "10.0.0.1",
+	})
+	cfg.Runner = &fakeRunner{}
+	cfg.LoadBalancerConfig.Host = lbHost
+
+	output := new(bytes.Buffer)
+	if err := New(tpl).Run(context.Background(), output, cfg); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !strings.Contains(output.String(), "server: https://"+lbHost+":443") {
+		t.Errorf("load balancer %s not found in %s", lbHost, output.String())
+	}
+
+	// Masters talk to their own api server
+	output.Reset()
+	cfg.IsMaster = true
+	cfg.Node = node.Node{PrivateIp: "10.0.0.1"}
+
+	if err := New(tpl).Run(context.Background(), output, cfg); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if strings.Contains(output.String(), lbHost) {
+		t.Errorf("unexpected load balancer in master manifest %s", output.String())
+	}
+}
+
 func TestWriteManifestError(t *testing.T) {
 	errMsg := "error has occurred"
 
@@ -220,8 +264,8 @@ func TestStepName(t *testing.T) {
 func TestDepends(t *testing.T) {
 	s := Step{}
 
-	if len(s.Depends()) != 1 {
-		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{certificates.StepName})
+	if deps := s.Depends(); !reflect.DeepEqual(deps, []string{ssh.StepName}) {
+		t.Errorf("Wrong dependency list %v expected %v", deps, []string{ssh.StepName})
 	}
 }
 
@@ -0,0 +1,23 @@
+package steps
+
+import "context"
+
+// Resource is a cloud resource that a step would delete.
+type Resource struct {
+	Step string `json:"step"`
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	// Foreign resources have not been created by the cluster,
+	// steps skip them when the cluster is deleted.
+	Foreign bool `json:"foreign"`
+	// Untagged resources belong to a cluster that has been created before
+	// resources were tagged, they are deleted with the cluster only when
+	// the delete request asks for it.
+	Untagged bool `json:"untagged,omitempty"`
+}
+
+// Planner is implemented by steps that are able to tell which
+// cloud resources they would delete without deleting them.
+type Planner interface {
+	Plan(context.Context, *Config) ([]Resource, error)
+}
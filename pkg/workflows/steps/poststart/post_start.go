@@ -11,6 +11,8 @@ import (
 	"github.com/supergiant/control/pkg/node"
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/cni"
+	"github.com/supergiant/control/pkg/workflows/steps/downloadk8sbinary"
 	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
 )
 
@@ -40,26 +42,35 @@ func New(script *template.Template) *Step {
 
 func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
 	ctx2, _ := context.WithTimeout(ctx, config.PostStartConfig.Timeout)
-	config.PostStartConfig.IsMaster = config.IsMaster
+	masterNode := config.GetMaster()
 
-	if config.IsMaster {
-		config.PostStartConfig.Host = config.Node.PrivateIp
-	} else {
-		if masterNode := config.GetMaster(); masterNode != nil {
+	var postStart steps.PostStartConfig
+	config.Update(func() {
+		config.PostStartConfig.IsMaster = config.IsMaster
+
+		if config.IsMaster {
+			config.PostStartConfig.Host = config.Node.PrivateIp
+		} else if masterNode != nil {
 			config.PostStartConfig.Host = masterNode.PrivateIp
 		}
-	}
+		postStart = config.PostStartConfig
+	})
 
-	err := steps.RunTemplate(ctx2, s.script, config.Runner, out, config.PostStartConfig)
+	err := steps.RunTemplate(ctx2, s.script, config.Runner, out, postStart)
 
 	if err != nil {
 		return errors.Wrap(err, "run post start script step")
 	}
 
 	// Mark current node as active to allow cluster check task select it for cluster wide task
-	config.Node.State = node.StateActive
-	config.NodeChan() <- config.Node
-	config.SshConfig.BootstrapPrivateKey = ""
+	var n node.Node
+	config.Update(func() {
+		config.Node.State = node.StateActive
+		n = config.Node
+		config.SshConfig.BootstrapPrivateKey = ""
+	})
+
+	config.NodeChan() <- n
 
 	return nil
 }
@@ -77,5 +88,5 @@ func (s *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return []string{kubelet.StepName}
+	return []string{kubelet.StepName, cni.StepName, downloadk8sbinary.StepName}
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
 	"text/template"
@@ -17,6 +18,8 @@ import (
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/cni"
+	"github.com/supergiant/control/pkg/workflows/steps/downloadk8sbinary"
 	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
 )
 
@@ -218,8 +221,8 @@ func TestStepName(t *testing.T) {
 func TestDepends(t *testing.T) {
 	s := Step{}
 
-	if len(s.Depends()) != 1 && s.Depends()[0] != kubelet.StepName {
-		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{kubelet.StepName})
+	if deps := s.Depends(); !reflect.DeepEqual(deps, []string{kubelet.StepName, cni.StepName, downloadk8sbinary.StepName}) {
+		t.Errorf("Wrong dependency list %v expected %v", deps, []string{kubelet.StepName, cni.StepName, downloadk8sbinary.StepName})
 	}
 }
 
@@ -0,0 +1,74 @@
+package restart
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeRunner struct {
+	errMsg string
+}
+
+func (f *fakeRunner) Run(command *runner.Command) error {
+	if len(f.errMsg) > 0 {
+		return errors.New(f.errMsg)
+	}
+
+	_, err := io.Copy(command.Out, strings.NewReader(command.Script))
+	return err
+}
+
+func TestRestart(t *testing.T) {
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	cfg := steps.NewConfig("test", "", "test", profile.Profile{})
+	cfg.Runner = &fakeRunner{}
+
+	output := new(bytes.Buffer)
+	if err := New(tpl).Run(context.Background(), output, cfg); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{"systemctl restart kubelet", "docker restart"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in %s", expected, output.String())
+		}
+	}
+}
+
+func TestRestartError(t *testing.T) {
+	errMsg := "error has occurred"
+
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	cfg := steps.NewConfig("test", "", "test", profile.Profile{})
+	cfg.Runner = &fakeRunner{
+		errMsg: errMsg,
+	}
+
+	err := New(tpl).Run(context.Background(), new(bytes.Buffer), cfg)
+
+	if err == nil || !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("expected error %s actual %v", errMsg, err)
+	}
+}
@@ -0,0 +1,121 @@
+package steps
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultRetryPolicy runs a step once, steps that don't declare
+// a policy fail on the first error.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+// DownloadRetryPolicy retries steps that download packages or binaries
+// and fail for network hiccups.
+var DownloadRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     time.Second * 15,
+	MaxBackoff:  time.Minute,
+}
+
+// RetryPolicy tells how many times a failed step is run again
+// and how long to wait between attempts.
+type RetryPolicy struct {
+	MaxAttempts int
+	// Backoff is a delay before the second attempt, it doubles
+	// with every next attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable tells whether the error is transient, all errors
+	// are retried when it is nil.
+	Retryable func(error) bool
+}
+
+// Retrier is implemented by steps that may fail for a transient reason,
+// e.g. package manager lock is held or cloud API throttles requests.
+type Retrier interface {
+	RetryPolicy() RetryPolicy
+}
+
+// RetryOn returns a function that treats errors which messages
+// contain any of the parts as retryable.
+func RetryOn(parts ...string) func(error) bool {
+	return func(err error) bool {
+		for _, part := range parts {
+			if strings.Contains(err.Error(), part) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// GetRetryPolicy returns the policy declared by the step overridden
+// with the policy of the profile the config has been built from.
+func GetRetryPolicy(step Step, config *Config) RetryPolicy {
+	policy := DefaultRetryPolicy
+	if retrier, ok := step.(Retrier); ok {
+		policy = retrier.RetryPolicy()
+	}
+
+	if config != nil {
+		override, ok := config.RetryPolicies[step.Name()]
+		if ok {
+			if override.MaxAttempts > 0 {
+				policy.MaxAttempts = override.MaxAttempts
+			}
+			// NOTE: profile is validated, durations that fail to parse are ignored.
+			if d, err := time.ParseDuration(override.Backoff); err == nil {
+				policy.Backoff = d
+			}
+			if d, err := time.ParseDuration(override.MaxBackoff); err == nil {
+				policy.MaxBackoff = d
+			}
+			if len(override.RetryOn) > 0 {
+				policy.Retryable = RetryOn(override.RetryOn...)
+			}
+		}
+	}
+
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	return policy
+}
+
+// ShouldRetry tells whether the step should run again after the attempt
+// has failed with the error, attempts are counted from 1.
+func (p RetryPolicy) ShouldRetry(attempt int, err error) bool {
+	if err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+
+	// Cancelled tasks are never retried
+	cause := errors.Cause(err)
+	if cause == context.Canceled || cause == context.DeadlineExceeded {
+		return false
+	}
+
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// Delay returns how long to wait after the attempt before the next one.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay > 0; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			break
+		}
+	}
+
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	return delay
+}
@@ -0,0 +1,89 @@
+package steps
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/profile"
+)
+
+type retryStep struct {
+	name   string
+	policy RetryPolicy
+}
+
+func (s *retryStep) Run(context.Context, io.Writer, *Config) error      { return nil }
+func (s *retryStep) Rollback(context.Context, io.Writer, *Config) error { return nil }
+func (s *retryStep) Name() string                                       { return s.name }
+func (s *retryStep) Description() string                                { return "" }
+func (s *retryStep) Depends() []string                                  { return nil }
+func (s *retryStep) RetryPolicy() RetryPolicy                           { return s.policy }
+
+func TestGetRetryPolicy(t *testing.T) {
+	step := &retryStep{
+		name: "docker",
+		policy: RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Second,
+			Retryable:   RetryOn("lock"),
+		},
+	}
+
+	policy := GetRetryPolicy(step, &Config{})
+	require.Equal(t, 3, policy.MaxAttempts)
+	require.Equal(t, time.Second, policy.Backoff)
+	require.True(t, policy.ShouldRetry(1, errors.New("could not get lock")))
+	require.False(t, policy.ShouldRetry(1, errors.New("no space left on device")))
+	require.False(t, policy.ShouldRetry(3, errors.New("could not get lock")))
+
+	policy = GetRetryPolicy(step, &Config{
+		RetryPolicies: map[string]profile.RetryPolicy{
+			"docker": {
+				MaxAttempts: 5,
+				MaxBackoff:  "2s",
+				RetryOn:     []string{"no space"},
+			},
+		},
+	})
+	require.Equal(t, 5, policy.MaxAttempts)
+	require.Equal(t, time.Second, policy.Backoff, "backoff of the step is kept")
+	require.Equal(t, 2*time.Second, policy.MaxBackoff)
+	require.True(t, policy.ShouldRetry(4, errors.New("no space left on device")))
+	require.False(t, policy.ShouldRetry(1, errors.New("could not get lock")))
+
+	policy = GetRetryPolicy(&retryStep{name: "cni"}, nil)
+	require.Equal(t, 1, policy.MaxAttempts, "zero attempts mean a single run")
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3}
+
+	require.False(t, policy.ShouldRetry(1, nil))
+	require.True(t, policy.ShouldRetry(1, errors.New("error")))
+	require.False(t, policy.ShouldRetry(1, errors.Wrap(context.Canceled, "run")))
+	require.False(t, DefaultRetryPolicy.ShouldRetry(1, errors.New("error")))
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{
+		Backoff:    time.Second,
+		MaxBackoff: 5 * time.Second,
+	}
+
+	for attempt, expected := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		4:  5 * time.Second,
+		10: 5 * time.Second,
+	} {
+		require.Equal(t, expected, policy.Delay(attempt), "attempt %d", attempt)
+	}
+
+	require.Zero(t, RetryPolicy{}.Delay(3))
+}
@@ -3,11 +3,14 @@ package ssh
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/node"
 	"github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -42,9 +45,44 @@ func (s *Step) Run(ctx context.Context, writer io.Writer, config *steps.Config)
 	if err != nil {
 		return errors.Wrap(err, "ssh config step")
 	}
+
+	// NOTE: there is no step that creates machines of bare metal provider,
+	// planned machine is added to the cluster when it is reachable over ssh.
+	if config.Provider == clouds.BareMetal && config.Node.State == node.StatePlanned {
+		addMachine(config)
+	}
+
 	return nil
 }
 
+func addMachine(config *steps.Config) {
+	role := node.RoleMaster
+	if !config.IsMaster {
+		role = node.RoleNode
+	}
+
+	var n node.Node
+	config.Update(func() {
+		config.Node.ID = config.Node.PublicIp
+		config.Node.TaskID = config.TaskID
+		config.Node.Name = util.MakeNodeName(config.ClusterName, config.TaskID, config.IsMaster)
+		config.Node.Role = role
+		config.Node.Pool = config.PoolName()
+		config.Node.CreatedAt = time.Now().Unix()
+		config.Node.State = node.StateProvisioning
+		n = config.Node
+	})
+
+	// Update node state in cluster
+	config.NodeChan() <- n
+
+	if config.IsMaster {
+		config.AddMaster(&config.Node)
+	} else {
+		config.AddNode(&config.Node)
+	}
+}
+
 func (s *Step) Name() string {
 	return StepName
 }
@@ -58,5 +96,5 @@ func (s *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return []string{"node"}
+	return nil
 }
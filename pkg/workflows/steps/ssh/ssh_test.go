@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/node"
 	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/workflows/steps"
@@ -76,6 +77,46 @@ func TestStepRunSuccess(t *testing.T) {
 	}
 }
 
+func TestStepRunBareMetal(t *testing.T) {
+	step := &Step{}
+
+	config := steps.NewConfig("test", "", "", profile.Profile{
+		Provider:       clouds.BareMetal,
+		MasterProfiles: []profile.NodeProfile{{}},
+	})
+	config.TaskID = "abcdefgh"
+	config.IsMaster = true
+	config.SshConfig.BootstrapPrivateKey = privateKey
+	config.Node = node.Node{
+		PublicIp:  "10.20.30.40",
+		PrivateIp: "10.0.0.1",
+		State:     node.StatePlanned,
+	}
+
+	if err := step.Run(context.Background(), ioutil.Discard, config); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	n := <-config.NodeChan()
+	if n.Name != "test-master-abcd" || n.Role != node.RoleMaster ||
+		n.State != node.StateProvisioning || n.ID != "10.20.30.40" {
+		t.Errorf("Wrong node %v", n)
+	}
+
+	if master := config.GetMasters()["test-master-abcd"]; master == nil {
+		t.Errorf("Master must be added to config")
+	}
+
+	// Machines that are already in the cluster are not added again
+	if err := step.Run(context.Background(), ioutil.Discard, config); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if len(config.NodeChan()) != 0 {
+		t.Errorf("Node must not be sent twice")
+	}
+}
+
 func TestStepRunError(t *testing.T) {
 	step := &Step{}
 
@@ -114,8 +155,8 @@ func TestStepName(t *testing.T) {
 func TestDepends(t *testing.T) {
 	s := Step{}
 
-	if len(s.Depends()) != 1 && s.Depends()[0] != "node" {
-		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{"node"})
+	if len(s.Depends()) != 0 {
+		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{})
 	}
 }
 
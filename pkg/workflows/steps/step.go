@@ -3,6 +3,7 @@ package steps
 import (
 	"context"
 	"io"
+	"sort"
 	"sync"
 )
 
@@ -34,3 +35,22 @@ func GetStep(stepName string) Step {
 	defer m.RUnlock()
 	return stepMap[stepName]
 }
+
+// ListSteps returns registered steps sorted by name.
+func ListSteps() []Step {
+	m.RLock()
+	defer m.RUnlock()
+
+	names := make([]string, 0, len(stepMap))
+	for name := range stepMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]Step, 0, len(names))
+	for _, name := range names {
+		list = append(list, stepMap[name])
+	}
+
+	return list
+}
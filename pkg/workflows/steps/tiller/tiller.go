@@ -10,7 +10,7 @@ import (
 
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/poststart"
+	"github.com/supergiant/control/pkg/workflows/steps/clustercheck"
 )
 
 const (
@@ -40,7 +40,7 @@ func New(script *template.Template) *Step {
 }
 
 func (j *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	err := steps.RunTemplate(context.Background(), j.script, config.Runner, out, config.TillerConfig)
+	err := steps.RunTemplate(ctx, j.script, config.Runner, out, config.TillerConfig)
 
 	if err != nil {
 		return errors.Wrap(err, "install tiller step")
@@ -62,5 +62,5 @@ func (s *Step) Description() string {
 }
 
 func (s *Step) Depends() []string {
-	return []string{poststart.StepName}
+	return []string{clustercheck.StepName}
 }
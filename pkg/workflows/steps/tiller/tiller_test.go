@@ -5,6 +5,7 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
 	"text/template"
@@ -14,7 +15,7 @@ import (
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
-	"github.com/supergiant/control/pkg/workflows/steps/poststart"
+	"github.com/supergiant/control/pkg/workflows/steps/clustercheck"
 )
 
 type fakeRunner struct {
@@ -125,8 +126,8 @@ func TestStepName(t *testing.T) {
 func TestDepends(t *testing.T) {
 	s := Step{}
 
-	if len(s.Depends()) != 1 && s.Depends()[0] != poststart.StepName {
-		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{poststart.StepName})
+	if deps := s.Depends(); !reflect.DeepEqual(deps, []string{clustercheck.StepName}) {
+		t.Errorf("Wrong dependency list %v expected %v", deps, []string{clustercheck.StepName})
 	}
 }
 
@@ -0,0 +1,76 @@
+package upgradecheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const StepName = "upgradecheck"
+
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+// Run waits until node is ready with upgraded kubelet, on master
+// nodes api server must be healthy as well.
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	master := config.GetMaster()
+
+	var upgrade steps.UpgradeConfig
+	config.Update(func() {
+		config.UpgradeConfig.IsMaster = config.IsMaster
+
+		if config.IsMaster {
+			config.UpgradeConfig.MasterHost = config.Node.PrivateIp
+		} else if master != nil {
+			config.UpgradeConfig.MasterHost = master.PrivateIp
+		}
+		upgrade = config.UpgradeConfig
+	})
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, upgrade)
+	if err != nil {
+		return errors.Wrap(err, "upgrade health check step")
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Wait until upgraded node is healthy"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
@@ -0,0 +1,111 @@
+package upgradecheck
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeRunner struct {
+	errMsg string
+}
+
+func (f *fakeRunner) Run(command *runner.Command) error {
+	if len(f.errMsg) > 0 {
+		return errors.New(f.errMsg)
+	}
+
+	_, err := io.Copy(command.Out, strings.NewReader(command.Script))
+	return err
+}
+
+func TestUpgradeCheck(t *testing.T) {
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	testCases := []struct {
+		isMaster      bool
+		expectHealthz bool
+		expectedHost  string
+	}{
+		{
+			isMaster:      true,
+			expectHealthz: true,
+			expectedHost:  "10.0.0.2",
+		},
+		{
+			isMaster:      false,
+			expectHealthz: false,
+			expectedHost:  "10.0.0.3",
+		},
+	}
+
+	for _, testCase := range testCases {
+		cfg := steps.NewConfig("test", "", "test", profile.Profile{K8SVersion: "1.12.3"})
+		cfg.Runner = &fakeRunner{}
+		cfg.IsMaster = testCase.isMaster
+		cfg.Node = node.Node{
+			PrivateIp: "10.0.0.2",
+		}
+		cfg.AddMaster(&node.Node{
+			ID:        "master",
+			PrivateIp: "10.0.0.3",
+			State:     node.StateActive,
+		})
+
+		output := new(bytes.Buffer)
+		if err := New(tpl).Run(context.Background(), output, cfg); err != nil {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+
+		if !strings.Contains(output.String(), "http://"+testCase.expectedHost+":8080") {
+			t.Errorf("master host %s not found in %s", testCase.expectedHost, output.String())
+		}
+
+		if !strings.Contains(output.String(), "v1.12.3") {
+			t.Errorf("kubelet version not found in %s", output.String())
+		}
+
+		if strings.Contains(output.String(), "/healthz") != testCase.expectHealthz {
+			t.Errorf("api server health check expected %v in %s",
+				testCase.expectHealthz, output.String())
+		}
+	}
+}
+
+func TestUpgradeCheckError(t *testing.T) {
+	errMsg := "error has occurred"
+
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	cfg := steps.NewConfig("test", "", "test", profile.Profile{})
+	cfg.Runner = &fakeRunner{
+		errMsg: errMsg,
+	}
+
+	err := New(tpl).Run(context.Background(), new(bytes.Buffer), cfg)
+
+	if err == nil || !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("expected error %s actual %v", errMsg, err)
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"runtime/debug"
+	"time"
 
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/events"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/storage"
 	"github.com/supergiant/control/pkg/util"
@@ -29,6 +32,17 @@ type Task struct {
 	Status       statuses.Status `json:"status"`
 	StepStatuses []StepStatus    `json:"stepsStatuses"`
 
+	// KubeID is an id of the kube the task belongs to,
+	// tasks that are not related to any kube have it empty.
+	KubeID string `json:"kubeId,omitempty"`
+	// CreatedAt is when the task has been created, StartedAt and
+	// FinishedAt are times of its last run.
+	CreatedAt  time.Time `json:"createdAt"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	// Steps are names of steps of a custom task.
+	Steps []string `json:"steps,omitempty"`
+
 	workflow   Workflow
 	repository storage.Interface
 }
@@ -51,9 +65,10 @@ func NewTask(taskType string, repository storage.Interface) (*Task, error) {
 
 func newTask(workflowType string, workflow Workflow, repository storage.Interface) *Task {
 	return &Task{
-		ID:     uuid.New(),
-		Type:   workflowType,
-		Status: statuses.Todo,
+		ID:        uuid.New(),
+		Type:      workflowType,
+		Status:    statuses.Todo,
+		CreatedAt: time.Now(),
 
 		workflow:   workflow,
 		repository: repository,
@@ -62,12 +77,19 @@ func newTask(workflowType string, workflow Workflow, repository storage.Interfac
 
 // Run executes all steps of workflow and tracks the progress in persistent storage
 func (w *Task) Run(ctx context.Context, config steps.Config, out io.WriteCloser) chan error {
+	return w.RunConfig(ctx, &config, out)
+}
+
+// RunConfig is Run that takes the config of the task without copying it,
+// the config must not be shared with other tasks.
+func (w *Task) RunConfig(ctx context.Context, config *steps.Config, out io.WriteCloser) chan error {
 	errChan := make(chan error, 1)
 
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
 				w.Status = statuses.Error
+				w.FinishedAt = time.Now()
 				if err := w.sync(ctx); err != nil {
 					logrus.Errorf("sync error %v for task %s", err, w.ID)
 				}
@@ -79,6 +101,9 @@ func (w *Task) Run(ctx context.Context, config steps.Config, out io.WriteCloser)
 			return
 		}
 
+		ctx, done := running.track(ctx, w.ID)
+		defer done()
+
 		// Create list of statuses to track
 		for _, step := range w.workflow {
 			w.StepStatuses = append(w.StepStatuses, StepStatus{
@@ -89,29 +114,36 @@ func (w *Task) Run(ctx context.Context, config steps.Config, out io.WriteCloser)
 		}
 
 		// Set config to the task
-		w.Config = &config
+		w.Config = config
+		w.KubeID = config.ClusterID
 		w.Status = statuses.Todo
+		w.StartedAt = time.Now()
 
 		// Save task state before first step
 		if err := w.sync(ctx); err != nil {
 			logrus.Errorf("Error saving task state %v", err)
 		}
+		w.emit(events.TaskStarted, "task %s has started", w.Type)
 		// Start from the first step
 		err := w.startFrom(ctx, w.ID, out, 0)
 
 		if err != nil {
 			if ctx.Err() == context.Canceled {
 				w.Status = statuses.Cancelled
+				w.FinishedAt = time.Now()
 				// Save task in cancelled state
 				if err := w.sync(context.Background()); err != nil {
 					logrus.Errorf("failed to sync task %s to db: %v", w.ID, err)
 				}
+				w.emit(events.TaskCancelled, "task %s has been cancelled", w.Type)
 				errChan <- ctx.Err()
 			} else {
 				w.Status = statuses.Error
+				w.FinishedAt = time.Now()
 				if err := w.sync(ctx); err != nil {
 					logrus.Errorf("failed to sync task %s to db: %v", w.ID, err)
 				}
+				w.emit(events.TaskFailed, "task %s has failed: %v", w.Type, err)
 				errChan <- err
 			}
 
@@ -119,6 +151,7 @@ func (w *Task) Run(ctx context.Context, config steps.Config, out io.WriteCloser)
 		}
 
 		logrus.Infof("Task %s has finished successfully", w.ID)
+		w.emit(events.TaskSucceeded, "task %s has finished successfully", w.Type)
 		// Notify provisioner that task output closed with error
 		if err := out.Close(); err != nil {
 			errChan <- err
@@ -137,6 +170,9 @@ func (w *Task) Restart(ctx context.Context, id string, out io.Writer) chan error
 	wsLog.Infof("Restarting task %s", id)
 	go func() {
 		defer close(errChan)
+		ctx, done := running.track(ctx, id)
+		defer done()
+
 		data, err := w.repository.Get(ctx, Prefix, id)
 
 		if err != nil {
@@ -151,100 +187,415 @@ func (w *Task) Restart(ctx context.Context, id string, out io.Writer) chan error
 			return
 		}
 
-		i := 0
-		// Skip successfully finished steps
-		for index, stepStatus := range w.StepStatuses {
-			if stepStatus.Status == statuses.Error {
-				i = index
-				break
-			}
-		}
+		w.start()
+
+		// Skip successfully finished steps, steps that have been run at once
+		// with the failed one may have finished after it.
+		i := w.pendingStep()
+		w.emit(events.TaskStarted, "task %s has been restarted from step %d", w.Type, i)
 		// Start from the last failed one
 		err = w.startFrom(ctx, id, out, i)
 
 		if err != nil {
-			if err == context.Canceled {
+			if ctx.Err() == context.Canceled {
 				w.Status = statuses.Cancelled
+				w.FinishedAt = time.Now()
 				// Save task in cancelled state
 				if err := w.sync(context.Background()); err != nil {
 					logrus.Errorf("failed to sync task %s to db: %v", w.ID, err)
 				}
+				w.emit(events.TaskCancelled, "task %s has been cancelled", w.Type)
 			} else {
 				w.Status = statuses.Error
+				w.FinishedAt = time.Now()
 				if err := w.sync(ctx); err != nil {
 					logrus.Errorf("failed to sync task %s to db: %v", w.ID, err)
 				}
+				w.emit(events.TaskFailed, "task %s has failed: %v", w.Type, err)
 				errChan <- err
 			}
 
 			return
 		}
+
+		w.emit(events.TaskSucceeded, "task %s has finished successfully", w.Type)
 	}()
 	return errChan
 }
 
-// start task execution from particular step
+// Resume continues the task that has been loaded from storage from the
+// first step that has not finished successfully, it is used for tasks
+// interrupted by restart of the controlplane.
+func (w *Task) Resume(ctx context.Context, out io.WriteCloser) chan error {
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(errChan)
+		defer func() {
+			if r := recover(); r != nil {
+				w.Status = statuses.Error
+				w.FinishedAt = time.Now()
+				if err := w.sync(ctx); err != nil {
+					logrus.Errorf("sync error %v for task %s", err, w.ID)
+				}
+				debug.PrintStack()
+				errChan <- errors.Errorf("provisioning failed, unexpected panic: %v ", r)
+			}
+		}()
+
+		if w.Config == nil || len(w.StepStatuses) != len(w.workflow) {
+			errChan <- errors.Errorf("task %s of type %s can not be resumed", w.ID, w.Type)
+			return
+		}
+
+		ctx, done := running.track(ctx, w.ID)
+		defer done()
+
+		w.start()
+		logrus.Infof("Resume task %s from step %d", w.ID, w.pendingStep())
+		w.emit(events.TaskStarted, "task %s has been resumed from step %d", w.Type, w.pendingStep())
+		err := w.startFrom(ctx, w.ID, out, w.pendingStep())
+
+		if err != nil {
+			if ctx.Err() == context.Canceled {
+				w.Status = statuses.Cancelled
+				w.FinishedAt = time.Now()
+				err = ctx.Err()
+				// Save task in cancelled state
+				if err := w.sync(context.Background()); err != nil {
+					logrus.Errorf("failed to sync task %s to db: %v", w.ID, err)
+				}
+				w.emit(events.TaskCancelled, "task %s has been cancelled", w.Type)
+			} else {
+				w.Status = statuses.Error
+				w.FinishedAt = time.Now()
+				if err := w.sync(ctx); err != nil {
+					logrus.Errorf("failed to sync task %s to db: %v", w.ID, err)
+				}
+				w.emit(events.TaskFailed, "task %s has failed: %v", w.Type, err)
+			}
+
+			errChan <- err
+			return
+		}
+
+		logrus.Infof("Task %s has finished successfully", w.ID)
+		w.emit(events.TaskSucceeded, "task %s has finished successfully", w.Type)
+		if err := out.Close(); err != nil {
+			errChan <- err
+		}
+	}()
+
+	return errChan
+}
+
+// Interrupt marks the task that has been stopped without finishing as failed,
+// reason is saved as an error of steps that were in progress.
+func (w *Task) Interrupt(ctx context.Context, reason string) error {
+	// NOTE: task that has never been run has no step statuses yet,
+	// reason is saved as an error of its first step.
+	if len(w.StepStatuses) == 0 {
+		w.StepStatuses = todoStatuses(w.workflow)
+	}
+
+	interrupted := false
+	for i := range w.StepStatuses {
+		if w.StepStatuses[i].Status == statuses.Executing {
+			w.StepStatuses[i].Status = statuses.Error
+			w.StepStatuses[i].ErrMsg = reason
+			interrupted = true
+		}
+	}
+
+	if i := w.pendingStep(); !interrupted && i < len(w.StepStatuses) {
+		w.StepStatuses[i].Status = statuses.Error
+		w.StepStatuses[i].ErrMsg = reason
+	}
+
+	w.Status = statuses.Error
+	w.FinishedAt = time.Now()
+
+	return w.sync(ctx)
+}
+
+// todoStatuses returns statuses of steps of the workflow that have not
+// been run, workflows with steps that are not registered have none.
+func todoStatuses(w Workflow) []StepStatus {
+	stepStatuses := make([]StepStatus, 0, len(w))
+	for _, step := range w {
+		if step == nil {
+			return nil
+		}
+
+		stepStatuses = append(stepStatuses, StepStatus{
+			Status:   statuses.Todo,
+			StepName: step.Name(),
+		})
+	}
+
+	return stepStatuses
+}
+
+// Pending returns true if the task has not been run yet or some of its
+// steps have not finished successfully.
+func (w *Task) Pending() bool {
+	return w.Config == nil || w.pendingStep() < len(w.StepStatuses)
+}
+
+// start records the start of the task that has been loaded from storage.
+func (w *Task) start() {
+	w.KubeID = w.kubeID()
+	w.StartedAt = time.Now()
+	w.FinishedAt = time.Time{}
+}
+
+// pendingStep returns index of the first step that has not finished successfully
+func (w *Task) pendingStep() int {
+	for index, stepStatus := range w.StepStatuses {
+		if stepStatus.Status != statuses.Success {
+			return index
+		}
+	}
+
+	return len(w.StepStatuses)
+}
+
+// startFrom runs steps of the workflow that have not finished successfully,
+// steps before i are considered finished. A step is started as soon as steps
+// it depends on have succeeded, no steps are started after a failure.
 func (w *Task) startFrom(ctx context.Context, id string, out io.Writer, i int) error {
-	// Start workflow from the last failed step
+	deps, err := w.workflow.dependencies()
+	if err != nil {
+		return errors.Wrapf(err, "workflow %s", w.Type)
+	}
+
+	// Steps that run at once share the output
+	out = &syncWriter{w: out}
 	wsLog := util.GetLogger(out)
-	for index := i; index < len(w.StepStatuses); index++ {
-		step := w.workflow[index]
 
-		wsLog.Infof("[%s] - started", step.Name())
-		logrus.Info(step.Name())
+	type result struct {
+		index   int
+		attempt int
+		err     error
+		// retrying is set when the step runs again after delay
+		retrying bool
+		delay    time.Duration
+		// recorded is set when error of the attempt has been already reported
+		recorded bool
+	}
 
-		// sync to storage with task in executing state
-		w.Status = statuses.Executing
-		w.StepStatuses[index].Status = statuses.Executing
+	done := make([]bool, len(w.StepStatuses))
+	started := make([]bool, len(w.StepStatuses))
+	for index := range w.StepStatuses {
+		done[index] = index < i || w.StepStatuses[index].Status == statuses.Success
+		started[index] = done[index]
+	}
 
-		if err := w.sync(ctx); err != nil {
-			logrus.Errorf("sync error %v", err)
+	limit := getParallelism()
+	results := make(chan result, len(w.StepStatuses))
+	inFlight := 0
+	var failure error
+
+	for {
+		for index := 0; index < len(w.StepStatuses) && failure == nil && ctx.Err() == nil; index++ {
+			if inFlight >= limit || started[index] || !ready(deps[index], done) {
+				continue
+			}
+
+			step := w.workflow[index]
+			wsLog.Infof("[%s] - started", step.Name())
+			logrus.Info(step.Name())
+
+			// sync to storage with task in executing state
+			w.Status = statuses.Executing
+			w.StepStatuses[index].Status = statuses.Executing
+			w.StepStatuses[index].Attempts = 0
+			w.StepStatuses[index].AttemptErrors = nil
+			if err := w.sync(ctx); err != nil {
+				logrus.Errorf("sync error %v", err)
+			}
+
+			started[index] = true
+			inFlight++
+			go func(index int, policy steps.RetryPolicy) {
+				step := w.workflow[index]
+				w.waitForBootstrap(step)
+
+				for attempt := 1; ; attempt++ {
+					err := w.runStep(ctx, out, step)
+					if !policy.ShouldRetry(attempt, err) {
+						results <- result{index: index, attempt: attempt, err: err}
+						return
+					}
+
+					delay := policy.Delay(attempt)
+					results <- result{index: index, attempt: attempt, err: err, retrying: true, delay: delay}
+
+					select {
+					case <-time.After(delay):
+					case <-ctx.Done():
+						results <- result{index: index, attempt: attempt, err: err, recorded: true}
+						return
+					}
+				}
+			}(index, steps.GetRetryPolicy(step, w.Config))
+		}
+
+		if inFlight == 0 {
+			break
+		}
+
+		r := <-results
+		step := w.workflow[r.index]
+
+		w.StepStatuses[r.index].Attempts = r.attempt
+		if r.err != nil && !r.recorded {
+			w.StepStatuses[r.index].AttemptErrors = append(w.StepStatuses[r.index].AttemptErrors, r.err.Error())
 		}
 
-		//TODO move to step
-		if w.Config.IsMaster {
-			if step.Name() == etcd.StepName {
-				//wait until all masters are ready for etcd bootstrapping
-				w.Config.ReadyForBootstrapLatch.Done()
-				w.Config.ReadyForBootstrapLatch.Wait()
+		if r.retrying {
+			wsLog.Infof("[%s] - attempt %d failed: %s, retry in %s", step.Name(), r.attempt, r.err.Error(), r.delay)
+			if err := w.sync(ctx); err != nil {
+				logrus.Errorf("sync error %v for step %s", err, step.Name())
 			}
+			continue
 		}
 
-		if err := step.Run(ctx, out, w.Config); err != nil {
+		inFlight--
+		if r.err != nil {
+			// Rollback and saving of the cancelled task must not be cancelled
+			syncCtx := ctx
+			if ctx.Err() == context.Canceled {
+				syncCtx = context.Background()
+			}
+
 			// Mark step status as error
-			w.StepStatuses[index].Status = statuses.Error
+			w.StepStatuses[r.index].Status = statuses.Error
+			w.StepStatuses[r.index].ErrMsg = r.err.Error()
 			w.Status = statuses.Error
-			w.StepStatuses[index].ErrMsg = err.Error()
-			w.sync(ctx)
 
-			wsLog.Infof("[%s] - failed: %s", step.Name(), err.Error())
-			if err2 := w.sync(ctx); err2 != nil {
-				logrus.Errorf("sync error %v for step %s", err2, step.Name())
+			if syncCtx != ctx {
+				w.StepStatuses[r.index].Status = statuses.Cancelled
+				wsLog.Infof("[%s] - cancelled: %s", step.Name(), r.err.Error())
+			} else {
+				wsLog.Infof("[%s] - failed: %s", step.Name(), r.err.Error())
+				e := w.event(events.StepFailed, "step %s has failed: %v", step.Name(), r.err)
+				e.Details = map[string]string{"step": step.Name()}
+				events.Emit(e)
 			}
 
-			if err3 := step.Rollback(ctx, out, w.Config); err3 != nil {
-				logrus.Errorf("rollback: step %s : %v", step.Name(), err3)
+			if err := w.sync(syncCtx); err != nil {
+				logrus.Errorf("sync error %v for step %s", err, step.Name())
 			}
 
-			return err
-		} else {
-			wsLog.Infof("[%s] - success", step.Name())
-			// Mark step as success
-			w.StepStatuses[index].Status = statuses.Success
-			w.Status = statuses.Success
+			if err := step.Rollback(syncCtx, out, w.Config); err != nil {
+				logrus.Errorf("rollback: step %s : %v", step.Name(), err)
+			}
 
-			if err := w.sync(ctx); err != nil {
-				logrus.Errorf("sync error %v for step %s", err, step.Name())
+			if failure == nil {
+				failure = r.err
 			}
+			continue
 		}
+
+		wsLog.Infof("[%s] - success", step.Name())
+		// Mark step as success
+		done[r.index] = true
+		w.StepStatuses[r.index].Status = statuses.Success
+
+		if err := w.sync(ctx); err != nil {
+			logrus.Errorf("sync error %v for step %s", err, step.Name())
+		}
+	}
+
+	if failure != nil {
+		return failure
+	}
+
+	// Steps are not started when context is done
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	w.Status = statuses.Success
+	w.FinishedAt = time.Now()
+	if err := w.sync(ctx); err != nil {
+		logrus.Errorf("sync error %v for task %s", err, w.ID)
 	}
 
 	return nil
 }
 
+// runStep runs the step, panic of the step fails only the step.
+func (w *Task) runStep(ctx context.Context, out io.Writer, step steps.Step) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			debug.PrintStack()
+			err = errors.Errorf("unexpected panic: %v", r)
+		}
+	}()
+
+	return step.Run(ctx, out, w.Config)
+}
+
+// waitForBootstrap blocks etcd step of a master until all masters are
+// ready for etcd bootstrapping, it is done once for all attempts.
+func (w *Task) waitForBootstrap(step steps.Step) {
+	//TODO move to step
+	if w.Config.IsMaster {
+		if step.Name() == etcd.StepName {
+			w.Config.ReadyForBootstrapLatch.Done()
+			w.Config.ReadyForBootstrapLatch.Wait()
+		}
+	}
+}
+
+// ready returns true if all steps with indexes deps are done.
+func ready(deps []int, done []bool) bool {
+	for _, i := range deps {
+		if !done[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// emit records an event of the task, tasks that don't belong
+// to any kube don't have events.
+func (w *Task) emit(t events.Type, format string, args ...interface{}) {
+	events.Emit(w.event(t, format, args...))
+}
+
+func (w *Task) event(t events.Type, format string, args ...interface{}) events.Event {
+	e := events.Event{
+		Type:    t,
+		KubeID:  w.kubeID(),
+		TaskID:  w.ID,
+		Message: fmt.Sprintf(format, args...),
+	}
+
+	if w.Config != nil {
+		e.NodeName = w.Config.Node.Name
+	}
+
+	return e
+}
+
 // synchronize state of workflow to storage
 func (w *Task) sync(ctx context.Context) error {
-	data, err := json.Marshal(w)
+	// Steps that run at once may write to the config meanwhile
+	var (
+		data []byte
+		err  error
+	)
+	if w.Config != nil {
+		w.Config.View(func() {
+			data, err = json.Marshal(w)
+		})
+	} else {
+		data, err = json.Marshal(w)
+	}
 	buf := &bytes.Buffer{}
 
 	if err != nil {
@@ -9,9 +9,11 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/workflows/statuses"
 	"github.com/supergiant/control/pkg/workflows/steps"
@@ -41,7 +43,14 @@ func (f *MockRepository) Get(ctx context.Context, prefix string, key string) ([]
 }
 
 func (f *MockRepository) GetAll(ctx context.Context, prefix string) ([][]byte, error) {
-	return nil, nil
+	result := make([][]byte, 0)
+	for key, value := range f.storage {
+		if strings.HasPrefix(key, prefix) {
+			result = append(result, value)
+		}
+	}
+
+	return result, nil
 }
 
 func (f *MockRepository) Delete(ctx context.Context, prefix string, key string) error {
@@ -55,6 +64,7 @@ type MockStep struct {
 	messages    []string
 	errs        []error
 	rollback    bool
+	depends     []string
 }
 
 func (f *MockStep) Rollback(context.Context, io.Writer, *steps.Config) error {
@@ -89,7 +99,7 @@ func (f *MockStep) Description() string {
 }
 
 func (f *MockStep) Depends() []string {
-	return nil
+	return f.depends
 }
 
 func TestNewTask(t *testing.T) {
@@ -285,6 +295,109 @@ func TestWorkflowRestart(t *testing.T) {
 	}
 }
 
+func TestTaskResume(t *testing.T) {
+	s := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+	step1 := &MockStep{name: "step1"}
+	step2 := &MockStep{name: "step2"}
+	step3 := &MockStep{name: "step3"}
+
+	task := &Task{
+		ID:     "abcd",
+		Config: &steps.Config{},
+		StepStatuses: []StepStatus{
+			{StepName: "step1", Status: statuses.Success},
+			{StepName: "step2", Status: statuses.Executing},
+			{StepName: "step3", Status: statuses.Todo},
+		},
+		repository: s,
+		workflow:   []steps.Step{step1, step2, step3},
+	}
+
+	if !task.Pending() {
+		t.Errorf("task must be pending")
+	}
+
+	err := <-task.Resume(context.Background(), &bufferCloser{})
+
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if step1.counter != 0 || step2.counter != 1 || step3.counter != 1 {
+		t.Errorf("wrong steps have been run %d %d %d",
+			step1.counter, step2.counter, step3.counter)
+	}
+
+	if task.Pending() || task.Status != statuses.Success {
+		t.Errorf("task must be finished actual status %s", task.Status)
+	}
+
+	task.workflow = task.workflow[:1]
+	if err := <-task.Resume(context.Background(), &bufferCloser{}); err == nil {
+		t.Errorf("task with changed workflow must not be resumed")
+	}
+}
+
+func TestTaskInterrupt(t *testing.T) {
+	s := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+	reason := "interrupted"
+
+	task := &Task{
+		ID:     "abcd",
+		Config: &steps.Config{},
+		Status: statuses.Executing,
+		StepStatuses: []StepStatus{
+			{StepName: "step1", Status: statuses.Success},
+			{StepName: "step2", Status: statuses.Executing},
+		},
+		repository: s,
+	}
+
+	if err := task.Interrupt(context.Background(), reason); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	stored, err := DeserializeTask(s.storage[Prefix+task.ID], s)
+	require.Nil(t, err)
+
+	if stored.Status != statuses.Error {
+		t.Errorf("wrong task status %s", stored.Status)
+	}
+
+	if stored.StepStatuses[1].Status != statuses.Error ||
+		stored.StepStatuses[1].ErrMsg != reason {
+		t.Errorf("wrong step status %v", stored.StepStatuses[1])
+	}
+
+	if !(&Task{}).Pending() {
+		t.Errorf("task without config must be pending")
+	}
+
+	// Task that has never been run
+	task = &Task{
+		ID:     "efgh",
+		Status: statuses.Todo,
+		workflow: []steps.Step{
+			&MockStep{name: "step1"},
+			&MockStep{name: "step2"},
+		},
+		repository: s,
+	}
+
+	if err := task.Interrupt(context.Background(), reason); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(task.StepStatuses) != 2 || task.StepStatuses[0].Status != statuses.Error ||
+		task.StepStatuses[0].ErrMsg != reason || task.StepStatuses[1].Status != statuses.Todo {
+		t.Errorf("wrong step statuses of task that has not been run %v", task.StepStatuses)
+	}
+}
+
 func TestRollback(t *testing.T) {
 	s := &MockRepository{
 		storage: make(map[string][]byte),
@@ -350,3 +463,62 @@ func TestPanicHandler(t *testing.T) {
 	err := <-errChan
 	require.Error(t, err)
 }
+
+type retryStep struct {
+	MockStep
+	policy steps.RetryPolicy
+}
+
+func (s *retryStep) RetryPolicy() steps.RetryPolicy {
+	return s.policy
+}
+
+func TestTaskRetry(t *testing.T) {
+	s := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+
+	flaky := &retryStep{
+		MockStep: MockStep{
+			name: "flaky",
+			errs: []error{errors.New("lock is held"), errors.New("lock is held"), nil},
+		},
+		policy: steps.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			Retryable:   steps.RetryOn("lock"),
+		},
+	}
+	broken := &retryStep{
+		MockStep: MockStep{
+			name: "broken",
+			errs: []error{errors.New("no space left"), errors.New("no space left")},
+		},
+		policy: steps.RetryPolicy{
+			MaxAttempts: 3,
+			Retryable:   steps.RetryOn("lock"),
+		},
+	}
+
+	task := &Task{
+		ID:         "abcd",
+		repository: s,
+		workflow:   []steps.Step{flaky, broken},
+	}
+
+	err := <-task.Run(context.Background(), steps.Config{
+		RetryPolicies: map[string]profile.RetryPolicy{
+			"broken": {MaxAttempts: 2, RetryOn: []string{"no space"}},
+		},
+	}, &bufferCloser{})
+	require.Error(t, err)
+
+	require.Equal(t, statuses.Success, task.StepStatuses[0].Status)
+	require.Equal(t, 3, task.StepStatuses[0].Attempts)
+	require.Equal(t, []string{"lock is held", "lock is held"}, task.StepStatuses[0].AttemptErrors)
+
+	require.Equal(t, statuses.Error, task.StepStatuses[1].Status)
+	require.Equal(t, 2, task.StepStatuses[1].Attempts, "profile overrides attempts")
+	require.Len(t, task.StepStatuses[1].AttemptErrors, 2)
+	require.True(t, broken.rollback)
+}
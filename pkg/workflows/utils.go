@@ -17,12 +17,13 @@ func DeserializeTask(data []byte, repository storage.Interface) (*Task, error) {
 
 	// Assign repository from task handler to task and restore workflow
 	task.repository = repository
-	task.workflow = GetWorkflow(task.Type)
+	task.workflow = task.restoreWorkflow()
 
 	// NOTE(stgleb): If step has failed on machine creation state
 	// public ip will be blank and lead to error when restart
 	// TODO(stgleb): Move ssh runner creation to task Restart method
-	if task.Config.Node.PublicIp != "" {
+	// NOTE: tasks that have never been run have no config
+	if task.Config != nil && task.Config.Node.PublicIp != "" {
 		cfg := ssh.Config{
 			Host:    task.Config.Node.PublicIp,
 			Port:    task.Config.SshConfig.Port,
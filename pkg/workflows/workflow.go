@@ -1,12 +1,16 @@
 package workflows
 
 import (
+	"sort"
 	"sync"
 
+	"github.com/pkg/errors"
+
 	"github.com/supergiant/control/pkg/workflows/statuses"
 	"github.com/supergiant/control/pkg/workflows/steps"
 	"github.com/supergiant/control/pkg/workflows/steps/amazon"
 	"github.com/supergiant/control/pkg/workflows/steps/authorizedKeys"
+	"github.com/supergiant/control/pkg/workflows/steps/baremetal"
 	"github.com/supergiant/control/pkg/workflows/steps/certificates"
 	"github.com/supergiant/control/pkg/workflows/steps/clustercheck"
 	"github.com/supergiant/control/pkg/workflows/steps/cni"
@@ -21,9 +25,11 @@ import (
 	"github.com/supergiant/control/pkg/workflows/steps/network"
 	"github.com/supergiant/control/pkg/workflows/steps/poststart"
 	"github.com/supergiant/control/pkg/workflows/steps/prometheus"
+	"github.com/supergiant/control/pkg/workflows/steps/restart"
 	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 	"github.com/supergiant/control/pkg/workflows/steps/storageclass"
 	"github.com/supergiant/control/pkg/workflows/steps/tiller"
+	"github.com/supergiant/control/pkg/workflows/steps/upgradecheck"
 )
 
 // StepStatus aggregates data that is needed to track progress
@@ -32,6 +38,10 @@ type StepStatus struct {
 	Status   statuses.Status `json:"status"`
 	StepName string          `json:"stepName"`
 	ErrMsg   string          `json:"errorMessage"`
+	// Attempts is how many times the step has run during the last start
+	// of the task, errors of failed attempts are kept in order.
+	Attempts      int      `json:"attempts,omitempty"`
+	AttemptErrors []string `json:"attemptErrors,omitempty"`
 }
 
 // Workflow is a template for doing some actions
@@ -43,6 +53,7 @@ const (
 	Cluster = "Cluster"
 
 	DigitalOceanMaster        = "DigitalOceanMaster"
+	DigitalOceanPreProvision  = "DigitalOceanPreProvisionCluster"
 	DigitalOceanNode          = "DigitalOceanNode"
 	DigitalOceanDeleteNode    = "DigitalOceanDeleteNode"
 	DigitalOceanDeleteCluster = "DigitalOceanDeleteCluster"
@@ -52,9 +63,18 @@ const (
 	AWSDeleteCluster          = "AWSDeleteCluster"
 	AWSDeleteNode             = "AWSDeleteNode"
 	GCEMaster                 = "GCEMaster"
+	GCEPreProvision           = "GCEPreProvisionCluster"
 	GCENode                   = "GCENode"
 	GCEDeleteCluster          = "GCEDeleteCluster"
 	GCEDeleteNode             = "GCEDeleteNode"
+	BareMetalMaster           = "BareMetalMaster"
+	BareMetalNode             = "BareMetalNode"
+	BareMetalDeleteCluster    = "BareMetalDeleteCluster"
+	BareMetalDeleteNode       = "BareMetalDeleteNode"
+	UpgradeMaster             = "UpgradeMaster"
+	UpgradeNode               = "UpgradeNode"
+	RestoreEtcd               = "RestoreEtcd"
+	RotateCertificates        = "RotateCertificates"
 )
 
 type WorkflowSet struct {
@@ -63,6 +83,8 @@ type WorkflowSet struct {
 	ProvisionNode   string
 	DeleteNode      string
 	DeleteCluster   string
+	UpgradeMaster   string
+	UpgradeNode     string
 }
 
 var (
@@ -70,9 +92,16 @@ var (
 	workflowMap map[string]Workflow
 )
 
-func Init() {
+// Init builds workflows of registered steps, an error is returned if
+// some workflow has steps that are missing or depend on each other.
+func Init() error {
 	workflowMap = make(map[string]Workflow)
 
+	// Steps declare dependencies that hold in every workflow they are
+	// part of, these ones are needed only when machines are provisioned.
+	masterFlannel := after(steps.GetStep(flannel.StepName), etcd.StepName, network.StepName)
+	provisionKubelet := after(steps.GetStep(kubelet.StepName), docker.StepName, certificates.StepName)
+
 	digitalOceanMasterWorkflow := []steps.Step{
 		steps.GetStep(digitalocean.CreateMachineStepName),
 		steps.GetStep(ssh.StepName),
@@ -80,12 +109,13 @@ func Init() {
 		steps.GetStep(cni.StepName),
 		steps.GetStep(etcd.StepName),
 		steps.GetStep(network.StepName),
-		steps.GetStep(flannel.StepName),
+		masterFlannel,
 		steps.GetStep(docker.StepName),
 		steps.GetStep(certificates.StepName),
 		steps.GetStep(manifest.StepName),
-		steps.GetStep(kubelet.StepName),
+		provisionKubelet,
 		steps.GetStep(poststart.StepName),
+		steps.GetStep(digitalocean.RegisterLoadBalancerStepName),
 	}
 	digitalOceanNodeWorkflow := []steps.Step{
 		steps.GetStep(digitalocean.CreateMachineStepName),
@@ -95,11 +125,16 @@ func Init() {
 		steps.GetStep(flannel.StepName),
 		steps.GetStep(docker.StepName),
 		steps.GetStep(certificates.StepName),
-		steps.GetStep(kubelet.StepName),
+		provisionKubelet,
 		steps.GetStep(cni.StepName),
 		steps.GetStep(poststart.StepName),
 	}
 
+	// Load balancer in front of masters is created only for multi master clusters
+	digitalOceanPreProvision := []steps.Step{
+		steps.GetStep(digitalocean.CreateLoadBalancerStepName),
+	}
+
 	awsPreProvision := []steps.Step{
 		steps.GetStep(amazon.StepFindAMI),
 		steps.GetStep(amazon.StepCreateVPC),
@@ -110,6 +145,7 @@ func Init() {
 		steps.GetStep(amazon.StepCreateSubnets),
 		steps.GetStep(amazon.StepCreateRouteTable),
 		steps.GetStep(amazon.StepAssociateRouteTable),
+		steps.GetStep(amazon.CreateLoadBalancerStepName),
 	}
 
 	awsMasterWorkflow := []steps.Step{
@@ -120,12 +156,13 @@ func Init() {
 		steps.GetStep(cni.StepName),
 		steps.GetStep(etcd.StepName),
 		steps.GetStep(network.StepName),
-		steps.GetStep(flannel.StepName),
+		masterFlannel,
 		steps.GetStep(docker.StepName),
 		steps.GetStep(certificates.StepName),
 		steps.GetStep(manifest.StepName),
-		steps.GetStep(kubelet.StepName),
+		provisionKubelet,
 		steps.GetStep(poststart.StepName),
+		steps.GetStep(amazon.RegisterLoadBalancerStepName),
 	}
 
 	awsNodeWorkflow := []steps.Step{
@@ -137,7 +174,7 @@ func Init() {
 		steps.GetStep(manifest.StepName),
 		steps.GetStep(flannel.StepName),
 		steps.GetStep(docker.StepName),
-		steps.GetStep(kubelet.StepName),
+		provisionKubelet,
 		steps.GetStep(cni.StepName),
 		steps.GetStep(poststart.StepName),
 	}
@@ -155,11 +192,13 @@ func Init() {
 	}
 
 	digitalOceanDeleteClusterWorkflow := []steps.Step{
+		steps.GetStep(digitalocean.DeleteLoadBalancerStepName),
 		steps.GetStep(digitalocean.DeleteClusterMachines),
 		steps.GetStep(digitalocean.DeleteDeleteKeysStepName),
 	}
 
 	awsDeleteClusterWorkflow := []steps.Step{
+		steps.GetStep(amazon.DeleteLoadBalancerStepName),
 		steps.GetStep(amazon.DeleteClusterMachinesStepName),
 		steps.GetStep(amazon.DeleteSecurityGroupsStepName),
 		steps.GetStep(amazon.DisassociateRouteTableStepName),
@@ -183,7 +222,7 @@ func Init() {
 		steps.GetStep(flannel.StepName),
 		steps.GetStep(docker.StepName),
 		steps.GetStep(certificates.StepName),
-		steps.GetStep(kubelet.StepName),
+		provisionKubelet,
 		steps.GetStep(cni.StepName),
 		steps.GetStep(poststart.StepName),
 	}
@@ -196,15 +235,21 @@ func Init() {
 		steps.GetStep(cni.StepName),
 		steps.GetStep(etcd.StepName),
 		steps.GetStep(network.StepName),
-		steps.GetStep(flannel.StepName),
+		masterFlannel,
 		steps.GetStep(docker.StepName),
 		steps.GetStep(certificates.StepName),
 		steps.GetStep(manifest.StepName),
-		steps.GetStep(kubelet.StepName),
+		provisionKubelet,
 		steps.GetStep(poststart.StepName),
+		steps.GetStep(gce.RegisterLoadBalancerStepName),
+	}
+
+	gcePreProvision := []steps.Step{
+		steps.GetStep(gce.CreateLoadBalancerStepName),
 	}
 
 	gceDeleteCluster := []steps.Step{
+		steps.GetStep(gce.DeleteLoadBalancerStepName),
 		steps.GetStep(gce.DeleteClusterStepName),
 	}
 
@@ -212,6 +257,76 @@ func Init() {
 		steps.GetStep(gce.DeleteNodeStepName),
 	}
 
+	// Machines of bare metal provider already exist, workflows start
+	// with ssh and delete only removes kubernetes components.
+	bareMetalMasterWorkflow := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(downloadk8sbinary.StepName),
+		steps.GetStep(cni.StepName),
+		steps.GetStep(etcd.StepName),
+		steps.GetStep(network.StepName),
+		masterFlannel,
+		steps.GetStep(docker.StepName),
+		steps.GetStep(certificates.StepName),
+		steps.GetStep(manifest.StepName),
+		provisionKubelet,
+		steps.GetStep(poststart.StepName),
+	}
+
+	bareMetalNodeWorkflow := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(downloadk8sbinary.StepName),
+		steps.GetStep(manifest.StepName),
+		steps.GetStep(flannel.StepName),
+		steps.GetStep(docker.StepName),
+		steps.GetStep(certificates.StepName),
+		provisionKubelet,
+		steps.GetStep(cni.StepName),
+		steps.GetStep(poststart.StepName),
+	}
+
+	bareMetalDeleteCluster := []steps.Step{
+		steps.GetStep(baremetal.TeardownClusterStepName),
+	}
+
+	bareMetalDeleteNode := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(baremetal.TeardownNodeStepName),
+	}
+
+	// Upgrade workflows run over ssh and don't depend on cloud provider
+	upgradeMasterWorkflow := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(downloadk8sbinary.StepName),
+		steps.GetStep(manifest.StepName),
+		steps.GetStep(kubelet.StepName),
+		steps.GetStep(upgradecheck.StepName),
+	}
+
+	// NOTE: node is drained through kubernetes API by the upgrade
+	// before its task runs and uncordoned after the task has finished.
+	upgradeNodeWorkflow := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(downloadk8sbinary.StepName),
+		steps.GetStep(manifest.StepName),
+		steps.GetStep(kubelet.StepName),
+		steps.GetStep(upgradecheck.StepName),
+	}
+
+	restoreEtcdWorkflow := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(etcd.RestoreStepName),
+	}
+
+	// Certificates are issued again from CA of the kube, upgrade
+	// health check makes sure node is back before the next one
+	rotateCertificatesWorkflow := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(certificates.StepName),
+		steps.GetStep(restart.StepName),
+		steps.GetStep(upgradecheck.StepName),
+	}
+
 	m.Lock()
 	defer m.Unlock()
 
@@ -219,6 +334,7 @@ func Init() {
 	workflowMap[Cluster] = commonWorkflow
 	workflowMap[DigitalOceanMaster] = digitalOceanMasterWorkflow
 	workflowMap[DigitalOceanNode] = digitalOceanNodeWorkflow
+	workflowMap[DigitalOceanPreProvision] = digitalOceanPreProvision
 	workflowMap[DigitalOceanDeleteCluster] = digitalOceanDeleteClusterWorkflow
 	workflowMap[AWSMaster] = awsMasterWorkflow
 	workflowMap[AWSNode] = awsNodeWorkflow
@@ -227,14 +343,44 @@ func Init() {
 	workflowMap[AWSDeleteNode] = awsDeleteNodeWorkflow
 	workflowMap[GCENode] = gceNodeWorkflow
 	workflowMap[GCEMaster] = gceMasterWorkflow
+	workflowMap[GCEPreProvision] = gcePreProvision
 	workflowMap[GCEDeleteCluster] = gceDeleteCluster
 	workflowMap[GCEDeleteNode] = gceDeleteNode
+	workflowMap[BareMetalMaster] = bareMetalMasterWorkflow
+	workflowMap[BareMetalNode] = bareMetalNodeWorkflow
+	workflowMap[BareMetalDeleteCluster] = bareMetalDeleteCluster
+	workflowMap[BareMetalDeleteNode] = bareMetalDeleteNode
+	workflowMap[UpgradeMaster] = upgradeMasterWorkflow
+	workflowMap[UpgradeNode] = upgradeNodeWorkflow
+	workflowMap[RestoreEtcd] = restoreEtcdWorkflow
+	workflowMap[RotateCertificates] = rotateCertificatesWorkflow
+
+	names := make([]string, 0, len(workflowMap))
+	for name := range workflowMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := workflowMap[name].Validate(); err != nil {
+			return errors.Wrapf(err, "workflow %s", name)
+		}
+	}
+
+	return nil
 }
 
-func RegisterWorkFlow(workflowName string, workflow Workflow) {
+// RegisterWorkFlow adds the workflow unless its steps depend on
+// missing steps or on each other.
+func RegisterWorkFlow(workflowName string, workflow Workflow) error {
+	if err := workflow.Validate(); err != nil {
+		return errors.Wrapf(err, "workflow %s", workflowName)
+	}
+
 	m.Lock()
 	defer m.Unlock()
 	workflowMap[workflowName] = workflow
+	return nil
 }
 
 func GetWorkflow(workflowName string) Workflow {
@@ -20,6 +20,12 @@ DNS.5 = kubernetes.default.svc.cluster.local
 IP.1 = {{ .PublicIP }}
 IP.2 = {{ .PrivateIP }}
 IP.3 = {{ .KubernetesSvcIP }}
+{{- if .LoadBalancerIP }}
+IP.4 = {{ .LoadBalancerIP }}
+{{- end }}
+{{- if .LoadBalancerDNS }}
+DNS.6 = {{ .LoadBalancerDNS }}
+{{- end }}
 EOF"
 
 sudo bash -c "cat > /etc/kubernetes/ssl/ca.pem <<EOF
@@ -45,7 +51,7 @@ sudo bash -c "cat > /etc/kubernetes/ssl/admin-key.pem <<EOF
 
 
 sudo cp /etc/kubernetes/ssl/ca.pem /usr/share/ca-certificates/ca.crt
-sudo bash -c "echo \"ca.crt\" >> /etc/ca-certificates.conf"
+grep -qx "ca.crt" /etc/ca-certificates.conf || sudo bash -c "echo \"ca.crt\" >> /etc/ca-certificates.conf"
 sudo update-ca-certificates
 
 sudo chmod 600 /etc/kubernetes/ssl/*-key.pem
@@ -0,0 +1,24 @@
+set -e
+
+sudo systemctl stop etcd.service
+
+# Keep data of the member until the next restore in case snapshot is broken
+sudo rm -rf {{ .ETCDConfig.DataDir }}.old
+if [ -d {{ .ETCDConfig.DataDir }} ]; then
+    sudo mv {{ .ETCDConfig.DataDir }} {{ .ETCDConfig.DataDir }}.old
+fi
+
+sudo ETCDCTL_API=3 etcdctl snapshot restore {{ .SnapshotFile }} \
+            --name {{ .ETCDConfig.Name }} \
+            --data-dir {{ .ETCDConfig.DataDir }} \
+            --initial-cluster {{ .InitialClusterIPs }} \
+            --initial-cluster-token {{ .ETCDConfig.ClusterToken }} \
+            --initial-advertise-peer-urls http://{{ .NodePrivateIP }}:{{ .ETCDConfig.ManagementPort }}
+sudo rm -f {{ .SnapshotFile }}
+
+sudo systemctl start etcd.service
+
+while [[ "$(curl -s -o /dev/null -w ''%{http_code}'' http://{{ .NodePrivateIP }}:{{ .ETCDConfig.ServicePort }}/health)" != "200" ]]; do printf 'wait for etcd\n';sleep 5; done
+
+# API server caches the state of etcd, restart it to drop the cache
+sudo docker ps -q --filter name=k8s_kube-apiserver | xargs -r sudo docker restart
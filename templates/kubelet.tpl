@@ -35,6 +35,8 @@ ExecStart=/usr/bin/docker run \
       --cloud-provider={{ .ProviderString }} \{{ end }}
       {{- if .NodeLabels }}
       --node-labels={{ .NodeLabels }} \{{ end }}
+      {{- if .NodeTaints }}
+      --register-with-taints={{ .NodeTaints }} \{{ end }}
       --volume-plugin-dir=/etc/kubernetes/volumeplugins --fail-swap-on=false --register-node=true \
       --anonymous-auth=false
 Restart=always
@@ -48,4 +50,4 @@ WantedBy=multi-user.target
 EOF"
 sudo systemctl daemon-reload
 sudo systemctl enable kubelet.service
-sudo systemctl start kubelet.service
+sudo systemctl restart kubelet.service
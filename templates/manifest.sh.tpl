@@ -200,13 +200,23 @@ kind: Config
 users:
 - name: kubelet
   user:
+{{- if and .LoadBalancerHost (not .IsMaster) }}
+    client-certificate: /etc/kubernetes/ssl/admin.pem
+    client-key: /etc/kubernetes/ssl/admin-key.pem
+{{- else }}
     username: kubelet
     password: '{{ .Password }}'
+{{- end }}
 clusters:
 - name: local
   cluster:
+{{- if and .LoadBalancerHost (not .IsMaster) }}
+    certificate-authority: /etc/kubernetes/ssl/ca.pem
+    server: https://{{ .LoadBalancerHost }}:443
+{{- else }}
     insecure-skip-tls-verify: true
     server: http://{{ .MasterHost }}:8080
+{{- end }}
 contexts:
 - context:
     cluster: local
@@ -232,7 +242,11 @@ spec:
     - /hyperkube
     - proxy
     - --v=2
+{{- if and .LoadBalancerHost (not .IsMaster) }}
+    - --kubeconfig={{ .KubernetesConfigDir }}/worker-kubeconfig.yaml
+{{- else }}
     - --master=http://{{ .MasterHost }}:{{ .MasterPort }}
+{{- end }}
     - --proxy-mode=iptables
     securityContext:
       privileged: true
@@ -240,10 +254,20 @@ spec:
     - mountPath: /etc/ssl/certs
       name: ssl-certs-host
       readOnly: true
+{{- if and .LoadBalancerHost (not .IsMaster) }}
+    - mountPath: /etc/kubernetes
+      name: kubernetes
+      readOnly: true
+{{- end }}
   volumes:
   - hostPath:
       path: /etc/ssl/certs
     name: ssl-certs-host
+{{- if and .LoadBalancerHost (not .IsMaster) }}
+  - hostPath:
+      path: /etc/kubernetes
+    name: kubernetes
+{{- end }}
 EOF"
 
 
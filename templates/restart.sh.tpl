@@ -0,0 +1,8 @@
+NODE_NAME=$(hostname | tr '[:upper:]' '[:lower:]')
+
+echo "Restart kubernetes components of node ${NODE_NAME}"
+sudo systemctl restart kubelet.service
+
+# Containers of static pods keep running when kubelet restarts,
+# restart them to reload certificates
+sudo docker ps -q --filter name=k8s_kube- | xargs -r sudo docker restart
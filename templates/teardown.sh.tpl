@@ -0,0 +1,31 @@
+#!/bin/bash
+# Kubernetes components are removed from the machine, the machine itself
+# and packages that were installed before provisioning are left intact.
+
+echo "Stop kubernetes services"
+sudo systemctl stop kubelet.service flanneld.service etcd.service
+sudo systemctl disable kubelet.service flanneld.service etcd.service
+
+echo "Remove kubelet and pod containers"
+sudo docker ps -aq --filter name=k8s_ | xargs -r sudo docker rm -f
+sudo docker ps -a | awk '/hyperkube/ {print $1}' | xargs -r sudo docker rm -f
+
+mount | awk '$3 ~ /^\/var\/lib\/kubelet/ {print $3}' | sort -r | xargs -r sudo umount
+
+echo "Remove kubernetes files"
+sudo rm -f /etc/systemd/system/kubelet.service \
+    /etc/systemd/system/flanneld.service \
+    /etc/systemd/system/etcd.service \
+    /etc/systemd/system/docker.service
+sudo rm -f /usr/bin/kubectl /usr/bin/flanneld /usr/bin/etcd /usr/bin/etcdctl
+sudo rm -rf /etc/kubernetes /srv/kubernetes /var/lib/kubelet \
+    /var/supergiant/etcd-data /run/flannel /etc/cni /var/lib/cni /opt/bin
+
+sudo ip link delete flannel.1 2>/dev/null
+sudo ip link delete cni0 2>/dev/null
+
+# Docker unit of the package is used again, it doesn't depend on flannel
+sudo systemctl daemon-reload
+sudo systemctl restart docker.service
+
+exit 0
@@ -0,0 +1,20 @@
+NODE_NAME=$(hostname | tr '[:upper:]' '[:lower:]')
+KUBECTL="sudo kubectl --server=http://{{ .MasterHost }}:{{ .MasterPort }}"
+DEADLINE=$(( $(date +%s) + {{ .ReadyTimeout.Seconds }} ))
+
+wait_deadline() {
+    if [ $(date +%s) -gt ${DEADLINE} ]; then
+        echo "node ${NODE_NAME} is not healthy after upgrade to v{{ .K8SVersion }}"
+        exit 1
+    fi
+    printf '.'
+    sleep 5
+}
+
+{{ if .IsMaster }}
+until [ "$(curl --silent http://{{ .MasterHost }}:{{ .MasterPort }}/healthz)" == "ok" ]; do wait_deadline; done
+{{ end }}
+until [ "$(${KUBECTL} get node ${NODE_NAME} -o jsonpath='{.status.nodeInfo.kubeletVersion}')" == "v{{ .K8SVersion }}" ]; do wait_deadline; done
+until [ "$(${KUBECTL} get node ${NODE_NAME} -o jsonpath='{.status.conditions[?(@.type=="Ready")].status}')" == "True" ]; do wait_deadline; done
+
+echo "Node ${NODE_NAME} is ready with kubelet v{{ .K8SVersion }}"
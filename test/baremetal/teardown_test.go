@@ -0,0 +1,73 @@
+// +build integration
+
+package baremetal
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/node"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/baremetal"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
+)
+
+// Machine is a container running sshd, e.g.
+// docker run -d -p 2222:22 -e SSH_USERS=test:1000:1000 -v $HOME/.ssh/id_rsa.pub:/etc/authorized_keys/test panubo/sshd
+// SSHD_HOST=127.0.0.1 SSHD_PORT=2222 SSHD_USER=test SSHD_KEY=$HOME/.ssh/id_rsa go test -tags integration ./test/baremetal/
+func machineConfig(t *testing.T) *steps.Config {
+	host := os.Getenv("SSHD_HOST")
+	if host == "" {
+		t.Skip("SSHD_HOST is not set")
+	}
+
+	key, err := ioutil.ReadFile(os.Getenv("SSHD_KEY"))
+	if err != nil {
+		t.Fatalf("read private key %v", err)
+	}
+
+	config := steps.NewConfig("test", "", "test", profile.Profile{
+		Provider:       clouds.BareMetal,
+		MasterProfiles: []profile.NodeProfile{{}},
+	})
+	config.TaskID = "abcdefgh"
+	config.IsMaster = true
+	config.SshConfig.Port = os.Getenv("SSHD_PORT")
+	config.SshConfig.User = os.Getenv("SSHD_USER")
+	config.SshConfig.BootstrapPrivateKey = string(key)
+	config.Node = node.Node{
+		PublicIp: host,
+		State:    node.StatePlanned,
+	}
+
+	return config
+}
+
+func TestTeardownNode(t *testing.T) {
+	config := machineConfig(t)
+
+	if err := templatemanager.Init("../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := new(ssh.Step).Run(context.Background(), ioutil.Discard, config); err != nil {
+		t.Fatalf("ssh step %v", err)
+	}
+
+	if n := <-config.NodeChan(); n.State != node.StateProvisioning {
+		t.Errorf("wrong node state %s", n.State)
+	}
+
+	tpl, _ := templatemanager.GetTemplate("teardown")
+	out := new(bytes.Buffer)
+
+	if err := baremetal.NewTeardownNodeStep(tpl).Run(context.Background(), out, config); err != nil {
+		t.Fatalf("teardown %v: %s", err, out.String())
+	}
+}